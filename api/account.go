@@ -1,53 +1,135 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	db "github.com/codercollo/simple_bank/db/sqlc"
 	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 )
 
 // Request body for account creation
 type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
+	Nickname string `json:"nickname" binding:"omitempty,max=64"`
+
+	//Optional opening balance in minor units, moved from another account of
+	//the owner in the same currency
+	InitialBalance   Amount `json:"initial_balance" binding:"omitempty,min=0"`
+	FundingAccountID int64  `json:"funding_account_id" binding:"omitempty,min=1"`
+}
+
+// Query params for account creation
+type createAccountQuery struct {
+	GetOrCreate bool `form:"get_or_create"`
 }
 
 // createAccount handles HTTP requests to creare a new bank account
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
+	var query createAccountQuery
 
 	//Validate input
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	req.Currency = util.NormalizeCurrency(req.Currency)
+
+	//Nicknames tell apart several accounts in one currency, so they are only
+	//accepted when that is allowed; otherwise the empty nickname keeps the
+	//owner to a single account per currency
+	req.Nickname = strings.TrimSpace(req.Nickname)
+	if req.Nickname != "" && !server.config.AllowMultipleAccountsPerCurrency {
+		err := withCode(codeValidationError, errors.New("account nicknames require multiple accounts per currency to be enabled"))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//An opening balance needs an account to take it from, and vice versa
+	if (req.InitialBalance > 0) != (req.FundingAccountID > 0) {
+		err := withCode(codeValidationError, errors.New("initial_balance and funding_account_id must be given together"))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
 
 	//Get authenticated user
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
+	//Look for an existing account in this currency, returned when asked to and
+	//rejected otherwise. The unique constraint still catches concurrent inserts.
+	lookup := db.GetAccountByOwnerAndCurrencyParams{
+		Owner:    authPayload.Username,
+		Currency: req.Currency,
+		Nickname: req.Nickname,
+	}
+	existing, err := server.store.GetAccountByOwnerAndCurrency(ctx, lookup)
+	switch {
+	case err == nil && query.GetOrCreate:
+		ctx.JSON(http.StatusOK, existing)
+		return
+	case err == nil:
+		ctx.JSON(http.StatusConflict, errorResponse(duplicateAccountError(lookup)))
+		return
+	case !errors.Is(err, db.ErrRecordNotFound):
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
 	//Prepare DB params
 	arg := db.CreateAccountParams{
 		Owner:    authPayload.Username,
 		Currency: req.Currency,
 		Balance:  0,
+		Nickname: req.Nickname,
 	}
 
-	//Execute DB insert account
-	account, err := server.store.CreateAccount(ctx, arg)
+	//Execute DB insert account, funding it in the same transaction if asked to
+	var account db.Account
+	if req.FundingAccountID > 0 {
+		if _, valid := server.fundingAccount(ctx, req.FundingAccountID, req.Currency); !valid {
+			return
+		}
+
+		fundingArg := db.CreateAccountWithFundingTxParams{
+			Account:          arg,
+			FundingAccountID: req.FundingAccountID,
+			InitialBalance:   int64(req.InitialBalance),
+		}
+		fundingArg.DailyLimit, fundingArg.DailyLimitSince = server.dailyTransferLimit()
+
+		var result db.CreateAccountWithFundingTxResult
+		result, err = server.store.CreateAccountWithFundingTx(ctx, fundingArg)
+		if err == nil {
+			server.notifyBalanceAlerts(ctx, result.Alerts)
+		}
+		account = result.Account
+	} else {
+		account, err = server.store.CreateAccount(ctx, arg)
+	}
 	if err != nil {
-		//Handle constraint violations
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
+		//A concurrent request may have created the account first
+		if errors.Is(err, db.ErrUniqueViolation) && query.GetOrCreate {
+			if existing, getErr := server.store.GetAccountByOwnerAndCurrency(ctx, lookup); getErr == nil {
+				ctx.JSON(http.StatusOK, existing)
 				return
 			}
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+
+		//Handle an account created after the lookup, or an owner that is gone
+		if errors.Is(err, db.ErrUniqueViolation) {
+			ctx.JSON(http.StatusConflict, errorResponse(duplicateAccountError(lookup)))
+			return
+		}
+		respondError(ctx, err)
 		return
 	}
 
@@ -56,6 +138,14 @@ func (server *Server) createAccount(ctx *gin.Context) {
 
 }
 
+// duplicateAccountError describes the account an owner already has in a currency
+func duplicateAccountError(lookup db.GetAccountByOwnerAndCurrencyParams) error {
+	if lookup.Nickname != "" {
+		return withCode(codeAlreadyExists, fmt.Errorf("you already have a %s account named %q", lookup.Currency, lookup.Nickname))
+	}
+	return withCode(codeAlreadyExists, fmt.Errorf("you already have a %s account", lookup.Currency))
+}
+
 // URI params for get account
 type getAccountRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
@@ -74,7 +164,7 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	//Get account
 	account, err := server.store.GetAccount(ctx, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, db.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, errorResponse(err))
 			return
 		}
@@ -83,10 +173,10 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	//Check ownership
+	//Check ownership, bankers may view any account
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to the authenticated user")
+	if account.Owner != authPayload.Username && authPayload.Role != util.BankerRole {
+		err := withCode(codeUnauthorized, errors.New("account doesn't belong to the authenticated user"))
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
@@ -96,10 +186,114 @@ func (server *Server) getAccount(ctx *gin.Context) {
 
 }
 
+// fundingAccount fetches the account a new account is funded from. Accounts of
+// other users read as missing, so neither their existence nor their currency leaks.
+func (server *Server) fundingAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if err == nil && account.Owner != authPayload.Username {
+		err = db.ErrRecordNotFound
+	}
+	if err != nil {
+		respondError(ctx, err)
+		return account, false
+	}
+	if !activeAccount(ctx, account) {
+		return account, false
+	}
+
+	//Validate currency match once the account is known to be the user's
+	if account.Currency != currency {
+		err := withCode(codeCurrencyMismatch, fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+// ownedAccount fetches an account and verifies it belongs to the authenticated user
+func (server *Server) ownedAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	//Fetch account by ID
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		respondError(ctx, err)
+		return account, false
+	}
+
+	//Check ownership
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := withCode(codeUnauthorized, errors.New("account doesn't belong to the authenticated user"))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+// defaultBatchGetMaxAccounts caps batch lookups when no limit is configured
+const defaultBatchGetMaxAccounts = 50
+
+// Request body for fetching several accounts at once
+type batchGetAccountsRequest struct {
+	IDs []int64 `json:"ids" binding:"dive,min=1"`
+}
+
+// batchGetAccounts returns the requested accounts owned by the authenticated user.
+// IDs the user doesn't own are silently omitted so existence isn't leaked.
+func (server *Server) batchGetAccounts(ctx *gin.Context) {
+	var req batchGetAccountsRequest
+
+	//Validate request body
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Enforce the configured list size
+	maxAccounts := server.config.BatchGetMaxAccounts
+	if maxAccounts <= 0 {
+		maxAccounts = defaultBatchGetMaxAccounts
+	}
+	if len(req.IDs) > maxAccounts {
+		err := withCode(codeValidationError, fmt.Errorf("too many account ids: at most %d allowed", maxAccounts))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Nothing to look up
+	if len(req.IDs) == 0 {
+		ctx.JSON(http.StatusOK, []db.Account{})
+		return
+	}
+
+	//Fetch owned accounts
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	accounts, err := server.store.GetAccountsByIDs(ctx, db.GetAccountsByIDsParams{
+		Ids:   req.IDs,
+		Owner: authPayload.Username,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accounts)
+}
+
 // Query params for listing accounts
 type ListAccountRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Tag      string `form:"tag"`
+}
+
+// Paginated account list response
+type listAccountResponse struct {
+	Data     []db.Account `json:"data"`
+	PageID   int32        `json:"page_id"`
+	PageSize int32        `json:"page_size"`
 }
 
 // List accounts with pagination
@@ -115,87 +309,143 @@ func (server *Server) listAccount(ctx *gin.Context) {
 	//Get authenticated user
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	//Prepare DB params
-	arg := db.ListAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+	//Fetch accounts, optionally filtered by tag
+	var accounts []db.Account
+	var err error
+	if tag := normalizeTag(req.Tag); tag != "" {
+		accounts, err = server.store.ListAccountsByTag(ctx, db.ListAccountsByTagParams{
+			Owner:  authPayload.Username,
+			Tag:    tag,
+			Limit:  req.PageSize,
+			Offset: (req.PageID - 1) * req.PageSize,
+		})
+	} else {
+		accounts, err = server.store.ListAccounts(ctx, db.ListAccountsParams{
+			Owner:  authPayload.Username,
+			Limit:  req.PageSize,
+			Offset: (req.PageID - 1) * req.PageSize,
+		})
 	}
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	//Return accounts, an empty page as [] rather than null
+	if accounts == nil {
+		accounts = []db.Account{}
+	}
+	ctx.JSON(http.StatusOK, listAccountResponse{
+		Data:     accounts,
+		PageID:   req.PageID,
+		PageSize: req.PageSize,
+	})
+}
 
-	//Fetch accounts
-	accounts, err := server.store.ListAccounts(ctx, arg)
+// listOwnerCurrencies lists the currencies the authenticated user holds active accounts in
+func (server *Server) listOwnerCurrencies(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	currencies, err := server.store.ListOwnerCurrencies(ctx, authPayload.Username)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, currencies)
+}
+
+// URI params and body for updating account metadata
+type updateAccountRequest struct {
+	Currency string `json:"currency" binding:"required,currency"`
+}
+
+// updateAccount changes an account's non-financial details. Balances are only
+// ever moved by transfers, and the currency may only change while the account
+// is empty.
+func (server *Server) updateAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req updateAccountRequest
+
+	//Bind URI params and body
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	req.Currency = util.NormalizeCurrency(req.Currency)
+
+	account, ok := server.ownedAccount(ctx, uri.ID)
+	if !ok {
+		return
+	}
+	if account.Currency == req.Currency {
+		ctx.JSON(http.StatusOK, account)
+		return
+	}
+
+	errNotEmpty := withCode(codeValidationError, errors.New("currency can only change while the balance is zero"))
+	if account.Balance != 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errNotEmpty))
+		return
+	}
+
+	account, err := server.store.UpdateAccountCurrency(ctx, db.UpdateAccountCurrencyParams{
+		ID:       account.ID,
+		Currency: req.Currency,
+	})
+	if err != nil {
+		//The balance changed since it was read
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errNotEmpty))
+			return
+		}
+		if errors.Is(err, db.ErrUniqueViolation) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	//Return accounts
-	ctx.JSON(http.StatusOK, accounts)
+	ctx.JSON(http.StatusOK, account)
 }
 
-// // Update account request
-// type updateAccountRequest struct {
-// 	Balance int64 `json:"balance" binding:"required"`
-// }
-
-// // Update account balance
-// func (server *Server) updateAccount(ctx *gin.Context) {
-// 	//Parse and validate account ID
-// 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
-// 	if err != nil || id < 1 {
-// 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
-// 		return
-// 	}
-
-// 	//Bind JSON body
-// 	var req updateAccountRequest
-// 	if err := ctx.ShouldBindJSON(&req); err != nil {
-// 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-// 		return
-// 	}
-
-// 	//Update account
-// 	account, err := server.store.UpdateAccount(ctx, db.UpdateAccountParams{
-// 		ID:      id,
-// 		Balance: req.Balance,
-// 	})
-// 	if err != nil {
-// 		if err == sql.ErrNoRows {
-// 			ctx.JSON(http.StatusNotFound, errorResponse(err))
-// 			return
-// 		}
-// 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-// 		return
-// 	}
-
-// 	//Return updated account
-// 	ctx.JSON(http.StatusOK, account)
-// }
-
-// // deleteAccount deletes an account
-// func (server *Server) deleteAccount(ctx *gin.Context) {
-// 	//Parse account ID  from URL
-// 	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
-// 	if err != nil || id < 1 {
-// 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid account id"})
-// 		return
-// 	}
-
-// 	//Delete account in DB
-// 	err = server.store.DeleteAccount(ctx, id)
-// 	if err != nil {
-// 		if err == sql.ErrNoRows {
-// 			ctx.JSON(http.StatusNotFound, errorResponse(err))
-// 			return
-// 		}
-// 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-// 		return
-// 	}
-
-// 	//Success response
-// 	ctx.JSON(http.StatusOK, gin.H{"mesage": "account deleted"})
-// }
+// deleteAccount closes an empty account. The account is soft-deleted so its
+// transfers and entries stay in the history.
+func (server *Server) deleteAccount(ctx *gin.Context) {
+	var req getAccountRequest
+
+	//Bind URI params
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.ownedAccount(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	errNotEmpty := withCode(codeValidationError, errors.New("only accounts with a zero balance can be deleted"))
+	if account.Balance != 0 {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errNotEmpty))
+		return
+	}
+
+	account, err := server.store.SoftDeleteAccount(ctx, account.ID)
+	if err != nil {
+		//The balance changed since it was read
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errNotEmpty))
+			return
+		}
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// Query params for a point-in-time balance
+type getBalanceAsOfQuery struct {
+	AsOf time.Time `form:"as_of" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// Response for a point-in-time balance
+type balanceAsOfResponse struct {
+	AccountID int64     `json:"account_id"`
+	Currency  string    `json:"currency"`
+	Balance   int64     `json:"balance"`
+	AsOf      time.Time `json:"as_of"`
+}
+
+// getBalanceAsOf returns an account's balance at a past time, summing the
+// entries booked up to and including that time
+func (server *Server) getBalanceAsOf(ctx *gin.Context) {
+	var req getAccountRequest
+	var query getBalanceAsOfQuery
+
+	//Bind URI and query params
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Balances are only known up to now
+	if query.AsOf.After(time.Now()) {
+		err := withCode(codeValidationError, errors.New("as_of must not be in the future"))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.ownedAccount(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	balance, err := server.store.SumEntriesUntil(ctx, db.SumEntriesUntilParams{
+		AccountID: account.ID,
+		Until:     query.AsOf,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, balanceAsOfResponse{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		Balance:   balance,
+		AsOf:      query.AsOf,
+	})
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestGetBalanceAsOfAPI tests GET /accounts/:id/balance
+func TestGetBalanceAsOfAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	asOf := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name          string
+		username      string
+		asOf          string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			asOf:     asOf.Format(time.RFC3339),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					SumEntriesUntil(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.SumEntriesUntilParams) (int64, error) {
+						require.Equal(t, account.ID, arg.AccountID)
+						require.True(t, asOf.Equal(arg.Until))
+						return 42, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp balanceAsOfResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, account.ID, rsp.AccountID)
+				require.Equal(t, account.Currency, rsp.Currency)
+				require.Equal(t, int64(42), rsp.Balance)
+				require.True(t, asOf.Equal(rsp.AsOf))
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: other.Username,
+			asOf:     asOf.Format(time.RFC3339),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SumEntriesUntil(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "FutureTimestamp",
+			username: user.Username,
+			asOf:     time.Now().Add(time.Hour).Format(time.RFC3339),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().SumEntriesUntil(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeValidationError)
+			},
+		},
+		{
+			name:     "InvalidTimestamp",
+			username: user.Username,
+			asOf:     "yesterday",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SumEntriesUntil(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/accounts/%d/balance?as_of=%s", account.ID, url.QueryEscape(tc.asOf))
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// freezeAccount blocks an account from sending and receiving transfers,
+// e.g. while suspected fraud is investigated
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.setAccountStatus(ctx, server.store.FreezeAccount)
+}
+
+// unfreezeAccount opens a frozen account for transfers again
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.setAccountStatus(ctx, server.store.UnfreezeAccount)
+}
+
+// setAccountStatus applies a status change to the account in the URI
+func (server *Server) setAccountStatus(ctx *gin.Context, update func(ctx context.Context, id int64) (db.Account, error)) {
+	var req getAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := update(ctx, req.ID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFreezeAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	banker, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	frozen := account
+	frozen.Status = db.AccountFrozen
+
+	testCases := []struct {
+		name          string
+		path          string
+		role          string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "FreezeOK",
+			path: "freeze",
+			role: util.BankerRole,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().FreezeAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(frozen, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, frozen)
+			},
+		},
+		{
+			name: "UnfreezeOK",
+			path: "unfreeze",
+			role: util.BankerRole,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UnfreezeAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "DepositorForbidden",
+			path: "freeze",
+			role: util.DepositorRole,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().FreezeAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NotFound",
+			path: "freeze",
+			role: util.BankerRole,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().FreezeAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			path: "freeze",
+			role: util.BankerRole,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().FreezeAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/%s", account.ID, tc.path)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, banker.Username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestTransferFrozenAccount verifies transfers from or to a frozen account are blocked
+func TestTransferFrozenAccount(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.ID, account2.ID = 1, 2
+	account1.Currency, account2.Currency = util.USD, util.USD
+
+	testCases := []struct {
+		name       string
+		fromStatus string
+		toStatus   string
+		buildStubs func(store *mock.MockStore, from db.Account, to db.Account)
+	}{
+		{
+			name:       "FrozenSource",
+			fromStatus: db.AccountFrozen,
+			toStatus:   db.AccountActive,
+			buildStubs: func(store *mock.MockStore, from db.Account, to db.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to.ID)).Times(0)
+			},
+		},
+		{
+			name:       "FrozenDestination",
+			fromStatus: db.AccountActive,
+			toStatus:   db.AccountFrozen,
+			buildStubs: func(store *mock.MockStore, from db.Account, to db.Account) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to.ID)).Times(1).Return(to, nil)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			from, to := account1, account2
+			from.Status, to.Status = tc.fromStatus, tc.toStatus
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store, from, to)
+			store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			body := []byte(`{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD"}`)
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(body))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusForbidden, recorder.Code)
+
+			var rsp map[string]any
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+			require.Equal(t, codeAccountFrozen, rsp["code"])
+			require.Contains(t, rsp["error"], "is frozen")
+		})
+	}
+}
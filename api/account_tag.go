@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// maxAccountTags is the number of tags a single account may carry
+const maxAccountTags = 10
+
+// Request body for tagging an account
+type addAccountTagRequest struct {
+	Tag string `json:"tag" binding:"required,max=32"`
+}
+
+// URI params for removing an account tag
+type deleteAccountTagRequest struct {
+	ID  int64  `uri:"id" binding:"required,min=1"`
+	Tag string `uri:"tag" binding:"required"`
+}
+
+// Response payload listing the tags of an account
+type accountTagsResponse struct {
+	AccountID int64    `json:"account_id"`
+	Tags      []string `json:"tags"`
+}
+
+// normalizeTag lowercases and trims a tag so equivalent tags dedupe
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// addAccountTag attaches a tag to an owned account
+func (server *Server) addAccountTag(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req addAccountTagRequest
+
+	//Bind URI params and request body
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	tag := normalizeTag(req.Tag)
+	if tag == "" {
+		err := withCode(codeValidationError, errors.New("tag must not be blank"))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Check account ownership
+	if _, valid := server.ownedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	//Enforce the per-account limit, re-adding an existing tag is a no-op
+	tags, err := server.store.ListAccountTags(ctx, uri.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !slices.Contains(tags, tag) {
+		if len(tags) >= maxAccountTags {
+			err := withCode(codeTagLimitExceeded, fmt.Errorf("an account can have at most %d tags", maxAccountTags))
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+
+		err = server.store.AddAccountTag(ctx, db.AddAccountTagParams{AccountID: uri.ID, Tag: tag})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		tags = append(tags, tag)
+		slices.Sort(tags)
+	}
+
+	ctx.JSON(http.StatusOK, accountTagsResponse{AccountID: uri.ID, Tags: tags})
+}
+
+// deleteAccountTag removes a tag from an owned account
+func (server *Server) deleteAccountTag(ctx *gin.Context) {
+	var req deleteAccountTagRequest
+
+	//Bind URI params
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Check account ownership
+	if _, valid := server.ownedAccount(ctx, req.ID); !valid {
+		return
+	}
+
+	//Delete tag
+	err := server.store.DeleteAccountTag(ctx, db.DeleteAccountTagParams{
+		AccountID: req.ID,
+		Tag:       normalizeTag(req.Tag),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	tags, err := server.store.ListAccountTags(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accountTagsResponse{AccountID: req.ID, Tags: tags})
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestAccountTagAPI tests adding and removing account tags
+func TestAccountTagAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	//Tags already at the per-account limit
+	fullTags := make([]string, maxAccountTags)
+	for i := range fullTags {
+		fullTags[i] = fmt.Sprintf("tag%02d", i)
+	}
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		body          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "AddNormalized",
+			method: http.MethodPost,
+			path:   "/tags",
+			body:   `{"tag":"  Business "}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountTags(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return([]string{"personal"}, nil)
+				store.EXPECT().
+					AddAccountTag(gomock.Any(), gomock.Eq(db.AddAccountTagParams{AccountID: account.ID, Tag: "business"})).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchTags(t, recorder, []string{"business", "personal"})
+			},
+		},
+		{
+			name:   "AddDuplicate",
+			method: http.MethodPost,
+			path:   "/tags",
+			body:   `{"tag":"PERSONAL"}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountTags(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return([]string{"personal"}, nil)
+				store.EXPECT().AddAccountTag(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchTags(t, recorder, []string{"personal"})
+			},
+		},
+		{
+			name:   "AddOverLimit",
+			method: http.MethodPost,
+			path:   "/tags",
+			body:   `{"tag":"one-too-many"}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountTags(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(fullTags, nil)
+				store.EXPECT().AddAccountTag(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				var body map[string]any
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Equal(t, codeTagLimitExceeded, body["code"])
+			},
+		},
+		{
+			name:   "AddBlank",
+			method: http.MethodPost,
+			path:   "/tags",
+			body:   `{"tag":"   "}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "AddUnauthorizedUser",
+			method: http.MethodPost,
+			path:   "/tags",
+			body:   `{"tag":"business"}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().AddAccountTag(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:   "Remove",
+			method: http.MethodDelete,
+			path:   "/tags/Business",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					DeleteAccountTag(gomock.Any(), gomock.Eq(db.DeleteAccountTagParams{AccountID: account.ID, Tag: "business"})).
+					Times(1).
+					Return(nil)
+				store.EXPECT().ListAccountTags(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return([]string{"personal"}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchTags(t, recorder, []string{"personal"})
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d%s", account.ID, tc.path)
+			request, err := http.NewRequest(tc.method, url, bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestListAccountsByTagAPI verifies the tag filter only queries the caller's tagged accounts
+func TestListAccountsByTagAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	accounts := []db.Account{randomAccount(user.Username), randomAccount(user.Username)}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountsByTag(gomock.Any(), gomock.Eq(db.ListAccountsByTagParams{
+			Owner:  user.Username,
+			Tag:    "business",
+			Limit:  5,
+			Offset: 0,
+		})).
+		Times(1).
+		Return(accounts, nil)
+	store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/accounts?page_id=1&page_size=5&tag=Business", nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp listAccountResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp.Data, len(accounts))
+}
+
+// requireBodyMatchTags checks the tags returned for an account
+func requireBodyMatchTags(t *testing.T, recorder *httptest.ResponseRecorder, tags []string) {
+	var rsp accountTagsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, tags, rsp.Tags)
+}
@@ -26,9 +26,18 @@ func TestCreateAccountAPI(t *testing.T) {
 	user, _ := randomUser(t)
 	account := randomAccount(user.Username)
 
+	//A second account of the same owner in another currency
+	otherCurrency := util.EUR
+	if account.Currency == util.EUR {
+		otherCurrency = util.USD
+	}
+	otherAccount := randomAccount(user.Username)
+	otherAccount.Currency = otherCurrency
+
 	//Define test cases
 	testCases := []struct {
 		name          string
+		query         string
 		body          gin.H
 		buildStubs    func(store *mock.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
@@ -40,6 +49,15 @@ func TestCreateAccountAPI(t *testing.T) {
 				"currency": account.Currency,
 			},
 			buildStubs: func(store *mock.MockStore) {
+				//No account in this currency yet
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    user.Username,
+						Currency: account.Currency,
+					})).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+
 				//Expect account creation with valid params
 				arg := db.CreateAccountParams{
 					Owner:    user.Username,
@@ -57,7 +75,239 @@ func TestCreateAccountAPI(t *testing.T) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 				requireBodyMatchAccount(t, recorder.Body, account)
 			},
-		}, {
+		},
+		{
+			name: "LegacyCurrencyAlias",
+			body: gin.H{
+				"currency": "Ksh",
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//The legacy alias is stored under its ISO 4217 code
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    user.Username,
+						Currency: util.KES,
+					})).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Eq(db.CreateAccountParams{
+						Owner:    user.Username,
+						Currency: util.KES,
+					})).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "LowerCaseCurrency",
+			body: gin.H{
+				"currency": "kes",
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    user.Username,
+						Currency: util.KES,
+					})).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Eq(db.CreateAccountParams{
+						Owner:    user.Username,
+						Currency: util.KES,
+					})).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:  "GetOrCreateNew",
+			query: "?get_or_create=true",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//No account in this currency yet
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    user.Username,
+						Currency: account.Currency,
+					})).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:  "GetOrCreateExisting",
+			query: "?get_or_create=true",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//Existing account is returned without inserting
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:  "GetOrCreateConcurrentInsert",
+			query: "?get_or_create=true",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//Account appears between the lookup and the insert
+				gomock.InOrder(
+					store.EXPECT().
+						GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).
+						Return(db.Account{}, sql.ErrNoRows),
+					store.EXPECT().
+						CreateAccount(gomock.Any(), gomock.Any()).
+						Return(db.Account{}, db.ErrUniqueViolation),
+					store.EXPECT().
+						GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).
+						Return(account, nil),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "DuplicateCurrency",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//Without the flag an existing account is a conflict
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeAlreadyExists)
+				require.Contains(t, recorder.Body.String(), fmt.Sprintf("you already have a %s account", account.Currency))
+			},
+		},
+		{
+			name: "DuplicateCurrencyConcurrentInsert",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//The unique constraint catches an account created after the lookup
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeAlreadyExists)
+				require.Contains(t, recorder.Body.String(), fmt.Sprintf("you already have a %s account", account.Currency))
+			},
+		},
+		{
+			name: "OwnerNotFound",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//The owner was deleted after the token was issued
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, db.ErrForeignKeyViolation)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeForeignKey)
+			},
+		},
+		{
+			name: "DifferentCurrency",
+			body: gin.H{
+				"currency": otherCurrency,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//Owning an account in another currency does not block this one
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    user.Username,
+						Currency: otherCurrency,
+					})).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Eq(db.CreateAccountParams{
+						Owner:    user.Username,
+						Currency: otherCurrency,
+					})).
+					Times(1).
+					Return(otherAccount, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, otherAccount)
+			},
+		},
+		{
+			name: "LookupError",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrConnDone)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
 			name: "InvalidBody",
 			body: gin.H{
 				"owner": account.Owner,
@@ -82,6 +332,10 @@ func TestCreateAccountAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mock.MockStore) {
 				//Simulate database error
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
 				store.EXPECT().
 					CreateAccount(gomock.Any(), gomock.Any()).
 					Times(1).
@@ -116,12 +370,12 @@ func TestCreateAccountAPI(t *testing.T) {
 			require.NoError(t, err)
 
 			//Create HTTP request
-			request, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(data))
+			request, err := http.NewRequest(http.MethodPost, "/accounts"+tc.query, bytes.NewReader(data))
 			require.NoError(t, err)
 
 			//Add authorization header
 			tokenMaker := server.tokenMaker
-			addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 
 			//Send request and verify response
 			server.router.ServeHTTP(recorder, request)
@@ -131,144 +385,403 @@ func TestCreateAccountAPI(t *testing.T) {
 
 }
 
-// TestGetAccountAPI tests GET /accounts/:id endpoint
-func TestGetAccountAPI(t *testing.T) {
-	//Create test user and account
+// TestCreateAccountWithFundingAPI tests opening an account with an initial balance
+func TestCreateAccountWithFundingAPI(t *testing.T) {
 	user, _ := randomUser(t)
+	funding := randomAccount(user.Username)
 	account := randomAccount(user.Username)
+	account.Currency = funding.Currency
+	amount := funding.Balance / 2
 
-	//Create a random account for test data
+	otherFunding := randomAccount(util.RandomOwner())
+	otherFunding.Currency = funding.Currency
+
+	otherCurrency := util.EUR
+	if funding.Currency == util.EUR {
+		otherCurrency = util.USD
+	}
+
+	lookup := db.GetAccountByOwnerAndCurrencyParams{
+		Owner:    user.Username,
+		Currency: funding.Currency,
+	}
 
-	//Define all test scenarios
 	testCases := []struct {
 		name          string
-		accountID     int64
-		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		body          gin.H
+		config        func(config *util.Config)
 		buildStubs    func(store *mock.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
-			name:      "OK",
-			accountID: account.ID,
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				//Add valid bearer token for the account owner
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			name: "Funded",
+			body: gin.H{
+				"currency":           funding.Currency,
+				"initial_balance":    amount,
+				"funding_account_id": funding.ID,
 			},
 			buildStubs: func(store *mock.MockStore) {
-				//Expect GetAccount to be called once and succeed
+				funded := account
+				funded.Balance = amount
+
+				store.EXPECT().GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(lookup)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(funding.ID)).Times(1).Return(funding, nil)
 				store.EXPECT().
-					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					CreateAccountWithFundingTx(gomock.Any(), gomock.Eq(db.CreateAccountWithFundingTxParams{
+						Account: db.CreateAccountParams{
+							Owner:    user.Username,
+							Currency: funding.Currency,
+						},
+						FundingAccountID: funding.ID,
+						InitialBalance:   amount,
+					})).
 					Times(1).
-					Return(account, nil)
+					Return(db.CreateAccountWithFundingTxResult{Account: funded}, nil)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				//Verify HTTP 200 and correct response body
+				funded := account
+				funded.Balance = amount
+
 				require.Equal(t, http.StatusOK, recorder.Code)
-				requireBodyMatchAccount(t, recorder.Body, account)
+				requireBodyMatchAccount(t, recorder.Body, funded)
 			},
 		},
 		{
-			name:      "UnauthorizedUser",
-			accountID: account.ID,
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				//Token belongs to a different user than the account owner
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", time.Minute)
+			name: "FundedPassesDailyLimit",
+			body: gin.H{
+				"currency":           funding.Currency,
+				"initial_balance":    amount,
+				"funding_account_id": funding.ID,
+			},
+			config: func(config *util.Config) {
+				config.DailyTransferLimit = 50
 			},
 			buildStubs: func(store *mock.MockStore) {
-				//Account exists, but access should be denied
+				store.EXPECT().GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(lookup)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(funding.ID)).Times(1).Return(funding, nil)
 				store.EXPECT().
-					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					CreateAccountWithFundingTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(account, nil)
+					DoAndReturn(func(_ any, arg db.CreateAccountWithFundingTxParams) (db.CreateAccountWithFundingTxResult, error) {
+						require.Equal(t, int64(50), arg.DailyLimit)
+						require.WithinDuration(t, time.Now().Add(-24*time.Hour), arg.DailyLimitSince, time.Second)
+						return db.CreateAccountWithFundingTxResult{}, fmt.Errorf("account [%d]: %w", funding.ID, db.ErrDailyTransferLimit)
+					})
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				//Expect HTTP 401 Unauthorized
-				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeDailyLimit)
 			},
 		},
 		{
-			name:      "NoAuthorization",
-			accountID: account.ID,
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				//No authorization header provided
+			name: "InsufficientFunds",
+			body: gin.H{
+				"currency":           funding.Currency,
+				"initial_balance":    funding.Balance + 1,
+				"funding_account_id": funding.ID,
 			},
 			buildStubs: func(store *mock.MockStore) {
-				//Store must not be called when request is unauthenticated
+				store.EXPECT().GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(lookup)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(funding.ID)).Times(1).Return(funding, nil)
 				store.EXPECT().
-					GetAccount(gomock.Any(), gomock.Any()).
-					Times(0).
-					Return(account, nil)
+					CreateAccountWithFundingTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateAccountWithFundingTxResult{}, db.ErrInsufficientFunds)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				//Expect HTTP 401 Unauthorized
-				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorMessage(t, recorder, codeInsufficientFunds, db.ErrInsufficientFunds.Error())
 			},
 		},
-
 		{
-			name:      "NotFound",
-			accountID: account.ID,
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				//Valid token for account owner
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			name: "FundingAccountNotOwned",
+			body: gin.H{
+				"currency":           funding.Currency,
+				"initial_balance":    1,
+				"funding_account_id": otherFunding.ID,
 			},
 			buildStubs: func(store *mock.MockStore) {
-				//Simulate account not existing in the database
-				store.EXPECT().
-					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
-					Times(1).
-					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(lookup)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(otherFunding.ID)).Times(1).Return(otherFunding, nil)
+				store.EXPECT().CreateAccountWithFundingTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				//Expect HTTP 404 Not Found
 				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorMessage(t, recorder, codeNotFound, sql.ErrNoRows.Error())
 			},
 		},
-
 		{
-			name:      "InternalError",
-			accountID: account.ID,
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				//Valid token for account owner
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			//Another user's account must not reveal its currency
+			name: "FundingAccountNotOwnedOtherCurrency",
+			body: gin.H{
+				"currency":           otherCurrency,
+				"initial_balance":    1,
+				"funding_account_id": otherFunding.ID,
 			},
 			buildStubs: func(store *mock.MockStore) {
-				//Simulate databse connection error
-				store.EXPECT().
-					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
-					Times(1).
-					Return(db.Account{}, sql.ErrConnDone)
+				store.EXPECT().GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(otherFunding.ID)).Times(1).Return(otherFunding, nil)
+				store.EXPECT().CreateAccountWithFundingTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				//Expect HTTP 500 Internal server error
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
-
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorMessage(t, recorder, codeNotFound, sql.ErrNoRows.Error())
 			},
 		},
-
 		{
-			name:      "InvalidID",
-			accountID: 0,
-			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				//Valid token but invalid account ID in URL
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+			name: "FundingAccountNotFound",
+			body: gin.H{
+				"currency":           funding.Currency,
+				"initial_balance":    1,
+				"funding_account_id": otherFunding.ID,
 			},
 			buildStubs: func(store *mock.MockStore) {
-				//Store should not be called for invalid ID
-				store.EXPECT().
-					GetAccount(gomock.Any(), gomock.Any()).
-					Times(0)
+				store.EXPECT().GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(lookup)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(otherFunding.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().CreateAccountWithFundingTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				//Expect HTTP 400 Bad Request
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
-
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorMessage(t, recorder, codeNotFound, sql.ErrNoRows.Error())
 			},
 		},
-	}
-
-	//Execute all test cases
-	for i := range testCases {
+		{
+			name: "FundingCurrencyMismatch",
+			body: gin.H{
+				"currency":           otherCurrency,
+				"initial_balance":    1,
+				"funding_account_id": funding.ID,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(funding.ID)).Times(1).Return(funding, nil)
+				store.EXPECT().CreateAccountWithFundingTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				var body map[string]any
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Equal(t, codeCurrencyMismatch, body["code"])
+			},
+		},
+		{
+			name: "MissingFundingAccount",
+			body: gin.H{
+				"currency":        funding.Currency,
+				"initial_balance": 100,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccountWithFundingTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireErrorMessage(t, recorder, codeValidationError, "initial_balance and funding_account_id must be given together")
+			},
+		},
+		{
+			name: "NegativeInitialBalance",
+			body: gin.H{
+				"currency":           funding.Currency,
+				"initial_balance":    -1,
+				"funding_account_id": funding.ID,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateAccountWithFundingTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			if tc.config != nil {
+				tc.config(&server.config)
+			}
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestGetAccountAPI tests GET /accounts/:id endpoint
+func TestGetAccountAPI(t *testing.T) {
+	//Create test user and account
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	//Create a random account for test data
+
+	//Define all test scenarios
+	testCases := []struct {
+		name          string
+		accountID     int64
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Add valid bearer token for the account owner
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//Expect GetAccount to be called once and succeed
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				//Verify HTTP 200 and correct response body
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Token belongs to a different user than the account owner
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//Account exists, but access should be denied
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				//Expect HTTP 401 Unauthorized
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "BankerViewsOtherAccount",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Bankers may read accounts they don't own
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker_user", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//No authorization header provided
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//Store must not be called when request is unauthenticated
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				//Expect HTTP 401 Unauthorized
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+
+		{
+			name:      "NotFound",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Valid token for account owner
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//Simulate account not existing in the database
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				//Expect HTTP 404 Not Found
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+
+		{
+			name:      "InternalError",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Valid token for account owner
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//Simulate databse connection error
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Eq(account.ID)).
+					Times(1).
+					Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				//Expect HTTP 500 Internal server error
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+			},
+		},
+
+		{
+			name:      "InvalidID",
+			accountID: 0,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Valid token but invalid account ID in URL
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//Store should not be called for invalid ID
+				store.EXPECT().
+					GetAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				//Expect HTTP 400 Bad Request
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+			},
+		},
+	}
+
+	//Execute all test cases
+	for i := range testCases {
 		tc := testCases[i]
 
 		t.Run(tc.name, func(t *testing.T) {
@@ -304,354 +817,674 @@ func TestGetAccountAPI(t *testing.T) {
 
 }
 
-// TestListAccountAPI tests GET /accounts endpoint
-// func TestListAccountAPI(t *testing.T) {
-// 	user, _ := randomUser(t)
-
-// 	//Generate test accounts
-// 	accounts := []db.Account{
-// 		randomAccount(),
-// 		randomAccount(),
-// 		randomAccount(),
-// 	}
-
-// 	//Define test cases
-// 	testCases := []struct {
-// 		name          string
-// 		query         string
-// 		buildStubs    func(store *mock.MockStore)
-// 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
-// 	}{
-// 		{
-// 			name:  "OK",
-// 			query: "?page_id=1&page_size=5",
-// 			buildStubs: func(store *mock.MockStore) {
-// 				//Expect ListAccounts to be called once
-// 				store.EXPECT().
-// 					ListAccounts(gomock.Any(), gomock.Any()).
-// 					Times(1).
-// 					Return(accounts, nil)
-// 			},
-// 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-// 				//Expect 200 OK
-// 				require.Equal(t, http.StatusOK, recorder.Code)
-// 			},
-// 		},
-// 		{
-// 			name:  "InvalidQuery",
-// 			query: "?page_id=0&page_size=5",
-// 			buildStubs: func(store *mock.MockStore) {
-// 				//Store should not be called
-// 				store.EXPECT().
-// 					ListAccounts(gomock.Any(), gomock.Any()).
-// 					Times(0)
-
-// 			},
-// 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-// 				//Expect 400 Bad Request
-// 				require.Equal(t, http.StatusBadRequest, recorder.Code)
-// 			},
-// 		},
-
-// 		{
-// 			name:  "InternalError",
-// 			query: "?page_id=1&page_size=5",
-// 			buildStubs: func(store *mock.MockStore) {
-// 				//Simulate database error
-// 				store.EXPECT().
-// 					ListAccounts(gomock.Any(), gomock.Any()).
-// 					Times(1).
-// 					Return([]db.Account{}, sql.ErrConnDone)
-// 			},
-// 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-// 				//Expect 500 Internal Server Error
-// 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
-// 			},
-// 		},
-// 	}
-
-// 	//Run all test cases
-// 	for i := range testCases {
-// 		tc := testCases[i]
-// 		t.Run(tc.name, func(t *testing.T) {
-// 			//Setup gomock controller
-// 			ctrl := gomock.NewController(t)
-// 			defer ctrl.Finish()
-
-// 			//Initialize mock store
-// 			store := mock.NewMockStore(ctrl)
-// 			tc.buildStubs(store)
-
-// 			//Start test server
-// 			server := newTestServer(t, store)
-// 			recorder := httptest.NewRecorder()
-
-// 			//Create HTTP request
-// 			url := "/accounts" + tc.query
-// 			request, err := http.NewRequest(http.MethodGet, url, nil)
-// 			require.NoError(t, err)
-
-// 			//Send request and verify  response
-// 			server.router.ServeHTTP(recorder, request)
-// 			tc.checkResponse(t, recorder)
-// 		})
-// 	}
-// }
-
-// randomAccount generates a random account for testing
-func randomAccount(owner string) db.Account {
-	return db.Account{
-		ID:       util.RandomInt(1, 1000),
-		Owner:    owner,
-		Balance:  util.RandomMoney(),
-		Currency: util.RandomCurrency(),
-	}
-
-}
-
-// requireBodyMatchAccount validates response body against expected account
-func requireBodyMatchAccount(t *testing.T, body *bytes.Buffer, account db.Account) {
-	//Read response body
-	data, err := io.ReadAll(body)
-	require.NoError(t, err)
-
-	//Decode JSON response
-	var gotAccount db.Account
-	err = json.Unmarshal(data, &gotAccount)
-
-	//Compare expected and actual account
-	require.NoError(t, err)
-	require.Equal(t, account, gotAccount)
+// TestBatchGetAccountsAPI tests POST /accounts/batch_get endpoint
+func TestBatchGetAccountsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	owned1 := randomAccount(user.Username)
+	owned2 := randomAccount(user.Username)
+	other := randomAccount("other_user")
 
-}
+	//Define test cases
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OnlyOwnedReturned",
+			body: gin.H{"ids": []int64{owned1.ID, other.ID, owned2.ID}},
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.GetAccountsByIDsParams{
+					Ids:   []int64{owned1.ID, other.ID, owned2.ID},
+					Owner: user.Username,
+				}
+				store.EXPECT().
+					GetAccountsByIDs(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.Account{owned1, owned2}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
 
-// TestUpdateAccountAPI tests PUT /accounts/:id endpoint
-// func TestUpdateAccountAPI(t *testing.T) {
-// 	//Generate test account
-// 	account := randomAccount()
-// 	newBalance := util.RandomMoney()
-
-// 	//Define test cases
-// 	testCases := []struct {
-// 		name          string
-// 		accountID     int64
-// 		body          gin.H
-// 		buildStubs    func(store *mock.MockStore)
-// 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
-// 	}{
-// 		{
-// 			name:      "OK",
-// 			accountID: account.ID,
-// 			body: gin.H{
-// 				"balance": newBalance,
-// 			},
-// 			buildStubs: func(store *mock.MockStore) {
-// 				//Expect successful account update
-// 				arg := db.UpdateAccountParams{
-// 					ID:      account.ID,
-// 					Balance: newBalance,
-// 				}
-
-// 				updateAccount := account
-// 				updateAccount.Balance = newBalance
-
-// 				store.EXPECT().
-// 					UpdateAccount(gomock.Any(), gomock.Eq(arg)).
-// 					Times(1).
-// 					Return(updateAccount, nil)
-// 			},
-// 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-// 				//Expect 200 OK
-// 				require.Equal(t, http.StatusOK, recorder.Code)
-// 			},
-// 		},
-// 		{
-// 			name:      "InvalidID",
-// 			accountID: 0,
-// 			body: gin.H{
-// 				"balance": newBalance,
-// 			},
-// 			buildStubs: func(store *mock.MockStore) {
-// 				//Store should not be called
-// 				store.EXPECT().
-// 					UpdateAccount(gomock.Any(), gomock.Any()).
-// 					Times(0)
-// 			},
-// 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-// 				//Expect 400 Bad Request
-// 				require.Equal(t, http.StatusBadRequest, recorder.Code)
-// 			},
-// 		},
-// 		{
-// 			name:      "NotFound",
-// 			accountID: account.ID,
-// 			body: gin.H{
-// 				"balance": newBalance,
-// 			},
-// 			buildStubs: func(store *mock.MockStore) {
-// 				//Simulate account not found
-// 				store.EXPECT().
-// 					UpdateAccount(gomock.Any(), gomock.Any()).
-// 					Times(1).
-// 					Return(db.Account{}, sql.ErrNoRows)
-// 			},
-// 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-// 				//Expect 404 Not Found
-// 				require.Equal(t, http.StatusNotFound, recorder.Code)
-// 			},
-// 		},
-// 		{
-// 			name:      "InternalError",
-// 			accountID: account.ID,
-// 			body: gin.H{
-// 				"balance": newBalance,
-// 			},
-// 			buildStubs: func(store *mock.MockStore) {
-// 				//Simulate database error
-// 				store.EXPECT().
-// 					UpdateAccount(gomock.Any(), gomock.Any()).
-// 					Times(1).
-// 					Return(db.Account{}, sql.ErrConnDone)
-// 			},
-// 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-// 				//Expect 500 Internal Server Error
-// 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
-// 			},
-// 		},
-// 	}
-
-// 	//Run all test cases
-// 	for i := range testCases {
-// 		tc := testCases[i]
-
-// 		t.Run(tc.name, func(t *testing.T) {
-// 			//Setup gomock controller
-// 			ctrl := gomock.NewController(t)
-// 			defer ctrl.Finish()
-
-// 			//Initialize mock store
-// 			store := mock.NewMockStore(ctrl)
-// 			tc.buildStubs(store)
-
-// 			//Start test server
-// 			server := newTestServer(t, store)
-// 			recorder := httptest.NewRecorder()
-
-// 			//Encode request body
-// 			data, err := json.Marshal(tc.body)
-// 			require.NoError(t, err)
-
-// 			//Create HTTP request
-// 			url := fmt.Sprintf("/accounts/%d", tc.accountID)
-// 			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
-// 			require.NoError(t, err)
-
-// 			//Send request and verify response
-// 			server.router.ServeHTTP(recorder, request)
-// 			tc.checkResponse(t, recorder)
-
-// 		})
-// 	}
-
-// }
-
-// // TestDeleteAccountAPI tests DELETE /accounts/:id endpoint
-// func TestDeleteAccountAPI(t *testing.T) {
-// 	//Generate test account
-// 	account := randomAccount()
-
-// 	//Define test cases
-// 	testCases := []struct {
-// 		name          string
-// 		accountID     int64
-// 		buildStubs    func(store *mock.MockStore)
-// 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
-// 	}{
-// 		{
-// 			name:      "OK",
-// 			accountID: account.ID,
-// 			buildStubs: func(store *mock.MockStore) {
-// 				//Expect successful deletion
-// 				store.EXPECT().
-// 					DeleteAccount(gomock.Any(), gomock.Eq(account.ID)).
-// 					Times(1).
-// 					Return(nil)
-// 			},
-// 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-// 				//Ecpect 200 OK
-// 				require.Equal(t, http.StatusOK, recorder.Code)
-// 			},
-// 		},
-// 		{
-// 			name:      "InvalidID",
-// 			accountID: 0,
-// 			buildStubs: func(store *mock.MockStore) {
-// 				//Store should not be called
-// 				store.EXPECT().
-// 					DeleteAccount(gomock.Any(), gomock.Any()).
-// 					Times(0)
-// 			},
-// 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-// 				//Expect 400 Bad Request
-// 				require.Equal(t, http.StatusBadRequest, recorder.Code)
-// 			},
-// 		},
-// 		{
-// 			name:      "NotFound",
-// 			accountID: account.ID,
-// 			buildStubs: func(store *mock.MockStore) {
-// 				//Simulate account not found
-// 				store.EXPECT().
-// 					DeleteAccount(gomock.Any(), gomock.Eq(account.ID)).
-// 					Times(1).
-// 					Return(sql.ErrNoRows)
-// 			},
-// 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-// 				//Expect 404 Not Found
-// 				require.Equal(t, http.StatusNotFound, recorder.Code)
-// 			},
-// 		},
-// 		{
-// 			name:      "InternalError",
-// 			accountID: account.ID,
-// 			buildStubs: func(store *mock.MockStore) {
-// 				//Simulate database error
-// 				store.EXPECT().
-// 					DeleteAccount(gomock.Any(), gomock.Eq(account.ID)).
-// 					Times(1).
-// 					Return(sql.ErrConnDone)
-// 			},
-// 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-// 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
-// 			},
-// 		},
-// 	}
-
-// 	//Run all test cases
-// 	for i := range testCases {
-// 		tc := testCases[i]
-
-// 		t.Run(tc.name, func(t *testing.T) {
-// 			//Setup gomock controller
-// 			ctrl := gomock.NewController(t)
-// 			defer ctrl.Finish()
-
-// 			//Initialize mock store
-// 			store := mock.NewMockStore(ctrl)
-// 			tc.buildStubs(store)
-
-// 			//Start test server
-// 			server := newTestServer(t, store)
-// 			recorder := httptest.NewRecorder()
-
-// 			//Create HTTP request
-// 			url := fmt.Sprintf("/accounts/%d", tc.accountID)
-// 			request, err := http.NewRequest(http.MethodDelete, url, nil)
-// 			require.NoError(t, err)
-
-// 			//Send request and verify response
-// 			server.router.ServeHTTP(recorder, request)
-// 			tc.checkResponse(t, recorder)
-// 		})
-// 	}
-// }
+				var gotAccounts []db.Account
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &gotAccounts))
+				require.Equal(t, []db.Account{owned1, owned2}, gotAccounts)
+			},
+		},
+		{
+			name: "TooManyIDs",
+			body: gin.H{"ids": []int64{1, 2, 3, 4}},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccountsByIDs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "EmptyIDs",
+			body: gin.H{"ids": []int64{}},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccountsByIDs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, "[]", recorder.Body.String())
+			},
+		},
+		{
+			name: "InvalidID",
+			body: gin.H{"ids": []int64{0}},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccountsByIDs(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	//Run all test cases
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			//Cap batch size at three accounts
+			server := newTestServer(t, store)
+			server.config.BatchGetMaxAccounts = 3
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/accounts/batch_get", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestListAccountAPI tests GET /accounts endpoint
+func TestListAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	//Generate test accounts
+	accounts := []db.Account{
+		randomAccount(user.Username),
+		randomAccount(user.Username),
+		randomAccount(user.Username),
+	}
+
+	//Define test cases
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?page_id=2&page_size=5",
+			buildStubs: func(store *mock.MockStore) {
+				//Only the authenticated user's accounts are listed
+				arg := db.ListAccountsParams{
+					Owner:  user.Username,
+					Limit:  5,
+					Offset: 5,
+				}
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp listAccountResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, accounts, rsp.Data)
+				require.Equal(t, int32(2), rsp.PageID)
+				require.Equal(t, int32(5), rsp.PageSize)
+			},
+		},
+		{
+			name:  "Empty",
+			query: "?page_id=1&page_size=5",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"data":[]`)
+			},
+		},
+		{
+			name:  "InvalidPageID",
+			query: "?page_id=0&page_size=5",
+			buildStubs: func(store *mock.MockStore) {
+				//Store should not be called
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidPageSize",
+			query: "?page_id=1&page_size=100",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "?page_id=1&page_size=5",
+			buildStubs: func(store *mock.MockStore) {
+				//Simulate database error
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	//Run all test cases
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/accounts" + tc.query
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// randomAccount generates a random account for testing
+func randomAccount(owner string) db.Account {
+	return db.Account{
+		ID:       util.RandomInt(1, 1000),
+		Owner:    owner,
+		Balance:  util.RandomMoney(),
+		Currency: util.RandomCurrency(),
+		Status:   db.AccountActive,
+	}
+
+}
+
+// requireBodyMatchAccount validates response body against expected account
+func requireBodyMatchAccount(t *testing.T, body *bytes.Buffer, account db.Account) {
+	//Read response body
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	//Decode JSON response
+	var gotAccount db.Account
+	err = json.Unmarshal(data, &gotAccount)
+
+	//Compare expected and actual account
+	require.NoError(t, err)
+	require.Equal(t, account, gotAccount)
+
+}
+
+// TestUpdateAccountAPI tests PATCH /accounts/:id endpoint
+func TestUpdateAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	account := randomAccount(user.Username)
+	account.Currency = util.USD
+	account.Balance = 0
+	funded := account
+	funded.Balance = 100
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			body:     gin.H{"currency": util.EUR},
+			buildStubs: func(store *mock.MockStore) {
+				updated := account
+				updated.Currency = util.EUR
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					UpdateAccountCurrency(gomock.Any(), gomock.Eq(db.UpdateAccountCurrencyParams{
+						ID:       account.ID,
+						Currency: util.EUR,
+					})).
+					Times(1).
+					Return(updated, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				updated := account
+				updated.Currency = util.EUR
+				requireBodyMatchAccount(t, recorder.Body, updated)
+			},
+		},
+		{
+			name:     "NonZeroBalance",
+			username: user.Username,
+			body:     gin.H{"currency": util.EUR},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(funded, nil)
+				store.EXPECT().UpdateAccountCurrency(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "balance is zero")
+			},
+		},
+		{
+			name:     "BalanceChangedConcurrently",
+			username: user.Username,
+			body:     gin.H{"currency": util.EUR},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateAccountCurrency(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "CurrencyTaken",
+			username: user.Username,
+			body:     gin.H{"currency": util.EUR},
+			buildStubs: func(store *mock.MockStore) {
+				//The owner already holds an account in the new currency
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateAccountCurrency(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeAlreadyExists)
+			},
+		},
+		{
+			name:     "OwnerMismatch",
+			username: other.Username,
+			body:     gin.H{"currency": util.EUR},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateAccountCurrency(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "BalanceNotEditable",
+			username: user.Username,
+			body:     gin.H{"balance": 1000},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UnsupportedCurrency",
+			username: user.Username,
+			body:     gin.H{"currency": "XYZ"},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d", account.ID)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestDeleteAccountAPI tests DELETE /accounts/:id endpoint
+func TestDeleteAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+
+	account := randomAccount(user.Username)
+	account.Balance = 0
+	funded := account
+	funded.Balance = 100
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(store *mock.MockStore) {
+				deletedAt := time.Now()
+				deleted := account
+				deleted.DeletedAt = &deletedAt
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SoftDeleteAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(deleted, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp db.Account
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotNil(t, rsp.DeletedAt)
+			},
+		},
+		{
+			name:     "NonZeroBalance",
+			username: user.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(funded, nil)
+				store.EXPECT().SoftDeleteAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: other.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().SoftDeleteAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "AlreadyDeleted",
+			username: user.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().SoftDeleteAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d", account.ID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestCreateAccountNicknameAPI tests the configurable one-account-per-currency rule
+func TestCreateAccountNicknameAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Nickname = "savings"
+
+	testCases := []struct {
+		name          string
+		allowMultiple bool
+		body          gin.H
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:          "NicknameBlockedByDefault",
+			allowMultiple: false,
+			body:          gin.H{"currency": account.Currency, "nickname": "savings"},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:          "DistinctNicknameAllowed",
+			allowMultiple: true,
+			body:          gin.H{"currency": account.Currency, "nickname": " savings "},
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.CreateAccountParams{
+					Owner:    user.Username,
+					Currency: account.Currency,
+					Balance:  0,
+					Nickname: "savings",
+				}
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Eq(db.GetAccountByOwnerAndCurrencyParams{
+						Owner:    user.Username,
+						Currency: account.Currency,
+						Nickname: "savings",
+					})).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:          "DuplicateNickname",
+			allowMultiple: true,
+			body:          gin.H{"currency": account.Currency, "nickname": "savings"},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(account, nil)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `named \"savings\"`)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.AllowMultipleAccountsPerCurrency = tc.allowMultiple
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestListOwnerCurrenciesAPI tests GET /me/currencies
+func TestListOwnerCurrenciesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "MultiCurrency",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ListOwnerCurrencies(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return([]db.ListOwnerCurrenciesRow{
+						{Currency: util.EUR, AccountCount: 1},
+						{Currency: util.USD, AccountCount: 2},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp []db.ListOwnerCurrenciesRow
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, []db.ListOwnerCurrenciesRow{
+					{Currency: util.EUR, AccountCount: 1},
+					{Currency: util.USD, AccountCount: 2},
+				}, rsp)
+			},
+		},
+		{
+			name: "NoAccounts",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ListOwnerCurrencies(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return([]db.ListOwnerCurrenciesRow{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, "[]", recorder.Body.String())
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ListOwnerCurrencies(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/me/currencies", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+// Request body for reassigning an account
+type reassignAccountRequest struct {
+	NewOwner string `json:"new_owner" binding:"required,alphanum"`
+}
+
+// reassignAccount moves an account to another existing user
+func (server *Server) reassignAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req reassignAccountRequest
+
+	//Bind URI params and request body
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Change the owner and record who did it
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	result, err := server.store.ReassignAccountTx(ctx, db.ReassignAccountTxParams{
+		AccountID: uri.ID,
+		NewOwner:  req.NewOwner,
+		ChangedBy: authPayload.Username,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
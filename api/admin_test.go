@@ -0,0 +1,159 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestReassignAccountAPI tests POST /admin/accounts/:id/reassign
+func TestReassignAccountAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	owner, _ := randomUser(t)
+	newOwner, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: admin.Username,
+			body:     gin.H{"new_owner": newOwner.Username},
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.ReassignAccountTxParams{
+					AccountID: account.ID,
+					NewOwner:  newOwner.Username,
+					ChangedBy: admin.Username,
+				}
+				reassigned := account
+				reassigned.Owner = newOwner.Username
+				store.EXPECT().
+					ReassignAccountTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.ReassignAccountTxResult{
+						Account: reassigned,
+						OwnerChange: db.AccountOwnerChange{
+							AccountID:     account.ID,
+							PreviousOwner: owner.Username,
+							NewOwner:      newOwner.Username,
+							ChangedBy:     admin.Username,
+						},
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp db.ReassignAccountTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, newOwner.Username, rsp.Account.Owner)
+				require.Equal(t, owner.Username, rsp.OwnerChange.PreviousOwner)
+				require.Equal(t, admin.Username, rsp.OwnerChange.ChangedBy)
+			},
+		},
+		{
+			name:     "NewOwnerNotFound",
+			username: admin.Username,
+			body:     gin.H{"new_owner": "nobody"},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ReassignAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ReassignAccountTxResult{}, db.ErrNewOwnerNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Contains(t, recorder.Body.String(), db.ErrNewOwnerNotFound.Error())
+			},
+		},
+		{
+			name:     "NewOwnerHasAccount",
+			username: admin.Username,
+			body:     gin.H{"new_owner": newOwner.Username},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ReassignAccountTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ReassignAccountTxResult{}, db.ErrNewOwnerHasAccount)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeAlreadyExists)
+			},
+		},
+		{
+			name:     "NotAdmin",
+			username: owner.Username,
+			body:     gin.H{"new_owner": newOwner.Username},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ReassignAccountTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeForbidden)
+			},
+		},
+		{
+			name:     "MissingNewOwner",
+			username: admin.Username,
+			body:     gin.H{},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					ReassignAccountTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.setupRouter()
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/admin/accounts/%d/reassign", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			//Only the admin test user holds the banker role
+			role := util.DepositorRole
+			if tc.username == admin.Username {
+				role = util.BankerRole
+			}
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
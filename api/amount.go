@@ -0,0 +1,34 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Amount is a money amount in minor units of its currency, e.g. 150 is 1.50 USD
+// or 150 JPY. It accepts both JSON numbers and numeric strings, so clients that
+// stringify large numbers to avoid precision loss still work
+type Amount int64
+
+// UnmarshalJSON decodes a JSON number or a string holding an integer
+func (amount *Amount) UnmarshalJSON(data []byte) error {
+	//Unwrap string values
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		data = []byte(str)
+	}
+
+	//Parse the integer value
+	value, err := strconv.ParseInt(string(bytes.TrimSpace(data)), 10, 64)
+	if err != nil {
+		return withCode(codeValidationError, fmt.Errorf("invalid amount %s: must be an integer number of minor units, e.g. cents", data))
+	}
+
+	*amount = Amount(value)
+	return nil
+}
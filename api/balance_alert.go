@@ -0,0 +1,214 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/notify"
+	"github.com/gin-gonic/gin"
+)
+
+// Request body for creating or updating a balance alert
+type balanceAlertRequest struct {
+	LowThreshold  *int64 `json:"low_threshold" binding:"omitempty,min=0"`
+	HighThreshold *int64 `json:"high_threshold" binding:"omitempty,min=0"`
+}
+
+// validate checks that the thresholds form a usable alert
+func (req balanceAlertRequest) validate() error {
+	if req.LowThreshold == nil && req.HighThreshold == nil {
+		return errors.New("at least one of low_threshold or high_threshold is required")
+	}
+	if req.LowThreshold != nil && req.HighThreshold != nil && *req.LowThreshold >= *req.HighThreshold {
+		return errors.New("low_threshold must be less than high_threshold")
+	}
+	return nil
+}
+
+// Response payload for a balance alert
+type balanceAlertResponse struct {
+	AccountID     int64     `json:"account_id"`
+	LowThreshold  *int64    `json:"low_threshold"`
+	HighThreshold *int64    `json:"high_threshold"`
+	LowTriggered  bool      `json:"low_triggered"`
+	HighTriggered bool      `json:"high_triggered"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Convert DB balance alert model to API response
+func newBalanceAlertResponse(alert db.BalanceAlert) balanceAlertResponse {
+	rsp := balanceAlertResponse{
+		AccountID:     alert.AccountID,
+		LowTriggered:  alert.LowTriggered,
+		HighTriggered: alert.HighTriggered,
+		CreatedAt:     alert.CreatedAt,
+		UpdatedAt:     alert.UpdatedAt,
+	}
+	if alert.LowThreshold.Valid {
+		rsp.LowThreshold = &alert.LowThreshold.Int64
+	}
+	if alert.HighThreshold.Valid {
+		rsp.HighThreshold = &alert.HighThreshold.Int64
+	}
+	return rsp
+}
+
+// nullInt64 converts an optional value into its SQL representation
+func nullInt64(value *int64) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *value, Valid: true}
+}
+
+// bindBalanceAlertRequest binds the account URI and alert body of a request
+func bindBalanceAlertRequest(ctx *gin.Context) (getAccountRequest, balanceAlertRequest, bool) {
+	var uri getAccountRequest
+	var req balanceAlertRequest
+
+	//Bind URI params
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return uri, req, false
+	}
+
+	//Bind and validate request body
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return uri, req, false
+	}
+	if err := req.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(withCode(codeValidationError, err)))
+		return uri, req, false
+	}
+
+	return uri, req, true
+}
+
+// createBalanceAlert sets the balance thresholds of an owned account
+func (server *Server) createBalanceAlert(ctx *gin.Context) {
+	uri, req, ok := bindBalanceAlertRequest(ctx)
+	if !ok {
+		return
+	}
+
+	//Check account ownership
+	if _, valid := server.ownedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	//Insert alert
+	alert, err := server.store.CreateBalanceAlert(ctx, db.CreateBalanceAlertParams{
+		AccountID:     uri.ID,
+		LowThreshold:  nullInt64(req.LowThreshold),
+		HighThreshold: nullInt64(req.HighThreshold),
+	})
+	if err != nil {
+		//Handle an alert already configured for the account
+		if errors.Is(err, db.ErrUniqueViolation) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newBalanceAlertResponse(alert))
+}
+
+// getBalanceAlert returns the balance alert of an owned account
+func (server *Server) getBalanceAlert(ctx *gin.Context) {
+	var uri getAccountRequest
+
+	//Bind URI params
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Check account ownership
+	if _, valid := server.ownedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	//Fetch alert
+	alert, err := server.store.GetBalanceAlert(ctx, uri.ID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newBalanceAlertResponse(alert))
+}
+
+// updateBalanceAlert replaces the thresholds of an owned account's alert
+func (server *Server) updateBalanceAlert(ctx *gin.Context) {
+	uri, req, ok := bindBalanceAlertRequest(ctx)
+	if !ok {
+		return
+	}
+
+	//Check account ownership
+	if _, valid := server.ownedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	//Update thresholds and re-arm the alert
+	alert, err := server.store.UpdateBalanceAlert(ctx, db.UpdateBalanceAlertParams{
+		AccountID:     uri.ID,
+		LowThreshold:  nullInt64(req.LowThreshold),
+		HighThreshold: nullInt64(req.HighThreshold),
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newBalanceAlertResponse(alert))
+}
+
+// deleteBalanceAlert removes the balance alert of an owned account
+func (server *Server) deleteBalanceAlert(ctx *gin.Context) {
+	var uri getAccountRequest
+
+	//Bind URI params
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Check account ownership
+	if _, valid := server.ownedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	//Delete alert
+	if err := server.store.DeleteBalanceAlert(ctx, uri.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "balance alert deleted"})
+}
+
+// notifyBalanceAlerts delivers fired balance alerts to the account owners.
+// The transfer is already committed, so delivery failures are only logged.
+func (server *Server) notifyBalanceAlerts(ctx *gin.Context, events []db.BalanceAlertEvent) {
+	for _, event := range events {
+		notification := notify.Notification{
+			Username: event.Owner,
+			Subject:  fmt.Sprintf("%s balance alert for account %d", event.Kind, event.AccountID),
+			Content: fmt.Sprintf("Your account %d balance is %d %s, crossing your %s threshold of %d",
+				event.AccountID, event.Balance, event.Currency, event.Kind, event.Threshold),
+		}
+		if err := server.notifier.Notify(ctx, notification); err != nil {
+			log.Printf("cannot send balance alert for account %d: %v", event.AccountID, err)
+		}
+	}
+}
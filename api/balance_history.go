@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// balanceHistoryLimit caps the points returned for one range
+const balanceHistoryLimit = 1000
+
+// Query params for an account's balance history, from inclusive and to exclusive
+type balanceHistoryQuery struct {
+	From time.Time `form:"from" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   time.Time `form:"to" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// Balance of an account right after a change
+type balancePointResponse struct {
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Balance history response; Truncated is set when the range held more than
+// balanceHistoryLimit points
+type balanceHistoryResponse struct {
+	AccountID int64                  `json:"account_id"`
+	Currency  string                 `json:"currency"`
+	From      time.Time              `json:"from"`
+	To        time.Time              `json:"to"`
+	Truncated bool                   `json:"truncated"`
+	Points    []balancePointResponse `json:"points"`
+}
+
+// getBalanceHistory returns the balance snapshots of an owned account within a
+// date range, oldest first
+func (server *Server) getBalanceHistory(ctx *gin.Context) {
+	var req getAccountRequest
+	var query balanceHistoryQuery
+
+	//Bind URI and query params
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !query.To.After(query.From) {
+		err := withCode(codeValidationError, errors.New("to must be after from"))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.ownedAccount(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	//Fetch one extra point to detect a truncated range
+	snapshots, err := server.store.ListBalanceSnapshots(ctx, db.ListBalanceSnapshotsParams{
+		AccountID: account.ID,
+		FromTime:  query.From,
+		ToTime:    query.To,
+		Limit:     balanceHistoryLimit + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := balanceHistoryResponse{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		From:      query.From,
+		To:        query.To,
+		Truncated: len(snapshots) > balanceHistoryLimit,
+	}
+	if rsp.Truncated {
+		snapshots = snapshots[:balanceHistoryLimit]
+	}
+
+	rsp.Points = make([]balancePointResponse, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		rsp.Points = append(rsp.Points, balancePointResponse{
+			Balance:   snapshot.Balance,
+			CreatedAt: snapshot.CreatedAt,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetBalanceHistoryAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	to := time.Now().UTC().Truncate(time.Second)
+	from := to.Add(-24 * time.Hour)
+	snapshots := []db.BalanceSnapshot{
+		{ID: 1, AccountID: account.ID, Balance: 100, CreatedAt: from.Add(time.Hour)},
+		{ID: 2, AccountID: account.ID, Balance: 70, CreatedAt: from.Add(2 * time.Hour)},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		from          string
+		to            string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			from:     from.Format(time.RFC3339),
+			to:       to.Format(time.RFC3339),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ListBalanceSnapshots(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.ListBalanceSnapshotsParams) ([]db.BalanceSnapshot, error) {
+						require.Equal(t, account.ID, arg.AccountID)
+						require.True(t, from.Equal(arg.FromTime))
+						require.True(t, to.Equal(arg.ToTime))
+						require.Equal(t, int32(balanceHistoryLimit+1), arg.Limit)
+						return snapshots, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp balanceHistoryResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, account.ID, rsp.AccountID)
+				require.Equal(t, account.Currency, rsp.Currency)
+				require.False(t, rsp.Truncated)
+				require.Len(t, rsp.Points, 2)
+				require.Equal(t, int64(100), rsp.Points[0].Balance)
+				require.Equal(t, int64(70), rsp.Points[1].Balance)
+				require.True(t, snapshots[1].CreatedAt.Equal(rsp.Points[1].CreatedAt))
+			},
+		},
+		{
+			name:     "EmptyRange",
+			username: user.Username,
+			from:     from.Format(time.RFC3339),
+			to:       to.Format(time.RFC3339),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListBalanceSnapshots(gomock.Any(), gomock.Any()).Times(1).Return(nil, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp balanceHistoryResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotNil(t, rsp.Points)
+				require.Empty(t, rsp.Points)
+			},
+		},
+		{
+			name:     "Truncated",
+			username: user.Username,
+			from:     from.Format(time.RFC3339),
+			to:       to.Format(time.RFC3339),
+			buildStubs: func(store *mock.MockStore) {
+				many := make([]db.BalanceSnapshot, balanceHistoryLimit+1)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListBalanceSnapshots(gomock.Any(), gomock.Any()).Times(1).Return(many, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp balanceHistoryResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.True(t, rsp.Truncated)
+				require.Len(t, rsp.Points, balanceHistoryLimit)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: other.Username,
+			from:     from.Format(time.RFC3339),
+			to:       to.Format(time.RFC3339),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListBalanceSnapshots(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "ToBeforeFrom",
+			username: user.Username,
+			from:     to.Format(time.RFC3339),
+			to:       from.Format(time.RFC3339),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: user.Username,
+			from:     from.Format(time.RFC3339),
+			to:       to.Format(time.RFC3339),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListBalanceSnapshots(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/accounts/%d/balance_history?from=%s&to=%s",
+				account.ID, url.QueryEscape(tc.from), url.QueryEscape(tc.to))
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
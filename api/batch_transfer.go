@@ -0,0 +1,151 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// A single transfer of a batch request
+type batchTransferLegRequest struct {
+	ToAccountID int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount      Amount `json:"amount" binding:"required,gt=0"`
+	Currency    string `json:"currency" binding:"required,known_currency"`
+	Memo        string `json:"memo" binding:"max=140"`
+}
+
+// Batch transfer request payload, holding at most 100 transfers
+type batchTransferRequest struct {
+	FromAccountID int64                     `json:"from_account_id" binding:"required,min=1"`
+	Transfers     []batchTransferLegRequest `json:"transfers" binding:"required,min=1,max=100,dive"`
+}
+
+// Batch transfer result payload
+type batchTransferResponse struct {
+	FromAccount db.Account           `json:"from_account"`
+	Total       int64                `json:"total"`
+	Transfers   []transferTxResponse `json:"transfers"`
+}
+
+// createBatchTransfer sends several same-currency transfers from one account,
+// applying all of them or none
+func (server *Server) createBatchTransfer(ctx *gin.Context) {
+	var req batchTransferRequest
+
+	//Validate request body
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	for i := range req.Transfers {
+		req.Transfers[i].Currency = util.NormalizeCurrency(req.Transfers[i].Currency)
+	}
+
+	//Validate the source account before looking at the legs
+	fromAccount, valid := server.existingAccount(ctx, req.FromAccountID)
+	if !valid {
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := withCode(codeUnauthorized, errors.New("from account doesn't belong to the authenticated user"))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if !util.IsEnabledCurrency(fromAccount.Currency) {
+		err := withCode(codeCurrencyDisabled, fmt.Errorf("currency %s is disabled: batch transfers are not allowed", fromAccount.Currency))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var total int64
+	legs := make([]db.BatchTransferLeg, 0, len(req.Transfers))
+	for i, leg := range req.Transfers {
+		if leg.ToAccountID == req.FromAccountID {
+			err := withCode(codeValidationError, fmt.Errorf("transfers[%d]: cannot send to the source account", i))
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		if leg.Currency != fromAccount.Currency {
+			err := withCode(codeCurrencyMismatch, fmt.Errorf("transfers[%d]: currency %s doesn't match account [%d] in %s", i, leg.Currency, fromAccount.ID, fromAccount.Currency))
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		if maxAmount := server.config.MaxTransferAmount; maxAmount > 0 && int64(leg.Amount) > maxAmount {
+			err := withCode(codeTransferLimit, fmt.Errorf("transfers[%d]: amount %d exceeds the maximum transfer amount of %d", i, leg.Amount, maxAmount))
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		if int64(leg.Amount) > math.MaxInt64-total {
+			err := withCode(codeValidationError, errors.New("total amount of the batch is too large"))
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		total += int64(leg.Amount)
+
+		legs = append(legs, db.BatchTransferLeg{
+			ToAccountID: leg.ToAccountID,
+			Amount:      int64(leg.Amount),
+			Memo:        leg.Memo,
+		})
+	}
+
+	//Fail fast when the source can't cover the whole batch, the store checks
+	//again under lock
+	if fromAccount.Balance < total {
+		err := fmt.Errorf("account [%d] cannot cover the batch total of %d: %w", fromAccount.ID, total, db.ErrInsufficientFunds)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Destinations must exist and hold the same currency, conversions aren't
+	//supported in batches
+	for i, leg := range req.Transfers {
+		toAccount, valid := server.existingAccount(ctx, leg.ToAccountID)
+		if !valid {
+			return
+		}
+		if toAccount.Currency != fromAccount.Currency {
+			err := withCode(codeCurrencyMismatch, fmt.Errorf("transfers[%d]: account [%d] holds %s, batch transfers can't convert currencies", i, toAccount.ID, toAccount.Currency))
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		if server.config.RequireVerifiedEmailForTransfers && !server.verifiedOwner(ctx, toAccount.Owner, "recipient") {
+			return
+		}
+	}
+	if server.config.RequireVerifiedEmailForTransfers && !server.verifiedOwner(ctx, fromAccount.Owner, "sender") {
+		return
+	}
+
+	arg := db.BatchTransferTxParams{
+		FromAccountID: fromAccount.ID,
+		Legs:          legs,
+	}
+	arg.DailyLimit, arg.DailyLimitSince = server.dailyTransferLimit()
+
+	result, err := server.store.BatchTransferTx(ctx, arg)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	rsp := batchTransferResponse{
+		FromAccount: result.FromAccount,
+		Total:       result.Total,
+		Transfers:   make([]transferTxResponse, 0, len(result.Legs)),
+	}
+	for _, leg := range result.Legs {
+		server.metrics.recordTransfer(fromAccount.Currency, leg.Transfer.Amount)
+		server.notifyBalanceAlerts(ctx, leg.Alerts)
+		rsp.Transfers = append(rsp.Transfers, newTransferTxResponse(leg))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
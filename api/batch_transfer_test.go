@@ -0,0 +1,302 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestCreateBatchTransferAPI tests POST /transfers/batch
+func TestCreateBatchTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	user3, _ := randomUser(t)
+
+	from := randomAccount(user1.Username)
+	to1 := randomAccount(user2.Username)
+	to2 := randomAccount(user3.Username)
+	from.ID, to1.ID, to2.ID = 1, 2, 3
+	from.Currency, to1.Currency, to2.Currency = util.USD, util.USD, util.USD
+	from.Balance = 100
+
+	eurAccount := randomAccount(user3.Username)
+	eurAccount.ID = 4
+	eurAccount.Currency = util.EUR
+
+	body := gin.H{
+		"from_account_id": from.ID,
+		"transfers": []gin.H{
+			{"to_account_id": to1.ID, "amount": 30, "currency": util.USD, "memo": "salary"},
+			{"to_account_id": to2.ID, "amount": 50, "currency": util.USD},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: body,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to1.ID)).Times(1).Return(to1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to2.ID)).Times(1).Return(to2, nil)
+
+				arg := db.BatchTransferTxParams{
+					FromAccountID: from.ID,
+					Legs: []db.BatchTransferLeg{
+						{ToAccountID: to1.ID, Amount: 30, Memo: "salary"},
+						{ToAccountID: to2.ID, Amount: 50},
+					},
+				}
+				after := from
+				after.Balance = 20
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.BatchTransferTxResult{
+					Legs: []db.TransferTxResult{
+						{Transfer: db.Transfer{ID: 10, FromAccountID: from.ID, ToAccountID: to1.ID, Amount: 30}},
+						{Transfer: db.Transfer{ID: 11, FromAccountID: from.ID, ToAccountID: to2.ID, Amount: 50}},
+					},
+					FromAccount: after,
+					Total:       80,
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp batchTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, int64(80), rsp.Total)
+				require.Equal(t, int64(20), rsp.FromAccount.Balance)
+				require.Len(t, rsp.Transfers, 2)
+				require.Equal(t, to2.ID, rsp.Transfers[1].Transfer.ToAccountID)
+			},
+		},
+		{
+			name: "LowercaseCurrency",
+			body: gin.H{
+				"from_account_id": from.ID,
+				"transfers": []gin.H{
+					{"to_account_id": to1.ID, "amount": 30, "currency": strings.ToLower(util.USD)},
+					{"to_account_id": to2.ID, "amount": 50, "currency": " " + strings.ToLower(util.USD)},
+				},
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(_ any, id int64) (db.Account, error) {
+					return map[int64]db.Account{from.ID: from, to1.ID: to1, to2.ID: to2}[id], nil
+				})
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BatchTransferTxResult{FromAccount: from, Total: 80}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "OverTotalBalance",
+			body: gin.H{
+				"from_account_id": from.ID,
+				"transfers": []gin.H{
+					{"to_account_id": to1.ID, "amount": 60, "currency": util.USD},
+					{"to_account_id": to2.ID, "amount": 60, "currency": util.USD},
+				},
+			},
+			buildStubs: func(store *mock.MockStore) {
+				//Each leg fits the balance on its own, the total doesn't
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeInsufficientFunds)
+			},
+		},
+		{
+			name: "MidBatchFailure",
+			body: body,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(_ any, id int64) (db.Account, error) {
+					return map[int64]db.Account{from.ID: from, to1.ID: to1, to2.ID: to2}[id], nil
+				})
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.BatchTransferTxResult{}, &db.BatchTransferLegError{Leg: 1, Err: sql.ErrConnDone})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "transfers[1]")
+			},
+		},
+		{
+			name: "ConcurrentOverdraft",
+			body: body,
+			buildStubs: func(store *mock.MockStore) {
+				//The balance dropped after it was read, the store rejects the batch under lock
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(_ any, id int64) (db.Account, error) {
+					return map[int64]db.Account{from.ID: from, to1.ID: to1, to2.ID: to2}[id], nil
+				})
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BatchTransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeInsufficientFunds)
+			},
+		},
+		{
+			name: "DailyLimitExceeded",
+			body: body,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(_ any, id int64) (db.Account, error) {
+					return map[int64]db.Account{from.ID: from, to1.ID: to1, to2.ID: to2}[id], nil
+				})
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.BatchTransferTxResult{}, db.ErrDailyTransferLimit)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeDailyLimit)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: body,
+			buildStubs: func(store *mock.MockStore) {
+				other := from
+				other.Owner = user2.Username
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(other, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "LegCurrencyMismatch",
+			body: gin.H{
+				"from_account_id": from.ID,
+				"transfers": []gin.H{
+					{"to_account_id": to1.ID, "amount": 10, "currency": util.EUR},
+				},
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeCurrencyMismatch)
+			},
+		},
+		{
+			name: "DestinationOtherCurrency",
+			body: gin.H{
+				"from_account_id": from.ID,
+				"transfers": []gin.H{
+					{"to_account_id": eurAccount.ID, "amount": 10, "currency": util.USD},
+				},
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(eurAccount.ID)).Times(1).Return(eurAccount, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeCurrencyMismatch)
+			},
+		},
+		{
+			name: "DestinationNotFound",
+			body: body,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(to1.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "SendToSource",
+			body: gin.H{
+				"from_account_id": from.ID,
+				"transfers": []gin.H{
+					{"to_account_id": from.ID, "amount": 10, "currency": util.USD},
+				},
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(from.ID)).Times(1).Return(from, nil)
+				store.EXPECT().BatchTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeValidationError)
+			},
+		},
+		{
+			name: "EmptyBatch",
+			body: gin.H{
+				"from_account_id": from.ID,
+				"transfers":       []gin.H{},
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidLegAmount",
+			body: gin.H{
+				"from_account_id": from.ID,
+				"transfers": []gin.H{
+					{"to_account_id": to1.ID, "amount": -5, "currency": util.USD},
+				},
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers/batch", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+// Request body for changing the authenticated user's password
+type changePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+
+	//Block every refresh session, including the current one, once the password changed
+	RevokeSessions bool `json:"revoke_sessions"`
+}
+
+// Password change response payload
+type changePasswordResponse struct {
+	User            userResponse `json:"user"`
+	RevokedSessions int64        `json:"revoked_sessions"`
+}
+
+// changePassword replaces the authenticated user's password after checking the
+// old one. Attempts share the verify_password rate limit.
+func (server *Server) changePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+
+	//Validate request body
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Throttle repeated attempts for the same user
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !server.verifyPasswordLimiter.allow(authPayload.Username) {
+		err := withCode(codeRateLimited, errors.New("too many password verification attempts"))
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	//The caller must know the current password
+	err = server.passwords.Check(req.OldPassword, user.HashedPassword, user.PasswordPepperVersion)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(withCode(codeInvalidCredentials, errors.New("old password is incorrect"))))
+		return
+	}
+	if req.NewPassword == req.OldPassword {
+		err := withCode(codeValidationError, errors.New("new password must differ from the old one"))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedPassword, pepperVersion, err := server.passwords.Hash(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	user, err = server.store.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		Username:              user.Username,
+		HashedPassword:        hashedPassword,
+		PasswordPepperVersion: pepperVersion,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := changePasswordResponse{User: newUserResponse(user)}
+	if req.RevokeSessions {
+		rsp.RevokedSessions, err = server.store.BlockUserSessions(ctx, user.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestDisabledCurrency verifies disabled currencies block new activity but stay readable
+func TestDisabledCurrency(t *testing.T) {
+	user, _ := randomUser(t)
+	account1 := randomAccount(user.Username)
+	account2 := randomAccount(user.Username)
+	account1.ID, account2.ID = 1, 2
+	account1.Currency, account2.Currency = util.EUR, util.EUR
+	account1.Balance = 100
+
+	testCases := []struct {
+		name          string
+		method        string
+		url           string
+		body          string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "CreateAccountRejected",
+			method: http.MethodPost,
+			url:    "/accounts",
+			body:   `{"currency":"EUR"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:   "ExistingAccountReadable",
+			method: http.MethodGet,
+			url:    fmt.Sprintf("/accounts/%d", account1.ID),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account1)
+			},
+		},
+		{
+			name:   "PartialTransferRejected",
+			method: http.MethodPost,
+			url:    "/transfers",
+			body:   `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"EUR"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				var body map[string]any
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+				require.Equal(t, codeCurrencyDisabled, body["code"])
+			},
+		},
+		{
+			name:   "SweepAllowed",
+			method: http.MethodPost,
+			url:    "/transfers",
+			body:   `{"from_account_id":1,"to_account_id":2,"amount":100,"currency":"EUR"}`,
+			buildStubs: func(store *mock.MockStore) {
+				zero := int64(0)
+				arg := db.TransferTxParams{
+					FromAccountID:         account1.ID,
+					ToAccountID:           account2.ID,
+					Amount:                account1.Balance,
+					MinSourceBalanceAfter: &zero,
+				}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			require.NoError(t, util.SetDisabledCurrencies([]string{util.EUR}))
+			defer util.SetDisabledCurrencies(nil)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(tc.method, tc.url, bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// Entry response payload labelling the direction of the money movement.
+// Amount is always positive, SignedAmount keeps the stored raw value.
+type entryResponse struct {
+	ID           int64     `json:"id"`
+	AccountID    int64     `json:"account_id"`
+	Direction    string    `json:"direction"`
+	Amount       int64     `json:"amount"`
+	SignedAmount int64     `json:"signed_amount"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// newEntryResponse converts a DB entry to its API representation
+func newEntryResponse(entry db.Entry) entryResponse {
+	return entryResponse{
+		ID:           entry.ID,
+		AccountID:    entry.AccountID,
+		Direction:    entry.Direction(),
+		Amount:       entry.AbsAmount(),
+		SignedAmount: entry.Amount,
+		CreatedAt:    entry.CreatedAt,
+	}
+}
+
+// Query params for an account's entries over a date range, from inclusive
+// and to exclusive. Without a range the whole ledger is listed, newest first.
+type listAccountEntriesQuery struct {
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	PageID   int32     `form:"page_id" binding:"required,min=1"`
+	PageSize int32     `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// Entry in a statement, with the account balance right after it was booked
+type statementEntryResponse struct {
+	entryResponse
+	RunningBalance int64 `json:"running_balance"`
+}
+
+// Inflow/outflow totals over the whole requested range
+type statementSummary struct {
+	TotalDebit  int64 `json:"total_debit"`
+	TotalCredit int64 `json:"total_credit"`
+	Net         int64 `json:"net"`
+	EntryCount  int64 `json:"entry_count"`
+}
+
+// Account statement response
+type accountEntriesResponse struct {
+	AccountID int64                    `json:"account_id"`
+	Currency  string                   `json:"currency"`
+	From      time.Time                `json:"from"`
+	To        time.Time                `json:"to"`
+	Summary   statementSummary         `json:"summary"`
+	Entries   []statementEntryResponse `json:"entries"`
+}
+
+// Raw ledger listing response, newest entry first
+type ledgerEntriesResponse struct {
+	AccountID int64                    `json:"account_id"`
+	Currency  string                   `json:"currency"`
+	Entries   []statementEntryResponse `json:"entries"`
+}
+
+// listAccountEntries returns a page of an owned account's entries within a
+// date range, with running balances and a debit/credit summary of the range.
+// Without a range it falls back to the raw ledger listing.
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	var req getAccountRequest
+	var query listAccountEntriesQuery
+
+	//Bind URI and query params
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if query.From.IsZero() && query.To.IsZero() {
+		server.listLedgerEntries(ctx, req.ID, query)
+		return
+	}
+	if query.From.IsZero() || query.To.IsZero() {
+		err := withCode(codeValidationError, errors.New("from and to must be given together"))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !query.To.After(query.From) {
+		err := withCode(codeValidationError, errors.New("to must be after from"))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := server.ownedAccount(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	entries, err := server.store.ListEntriesByAccountAndDate(ctx, db.ListEntriesByAccountAndDateParams{
+		AccountID: account.ID,
+		FromTime:  query.From,
+		ToTime:    query.To,
+		Limit:     query.PageSize,
+		Offset:    (query.PageID - 1) * query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	summary, err := server.store.SummarizeEntriesInRange(ctx, db.SummarizeEntriesInRangeParams{
+		AccountID: account.ID,
+		FromTime:  query.From,
+		ToTime:    query.To,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := accountEntriesResponse{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		From:      query.From,
+		To:        query.To,
+		Summary: statementSummary{
+			TotalDebit:  summary.TotalDebit,
+			TotalCredit: summary.TotalCredit,
+			Net:         summary.TotalCredit - summary.TotalDebit,
+			EntryCount:  summary.EntryCount,
+		},
+		Entries: make([]statementEntryResponse, 0, len(entries)),
+	}
+
+	//Running balances start from everything booked before the page
+	if len(entries) > 0 {
+		balance, err := server.store.SumEntriesBeforeEntry(ctx, db.SumEntriesBeforeEntryParams{
+			AccountID: account.ID,
+			CreatedAt: entries[0].CreatedAt,
+			ID:        entries[0].ID,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		for _, entry := range entries {
+			balance += entry.Amount
+			rsp.Entries = append(rsp.Entries, statementEntryResponse{
+				entryResponse:  newEntryResponse(entry),
+				RunningBalance: balance,
+			})
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// listLedgerEntries returns a page of an owned account's entries, newest
+// first, for reconciling the ledger against the balance
+func (server *Server) listLedgerEntries(ctx *gin.Context, accountID int64, query listAccountEntriesQuery) {
+	account, ok := server.ownedAccount(ctx, accountID)
+	if !ok {
+		return
+	}
+
+	entries, err := server.store.ListEntries(ctx, db.ListEntriesParams{
+		AccountID: account.ID,
+		Limit:     query.PageSize,
+		Offset:    (query.PageID - 1) * query.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := ledgerEntriesResponse{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		Entries:   make([]statementEntryResponse, len(entries)),
+	}
+
+	//Running balances accumulate from the oldest entry of the page, the last one
+	if len(entries) > 0 {
+		oldest := entries[len(entries)-1]
+		balance, err := server.store.SumEntriesBeforeEntry(ctx, db.SumEntriesBeforeEntryParams{
+			AccountID: account.ID,
+			CreatedAt: oldest.CreatedAt,
+			ID:        oldest.ID,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		for i := len(entries) - 1; i >= 0; i-- {
+			balance += entries[i].Amount
+			rsp.Entries[i] = statementEntryResponse{
+				entryResponse:  newEntryResponse(entries[i]),
+				RunningBalance: balance,
+			}
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,373 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestNewEntryResponse verifies debits report a positive amount and their direction
+func TestNewEntryResponse(t *testing.T) {
+	debit := newEntryResponse(db.Entry{ID: 1, AccountID: 2, Amount: -50})
+	require.Equal(t, db.EntryDebit, debit.Direction)
+	require.Equal(t, int64(50), debit.Amount)
+	require.Equal(t, int64(-50), debit.SignedAmount)
+
+	credit := newEntryResponse(db.Entry{ID: 2, AccountID: 3, Amount: 50})
+	require.Equal(t, db.EntryCredit, credit.Direction)
+	require.Equal(t, int64(50), credit.Amount)
+	require.Equal(t, int64(50), credit.SignedAmount)
+}
+
+// TestCreateTransferEntryDirection verifies the transfer response labels its entries
+func TestCreateTransferEntryDirection(t *testing.T) {
+	user, _ := randomUser(t)
+	account1 := randomAccount(user.Username)
+	account2 := randomAccount(user.Username)
+	account1.ID, account2.ID = 1, 2
+	account1.Currency, account2.Currency = util.USD, util.USD
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+	store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{
+		FromEntry: db.Entry{ID: 1, AccountID: account1.ID, Amount: -10},
+		ToEntry:   db.Entry{ID: 2, AccountID: account2.ID, Amount: 10},
+	}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	body := `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD"}`
+	request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp struct {
+		FromEntry entryResponse `json:"from_entry"`
+		ToEntry   entryResponse `json:"to_entry"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, db.EntryDebit, rsp.FromEntry.Direction)
+	require.Equal(t, int64(10), rsp.FromEntry.Amount)
+	require.Equal(t, int64(-10), rsp.FromEntry.SignedAmount)
+	require.Equal(t, db.EntryCredit, rsp.ToEntry.Direction)
+	require.Equal(t, int64(10), rsp.ToEntry.SignedAmount)
+}
+
+// TestListAccountEntriesAPI tests GET /accounts/:id/entries
+func TestListAccountEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	to := time.Now().UTC().Truncate(time.Second)
+	from := to.Add(-24 * time.Hour)
+	entries := []db.Entry{
+		{ID: 3, AccountID: account.ID, Amount: 100, CreatedAt: from.Add(time.Hour)},
+		{ID: 4, AccountID: account.ID, Amount: -30, CreatedAt: from.Add(2 * time.Hour)},
+		{ID: 5, AccountID: account.ID, Amount: 20, CreatedAt: from.Add(3 * time.Hour)},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		from          string
+		to            string
+		pageID        int
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			from:     from.Format(time.RFC3339),
+			to:       to.Format(time.RFC3339),
+			pageID:   2,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ListEntriesByAccountAndDate(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.ListEntriesByAccountAndDateParams) ([]db.Entry, error) {
+						require.Equal(t, account.ID, arg.AccountID)
+						require.True(t, from.Equal(arg.FromTime))
+						require.True(t, to.Equal(arg.ToTime))
+						require.Equal(t, int32(5), arg.Limit)
+						require.Equal(t, int32(5), arg.Offset)
+						return entries, nil
+					})
+				store.EXPECT().
+					SummarizeEntriesInRange(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.SummarizeEntriesInRangeRow{TotalDebit: 80, TotalCredit: 220, EntryCount: 8}, nil)
+				store.EXPECT().
+					SumEntriesBeforeEntry(gomock.Any(), gomock.Eq(db.SumEntriesBeforeEntryParams{
+						AccountID: account.ID,
+						CreatedAt: entries[0].CreatedAt,
+						ID:        entries[0].ID,
+					})).
+					Times(1).
+					Return(int64(50), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp accountEntriesResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, account.ID, rsp.AccountID)
+				require.Equal(t, statementSummary{TotalDebit: 80, TotalCredit: 220, Net: 140, EntryCount: 8}, rsp.Summary)
+				require.Len(t, rsp.Entries, 3)
+				require.Equal(t, []int64{150, 120, 140}, []int64{
+					rsp.Entries[0].RunningBalance,
+					rsp.Entries[1].RunningBalance,
+					rsp.Entries[2].RunningBalance,
+				})
+				require.Equal(t, db.EntryDebit, rsp.Entries[1].Direction)
+				require.Equal(t, int64(30), rsp.Entries[1].Amount)
+			},
+		},
+		{
+			name:     "EmptyRange",
+			username: user.Username,
+			from:     from.Format(time.RFC3339),
+			to:       to.Format(time.RFC3339),
+			pageID:   1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntriesByAccountAndDate(gomock.Any(), gomock.Any()).Times(1).Return(nil, nil)
+				store.EXPECT().SummarizeEntriesInRange(gomock.Any(), gomock.Any()).Times(1).Return(db.SummarizeEntriesInRangeRow{}, nil)
+				store.EXPECT().SumEntriesBeforeEntry(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp accountEntriesResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotNil(t, rsp.Entries)
+				require.Empty(t, rsp.Entries)
+				require.Zero(t, rsp.Summary)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: other.Username,
+			from:     from.Format(time.RFC3339),
+			to:       to.Format(time.RFC3339),
+			pageID:   1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntriesByAccountAndDate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "ToBeforeFrom",
+			username: user.Username,
+			from:     to.Format(time.RFC3339),
+			to:       from.Format(time.RFC3339),
+			pageID:   1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidDate",
+			username: user.Username,
+			from:     "yesterday",
+			to:       to.Format(time.RFC3339),
+			pageID:   1,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/accounts/%d/entries?from=%s&to=%s&page_id=%d&page_size=5",
+				account.ID, url.QueryEscape(tc.from), url.QueryEscape(tc.to), tc.pageID)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestListLedgerEntriesAPI tests the raw newest-first listing without a date range
+func TestListLedgerEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	entries := []db.Entry{
+		{ID: 9, AccountID: account.ID, Amount: 20, CreatedAt: now},
+		{ID: 8, AccountID: account.ID, Amount: -30, CreatedAt: now.Add(-time.Hour)},
+		{ID: 7, AccountID: account.ID, Amount: 100, CreatedAt: now.Add(-2 * time.Hour)},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		query         string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			query:    "page_id=3&page_size=5",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ListEntries(gomock.Any(), gomock.Eq(db.ListEntriesParams{
+						AccountID: account.ID,
+						Limit:     5,
+						Offset:    10,
+					})).
+					Times(1).
+					Return(entries, nil)
+				store.EXPECT().
+					SumEntriesBeforeEntry(gomock.Any(), gomock.Eq(db.SumEntriesBeforeEntryParams{
+						AccountID: account.ID,
+						CreatedAt: entries[2].CreatedAt,
+						ID:        entries[2].ID,
+					})).
+					Times(1).
+					Return(int64(50), nil)
+				store.EXPECT().ListEntriesByAccountAndDate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp ledgerEntriesResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, account.ID, rsp.AccountID)
+				require.Len(t, rsp.Entries, 3)
+				require.Equal(t, []int64{9, 8, 7}, []int64{rsp.Entries[0].ID, rsp.Entries[1].ID, rsp.Entries[2].ID})
+				require.Equal(t, []int64{140, 120, 150}, []int64{
+					rsp.Entries[0].RunningBalance,
+					rsp.Entries[1].RunningBalance,
+					rsp.Entries[2].RunningBalance,
+				})
+				require.Equal(t, int64(-30), rsp.Entries[1].SignedAmount)
+				require.True(t, entries[1].CreatedAt.Equal(rsp.Entries[1].CreatedAt))
+			},
+		},
+		{
+			name:     "EmptyPage",
+			username: user.Username,
+			query:    "page_id=1&page_size=5",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Any()).Times(1).Return(nil, nil)
+				store.EXPECT().SumEntriesBeforeEntry(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp ledgerEntriesResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotNil(t, rsp.Entries)
+				require.Empty(t, rsp.Entries)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: other.Username,
+			query:    "page_id=1&page_size=5",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidPageSize",
+			username: user.Username,
+			query:    "page_id=1&page_size=50",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "HalfOpenRange",
+			username: user.Username,
+			query:    "page_id=1&page_size=5&from=" + url.QueryEscape(now.Format(time.RFC3339)),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/accounts/%d/entries?%s", account.ID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// Machine-readable error codes returned alongside the error message
+const (
+	codeValidationError    = "VALIDATION_ERROR"
+	codeNotFound           = "NOT_FOUND"
+	codeMethodNotAllowed   = "METHOD_NOT_ALLOWED"
+	codeAlreadyExists      = "ALREADY_EXISTS"
+	codeForeignKey         = "FOREIGN_KEY_VIOLATION"
+	codeUnauthorized       = "UNAUTHORIZED"
+	codeForbidden          = "FORBIDDEN"
+	codeInvalidCredentials = "INVALID_CREDENTIALS"
+	codeInvalidToken       = "INVALID_TOKEN"
+	codeTokenExpired       = "TOKEN_EXPIRED"
+	codeTokenNotActive     = "TOKEN_NOT_ACTIVE_YET"
+	codeCurrencyMismatch   = "CURRENCY_MISMATCH"
+	codeAccountFrozen      = "ACCOUNT_FROZEN"
+	codeCurrencyDisabled   = "CURRENCY_DISABLED"
+	codeNoExchangeRate     = "EXCHANGE_RATE_REQUIRED"
+	codeEmailNotVerified   = "EMAIL_NOT_VERIFIED"
+	codeMinBalanceNotMet   = "MIN_BALANCE_NOT_MET"
+	codeInsufficientFunds  = "INSUFFICIENT_FUNDS"
+	codeRefundExceeded     = "REFUND_EXCEEDS_REMAINDER"
+	codeAlreadyReversed    = "TRANSFER_ALREADY_REVERSED"
+	codeTransferLimit      = "TRANSFER_LIMIT_EXCEEDED"
+	codeDailyLimit         = "DAILY_LIMIT_EXCEEDED"
+	codeRequestExpired     = "TRANSFER_REQUEST_EXPIRED"
+	codeRequestAnswered    = "TRANSFER_REQUEST_ANSWERED"
+	codeVerifyEmailUsed    = "VERIFY_EMAIL_USED"
+	codeVerifyEmailExpired = "VERIFY_EMAIL_EXPIRED"
+	codeRateLimited        = "RATE_LIMITED"
+	codeTagLimitExceeded   = "TAG_LIMIT_EXCEEDED"
+	codeOriginNotAllowed   = "ORIGIN_NOT_ALLOWED"
+	codeIPNotAllowed       = "IP_NOT_ALLOWED"
+	codeUnavailable        = "SERVICE_UNAVAILABLE"
+	codeBodyTooLarge       = "BODY_TOO_LARGE"
+	codeInternal           = "INTERNAL_ERROR"
+)
+
+// codedError attaches a machine-readable code to an error
+type codedError struct {
+	code string
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+
+func (e *codedError) Unwrap() error { return e.err }
+
+// withCode tags an error with an explicit error code
+func withCode(code string, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// errorCode derives the error code from the typed error
+func errorCode(err error) string {
+	var coded *codedError
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var numErr *strconv.NumError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &coded):
+		return coded.code
+	case errors.As(err, &maxBytesErr):
+		return codeBodyTooLarge
+	case errors.Is(err, db.ErrRecordNotFound):
+		return codeNotFound
+	case errors.Is(err, db.ErrUniqueViolation):
+		return codeAlreadyExists
+	case errors.Is(err, db.ErrForeignKeyViolation):
+		return codeForeignKey
+	case errors.Is(err, db.ErrMinBalancePrecondition):
+		return codeMinBalanceNotMet
+	case errors.Is(err, db.ErrInsufficientFunds):
+		return codeInsufficientFunds
+	case errors.Is(err, db.ErrAccountFrozen):
+		return codeAccountFrozen
+	case errors.Is(err, db.ErrDailyTransferLimit):
+		return codeDailyLimit
+	case errors.Is(err, ErrNoExchangeRate):
+		return codeNoExchangeRate
+	case errors.Is(err, db.ErrRefundExceedsRemainder):
+		return codeRefundExceeded
+	case errors.Is(err, db.ErrTransferAlreadyReversed):
+		return codeAlreadyReversed
+	case errors.Is(err, db.ErrTransferRequestExpired):
+		return codeRequestExpired
+	case errors.Is(err, db.ErrTransferRequestNotPending):
+		return codeRequestAnswered
+	case errors.Is(err, db.ErrVerifyEmailUsed):
+		return codeVerifyEmailUsed
+	case errors.Is(err, db.ErrVerifyEmailExpired),
+		errors.Is(err, db.ErrVerifyEmailChanged):
+		return codeVerifyEmailExpired
+	case errors.Is(err, db.ErrNewOwnerHasAccount):
+		return codeAlreadyExists
+	case errors.Is(err, db.ErrNewOwnerNotFound):
+		return codeNotFound
+	case errors.Is(err, db.ErrOwnerUnchanged):
+		return codeValidationError
+	case errors.Is(err, db.ErrFundingAccountNotOwned):
+		return codeForbidden
+	case errors.Is(err, db.ErrFundingCurrencyMismatch):
+		return codeCurrencyMismatch
+	case errors.Is(err, token.ErrExpiredToken):
+		return codeTokenExpired
+	case errors.Is(err, token.ErrInvalidToken):
+		return codeInvalidToken
+	case errors.Is(err, token.ErrTokenNotActiveYet):
+		return codeTokenNotActive
+	case errors.As(err, &validationErrs),
+		errors.As(err, &syntaxErr),
+		errors.As(err, &typeErr),
+		errors.As(err, &numErr),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return codeValidationError
+	}
+
+	//Untranslated driver errors
+	switch db.ErrorCode(err) {
+	case db.UniqueViolation:
+		return codeAlreadyExists
+	case db.ForeignKeyViolation:
+		return codeForeignKey
+	}
+	return codeInternal
+}
+
+// errorStatus maps error codes to the HTTP status they are usually served with
+var errorStatus = map[string]int{
+	codeValidationError:    http.StatusBadRequest,
+	codeNotFound:           http.StatusNotFound,
+	codeMethodNotAllowed:   http.StatusMethodNotAllowed,
+	codeAlreadyExists:      http.StatusConflict,
+	codeForeignKey:         http.StatusUnprocessableEntity,
+	codeUnauthorized:       http.StatusUnauthorized,
+	codeForbidden:          http.StatusForbidden,
+	codeInvalidCredentials: http.StatusUnauthorized,
+	codeInvalidToken:       http.StatusUnauthorized,
+	codeTokenExpired:       http.StatusUnauthorized,
+	codeTokenNotActive:     http.StatusUnauthorized,
+	codeCurrencyMismatch:   http.StatusBadRequest,
+	codeAccountFrozen:      http.StatusForbidden,
+	codeCurrencyDisabled:   http.StatusBadRequest,
+	codeNoExchangeRate:     http.StatusBadRequest,
+	codeEmailNotVerified:   http.StatusForbidden,
+	codeMinBalanceNotMet:   http.StatusPreconditionFailed,
+	codeInsufficientFunds:  http.StatusBadRequest,
+	codeRefundExceeded:     http.StatusConflict,
+	codeAlreadyReversed:    http.StatusConflict,
+	codeTransferLimit:      http.StatusBadRequest,
+	codeDailyLimit:         http.StatusForbidden,
+	codeRequestExpired:     http.StatusGone,
+	codeRequestAnswered:    http.StatusConflict,
+	codeVerifyEmailUsed:    http.StatusConflict,
+	codeVerifyEmailExpired: http.StatusGone,
+	codeRateLimited:        http.StatusTooManyRequests,
+	codeTagLimitExceeded:   http.StatusBadRequest,
+	codeOriginNotAllowed:   http.StatusForbidden,
+	codeIPNotAllowed:       http.StatusForbidden,
+	codeUnavailable:        http.StatusServiceUnavailable,
+	codeBodyTooLarge:       http.StatusRequestEntityTooLarge,
+	codeInternal:           http.StatusInternalServerError,
+}
+
+// httpStatusForError derives the HTTP status from the typed error, falling
+// back to 500 for errors the API does not know
+func httpStatusForError(err error) int {
+	if status, ok := errorStatus[errorCode(err)]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// respondError writes the error response with the status matching the error
+func respondError(ctx *gin.Context, err error) {
+	ctx.JSON(httpStatusForError(err), errorResponse(err))
+}
+
+// fieldError describes a single invalid request field
+type fieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
+}
+
+// fieldErrors lists every invalid field reported by a binding error
+func fieldErrors(err error) []fieldError {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &validationErrs):
+		fields := make([]fieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, fieldError{
+				Field:   fe.Field(),
+				Tag:     fe.Tag(),
+				Message: fieldErrorMessage(fe),
+			})
+		}
+		return fields
+	case errors.As(err, &typeErr):
+		return []fieldError{{
+			Field:   typeErr.Field,
+			Tag:     "type",
+			Message: fmt.Sprintf("must be a %s", typeErr.Type),
+		}}
+	}
+	return nil
+}
+
+// fieldErrorMessage renders a human-readable message for a failed validation
+func fieldErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "currency":
+		return fmt.Sprintf("unsupported or disabled currency %v", fe.Value())
+	case "known_currency":
+		return fmt.Sprintf("unsupported currency %v", fe.Value())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", fe.Param())
+	case "email":
+		return "must be a valid email address"
+	case "alphanum":
+		return "must contain only letters and digits"
+	}
+	return fmt.Sprintf("failed on the %s validation", fe.Tag())
+}
+
+// errorResponse formats errors into a consistent JSON response
+func errorResponse(err error) gin.H {
+	rsp := gin.H{
+		"error": err.Error(),
+		"code":  errorCode(err),
+	}
+	if fields := fieldErrors(err); len(fields) > 0 {
+		rsp["fields"] = fields
+	}
+	return rsp
+}
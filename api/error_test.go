@@ -0,0 +1,190 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestErrorCode verifies typed errors map to stable codes
+func TestErrorCode(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{name: "NotFound", err: sql.ErrNoRows, code: codeNotFound},
+		{name: "WrappedNotFound", err: fmt.Errorf("get account: %w", sql.ErrNoRows), code: codeNotFound},
+		{name: "EmptyBody", err: io.EOF, code: codeValidationError},
+		{name: "MalformedJSON", err: json.Unmarshal([]byte(`{`), &struct{}{}), code: codeValidationError},
+		{name: "MinBalance", err: db.ErrMinBalancePrecondition, code: codeMinBalanceNotMet},
+		{name: "DailyLimit", err: fmt.Errorf("account [1]: %w", db.ErrDailyTransferLimit), code: codeDailyLimit},
+		{name: "ExpiredToken", err: token.ErrExpiredToken, code: codeTokenExpired},
+		{name: "TokenNotActive", err: token.ErrTokenNotActiveYet, code: codeTokenNotActive},
+		{name: "InvalidToken", err: token.ErrInvalidToken, code: codeInvalidToken},
+		{name: "UniqueViolation", err: &pq.Error{Code: "23505"}, code: codeAlreadyExists},
+		{name: "ForeignKeyViolation", err: &pq.Error{Code: "23503"}, code: codeForeignKey},
+		{name: "Coded", err: withCode(codeCurrencyMismatch, errors.New("mismatch")), code: codeCurrencyMismatch},
+		{name: "Unknown", err: sql.ErrConnDone, code: codeInternal},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.code, errorCode(tc.err))
+
+			rsp := errorResponse(tc.err)
+			require.Equal(t, tc.err.Error(), rsp["error"])
+			require.Equal(t, tc.code, rsp["code"])
+		})
+	}
+}
+
+// TestHTTPStatusForError verifies domain errors map to their HTTP status
+func TestHTTPStatusForError(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{name: "NotFound", err: db.ErrRecordNotFound, status: http.StatusNotFound},
+		{name: "WrappedNotFound", err: fmt.Errorf("get account: %w", sql.ErrNoRows), status: http.StatusNotFound},
+		{name: "UniqueViolation", err: db.ErrUniqueViolation, status: http.StatusConflict},
+		{name: "ForeignKeyViolation", err: db.ErrForeignKeyViolation, status: http.StatusUnprocessableEntity},
+		{name: "MinBalance", err: db.ErrMinBalancePrecondition, status: http.StatusPreconditionFailed},
+		{name: "InsufficientFunds", err: fmt.Errorf("account [1]: %w", db.ErrInsufficientFunds), status: http.StatusBadRequest},
+		{name: "NoExchangeRate", err: ErrNoExchangeRate, status: http.StatusBadRequest},
+		{name: "RefundExceeded", err: db.ErrRefundExceedsRemainder, status: http.StatusConflict},
+		{name: "AlreadyReversed", err: db.ErrTransferAlreadyReversed, status: http.StatusConflict},
+		{name: "RequestExpired", err: db.ErrTransferRequestExpired, status: http.StatusGone},
+		{name: "RequestAnswered", err: db.ErrTransferRequestNotPending, status: http.StatusConflict},
+		{name: "VerifyEmailUsed", err: db.ErrVerifyEmailUsed, status: http.StatusConflict},
+		{name: "VerifyEmailExpired", err: db.ErrVerifyEmailExpired, status: http.StatusGone},
+		{name: "NewOwnerHasAccount", err: db.ErrNewOwnerHasAccount, status: http.StatusConflict},
+		{name: "NewOwnerNotFound", err: db.ErrNewOwnerNotFound, status: http.StatusNotFound},
+		{name: "OwnerUnchanged", err: db.ErrOwnerUnchanged, status: http.StatusBadRequest},
+		{name: "FundingAccountNotOwned", err: db.ErrFundingAccountNotOwned, status: http.StatusForbidden},
+		{name: "FundingCurrencyMismatch", err: db.ErrFundingCurrencyMismatch, status: http.StatusBadRequest},
+		{name: "AccountFrozen", err: fmt.Errorf("account [1]: %w", db.ErrAccountFrozen), status: http.StatusForbidden},
+		{name: "DailyLimit", err: fmt.Errorf("account [1]: %w", db.ErrDailyTransferLimit), status: http.StatusForbidden},
+		{name: "ExpiredToken", err: token.ErrExpiredToken, status: http.StatusUnauthorized},
+		{name: "InvalidToken", err: token.ErrInvalidToken, status: http.StatusUnauthorized},
+		{name: "TokenNotActive", err: token.ErrTokenNotActiveYet, status: http.StatusUnauthorized},
+		{name: "EmptyBody", err: io.EOF, status: http.StatusBadRequest},
+		{name: "AccountFrozen", err: withCode(codeAccountFrozen, errors.New("frozen")), status: http.StatusForbidden},
+		{name: "RateLimited", err: withCode(codeRateLimited, errors.New("slow down")), status: http.StatusTooManyRequests},
+		{name: "Unavailable", err: withCode(codeUnavailable, errors.New("down")), status: http.StatusServiceUnavailable},
+		{name: "BodyTooLarge", err: fmt.Errorf("read body: %w", &http.MaxBytesError{Limit: 64}), status: http.StatusRequestEntityTooLarge},
+		{name: "UnknownCode", err: withCode("SOMETHING_NEW", errors.New("new")), status: http.StatusInternalServerError},
+		{name: "Unknown", err: errors.New("boom"), status: http.StatusInternalServerError},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.status, httpStatusForError(tc.err))
+		})
+	}
+}
+
+// TestTransferErrorCodes verifies transfer failures carry the matching code
+func TestTransferErrorCodes(t *testing.T) {
+	user, _ := randomUser(t)
+	account1 := randomAccount(user.Username)
+	account2 := randomAccount(user.Username)
+	account1.ID = 1
+	account2.ID = 2
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	testCases := []struct {
+		name       string
+		body       string
+		buildStubs func(store *mock.MockStore)
+		status     int
+		code       string
+	}{
+		{
+			name: "Validation",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":0,"currency":"USD"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusBadRequest,
+			code:   codeValidationError,
+		},
+		{
+			name: "NotFound",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			status: http.StatusNotFound,
+			code:   codeNotFound,
+		},
+		{
+			name: "CurrencyMismatch",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"EUR"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			},
+			status: http.StatusBadRequest,
+			code:   codeCurrencyMismatch,
+		},
+		{
+			name: "MinBalanceNotMet",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD","min_source_balance_after":500}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrMinBalancePrecondition)
+			},
+			status: http.StatusPreconditionFailed,
+			code:   codeMinBalanceNotMet,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			require.Equal(t, tc.code, body["code"])
+			require.NotEmpty(t, body["error"])
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrNoExchangeRate is returned by an ExchangeRater that cannot quote a pair
+var ErrNoExchangeRate = errors.New("no exchange rate available")
+
+// ExchangeRater quotes how many units of one currency buy one unit of another
+type ExchangeRater interface {
+	Rate(ctx context.Context, from string, to string) (*big.Rat, error)
+}
+
+// ExchangeRate is an exact decimal rate accepted as a JSON number or string
+type ExchangeRate big.Rat
+
+// UnmarshalJSON decodes a positive decimal without going through a float
+func (rate *ExchangeRate) UnmarshalJSON(data []byte) error {
+	//Unwrap string values
+	if len(data) > 0 && data[0] == '"' {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		data = []byte(str)
+	}
+
+	//Parse the decimal value
+	value, ok := new(big.Rat).SetString(string(bytes.TrimSpace(data)))
+	if !ok || value.Sign() <= 0 {
+		return withCode(codeValidationError, fmt.Errorf("invalid exchange rate %s: must be a positive number", data))
+	}
+
+	*rate = ExchangeRate(*value)
+	return nil
+}
+
+// SetExchangeRater lets cross-currency transfers without an explicit rate use
+// a quoted one. Without a rater every cross-currency transfer needs a rate.
+func (server *Server) SetExchangeRater(rater ExchangeRater) {
+	server.exchangeRater = rater
+}
+
+// convertAmount converts amount between currencies using the requested rate,
+// falling back to the exchange rater when no rate was given. The result is
+// rounded with the mode configured for the destination currency, which is
+// returned so it can be recorded on the transfer.
+func (server *Server) convertAmount(ctx *gin.Context, amount int64, rate *big.Rat, from string, to string) (int64, util.RoundingMode, bool) {
+	if rate == nil {
+		var err error
+		rate, err = server.quoteRate(ctx, from, to)
+		if err != nil {
+			if errors.Is(err, ErrNoExchangeRate) {
+				rejectTransfer(ctx, http.StatusBadRequest, err)
+				return 0, "", false
+			}
+			rejectTransfer(ctx, http.StatusInternalServerError, err)
+			return 0, "", false
+		}
+	}
+
+	mode, err := server.config.FXRoundingModeFor(to)
+	if err != nil {
+		rejectTransfer(ctx, http.StatusInternalServerError, err)
+		return 0, "", false
+	}
+
+	//Reject rates that round the amount away or overflow it
+	converted, err := util.ConvertAmount(amount, minorUnitRate(rate, from, to), mode)
+	if err == nil && converted < 1 {
+		err = errors.New("amount rounds to zero")
+	}
+	if err != nil {
+		err := withCode(codeValidationError, fmt.Errorf("cannot convert %d %s to %s at rate %s: %w", amount, from, to, rate.RatString(), err))
+		rejectTransfer(ctx, http.StatusBadRequest, err)
+		return 0, "", false
+	}
+
+	return converted, mode, true
+}
+
+// minorUnitRate turns a rate between major units into one between minor units,
+// e.g. 150 JPY per USD is 1.5 JPY per US cent
+func minorUnitRate(rate *big.Rat, from string, to string) *big.Rat {
+	scale := big.NewRat(1, 1)
+	ten := big.NewRat(10, 1)
+	for exponent := util.CurrencyMinorUnits(to) - util.CurrencyMinorUnits(from); exponent != 0; {
+		if exponent > 0 {
+			scale.Mul(scale, ten)
+			exponent--
+		} else {
+			scale.Quo(scale, ten)
+			exponent++
+		}
+	}
+	return scale.Mul(scale, rate)
+}
+
+// quoteRate asks the exchange rater for a rate
+func (server *Server) quoteRate(ctx context.Context, from string, to string) (*big.Rat, error) {
+	if server.exchangeRater == nil {
+		return nil, fmt.Errorf("exchange_rate is required to convert %s to %s: %w", from, to, ErrNoExchangeRate)
+	}
+
+	rate, err := server.exchangeRater.Rate(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("cannot quote %s to %s: %w", from, to, err)
+	}
+	if rate == nil || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid %s to %s rate: %w", from, to, ErrNoExchangeRate)
+	}
+	return rate, nil
+}
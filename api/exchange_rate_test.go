@@ -0,0 +1,290 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// exchangeRaterFunc adapts a function to the ExchangeRater interface
+type exchangeRaterFunc func(ctx context.Context, from string, to string) (*big.Rat, error)
+
+func (f exchangeRaterFunc) Rate(ctx context.Context, from string, to string) (*big.Rat, error) {
+	return f(ctx, from, to)
+}
+
+// TestCreateTransferExchangeRate tests transfers between accounts of different currencies
+func TestCreateTransferExchangeRate(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account3 := randomAccount(user2.Username)
+	account1.ID = 1
+	account2.ID = 2
+	account3.ID = 3
+	account1.Currency = util.USD
+	account2.Currency = util.EUR
+	account3.Currency = util.USD
+
+	testCases := []struct {
+		name          string
+		body          string
+		rater         ExchangeRater
+		config        util.Config
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "SameCurrency",
+			body: `{"from_account_id":1,"to_account_id":3,"amount":100,"currency":"USD"}`,
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account3.ID,
+					Amount:        100,
+				}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "SameCurrencyWithRate",
+			body: `{"from_account_id":1,"to_account_id":3,"amount":100,"currency":"USD","exchange_rate":0.9}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeValidationError)
+			},
+		},
+		{
+			name: "ExplicitRate",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":100,"currency":"USD","exchange_rate":0.925}`,
+			buildStubs: func(store *mock.MockStore) {
+				//92.5 rounds half to even
+				arg := db.TransferTxParams{
+					FromAccountID:   account1.ID,
+					ToAccountID:     account2.ID,
+					Amount:          100,
+					ConvertedAmount: 92,
+					RoundingMode:    string(util.RoundHalfEven),
+				}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "ConfiguredRoundingMode",
+			body:   `{"from_account_id":1,"to_account_id":2,"amount":100,"currency":"USD","exchange_rate":"0.925"}`,
+			config: util.Config{FXRoundingModes: "EUR=half_up"},
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.TransferTxParams{
+					FromAccountID:   account1.ID,
+					ToAccountID:     account2.ID,
+					Amount:          100,
+					ConvertedAmount: 93,
+					RoundingMode:    string(util.RoundHalfUp),
+				}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "RoundDownAtHalf",
+			body:   `{"from_account_id":1,"to_account_id":2,"amount":100,"currency":"USD","exchange_rate":"0.935"}`,
+			config: util.Config{FXRoundingMode: "down"},
+			buildStubs: func(store *mock.MockStore) {
+				//93.5 rounds down where half even and half up would give 94
+				arg := db.TransferTxParams{
+					FromAccountID:   account1.ID,
+					ToAccountID:     account2.ID,
+					Amount:          100,
+					ConvertedAmount: 93,
+					RoundingMode:    string(util.RoundDown),
+				}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "QuotedRate",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":100,"currency":"USD"}`,
+			rater: exchangeRaterFunc(func(ctx context.Context, from string, to string) (*big.Rat, error) {
+				require.Equal(t, util.USD, from)
+				require.Equal(t, util.EUR, to)
+				return big.NewRat(1, 2), nil
+			}),
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.TransferTxParams{
+					FromAccountID:   account1.ID,
+					ToAccountID:     account2.ID,
+					Amount:          100,
+					ConvertedAmount: 50,
+					RoundingMode:    string(util.RoundHalfEven),
+				}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "MissingRate",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":100,"currency":"USD"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeNoExchangeRate)
+			},
+		},
+		{
+			name: "RaterHasNoRate",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":100,"currency":"USD"}`,
+			rater: exchangeRaterFunc(func(ctx context.Context, from string, to string) (*big.Rat, error) {
+				return nil, ErrNoExchangeRate
+			}),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeNoExchangeRate)
+			},
+		},
+		{
+			name: "RaterError",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":100,"currency":"USD"}`,
+			rater: exchangeRaterFunc(func(ctx context.Context, from string, to string) (*big.Rat, error) {
+				return nil, errors.New("rate service unavailable")
+			}),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "RateRoundsToZero",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":1,"currency":"USD","exchange_rate":0.1}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "MalformedRate",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":100,"currency":"USD","exchange_rate":"abc"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NegativeRate",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":100,"currency":"USD","exchange_rate":-1}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.FXRoundingMode = tc.config.FXRoundingMode
+			server.config.FXRoundingModes = tc.config.FXRoundingModes
+			server.SetExchangeRater(tc.rater)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestMinorUnitRate verifies major-unit rates convert between minor units of different exponents
+func TestMinorUnitRate(t *testing.T) {
+	testCases := []struct {
+		amount int64
+		rate   *big.Rat
+		from   string
+		to     string
+		want   int64
+	}{
+		{100, big.NewRat(150, 1), util.USD, "JPY", 150},
+		{150, big.NewRat(1, 150), "JPY", util.USD, 100},
+		{100, big.NewRat(1, 1), util.USD, "KWD", 1000},
+		{250, big.NewRat(9, 10), util.USD, util.EUR, 225},
+	}
+
+	for _, tc := range testCases {
+		converted, err := util.ConvertAmount(tc.amount, minorUnitRate(tc.rate, tc.from, tc.to), util.RoundHalfEven)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, converted, "%s to %s", tc.from, tc.to)
+	}
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Probe response payload
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+// healthz reports the process is up, without touching its dependencies
+func (server *Server) healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// readyz reports whether the server can take traffic, which needs the database
+func (server *Server) readyz(ctx *gin.Context) {
+	if err := server.store.Ping(ctx); err != nil {
+		err := withCode(codeUnavailable, fmt.Errorf("database is unreachable: %w", err))
+		ctx.JSON(http.StatusServiceUnavailable, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, healthResponse{Status: "ok"})
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Optional request body for logging out
+type logoutRequest struct {
+	//Revoke every session of the user instead of only the current one
+	LogoutAll bool `json:"logout_all"`
+}
+
+// Logout response payload
+type logoutResponse struct {
+	RevokedSessions int64 `json:"revoked_sessions"`
+}
+
+// logout revokes the refresh session the current access token was issued
+// for, so it can no longer be renewed. The access token itself stays valid
+// until it expires.
+func (server *Server) logout(ctx *gin.Context) {
+	var req logoutRequest
+
+	//The body is optional
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if req.LogoutAll {
+		revoked, err := server.store.BlockUserSessions(ctx, authPayload.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusOK, logoutResponse{RevokedSessions: revoked})
+		return
+	}
+
+	session, err := server.store.GetSessionByAccessToken(ctx, uuid.NullUUID{UUID: authPayload.ID, Valid: true})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := withCode(codeNotFound, errors.New("no session for this access token"))
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if session.Username != authPayload.Username {
+		err := withCode(codeUnauthorized, errors.New("session belongs to another user"))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	//Logging out twice is harmless, the second call revokes nothing
+	revoked, err := server.store.RevokeSession(ctx, session.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, logoutResponse{RevokedSessions: revoked})
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestLogoutAPI tests POST /users/logout
+func TestLogoutAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+	session := db.Session{ID: uuid.New(), Username: user.Username}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetSessionByAccessToken(gomock.Any(), gomock.Any()).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(int64(1), nil)
+				store.EXPECT().BlockUserSessions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"revoked_sessions":1}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "LogoutAll",
+			body: gin.H{"logout_all": true},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetSessionByAccessToken(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BlockUserSessions(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(int64(3), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"revoked_sessions":3}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "AlreadyLoggedOut",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetSessionByAccessToken(gomock.Any(), gomock.Any()).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(int64(0), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"revoked_sessions":0}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetSessionByAccessToken(gomock.Any(), gomock.Any()).Times(1).Return(db.Session{}, sql.ErrNoRows)
+				store.EXPECT().RevokeSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "OtherUsersSession",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetSessionByAccessToken(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Session{ID: session.ID, Username: other.Username}, nil)
+				store.EXPECT().RevokeSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetSessionByAccessToken(gomock.Any(), gomock.Any()).Times(1).Return(session, nil)
+				store.EXPECT().RevokeSession(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body []byte
+			if tc.body != nil {
+				data, err := json.Marshal(tc.body)
+				require.NoError(t, err)
+				body = data
+			}
+
+			request, err := http.NewRequest(http.MethodPost, "/users/logout", bytes.NewReader(body))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestRenewAfterLogout verifies a refresh token stops working once its session is logged out
+func TestRenewAfterLogout(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole, time.Hour)
+	require.NoError(t, err)
+
+	session := db.Session{
+		ID:            refreshPayload.ID,
+		Username:      user.Username,
+		RefreshToken:  refreshToken,
+		ExpiresAt:     refreshPayload.ExpiredAt,
+		AccessTokenID: uuid.NullUUID{UUID: accessPayload.ID, Valid: true},
+	}
+
+	//Logout blocks the session found through the access token
+	store.EXPECT().
+		GetSessionByAccessToken(gomock.Any(), gomock.Eq(session.AccessTokenID)).
+		Times(1).
+		Return(session, nil)
+	store.EXPECT().
+		RevokeSession(gomock.Any(), gomock.Eq(session.ID)).
+		Times(1).
+		DoAndReturn(func(_ any, _ uuid.UUID) (int64, error) {
+			session.IsBlocked = true
+			return 1, nil
+		})
+	store.EXPECT().
+		GetSession(gomock.Any(), gomock.Eq(session.ID)).
+		Times(1).
+		DoAndReturn(func(_ any, _ uuid.UUID) (db.Session, error) {
+			return session, nil
+		})
+	store.EXPECT().SetSessionAccessToken(gomock.Any(), gomock.Any()).Times(0)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/users/logout", nil)
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+	require.NoError(t, err)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Failed transfer reasons, kept to a fixed set to bound label cardinality
+const (
+	transferFailureInvalidRequest     = "invalid_request"
+	transferFailureUnauthorized       = "unauthorized"
+	transferFailureAccountNotFound    = "account_not_found"
+	transferFailurePreconditionFailed = "precondition_failed"
+	transferFailureInsufficientFunds  = "insufficient_funds"
+	transferFailureAccountFrozen      = "account_frozen"
+	transferFailureCurrencyMismatch   = "currency_mismatch"
+	transferFailureLimitExceeded      = "limit_exceeded"
+	transferFailureInternal           = "internal"
+)
+
+// unmatchedRoute labels requests that matched no route, so arbitrary paths
+// don't create new series
+const unmatchedRoute = "unmatched"
+
+// metrics holds the business metrics exposed on /metrics
+type metrics struct {
+	registry        *prometheus.Registry
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	transfersTotal  *prometheus.CounterVec
+	transferAmount  *prometheus.HistogramVec
+	failedTransfers *prometheus.CounterVec
+	newUsers        prometheus.Counter
+	activeAccounts  prometheus.Gauge
+}
+
+// newMetrics creates and registers the business metrics on a dedicated registry
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "simplebank",
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "simplebank",
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		transfersTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "simplebank",
+			Name:      "transfers_total",
+			Help:      "Number of completed transfers.",
+		}, []string{"currency"}),
+		transferAmount: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "simplebank",
+			Name:      "transfer_amount",
+			Help:      "Amount of completed transfers in minor units.",
+			Buckets:   prometheus.ExponentialBuckets(100, 10, 6),
+		}, []string{"currency"}),
+		failedTransfers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "simplebank",
+			Name:      "failed_transfers_total",
+			Help:      "Number of rejected or failed transfers.",
+		}, []string{"reason"}),
+		newUsers: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "simplebank",
+			Name:      "new_users_total",
+			Help:      "Number of registered users.",
+		}),
+		activeAccounts: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "simplebank",
+			Name:      "active_accounts",
+			Help:      "Number of open accounts that are not frozen.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		m.requestsTotal,
+		m.requestDuration,
+		m.transfersTotal,
+		m.transferAmount,
+		m.failedTransfers,
+		m.newUsers,
+		m.activeAccounts,
+	)
+	return m
+}
+
+// middleware records the status and latency of every request by its route template
+func (m *metrics) middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := ctx.Request.Method
+		m.requestsTotal.WithLabelValues(method, route, strconv.Itoa(ctx.Writer.Status())).Inc()
+		m.requestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// recordTransfer counts a completed transfer and its amount
+func (m *metrics) recordTransfer(currency string, amount int64) {
+	m.transfersTotal.WithLabelValues(currency).Inc()
+	m.transferAmount.WithLabelValues(currency).Observe(float64(amount))
+}
+
+// recordTransferFailure counts a failed transfer by the cause of the error
+// response. Responses without a known cause are counted by their status.
+func (m *metrics) recordTransferFailure(status int, err error) {
+	if status < http.StatusBadRequest {
+		return
+	}
+	m.failedTransfers.WithLabelValues(transferFailureReason(status, err)).Inc()
+}
+
+// transferFailureReason maps the error a transfer failed with to its reason
+func transferFailureReason(status int, err error) string {
+	switch {
+	case err == nil:
+	case errors.Is(err, db.ErrInsufficientFunds):
+		return transferFailureInsufficientFunds
+	case errors.Is(err, db.ErrMinBalancePrecondition):
+		return transferFailurePreconditionFailed
+	case errors.Is(err, db.ErrRecordNotFound):
+		return transferFailureAccountNotFound
+	default:
+		//Checks made by the handler carry an explicit code instead
+		switch errorCode(err) {
+		case codeAccountFrozen:
+			return transferFailureAccountFrozen
+		case codeCurrencyMismatch:
+			return transferFailureCurrencyMismatch
+		case codeTransferLimit, codeDailyLimit:
+			return transferFailureLimitExceeded
+		case codeUnauthorized:
+			return transferFailureUnauthorized
+		}
+	}
+
+	switch {
+	case status == http.StatusUnauthorized:
+		return transferFailureUnauthorized
+	case status == http.StatusNotFound:
+		return transferFailureAccountNotFound
+	case status == http.StatusPreconditionFailed:
+		return transferFailurePreconditionFailed
+	case status >= http.StatusInternalServerError:
+		return transferFailureInternal
+	}
+	return transferFailureInvalidRequest
+}
+
+// metricsHandler refreshes store-backed gauges and serves the registry
+func (server *Server) metricsHandler() gin.HandlerFunc {
+	handler := promhttp.HandlerFor(server.metrics.registry, promhttp.HandlerOpts{})
+
+	return func(ctx *gin.Context) {
+		//Refresh the account gauge from the database
+		count, err := server.store.CountAccounts(ctx)
+		if err != nil {
+			log.Printf("cannot count accounts for metrics: %v", err)
+		} else {
+			server.metrics.activeAccounts.Set(float64(count))
+		}
+
+		handler.ServeHTTP(ctx.Writer, ctx.Request)
+	}
+}
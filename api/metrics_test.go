@@ -0,0 +1,208 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestTransferMetrics verifies a transfer is counted in its currency
+func TestTransferMetrics(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.ID = 1
+	account2.ID = 2
+	account1.Currency = util.EUR
+	account2.Currency = util.EUR
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+	store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, nil)
+	store.EXPECT().CountAccounts(gomock.Any()).Times(1).Return(int64(2), nil)
+
+	server := newTestServer(t, store)
+	server.config.EnableMetrics = true
+	server.setupRouter()
+
+	//Perform a transfer of 250 EUR minor units
+	recorder := httptest.NewRecorder()
+	body := `{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`
+	request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	//Scrape the metrics endpoint
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	metrics := recorder.Body.String()
+	require.Contains(t, metrics, `simplebank_transfers_total{currency="EUR"} 1`)
+	require.Contains(t, metrics, `simplebank_transfer_amount_bucket{currency="EUR",le="100"} 0`)
+	require.Contains(t, metrics, `simplebank_transfer_amount_bucket{currency="EUR",le="1000"} 1`)
+	require.Contains(t, metrics, `simplebank_transfer_amount_sum{currency="EUR"} 250`)
+	require.Contains(t, metrics, `simplebank_active_accounts 2`)
+	require.NotContains(t, metrics, "simplebank_failed_transfers_total{")
+}
+
+// TestTransferFailureMetrics verifies rejected transfers are counted by reason
+func TestTransferFailureMetrics(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	server.metrics.recordTransferFailure(http.StatusOK, nil)
+	server.metrics.recordTransferFailure(http.StatusNotFound, sql.ErrNoRows)
+	server.metrics.recordTransferFailure(http.StatusBadRequest, errors.New("invalid body"))
+	server.metrics.recordTransferFailure(http.StatusInternalServerError, sql.ErrConnDone)
+
+	//The cause is taken from the error, not the status it was served with
+	server.metrics.recordTransferFailure(http.StatusBadRequest, fmt.Errorf("account [1]: %w", db.ErrInsufficientFunds))
+	server.metrics.recordTransferFailure(http.StatusForbidden, fmt.Errorf("account [1]: %w", db.ErrDailyTransferLimit))
+	server.metrics.recordTransferFailure(http.StatusBadRequest, withCode(codeTransferLimit, errors.New("amount too large")))
+	server.metrics.recordTransferFailure(http.StatusForbidden, withCode(codeAccountFrozen, errors.New("frozen")))
+	server.metrics.recordTransferFailure(http.StatusBadRequest, withCode(codeCurrencyMismatch, errors.New("mismatch")))
+
+	families, err := server.metrics.registry.Gather()
+	require.NoError(t, err)
+
+	counts := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() != "simplebank_failed_transfers_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			counts[metric.GetLabel()[0].GetValue()] = metric.GetCounter().GetValue()
+		}
+	}
+
+	require.Equal(t, map[string]float64{
+		transferFailureAccountNotFound:   1,
+		transferFailureInvalidRequest:    1,
+		transferFailureInternal:          1,
+		transferFailureInsufficientFunds: 1,
+		transferFailureLimitExceeded:     2,
+		transferFailureAccountFrozen:     1,
+		transferFailureCurrencyMismatch:  1,
+	}, counts)
+}
+
+// TestTransferFailureMetricsFromHandler verifies a rejected transfer is counted
+// by the error the handler responded with
+func TestTransferFailureMetricsFromHandler(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.ID = 1
+	account2.ID = 2
+	account1.Currency = util.EUR
+	account2.Currency = util.EUR
+	account2.Status = db.AccountFrozen
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+	store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().CountAccounts(gomock.Any()).Times(1).Return(int64(2), nil)
+
+	server := newTestServer(t, store)
+	server.config.EnableMetrics = true
+	server.setupRouter()
+
+	recorder := httptest.NewRecorder()
+	body := `{"from_account_id":1,"to_account_id":2,"amount":250,"currency":"EUR"}`
+	request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Contains(t, recorder.Body.String(), `simplebank_failed_transfers_total{reason="account_frozen"} 1`)
+}
+
+// TestRequestMetrics verifies requests are counted by route template and status
+func TestRequestMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).Return(db.Account{}, sql.ErrNoRows)
+	store.EXPECT().CountAccounts(gomock.Any()).Times(2).Return(int64(0), nil)
+
+	server := newTestServer(t, store)
+	server.config.EnableMetrics = true
+	server.setupRouter()
+
+	scrape := func() string {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+		require.NoError(t, err)
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		return recorder.Body.String()
+	}
+
+	require.NotContains(t, scrape(), `route="/accounts/:id"`)
+
+	//Two lookups of different accounts share the route template
+	user, _ := randomUser(t)
+	for _, url := range []string{"/accounts/1", "/accounts/2"} {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusNotFound, recorder.Code)
+	}
+
+	//Unknown paths are grouped under a single label
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/no/such/path", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	metrics := scrape()
+	require.Contains(t, metrics, `simplebank_http_requests_total{method="GET",route="/accounts/:id",status="404"} 2`)
+	require.Contains(t, metrics, `simplebank_http_requests_total{method="GET",route="/metrics",status="200"} 1`)
+	require.Contains(t, metrics, `simplebank_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	require.Contains(t, metrics, `simplebank_http_request_duration_seconds_count{method="GET",route="/accounts/:id"} 2`)
+}
+
+// TestMetricsDisabled verifies /metrics is only served when enabled
+func TestMetricsDisabled(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -1,13 +1,29 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
 // Authorization-related constants
@@ -17,44 +33,288 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
-// authMiddleware validates access tokens for protected routes
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+// authMiddleware validates access tokens for protected routes.
+// The token is read from the Authorization header or, when the header is
+// absent and authCookieName is set, from that cookie.
+func authMiddleware(tokenMaker token.Maker, authCookieName string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		//Read Authorization header
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
-			err := errors.New("authorization header is not provided")
+			//Fall back to the access token cookie
+			if authCookieName != "" {
+				if accessToken, err := ctx.Cookie(authCookieName); err == nil && accessToken != "" {
+					verifyAccessToken(ctx, tokenMaker, accessToken)
+					return
+				}
+			}
+
+			err := withCode(codeUnauthorized, errors.New("authorization header is not provided"))
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
 			return
 		}
 
 		//Split header into type and token
-		fields := strings.Fields(authorizationHeader)
-		if len(fields) < 2 {
-			err := errors.New("invalid authorization header format")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+		authorizationType, accessToken, err := parseAuthorizationHeader(authorizationHeader)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(withCode(codeUnauthorized, err)))
 			return
 		}
 
 		//Validate authorization type
-		authorizationType := strings.ToLower(fields[0])
 		if authorizationType != authorizationTypeBearer {
-			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
+			err := withCode(codeUnauthorized, fmt.Errorf("unsupported authorization type %s", authorizationType))
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
 			return
 		}
 
 		//Verify access token
-		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		verifyAccessToken(ctx, tokenMaker, accessToken)
+	}
+}
+
+// parseAuthorizationHeader splits an Authorization header into its lowercased
+// scheme and its credential. The header must hold exactly those two fields,
+// separated by any amount of whitespace.
+func parseAuthorizationHeader(header string) (scheme string, credential string, err error) {
+	fields := strings.Fields(header)
+	switch {
+	case len(fields) == 0:
+		return "", "", errors.New("authorization header is not provided")
+	case len(fields) != 2:
+		return "", "", errors.New("invalid authorization header format")
+	}
+	return strings.ToLower(fields[0]), fields[1], nil
+}
+
+// verifyAccessToken verifies the token and stores its payload for downstream handlers
+func verifyAccessToken(ctx *gin.Context, tokenMaker token.Maker, accessToken string) {
+	payload, err := tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		rsp := errorResponse(err)
+
+		//Tell clients when the token expired so they refresh instead of re-login
+		var expiredErr *token.ExpiredTokenError
+		if errors.As(err, &expiredErr) {
+			rsp["expired_at"] = expiredErr.ExpiredAt
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, rsp)
+		return
+	}
+
+	//Store payload in context for downstream handler
+	ctx.Set(authorizationPayloadKey, payload)
+	ctx.Next()
+}
+
+// checkUpgradeOrigin reports whether a connection upgrade may proceed from the
+// request's Origin. Same-origin requests and the configured allowed origins are
+// accepted; requests without an Origin come from non-browser clients and are
+// not exposed to cross-site hijacking. Its signature matches the CheckOrigin
+// hook of websocket upgraders.
+func checkUpgradeOrigin(allowedOrigins []string, request *http.Request) bool {
+	origin := request.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, request.Host) {
+		return true
+	}
+
+	for _, allowed := range allowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultMaxBodyBytes caps request bodies when no limit is configured
+const defaultMaxBodyBytes = 1 << 20
+
+// maxBodyBytes rejects request bodies larger than limit with a 413. The body
+// is read up front through http.MaxBytesReader, so handlers never see more
+// than limit bytes however the client sends it.
+func maxBodyBytes(limit int64) gin.HandlerFunc {
+	if limit <= 0 {
+		limit = defaultMaxBodyBytes
+	}
+	return func(ctx *gin.Context) {
+		if ctx.Request.Body == nil || ctx.Request.Body == http.NoBody {
+			ctx.Next()
+			return
+		}
+
+		//A declared length over the limit is rejected without reading anything
+		if ctx.Request.ContentLength > limit {
+			respondBodyTooLarge(ctx, limit)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, limit))
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				respondBodyTooLarge(ctx, limit)
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, errorResponse(withCode(codeValidationError, err)))
+			return
+		}
+
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+		ctx.Next()
+	}
+}
+
+// respondBodyTooLarge aborts a request whose body exceeds the limit
+func respondBodyTooLarge(ctx *gin.Context, limit int64) {
+	err := withCode(codeBodyTooLarge, fmt.Errorf("request body exceeds %d bytes", limit))
+	ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, errorResponse(err))
+}
+
+// defaultContextTimeout bounds each request when no timeout is configured
+const defaultContextTimeout = 10 * time.Second
+
+// requestTimeout gives every request a deadline, so store calls made for slow
+// or abandoned requests are canceled instead of running to completion. A
+// negative timeout disables the deadline.
+func requestTimeout(timeout time.Duration) gin.HandlerFunc {
+	if timeout == 0 {
+		timeout = defaultContextTimeout
+	}
+	return func(ctx *gin.Context) {
+		if timeout < 0 {
+			ctx.Next()
+			return
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+		defer cancel()
+
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		ctx.Next()
+	}
+}
+
+// tracerName identifies the spans started by the API
+const tracerName = "github.com/codercollo/simple_bank/api"
+
+// tracingMiddleware starts a root span per request, continuing the caller's
+// trace when it sent one. Store calls made with the request context become its
+// children, and the trace context is echoed back in the response headers.
+func tracingMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		propagator := otel.GetTextMapPropagator()
+		reqCtx := propagator.Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		reqCtx, span := otel.Tracer(tracerName).Start(reqCtx, ctx.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", ctx.Request.Method),
+				attribute.String("http.route", route),
+			),
+		)
+		defer span.End()
+
+		propagator.Inject(reqCtx, propagation.HeaderCarrier(ctx.Writer.Header()))
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// upgradeOriginMiddleware rejects upgrade requests from disallowed origins
+// before any handler can switch protocols
+func upgradeOriginMiddleware(allowedOrigins []string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.IsWebsocket() && !checkUpgradeOrigin(allowedOrigins, ctx.Request) {
+			err := withCode(codeOriginNotAllowed, fmt.Errorf("origin %s is not allowed", ctx.GetHeader("Origin")))
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// requireRole only lets users holding one of the given roles through. It must
+// run after authMiddleware.
+func requireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if !slices.Contains(roles, authPayload.Role) {
+			err := withCode(codeForbidden, fmt.Errorf("role %q is not allowed to access this resource", authPayload.Role))
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
 			return
 		}
+		ctx.Next()
+	}
+}
+
+// Defaults for throttling the public authentication routes per client IP
+const (
+	defaultAuthRateLimit = 1
+	defaultAuthRateBurst = 5
+)
 
-		//Store payload in context for downstream handler
-		ctx.Set(authorizationPayloadKey, payload)
+// rateLimiter throttles requests per client IP. Clients out of tokens get a
+// 429 with a Retry-After header saying when to try again.
+func rateLimiter(limiter *keyedRateLimiter) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if wait := limiter.retryAfter(ctx.ClientIP()); wait > 0 {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			err := withCode(codeRateLimited, errors.New("too many requests"))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, errorResponse(err))
+			return
+		}
 		ctx.Next()
+	}
+}
+
+// newAuthRateLimiter creates a per-IP limiter for the authentication routes
+func newAuthRateLimiter(config util.Config) *keyedRateLimiter {
+	limit, burst := config.AuthRateLimit, config.AuthRateBurst
+	if limit <= 0 {
+		limit = defaultAuthRateLimit
+	}
+	if burst <= 0 {
+		burst = defaultAuthRateBurst
+	}
+	return newKeyedRateLimiter(rate.Limit(limit), burst)
+}
+
+// ipAllowlistMiddleware only lets requests from the given networks through.
+// The client IP honours X-Forwarded-For only from trusted proxies. An empty
+// allowlist allows every address.
+func ipAllowlistMiddleware(networks []netip.Prefix) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if len(networks) == 0 {
+			ctx.Next()
+			return
+		}
 
+		clientIP := ctx.ClientIP()
+		addr, err := netip.ParseAddr(clientIP)
+		if err != nil || !util.NetworksContain(networks, addr) {
+			err := withCode(codeIPNotAllowed, fmt.Errorf("ip address %s is not allowed", clientIP))
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+		ctx.Next()
 	}
 }
@@ -1,15 +1,29 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/codercollo/simple_bank/db/mock"
 	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
 )
 
 // addAuthorization attaches an Authorization header with a token
@@ -19,10 +33,11 @@ func addAuthorization(
 	tokenMaker token.Maker,
 	authorizationType string,
 	username string,
+	role string,
 	duration time.Duration,
 ) {
 	//Create token
-	token, payload, err := tokenMaker.CreateToken(username, duration)
+	token, payload, err := tokenMaker.CreateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
@@ -43,7 +58,7 @@ func TestAuthMiddleware(t *testing.T) {
 			name: "OK",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				//Valid bearer token
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -62,7 +77,7 @@ func TestAuthMiddleware(t *testing.T) {
 			name: "UnsupportedUnauthorization",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				//Unsupported auth type
-				addAuthorization(t, request, tokenMaker, "unsupprted", "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, "unsupprted", "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -72,7 +87,7 @@ func TestAuthMiddleware(t *testing.T) {
 			name: "InvalidAuthorizationFormat",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				//Missing auth type
-				addAuthorization(t, request, tokenMaker, "", "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, "", "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -82,7 +97,31 @@ func TestAuthMiddleware(t *testing.T) {
 			name: "ExpiredToken",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 				//Expired token
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", -time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, -time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExtraWhitespace",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Surrounding and repeated whitespace is tolerated
+				accessToken, _, err := tokenMaker.CreateToken("user", util.DepositorRole, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("  Bearer \t  %s  ", accessToken))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "ExtraFields",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Trailing fields after the token are rejected
+				accessToken, _, err := tokenMaker.CreateToken("user", util.DepositorRole, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("Bearer %s extra", accessToken))
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -102,7 +141,7 @@ func TestAuthMiddleware(t *testing.T) {
 			authPath := "/auth"
 			server.router.GET(
 				authPath,
-				authMiddleware(server.tokenMaker),
+				authMiddleware(server.tokenMaker, ""),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -123,3 +162,630 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+// TestRequireRole verifies only the allowed roles reach a protected route
+func TestRequireRole(t *testing.T) {
+	testCases := []struct {
+		name   string
+		role   string
+		status int
+	}{
+		{name: "Banker", role: util.BankerRole, status: http.StatusOK},
+		{name: "Depositor", role: util.DepositorRole, status: http.StatusForbidden},
+		{name: "NoRole", role: "", status: http.StatusForbidden},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+
+			//Route reserved for bankers
+			rolePath := "/role"
+			server.router.GET(
+				rolePath,
+				authMiddleware(server.tokenMaker, ""),
+				requireRole(util.BankerRole),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, rolePath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "user", tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.status, recorder.Code)
+			if tc.status == http.StatusForbidden {
+				require.Contains(t, recorder.Body.String(), codeForbidden)
+			}
+		})
+	}
+}
+
+// TestAuthRateLimiter verifies signup and login are throttled per client IP
+func TestAuthRateLimiter(t *testing.T) {
+	const burst = 3
+
+	for _, path := range []string{"/users", "/users/login"} {
+		t.Run(path, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			server.config.AuthRateLimit = 0.01
+			server.config.AuthRateBurst = burst
+			server.setupRouter()
+
+			send := func(remoteAddr string) *httptest.ResponseRecorder {
+				//An empty body never reaches the store
+				request, err := http.NewRequest(http.MethodPost, path, nil)
+				require.NoError(t, err)
+				request.RemoteAddr = remoteAddr
+
+				recorder := httptest.NewRecorder()
+				server.router.ServeHTTP(recorder, request)
+				return recorder
+			}
+
+			//The burst is let through
+			for range burst {
+				recorder := send("203.0.113.1:1234")
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Empty(t, recorder.Header().Get("Retry-After"))
+			}
+
+			//The next request is throttled
+			recorder := send("203.0.113.1:1234")
+			require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			require.Contains(t, recorder.Body.String(), codeRateLimited)
+			retryAfter, err := strconv.Atoi(recorder.Header().Get("Retry-After"))
+			require.NoError(t, err)
+			require.Positive(t, retryAfter)
+
+			//Other clients keep their own budget
+			recorder = send("203.0.113.2:1234")
+			require.Equal(t, http.StatusBadRequest, recorder.Code)
+		})
+	}
+}
+
+// TestParseAuthorizationHeader verifies splitting of Authorization headers
+func TestParseAuthorizationHeader(t *testing.T) {
+	testCases := []struct {
+		name       string
+		header     string
+		scheme     string
+		credential string
+		wantErr    bool
+	}{
+		{name: "Bearer", header: "Bearer abc", scheme: "bearer", credential: "abc"},
+		{name: "MixedCaseScheme", header: "BeArEr abc", scheme: "bearer", credential: "abc"},
+		{name: "CredentialCaseKept", header: "bearer AbC", scheme: "bearer", credential: "AbC"},
+		{name: "MultiSpace", header: "Bearer    abc", scheme: "bearer", credential: "abc"},
+		{name: "ExtraWhitespace", header: " \tBearer\tabc  ", scheme: "bearer", credential: "abc"},
+		{name: "Empty", header: "", wantErr: true},
+		{name: "WhitespaceOnly", header: "   ", wantErr: true},
+		{name: "SingleField", header: "Bearer", wantErr: true},
+		{name: "TooManyFields", header: "Bearer a b c", wantErr: true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			scheme, credential, err := parseAuthorizationHeader(tc.header)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.Empty(t, scheme)
+				require.Empty(t, credential)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.scheme, scheme)
+			require.Equal(t, tc.credential, credential)
+		})
+	}
+}
+
+// TestAuthMiddlewareCookie verifies cookie-based auth and header precedence
+func TestAuthMiddlewareCookie(t *testing.T) {
+	const cookieName = "access_token"
+
+	//Define test cases
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "CookieOK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Valid token in cookie, no header
+				addAuthCookie(t, request, tokenMaker, cookieName, "user", util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredCookie",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Expired token in cookie
+				addAuthCookie(t, request, tokenMaker, cookieName, "user", util.DepositorRole, -time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "HeaderTakesPrecedence",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Valid cookie must not rescue an invalid header
+				addAuthCookie(t, request, tokenMaker, cookieName, "user", util.DepositorRole, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, -time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "HeaderWithInvalidCookie",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Valid header wins over an invalid cookie
+				request.AddCookie(&http.Cookie{Name: cookieName, Value: "invalid"})
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "OtherCookie",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				//Token under an unexpected cookie name
+				addAuthCookie(t, request, tokenMaker, "other", "user", util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	//Run test cases
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+
+			//Protected route reading the auth cookie
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, cookieName),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// addAuthCookie attaches an access token cookie to the request
+func addAuthCookie(
+	t *testing.T,
+	request *http.Request,
+	tokenMaker token.Maker,
+	cookieName string,
+	username string,
+	role string,
+	duration time.Duration,
+) {
+	token, payload, err := tokenMaker.CreateToken(username, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	request.AddCookie(&http.Cookie{Name: cookieName, Value: token})
+}
+
+// TestAuthMiddlewareExpiryDetails verifies expired tokens report the expiry time
+func TestAuthMiddlewareExpiryDetails(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	authPath := "/auth"
+	server.router.GET(
+		authPath,
+		authMiddleware(server.tokenMaker, ""),
+		func(ctx *gin.Context) {
+			ctx.JSON(http.StatusOK, gin.H{})
+		},
+	)
+
+	//Expired token reports the code and expiry
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, authPath, nil)
+	require.NoError(t, err)
+
+	accessToken, payload, err := server.tokenMaker.CreateToken("user", util.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	var body struct {
+		Code      string     `json:"code"`
+		ExpiredAt *time.Time `json:"expired_at"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.Equal(t, codeTokenExpired, body.Code)
+	require.NotNil(t, body.ExpiredAt)
+	require.WithinDuration(t, payload.ExpiredAt, *body.ExpiredAt, time.Second)
+
+	//Malformed token reports a generic invalid token error
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, authPath, nil)
+	require.NoError(t, err)
+
+	request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, "malformed"))
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	body.Code, body.ExpiredAt = "", nil
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.Equal(t, codeInvalidToken, body.Code)
+	require.Nil(t, body.ExpiredAt)
+}
+
+// TestUpgradeOriginMiddleware verifies upgrades are only accepted from allowed origins
+func TestUpgradeOriginMiddleware(t *testing.T) {
+	testCases := []struct {
+		name    string
+		origin  string
+		upgrade bool
+		status  int
+	}{
+		{name: "AllowedOrigin", origin: "https://app.example.com", upgrade: true, status: http.StatusSwitchingProtocols},
+		{name: "SameOrigin", origin: "http://bank.example.com", upgrade: true, status: http.StatusSwitchingProtocols},
+		{name: "NoOrigin", upgrade: true, status: http.StatusSwitchingProtocols},
+		{name: "DisallowedOrigin", origin: "https://evil.example.com", upgrade: true, status: http.StatusForbidden},
+		{name: "PlainRequest", origin: "https://evil.example.com", upgrade: false, status: http.StatusOK},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			server.config.AllowedOrigins = []string{"https://app.example.com/"}
+			server.setupRouter()
+
+			//Upgrade endpoint switching protocols once the origin is accepted
+			server.router.GET("/ws", func(ctx *gin.Context) {
+				if ctx.IsWebsocket() {
+					ctx.Status(http.StatusSwitchingProtocols)
+					return
+				}
+				ctx.Status(http.StatusOK)
+			})
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/ws", nil)
+			require.NoError(t, err)
+
+			request.Host = "bank.example.com"
+			if tc.origin != "" {
+				request.Header.Set("Origin", tc.origin)
+			}
+			if tc.upgrade {
+				request.Header.Set("Connection", "Upgrade")
+				request.Header.Set("Upgrade", "websocket")
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+		})
+	}
+}
+
+// TestAdminIPAllowlist verifies admin routes are only reachable from allowed networks
+func TestAdminIPAllowlist(t *testing.T) {
+	testCases := []struct {
+		name          string
+		allowlist     []string
+		remoteAddr    string
+		forwardedFor  string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "InRange",
+			allowlist:  []string{"10.0.0.0/8"},
+			remoteAddr: "10.1.2.3:4000",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				//Passed the allowlist and stopped at authentication
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:       "OutOfRange",
+			allowlist:  []string{"10.0.0.0/8"},
+			remoteAddr: "203.0.113.9:4000",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeIPNotAllowed)
+			},
+		},
+		{
+			name:         "ForwardedFromTrustedProxy",
+			allowlist:    []string{"10.0.0.0/8"},
+			remoteAddr:   "192.168.0.1:4000",
+			forwardedFor: "10.1.2.3",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:         "ForwardedOutOfRange",
+			allowlist:    []string{"10.0.0.0/8"},
+			remoteAddr:   "192.168.0.1:4000",
+			forwardedFor: "203.0.113.9",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:         "ForwardedFromUntrustedClient",
+			allowlist:    []string{"10.0.0.0/8"},
+			remoteAddr:   "203.0.113.9:4000",
+			forwardedFor: "10.1.2.3",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:       "EmptyAllowsAll",
+			remoteAddr: "203.0.113.9:4000",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			server.config.AdminIPAllowlist = tc.allowlist
+			server.config.TrustedProxies = []string{"192.168.0.1"}
+			server.setupRouter()
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/admin/accounts/1/reassign", nil)
+			require.NoError(t, err)
+
+			request.RemoteAddr = tc.remoteAddr
+			if tc.forwardedFor != "" {
+				request.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestNewServerInvalidNetworks verifies malformed network lists are rejected
+func TestNewServerInvalidNetworks(t *testing.T) {
+	config := util.Config{
+		TokenSymmetricKey: util.RandomString(32),
+		AdminIPAllowlist:  []string{"10.0.0.0/40"},
+	}
+	_, err := NewServer(nil, config)
+	require.ErrorContains(t, err, "admin IP allowlist")
+
+	config.AdminIPAllowlist = nil
+	config.TrustedProxies = []string{"proxy"}
+	_, err = NewServer(nil, config)
+	require.ErrorContains(t, err, "trusted proxies")
+}
+
+// TestRequestTimeout verifies store calls see the request deadline and the client's cancellation
+func TestRequestTimeout(t *testing.T) {
+	testCases := []struct {
+		name    string
+		timeout time.Duration
+		cancel  bool
+		check   func(t *testing.T, ctx context.Context)
+	}{
+		{
+			name: "DefaultTimeout",
+			check: func(t *testing.T, ctx context.Context) {
+				deadline, ok := ctx.Deadline()
+				require.True(t, ok)
+				require.WithinDuration(t, time.Now().Add(defaultContextTimeout), deadline, time.Second)
+			},
+		},
+		{
+			name:    "ConfiguredTimeout",
+			timeout: 2 * time.Second,
+			check: func(t *testing.T, ctx context.Context) {
+				deadline, ok := ctx.Deadline()
+				require.True(t, ok)
+				require.WithinDuration(t, time.Now().Add(2*time.Second), deadline, time.Second)
+			},
+		},
+		{
+			name:    "Disabled",
+			timeout: -1,
+			check: func(t *testing.T, ctx context.Context) {
+				_, ok := ctx.Deadline()
+				require.False(t, ok)
+			},
+		},
+		{
+			name:   "ClientGone",
+			cancel: true,
+			check: func(t *testing.T, ctx context.Context) {
+				require.ErrorIs(t, ctx.Err(), context.Canceled)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			store.EXPECT().
+				Ping(gomock.Any()).
+				Times(1).
+				DoAndReturn(func(ctx context.Context) error {
+					tc.check(t, ctx)
+					return nil
+				})
+
+			server := newTestServer(t, store)
+			server.config.ContextTimeout = tc.timeout
+			server.setupRouter()
+
+			reqCtx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancel {
+				cancel()
+			}
+
+			request, err := http.NewRequestWithContext(reqCtx, http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+		})
+	}
+}
+
+// TestMaxBodyBytes verifies oversized request bodies are rejected with 413
+func TestMaxBodyBytes(t *testing.T) {
+	const limit = 64
+
+	testCases := []struct {
+		name   string
+		body   func() io.Reader
+		status int
+		code   string
+	}{
+		{
+			name: "Oversized",
+			body: func() io.Reader {
+				return strings.NewReader(`{"username":"` + strings.Repeat("a", limit) + `"}`)
+			},
+			status: http.StatusRequestEntityTooLarge,
+			code:   codeBodyTooLarge,
+		},
+		{
+			//Without a known length the limit is enforced while reading
+			name: "OversizedUnknownLength",
+			body: func() io.Reader {
+				return io.MultiReader(strings.NewReader(strings.Repeat(" ", 2*limit)), strings.NewReader("{}"))
+			},
+			status: http.StatusRequestEntityTooLarge,
+			code:   codeBodyTooLarge,
+		},
+		{
+			//Small bodies reach the handler untouched
+			name:   "WithinLimit",
+			body:   func() io.Reader { return strings.NewReader(`{}`) },
+			status: http.StatusBadRequest,
+			code:   codeValidationError,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+
+			server := newTestServer(t, store)
+			server.config.MaxRequestBodyBytes = limit
+			server.setupRouter()
+
+			request, err := http.NewRequest(http.MethodPost, "/users", tc.body())
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+
+			var body map[string]any
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			require.Equal(t, tc.code, body["code"])
+		})
+	}
+}
+
+// TestTracingMiddleware verifies each request gets a server span that continues
+// the caller's trace and is visible to the store
+func TestTracingMiddleware(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	const parentSpanID = "00f067aa0ba902b7"
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().
+		Ping(gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context) error {
+			//Store calls see the request span
+			spanCtx := trace.SpanContextFromContext(ctx)
+			require.True(t, spanCtx.IsValid())
+			require.Equal(t, traceID, spanCtx.TraceID().String())
+			return nil
+		})
+
+	server := newTestServer(t, store)
+
+	request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+	require.NoError(t, err)
+	request.Header.Set("traceparent", fmt.Sprintf("00-%s-%s-01", traceID, parentSpanID))
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Header().Get("traceparent"), traceID)
+
+	ended := spans.Ended()
+	require.Len(t, ended, 1)
+	require.Equal(t, "GET /readyz", ended[0].Name())
+	require.Equal(t, trace.SpanKindServer, ended[0].SpanKind())
+	require.Equal(t, parentSpanID, ended[0].Parent().SpanID().String())
+	require.Contains(t, ended[0].Attributes(), attribute.Int("http.response.status_code", http.StatusOK))
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/notify"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultPasswordResetTTL is how long a reset token stays valid when no TTL is configured
+const defaultPasswordResetTTL = 15 * time.Minute
+
+// errInvalidResetToken is the single answer to unknown, used and expired reset tokens
+var errInvalidResetToken = errors.New("password reset token is invalid or has expired")
+
+// Request body for asking a password reset
+type resetPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// Reset request response payload, the same whether or not the email is registered
+type resetPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+// requestPasswordReset sends a single-use reset token to the owner of an email.
+// The response never reveals whether the email belongs to a user.
+func (server *Server) requestPasswordReset(ctx *gin.Context) {
+	var req resetPasswordRequest
+
+	//Validate request body
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rsp := resetPasswordResponse{Message: "if the email is registered, a password reset token has been sent to it"}
+
+	user, err := server.store.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusOK, rsp)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ttl := server.config.PasswordResetTTL
+	if ttl <= 0 {
+		ttl = defaultPasswordResetTTL
+	}
+
+	//Only the hash is stored, the token itself goes to the user
+	resetToken := rand.Text()
+	reset, err := server.store.CreatePasswordReset(ctx, db.CreatePasswordResetParams{
+		Username:  user.Username,
+		TokenHash: db.HashResetToken(resetToken),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	notification := notify.Notification{
+		Username: user.Username,
+		Subject:  "Reset your password",
+		Content: fmt.Sprintf("Use the token %s with POST /users/reset_password/confirm before %s to choose a new password.",
+			resetToken, reset.ExpiresAt.UTC().Format(time.RFC3339)),
+	}
+	if err := server.notifier.Notify(ctx, notification); err != nil {
+		log.Printf("cannot send password reset %d: %v", reset.ID, err)
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// Request body for confirming a password reset
+type confirmPasswordResetRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// confirmPasswordReset sets a new password using a reset token
+func (server *Server) confirmPasswordReset(ctx *gin.Context) {
+	var req confirmPasswordResetRequest
+
+	//Validate request body
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedPassword, pepperVersion, err := server.passwords.Hash(req.NewPassword)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		Token:                 req.Token,
+		HashedPassword:        hashedPassword,
+		PasswordPepperVersion: pepperVersion,
+	})
+	if err != nil {
+		//Unknown, used and expired tokens look the same to the caller
+		if errors.Is(err, db.ErrRecordNotFound) ||
+			errors.Is(err, db.ErrPasswordResetUsed) ||
+			errors.Is(err, db.ErrPasswordResetExpired) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(withCode(codeInvalidToken, errInvalidResetToken)))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(result.User))
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/notify"
+	mocknotify "github.com/codercollo/simple_bank/notify/mock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestRequestPasswordResetAPI tests POST /users/reset_password
+func TestRequestPasswordResetAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mock.MockStore, notifier *mocknotify.MockNotifier)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mock.MockStore, notifier *mocknotify.MockNotifier) {
+				var tokenHash string
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().
+					CreatePasswordReset(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreatePasswordResetParams) (db.PasswordReset, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now().Add(defaultPasswordResetTTL), arg.ExpiresAt, time.Minute)
+						tokenHash = arg.TokenHash
+						return db.PasswordReset{ID: 1, Username: arg.Username, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}, nil
+					})
+
+				//The user receives the token whose hash was stored
+				notifier.EXPECT().
+					Notify(gomock.Any(), gomock.AssignableToTypeOf(notify.Notification{})).
+					Times(1).
+					DoAndReturn(func(_ any, notification notify.Notification) error {
+						require.Equal(t, user.Username, notification.Username)
+						fields := strings.Fields(notification.Content)
+						require.Equal(t, tokenHash, db.HashResetToken(fields[3]))
+						return nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownEmail",
+			body: gin.H{"email": "nobody@email.com"},
+			buildStubs: func(store *mock.MockStore, notifier *mocknotify.MockNotifier) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Times(0)
+				notifier.EXPECT().Notify(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				//Same answer as for a registered email
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp resetPasswordResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotEmpty(t, rsp.Message)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{"email": "not-an-email"},
+			buildStubs: func(store *mock.MockStore, notifier *mocknotify.MockNotifier) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"email": user.Email},
+			buildStubs: func(store *mock.MockStore, notifier *mocknotify.MockNotifier) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().CreatePasswordReset(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			notifier := mocknotify.NewMockNotifier(ctrl)
+			tc.buildStubs(store, notifier)
+
+			server := newTestServer(t, store)
+			server.notifier = notifier
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/reset_password", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestConfirmPasswordResetAPI tests POST /users/reset_password/confirm
+func TestConfirmPasswordResetAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	resetToken := "reset-token"
+	newPassword := "new-secret"
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(t *testing.T, server *Server, store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"token": resetToken, "new_password": newPassword},
+			buildStubs: func(t *testing.T, server *Server, store *mock.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+						require.Equal(t, resetToken, arg.Token)
+						require.NoError(t, server.passwords.Check(newPassword, arg.HashedPassword, arg.PasswordPepperVersion))
+
+						updated := user
+						updated.HashedPassword = arg.HashedPassword
+						updated.PasswordChangedAt = time.Now()
+						return db.ResetPasswordTxResult{User: updated, PasswordReset: db.PasswordReset{IsUsed: true}}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp userResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, user.Username, rsp.Username)
+				require.Empty(t, rsp.HashedPassword)
+				require.WithinDuration(t, time.Now(), rsp.PasswordChangedAt, time.Minute)
+			},
+		},
+		{
+			name: "ExpiredToken",
+			body: gin.H{"token": resetToken, "new_password": newPassword},
+			buildStubs: func(t *testing.T, server *Server, store *mock.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ResetPasswordTxResult{}, db.ErrPasswordResetExpired)
+			},
+			checkResponse: requireInvalidResetToken,
+		},
+		{
+			name: "ReusedToken",
+			body: gin.H{"token": resetToken, "new_password": newPassword},
+			buildStubs: func(t *testing.T, server *Server, store *mock.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ResetPasswordTxResult{}, db.ErrPasswordResetUsed)
+			},
+			checkResponse: requireInvalidResetToken,
+		},
+		{
+			name: "UnknownToken",
+			body: gin.H{"token": "unknown", "new_password": newPassword},
+			buildStubs: func(t *testing.T, server *Server, store *mock.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ResetPasswordTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: requireInvalidResetToken,
+		},
+		{
+			name: "ShortPassword",
+			body: gin.H{"token": resetToken, "new_password": "123"},
+			buildStubs: func(t *testing.T, server *Server, store *mock.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeValidationError)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"token": resetToken, "new_password": newPassword},
+			buildStubs: func(t *testing.T, server *Server, store *mock.MockStore) {
+				store.EXPECT().ResetPasswordTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ResetPasswordTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			tc.buildStubs(t, server, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/reset_password/confirm", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// requireInvalidResetToken checks a reset token was rejected without saying why
+func requireInvalidResetToken(t *testing.T, recorder *httptest.ResponseRecorder) {
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Contains(t, recorder.Body.String(), codeInvalidToken)
+	require.Contains(t, recorder.Body.String(), errInvalidResetToken.Error())
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// minLimiterIdleTTL is the shortest time a key's bucket is kept after its last use
+const minLimiterIdleTTL = time.Minute
+
+// keyedRateLimiter keeps a token bucket per key (username, client IP, ...).
+// Buckets idle for longer than it takes to refill are dropped, since a new
+// one would behave the same, so the map does not grow with every key seen.
+type keyedRateLimiter struct {
+	mu        sync.Mutex
+	limiters  map[string]*keyedLimiter
+	limit     rate.Limit
+	burst     int
+	idleTTL   time.Duration
+	lastSweep time.Time
+}
+
+// keyedLimiter is a key's token bucket and when it was last used
+type keyedLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newKeyedRateLimiter creates a limiter allowing burst requests per key,
+// refilled at the given rate
+func newKeyedRateLimiter(limit rate.Limit, burst int) *keyedRateLimiter {
+	//Keep buckets at least until they would have refilled completely
+	idleTTL := minLimiterIdleTTL
+	if limit > 0 && limit != rate.Inf {
+		if refill := time.Duration(float64(burst) / float64(limit) * float64(time.Second)); refill > idleTTL {
+			idleTTL = refill
+		}
+	}
+
+	return &keyedRateLimiter{
+		limiters:  make(map[string]*keyedLimiter),
+		limit:     limit,
+		burst:     burst,
+		idleTTL:   idleTTL,
+		lastSweep: time.Now(),
+	}
+}
+
+// limiter returns the token bucket for a key, creating it on first use
+func (l *keyedRateLimiter) limiter(key string) *rate.Limiter {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	//Drop idle buckets at most once per TTL
+	if now.Sub(l.lastSweep) >= l.idleTTL {
+		l.sweep(now)
+	}
+
+	entry, ok := l.limiters[key]
+	if !ok {
+		entry = &keyedLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.limiters[key] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter
+}
+
+// sweep removes the buckets not used within the idle TTL before now. The
+// caller must hold the lock.
+func (l *keyedRateLimiter) sweep(now time.Time) {
+	for key, entry := range l.limiters {
+		if now.Sub(entry.lastSeen) >= l.idleTTL {
+			delete(l.limiters, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// allow reports whether a request for the key may proceed now
+func (l *keyedRateLimiter) allow(key string) bool {
+	return l.limiter(key).Allow()
+}
+
+// retryAfter takes a token for the key if one is available and returns zero,
+// otherwise it returns how long until the next token without taking it
+func (l *keyedRateLimiter) retryAfter(key string) time.Duration {
+	reservation := l.limiter(key).Reserve()
+	if !reservation.OK() {
+		return time.Second
+	}
+
+	delay := reservation.Delay()
+	if delay > 0 {
+		reservation.Cancel()
+	}
+	return delay
+}
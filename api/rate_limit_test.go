@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// TestKeyedRateLimiterEvictsIdleKeys tests that buckets of keys no longer
+// seen are dropped while active ones keep their state
+func TestKeyedRateLimiterEvictsIdleKeys(t *testing.T) {
+	limiter := newKeyedRateLimiter(rate.Every(time.Second), 2)
+	require.Equal(t, minLimiterIdleTTL, limiter.idleTTL)
+
+	for i := 0; i < 100; i++ {
+		require.True(t, limiter.allow(fmt.Sprintf("10.0.0.%d", i)))
+	}
+	require.Equal(t, 100, len(limiter.limiters))
+
+	//An active key exhausts its burst
+	require.True(t, limiter.allow("active"))
+	require.True(t, limiter.allow("active"))
+	require.False(t, limiter.allow("active"))
+
+	//Only the keys idle for the whole TTL are swept
+	limiter.mu.Lock()
+	limiter.limiters["active"].lastSeen = time.Now().Add(limiter.idleTTL)
+	limiter.sweep(time.Now().Add(limiter.idleTTL))
+	limiter.mu.Unlock()
+	require.Equal(t, 1, len(limiter.limiters))
+	require.False(t, limiter.allow("active"))
+}
+
+// TestKeyedRateLimiterIdleTTL tests that slow refill rates keep buckets until full
+func TestKeyedRateLimiterIdleTTL(t *testing.T) {
+	limiter := newKeyedRateLimiter(rate.Every(time.Minute), 5)
+	require.Equal(t, 5*time.Minute, limiter.idleTTL)
+
+	limiter = newKeyedRateLimiter(rate.Inf, 0)
+	require.Equal(t, minLimiterIdleTTL, limiter.idleTTL)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// Refund request payload
+type refundTransferRequest struct {
+	Amount Amount `json:"amount" binding:"required,gt=0"`
+}
+
+// Refund result payload
+type refundTransferResponse struct {
+	transferTxResponse
+	OriginalTransfer db.Transfer `json:"original_transfer"`
+}
+
+// refundTransfer sends part of a received transfer back to its sender
+func (server *Server) refundTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	var req refundTransferRequest
+
+	//Bind URI params and request body
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransferWithOwners(ctx, uri.ID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	//Only the recipient or a banker may give the money back
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if transfer.ToOwner != authPayload.Username && authPayload.Role != util.BankerRole {
+		err := withCode(codeUnauthorized, errors.New("only the recipient of the transfer can refund it"))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	//Execute refund transaction
+	result, err := server.store.RefundTransferTx(ctx, db.RefundTransferTxParams{
+		TransferID: transfer.ID,
+		Amount:     int64(req.Amount),
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	//Deliver any balance alerts fired by the refund
+	server.notifyBalanceAlerts(ctx, result.Alerts)
+
+	ctx.JSON(http.StatusOK, refundTransferResponse{
+		transferTxResponse: newTransferTxResponse(result.TransferTxResult),
+		OriginalTransfer:   result.OriginalTransfer,
+	})
+}
+
+// Reversal result payload
+type reverseTransferResponse struct {
+	transferTxResponse
+	OriginalTransfer db.Transfer `json:"original_transfer"`
+}
+
+// reverseTransfer undoes a received transfer, sending back what was not refunded yet
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransferWithOwners(ctx, uri.ID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	//Only the owner of the destination account may reverse it
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if transfer.ToOwner != authPayload.Username {
+		err := withCode(codeUnauthorized, errors.New("only the recipient of the transfer can reverse it"))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx, transfer.ID)
+	if err != nil {
+		//A concurrent reversal surfaces as a unique violation, also a conflict
+		respondError(ctx, err)
+		return
+	}
+
+	//Deliver any balance alerts fired by the reversal
+	server.notifyBalanceAlerts(ctx, result.Alerts)
+
+	ctx.JSON(http.StatusOK, reverseTransferResponse{
+		transferTxResponse: newTransferTxResponse(result.TransferTxResult),
+		OriginalTransfer:   result.OriginalTransfer,
+	})
+}
@@ -0,0 +1,365 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestRefundTransferAPI tests POST /transfers/:id/refund
+func TestRefundTransferAPI(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+	admin, _ := randomUser(t)
+
+	transfer := db.GetTransferWithOwnersRow{
+		ID:            7,
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        100,
+		FromOwner:     sender.Username,
+		ToOwner:       recipient.Username,
+	}
+
+	//refundResult builds the store result after refunding the given amounts
+	refundResult := func(amount int64, refunded int64) db.RefundTransferTxResult {
+		return db.RefundTransferTxResult{
+			TransferTxResult: db.TransferTxResult{
+				Transfer: db.Transfer{ID: 8, FromAccountID: 2, ToAccountID: 1, Amount: amount},
+			},
+			OriginalTransfer: db.Transfer{ID: transfer.ID, FromAccountID: 1, ToAccountID: 2, Amount: 100, RefundedAmount: refunded},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "PartialRefund",
+			username: recipient.Username,
+			body:     gin.H{"amount": 40},
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.RefundTransferTxParams{TransferID: transfer.ID, Amount: 40}
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().RefundTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(refundResult(40, 40), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp refundTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, int64(40), rsp.Transfer.Amount)
+				require.Equal(t, int64(40), rsp.OriginalTransfer.RefundedAmount)
+			},
+		},
+		{
+			name:     "SecondRefundUpToRemainder",
+			username: recipient.Username,
+			body:     gin.H{"amount": 60},
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.RefundTransferTxParams{TransferID: transfer.ID, Amount: 60}
+				refunded := transfer
+				refunded.RefundedAmount = 40
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(refunded, nil)
+				store.EXPECT().RefundTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(refundResult(60, 100), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp refundTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, int64(100), rsp.OriginalTransfer.RefundedAmount)
+			},
+		},
+		{
+			name:     "OverRefund",
+			username: recipient.Username,
+			body:     gin.H{"amount": 61},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().RefundTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.RefundTransferTxResult{}, db.ErrRefundExceedsRemainder)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeRefundExceeded)
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			username: recipient.Username,
+			body:     gin.H{"amount": 10},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().RefundTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.RefundTransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeInsufficientFunds)
+			},
+		},
+		{
+			name:     "FrozenAccount",
+			username: recipient.Username,
+			body:     gin.H{"amount": 10},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().RefundTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.RefundTransferTxResult{}, db.ErrAccountFrozen)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeAccountFrozen)
+			},
+		},
+		{
+			name:     "AdminRefund",
+			username: admin.Username,
+			body:     gin.H{"amount": 10},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().RefundTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(refundResult(10, 10), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "SenderCannotRefund",
+			username: sender.Username,
+			body:     gin.H{"amount": 10},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().RefundTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "TransferNotFound",
+			username: recipient.Username,
+			body:     gin.H{"amount": 10},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(db.GetTransferWithOwnersRow{}, sql.ErrNoRows)
+				store.EXPECT().RefundTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidAmount",
+			username: recipient.Username,
+			body:     gin.H{"amount": 0},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/transfers/%d/refund", transfer.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			//Only the admin test user holds the banker role
+			role := util.DepositorRole
+			if tc.username == admin.Username {
+				role = util.BankerRole
+			}
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestReverseTransferAPI tests POST /transfers/:id/reverse
+func TestReverseTransferAPI(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+
+	transfer := db.GetTransferWithOwnersRow{
+		ID:            7,
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        100,
+		FromOwner:     sender.Username,
+		ToOwner:       recipient.Username,
+	}
+
+	reversal := db.ReverseTransferTxResult{
+		TransferTxResult: db.TransferTxResult{
+			Transfer: db.Transfer{
+				ID:            8,
+				FromAccountID: 2,
+				ToAccountID:   1,
+				Amount:        100,
+				ReversedOf:    sql.NullInt64{Int64: transfer.ID, Valid: true},
+			},
+		},
+		OriginalTransfer: db.Transfer{ID: transfer.ID, FromAccountID: 1, ToAccountID: 2, Amount: 100, RefundedAmount: 100},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: recipient.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(reversal, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp reverseTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, reversal.Transfer.ReversedOf, rsp.Transfer.ReversedOf)
+				require.Equal(t, int64(100), rsp.Transfer.Amount)
+				require.Equal(t, int64(100), rsp.OriginalTransfer.RefundedAmount)
+			},
+		},
+		{
+			name:     "AlreadyReversed",
+			username: recipient.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(db.ReverseTransferTxResult{}, db.ErrTransferAlreadyReversed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeAlreadyReversed)
+			},
+		},
+		{
+			name:     "FullyRefunded",
+			username: recipient.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ReverseTransferTxResult{}, db.ErrRefundExceedsRemainder)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeRefundExceeded)
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			username: recipient.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ReverseTransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeInsufficientFunds)
+			},
+		},
+		{
+			name:     "FrozenAccount",
+			username: recipient.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ReverseTransferTxResult{}, db.ErrAccountFrozen)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeAccountFrozen)
+			},
+		},
+		{
+			name:     "SenderCannotReverse",
+			username: sender.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "TransferNotFound",
+			username: recipient.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(db.GetTransferWithOwnersRow{}, sql.ErrNoRows)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: recipient.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.ReverseTransferTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/reverse", transfer.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/gin-gonic/gin"
+)
+
+// Search limits, results are capped per request and requests per user
+const (
+	maxSearchResults = 20
+	searchBurst      = 10
+	searchInterval   = time.Second
+)
+
+// Kinds of search results
+const (
+	searchResultAccount  = "account"
+	searchResultTransfer = "transfer"
+)
+
+// Query params for searching the user's resources
+type searchRequest struct {
+	Query string `form:"q" binding:"required,max=64"`
+}
+
+// searchResult is a single match, only the field named by Type is set
+type searchResult struct {
+	Type     string            `json:"type"`
+	Account  *db.Account       `json:"account,omitempty"`
+	Transfer *transferResponse `json:"transfer,omitempty"`
+}
+
+// Response payload for a search
+type searchResponse struct {
+	Results []searchResult `json:"results"`
+}
+
+// search finds the authenticated user's accounts by nickname, currency or
+// number and their transfers by memo or reference, matching on prefix
+func (server *Server) search(ctx *gin.Context) {
+	var req searchRequest
+
+	//Validate query params
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Throttle searches per user to protect the database
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !server.searchLimiter.allow(authPayload.Username) {
+		err := withCode(codeRateLimited, errors.New("too many search requests"))
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+		return
+	}
+
+	prefix := likePrefix(req.Query)
+	accounts, err := server.store.SearchOwnerAccounts(ctx, db.SearchOwnerAccountsParams{
+		Owner:  authPayload.Username,
+		Prefix: prefix,
+		Limit:  maxSearchResults,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	transfers, err := server.store.SearchOwnerTransfers(ctx, db.SearchOwnerTransfersParams{
+		Owner:  authPayload.Username,
+		Prefix: prefix,
+		Limit:  maxSearchResults,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	//Accounts first, then the most recent transfers, up to the cap
+	results := make([]searchResult, 0, len(accounts)+len(transfers))
+	for i := range accounts {
+		results = append(results, searchResult{Type: searchResultAccount, Account: &accounts[i]})
+	}
+	for _, transfer := range transfers {
+		rsp := newTransferResponse(db.GetTransferWithOwnersRow(transfer), "")
+		results = append(results, searchResult{Type: searchResultTransfer, Transfer: &rsp})
+	}
+	if len(results) > maxSearchResults {
+		results = results[:maxSearchResults]
+	}
+
+	ctx.JSON(http.StatusOK, searchResponse{Results: results})
+}
+
+// likeEscaper escapes the LIKE wildcards in user input
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePrefix turns user input into a lowercase LIKE prefix pattern, escaping
+// the wildcards it may contain
+func likePrefix(query string) string {
+	return likeEscaper.Replace(strings.ToLower(strings.TrimSpace(query))) + "%"
+}
+
+// likeSubstring turns user input into a LIKE pattern matching it anywhere,
+// escaping the wildcards it may contain
+func likeSubstring(query string) string {
+	return "%" + likeEscaper.Replace(strings.TrimSpace(query)) + "%"
+}
+
+// Query params for a banker's account search by owner
+type searchAccountsRequest struct {
+	Owner    string `form:"owner" binding:"required,max=64"`
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+}
+
+// searchAccounts lets bankers find any user's accounts by a case-insensitive
+// owner substring, ordered by owner and account number
+func (server *Server) searchAccounts(ctx *gin.Context) {
+	var req searchAccountsRequest
+
+	//Validate query params
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	accounts, err := server.store.SearchAccountsByOwner(ctx, db.SearchAccountsByOwnerParams{
+		Pattern: likeSubstring(req.Owner),
+		Limit:   req.PageSize,
+		Offset:  (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if accounts == nil {
+		accounts = []db.Account{}
+	}
+
+	ctx.JSON(http.StatusOK, listAccountResponse{
+		Data:     accounts,
+		PageID:   req.PageID,
+		PageSize: req.PageSize,
+	})
+}
@@ -0,0 +1,295 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestSearchAPI tests GET /search
+func TestSearchAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Nickname = "rent savings"
+	transfer := db.SearchOwnerTransfersRow{
+		ID:            7,
+		FromAccountID: account.ID,
+		ToAccountID:   account.ID + 1,
+		Amount:        10,
+		Memo:          "rent for may",
+		FromOwner:     user.Username,
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "NicknameAndMemo",
+			query: "?q=Rent",
+			buildStubs: func(store *mock.MockStore) {
+				//Both searches are scoped to the caller
+				store.EXPECT().
+					SearchOwnerAccounts(gomock.Any(), gomock.Eq(db.SearchOwnerAccountsParams{
+						Owner:  user.Username,
+						Prefix: "rent%",
+						Limit:  maxSearchResults,
+					})).
+					Times(1).
+					Return([]db.Account{account}, nil)
+				store.EXPECT().
+					SearchOwnerTransfers(gomock.Any(), gomock.Eq(db.SearchOwnerTransfersParams{
+						Owner:  user.Username,
+						Prefix: "rent%",
+						Limit:  maxSearchResults,
+					})).
+					Times(1).
+					Return([]db.SearchOwnerTransfersRow{transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp searchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Results, 2)
+
+				require.Equal(t, searchResultAccount, rsp.Results[0].Type)
+				require.Equal(t, account.Nickname, rsp.Results[0].Account.Nickname)
+				require.Nil(t, rsp.Results[0].Transfer)
+
+				require.Equal(t, searchResultTransfer, rsp.Results[1].Type)
+				require.Equal(t, transfer.Memo, rsp.Results[1].Transfer.Memo)
+				require.Empty(t, rsp.Results[1].Transfer.ToOwner)
+				require.Nil(t, rsp.Results[1].Account)
+			},
+		},
+		{
+			name:  "CapsResults",
+			query: "?q=1",
+			buildStubs: func(store *mock.MockStore) {
+				accounts := make([]db.Account, maxSearchResults)
+				store.EXPECT().SearchOwnerAccounts(gomock.Any(), gomock.Any()).Times(1).Return(accounts, nil)
+				store.EXPECT().SearchOwnerTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]db.SearchOwnerTransfersRow{transfer}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp searchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.Results, maxSearchResults)
+			},
+		},
+		{
+			name:  "MissingQuery",
+			query: "",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchOwnerAccounts(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().SearchOwnerTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/search"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestSearchRateLimited verifies searches beyond the burst are throttled
+func TestSearchRateLimited(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//Only the searches within the burst reach the store
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().SearchOwnerAccounts(gomock.Any(), gomock.Any()).Times(searchBurst).Return([]db.Account{}, nil)
+	store.EXPECT().SearchOwnerTransfers(gomock.Any(), gomock.Any()).Times(searchBurst).Return([]db.SearchOwnerTransfersRow{}, nil)
+
+	server := newTestServer(t, store)
+
+	for i := 0; i <= searchBurst; i++ {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/search?q=usd", nil)
+		require.NoError(t, err)
+
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+
+		if i < searchBurst {
+			require.Equal(t, http.StatusOK, recorder.Code)
+		} else {
+			require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		}
+	}
+}
+
+// TestLikePrefix verifies user input cannot inject LIKE wildcards
+func TestLikePrefix(t *testing.T) {
+	require.Equal(t, "rent%", likePrefix(" Rent "))
+	require.Equal(t, `50\%\_off\\%`, likePrefix(`50%_off\`))
+}
+
+// TestLikeSubstring verifies substring patterns keep case and escape wildcards
+func TestLikeSubstring(t *testing.T) {
+	require.Equal(t, "%Ali%", likeSubstring(" Ali "))
+	require.Equal(t, `%50\%\_off\\%`, likeSubstring(`50%_off\`))
+}
+
+// TestSearchAccountsAPI tests GET /accounts/search
+func TestSearchAccountsAPI(t *testing.T) {
+	banker, _ := randomUser(t)
+	depositor, _ := randomUser(t)
+	owner, _ := randomUser(t)
+	accounts := []db.Account{randomAccount(owner.Username), randomAccount(owner.Username)}
+
+	testCases := []struct {
+		name          string
+		query         string
+		role          string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "owner=" + owner.Username[1:4] + "&page_id=2&page_size=5",
+			role:  util.BankerRole,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					SearchAccountsByOwner(gomock.Any(), gomock.Eq(db.SearchAccountsByOwnerParams{
+						Pattern: "%" + owner.Username[1:4] + "%",
+						Limit:   5,
+						Offset:  5,
+					})).
+					Times(1).
+					Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp listAccountResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, int32(2), rsp.PageID)
+				require.Equal(t, int32(5), rsp.PageSize)
+				require.Len(t, rsp.Data, 2)
+				require.Equal(t, accounts[0].ID, rsp.Data[0].ID)
+				require.Equal(t, accounts[1].ID, rsp.Data[1].ID)
+			},
+		},
+		{
+			name:  "NoMatch",
+			query: "owner=nobody&page_id=1&page_size=5",
+			role:  util.BankerRole,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchAccountsByOwner(gomock.Any(), gomock.Any()).Times(1).Return(nil, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"data":[],"page_id":1,"page_size":5}`, recorder.Body.String())
+			},
+		},
+		{
+			name:  "Depositor",
+			query: "owner=a&page_id=1&page_size=5",
+			role:  util.DepositorRole,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchAccountsByOwner(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "MissingOwner",
+			query: "page_id=1&page_size=5",
+			role:  util.BankerRole,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchAccountsByOwner(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidPageSize",
+			query: "owner=a&page_id=1&page_size=100",
+			role:  util.BankerRole,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchAccountsByOwner(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "owner=a&page_id=1&page_size=5",
+			role:  util.BankerRole,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().SearchAccountsByOwner(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/search?%s", tc.query), nil)
+			require.NoError(t, err)
+
+			username := banker.Username
+			if tc.role == util.DepositorRole {
+				username = depositor.Username
+			}
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
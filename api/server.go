@@ -1,14 +1,20 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
 
 	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/notify"
 	"github.com/codercollo/simple_bank/token"
 	"github.com/codercollo/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/time/rate"
 )
 
 // Server serves HTTP requests for our banking service
@@ -16,28 +22,70 @@ type Server struct {
 	store      db.Store
 	router     *gin.Engine
 	tokenMaker token.Maker
+	notifier   notify.Notifier
+	metrics    *metrics
 	config     util.Config
+	passwords  *util.PasswordHasher
+
+	//Optional source of rates for cross-currency transfers
+	exchangeRater ExchangeRater
+
+	verifyPasswordLimiter *keyedRateLimiter
+	searchLimiter         *keyedRateLimiter
 }
 
 // NewServer creates a new HTTP server and setup routing
 func NewServer(store db.Store, config util.Config) (*Server, error) {
 
-	//Create PASETO token maker using the symmetric key
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	//Create the configured PASETO token maker
+	tokenMaker, err := newTokenMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
+	if err := checkTokenRoundTrip(tokenMaker); err != nil {
+		return nil, fmt.Errorf("token maker self-test failed: %w", err)
+	}
+
+	//Load the configured currencies, then block disabled ones for new
+	//accounts and transfers
+	if err := util.SetSupportedCurrencies(config.SupportedCurrencies); err != nil {
+		return nil, fmt.Errorf("invalid supported currencies: %w", err)
+	}
+	if err := util.SetDisabledCurrencies(config.DisabledCurrencies); err != nil {
+		return nil, err
+	}
+
+	//Reject malformed network lists before any route relies on them
+	if _, err := util.ParseNetworks(config.AdminIPAllowlist); err != nil {
+		return nil, fmt.Errorf("invalid admin IP allowlist: %w", err)
+	}
+	if _, err := util.ParseNetworks(config.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	passwords, err := util.NewPasswordHasher(config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create password hasher: %w", err)
+	}
 
 	//Initialize server with dependencies
 	server := &Server{
 		store:      store,
 		tokenMaker: tokenMaker,
+		notifier:   notify.NewLogNotifier(),
+		metrics:    newMetrics(),
 		config:     config,
+		passwords:  passwords,
+
+		verifyPasswordLimiter: newKeyedRateLimiter(rate.Every(verifyPasswordInterval), verifyPasswordBurst),
+		searchLimiter:         newKeyedRateLimiter(rate.Every(searchInterval), searchBurst),
 	}
 
-	//Register custom currency validator
+	//Register custom currency validator and request field names
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("known_currency", knownCurrency)
+		v.RegisterTagNameFunc(requestFieldName)
 	}
 
 	//Setup HTTP routes
@@ -51,34 +99,239 @@ func (server *Server) setupRouter() {
 	///Create Gin router
 	router := gin.Default()
 
-	//Public user routes
-	router.POST("/users", server.createUser)
-	router.POST("/users/login", server.loginUser)
+	//Only honour forwarded client IPs from trusted proxies, validated by NewServer
+	if err := router.SetTrustedProxies(server.config.TrustedProxies); err != nil {
+		panic(err)
+	}
+
+	//Handlers pass the gin context to the store, let it carry the request's
+	//cancellation and deadline
+	router.ContextWithFallback = true
+
+	//Record every request first, so ones rejected by later middleware count too
+	if server.config.EnableMetrics {
+		router.Use(server.metrics.middleware())
+	}
+	router.Use(tracingMiddleware())
+	router.Use(requestTimeout(server.config.ContextTimeout))
+
+	//Cap request bodies before any handler reads them
+	router.Use(maxBodyBytes(server.config.MaxRequestBodyBytes))
+
+	//Answer unmatched routes and methods with JSON errors
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(notFoundHandler)
+	router.NoMethod(methodNotAllowedHandler)
+
+	//Guard connection upgrades against cross-site hijacking
+	router.Use(upgradeOriginMiddleware(server.config.AllowedOrigins))
+
+	//Metrics endpoint
+	if server.config.EnableMetrics {
+		router.GET("/metrics", server.metricsHandler())
+	}
+
+	//Liveness and readiness probes
+	router.GET("/healthz", server.healthz)
+	router.GET("/readyz", server.readyz)
+
+	//Public user routes, signup and login are throttled per client IP
+	router.POST("/users", rateLimiter(newAuthRateLimiter(server.config)), server.createUser)
+	router.POST("/users/login", rateLimiter(newAuthRateLimiter(server.config)), server.loginUser)
+	router.POST("/tokens/renew_access", server.renewAccessToken)
+	router.GET("/users/verify_email", server.verifyEmail)
+	router.POST("/users/reset_password", server.requestPasswordReset)
+	router.POST("/users/reset_password/confirm", server.confirmPasswordReset)
 
 	//Auth-protected routes
-	authRoutes := router.Group("/").Use(authMiddleware((server.tokenMaker)))
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.config.AuthCookieName))
+
+	//User routes
+	authRoutes.GET("/users/me", server.getCurrentUser)
+	authRoutes.PATCH("/users/me", server.updateCurrentUser)
+	authRoutes.GET("/users/me/sessions", server.listSessions)
+	authRoutes.POST("/users/verify_password", server.verifyPassword)
+	authRoutes.POST("/users/change_password", server.changePassword)
+	authRoutes.POST("/users/logout", server.logout)
+
+	//Token routes
+	authRoutes.GET("/tokens/status", server.getTokenStatus)
+	authRoutes.POST("/tokens/introspect", requireRole(util.BankerRole), server.introspectToken)
 
 	//Account routes
 	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts/:id", server.getAccount)
+	authRoutes.GET("/accounts/:id/balance", server.getBalanceAsOf)
+	authRoutes.GET("/accounts/:id/entries", server.listAccountEntries)
+	authRoutes.GET("/accounts/:id/balance_history", server.getBalanceHistory)
 	authRoutes.GET("/accounts", server.listAccount)
-	// authRoutes.PATCH("/accounts/:id", server.updateAccount)
-	// authRoutes.DELETE("/accounts/:id", server.deleteAccount)
+	authRoutes.POST("/accounts/batch_get", server.batchGetAccounts)
+	authRoutes.GET("/me/currencies", server.listOwnerCurrencies)
+	authRoutes.PATCH("/accounts/:id", server.updateAccount)
+	authRoutes.DELETE("/accounts/:id", server.deleteAccount)
+	authRoutes.POST("/accounts/:id/freeze", requireRole(util.BankerRole), server.freezeAccount)
+	authRoutes.POST("/accounts/:id/unfreeze", requireRole(util.BankerRole), server.unfreezeAccount)
+
+	//Balance alert routes
+	authRoutes.POST("/accounts/:id/balance_alert", server.createBalanceAlert)
+	authRoutes.GET("/accounts/:id/balance_alert", server.getBalanceAlert)
+	authRoutes.PUT("/accounts/:id/balance_alert", server.updateBalanceAlert)
+	authRoutes.DELETE("/accounts/:id/balance_alert", server.deleteBalanceAlert)
+
+	//Account tag routes
+	authRoutes.POST("/accounts/:id/tags", server.addAccountTag)
+	authRoutes.DELETE("/accounts/:id/tags/:tag", server.deleteAccountTag)
+
+	//Statement routes
+	authRoutes.PUT("/accounts/:id/statements", server.updateAccountStatements)
 
 	//Transfer routes
 	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.POST("/transfers/batch", server.createBatchTransfer)
+	authRoutes.GET("/transfers", server.listTransfers)
+	authRoutes.GET("/transfers/:id", server.getTransfer)
+	authRoutes.POST("/transfers/:id/refund", server.refundTransfer)
+	authRoutes.POST("/transfers/:id/reverse", server.reverseTransfer)
+
+	//Transfer request routes
+	authRoutes.POST("/transfer_requests", server.createTransferRequest)
+	authRoutes.POST("/transfer_requests/:id/accept", server.acceptTransferRequest)
+	authRoutes.POST("/transfer_requests/:id/decline", server.declineTransferRequest)
+
+	//Search routes
+	authRoutes.GET("/search", server.search)
+	authRoutes.GET("/accounts/search", requireRole(util.BankerRole), server.searchAccounts)
+
+	//Admin routes, reachable only by bankers from trusted networks
+	adminNetworks, err := util.ParseNetworks(server.config.AdminIPAllowlist)
+	if err != nil {
+		panic(err)
+	}
+	adminRoutes := router.Group("/admin").Use(
+		ipAllowlistMiddleware(adminNetworks),
+		authMiddleware(server.tokenMaker, server.config.AuthCookieName),
+		requireRole(util.BankerRole),
+	)
+	adminRoutes.POST("/accounts/:id/reassign", server.reassignAccount)
 
 	//Assign router to server
 	server.router = router
 
 }
 
-// Start runs the HTTP server on a specific address
-func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+// HTTP server timeouts used when none are configured. Writes get more time
+// than the per-request context timeout so handlers can still respond.
+const (
+	defaultServerReadTimeout  = 15 * time.Second
+	defaultServerWriteTimeout = 30 * time.Second
+	defaultServerIdleTimeout  = 60 * time.Second
+)
+
+// shutdownTimeout bounds how long in-flight requests may finish on shutdown
+const shutdownTimeout = 10 * time.Second
+
+// httpServer builds the HTTP server for an address with the configured
+// timeouts, so slow clients cannot hold connections open indefinitely
+func (server *Server) httpServer(address string) *http.Server {
+	readTimeout := server.config.ServerReadTimeout
+	if readTimeout <= 0 {
+		readTimeout = defaultServerReadTimeout
+	}
+	writeTimeout := server.config.ServerWriteTimeout
+	if writeTimeout <= 0 {
+		writeTimeout = defaultServerWriteTimeout
+	}
+	idleTimeout := server.config.ServerIdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultServerIdleTimeout
+	}
+
+	return &http.Server{
+		Addr:              address,
+		Handler:           server.router,
+		ReadHeaderTimeout: readTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+}
+
+// Start runs the HTTP server on a specific address until ctx is done, then
+// shuts it down gracefully, letting in-flight requests finish
+func (server *Server) Start(ctx context.Context, address string) error {
+	httpServer := server.httpServer(address)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("cannot shut down server: %w", err)
+	}
+	return nil
+}
+
+// notFoundHandler responds to requests for unknown routes
+func notFoundHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusNotFound, errorResponse(withCode(codeNotFound, errors.New("not found"))))
 }
 
-// errorResponse formats errors into a consistent JSON response
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// methodNotAllowedHandler responds to known routes called with the wrong method.
+// Gin sets the Allow header with the permitted methods before calling it.
+func methodNotAllowedHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusMethodNotAllowed, errorResponse(withCode(codeMethodNotAllowed, errors.New("method not allowed"))))
+}
+
+// Token maker kinds selectable with TOKEN_MAKER
+const (
+	tokenMakerLocal  = "local"
+	tokenMakerPublic = "public"
+)
+
+// newTokenMaker builds a PASETO maker, local (symmetric) by default or public
+// (Ed25519 signed) when configured
+func newTokenMaker(config util.Config) (token.Maker, error) {
+	options := token.Options{
+		ClockSkew: config.TokenClockSkew,
+		Issuer:    config.TokenIssuer,
+		Audience:  config.TokenAudience,
+	}
+
+	switch config.TokenMaker {
+	case "", tokenMakerLocal:
+		return token.NewPasetoMakerWithOptions(config.TokenSymmetricKey, options)
+	case tokenMakerPublic:
+		return token.NewPasetoV2PublicMakerWithOptions(config.TokenPrivateKey, config.TokenPublicKey, options)
+	default:
+		return nil, fmt.Errorf("unknown token maker %q: must be %q or %q", config.TokenMaker, tokenMakerLocal, tokenMakerPublic)
+	}
+}
+
+// checkTokenRoundTrip creates and verifies a throwaway token so a misconfigured
+// key fails at startup instead of on the first login
+func checkTokenRoundTrip(tokenMaker token.Maker) error {
+	const selfTestUsername = "self-test"
+
+	accessToken, _, err := tokenMaker.CreateToken(selfTestUsername, util.DepositorRole, time.Minute)
+	if err != nil {
+		return fmt.Errorf("cannot create token: %w", err)
+	}
+
+	payload, err := tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return fmt.Errorf("cannot verify token: %w", err)
+	}
+	if payload.Username != selfTestUsername {
+		return fmt.Errorf("token round trip returned username %q", payload.Username)
+	}
+	return nil
 }
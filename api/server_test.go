@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNoRouteHandler verifies unknown paths return a JSON 404
+func TestNoRouteHandler(t *testing.T) {
+	server := newTestServer(t, nil)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/unknown", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.Equal(t, "not found", body["error"])
+	require.Equal(t, codeNotFound, body["code"])
+}
+
+// TestNoMethodHandler verifies wrong methods return a JSON 405 with Allow header
+func TestNoMethodHandler(t *testing.T) {
+	server := newTestServer(t, nil)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodDelete, "/users/login", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	require.Contains(t, strings.Split(recorder.Header().Get("Allow"), ", "), http.MethodPost)
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.Equal(t, "method not allowed", body["error"])
+	require.Equal(t, codeMethodNotAllowed, body["code"])
+}
+
+// TestNewServerTokenSelfTest verifies NewServer fails fast on a bad token key
+func TestNewServerTokenSelfTest(t *testing.T) {
+	//Key of the wrong size
+	_, err := NewServer(nil, util.Config{TokenSymmetricKey: util.RandomString(10)})
+	require.Error(t, err)
+
+	//Valid key passes the round trip
+	server, err := NewServer(nil, util.Config{TokenSymmetricKey: util.RandomString(32)})
+	require.NoError(t, err)
+	require.NotNil(t, server)
+}
+
+// TestNewServerTokenMaker verifies the token maker is picked from config
+func TestNewServerTokenMaker(t *testing.T) {
+	privateKey, publicKey, err := token.NewAsymmetricKeyPair()
+	require.NoError(t, err)
+
+	server, err := NewServer(nil, util.Config{
+		TokenMaker:      tokenMakerPublic,
+		TokenPrivateKey: privateKey,
+		TokenPublicKey:  publicKey,
+	})
+	require.NoError(t, err)
+	require.IsType(t, &token.PasetoPublicMaker{}, server.tokenMaker)
+
+	//A public maker that cannot sign fails the self-test
+	_, err = NewServer(nil, util.Config{TokenMaker: tokenMakerPublic, TokenPublicKey: publicKey})
+	require.ErrorIs(t, err, token.ErrCannotSign)
+
+	_, err = NewServer(nil, util.Config{TokenMaker: "jwt", TokenSymmetricKey: util.RandomString(32)})
+	require.ErrorContains(t, err, "unknown token maker")
+}
+
+// TestCheckTokenRoundTrip verifies the self-test rejects makers that cannot verify their own tokens
+func TestCheckTokenRoundTrip(t *testing.T) {
+	maker, err := token.NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+	require.NoError(t, checkTokenRoundTrip(maker))
+
+	require.Error(t, checkTokenRoundTrip(brokenMaker{maker}))
+}
+
+// brokenMaker issues tokens it cannot verify, as with mismatched keys
+type brokenMaker struct {
+	token.Maker
+}
+
+func (maker brokenMaker) VerifyToken(string) (*token.Payload, error) {
+	return nil, token.ErrInvalidToken
+}
+
+// TestHTTPServerTimeouts verifies the configured timeouts reach the http.Server
+func TestHTTPServerTimeouts(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.config.ServerReadTimeout = 5 * time.Second
+	server.config.ServerWriteTimeout = 20 * time.Second
+	server.config.ServerIdleTimeout = 90 * time.Second
+
+	httpServer := server.httpServer("127.0.0.1:8080")
+	require.Equal(t, "127.0.0.1:8080", httpServer.Addr)
+	require.Equal(t, server.router, httpServer.Handler)
+	require.Equal(t, 5*time.Second, httpServer.ReadHeaderTimeout)
+	require.Equal(t, 5*time.Second, httpServer.ReadTimeout)
+	require.Equal(t, 20*time.Second, httpServer.WriteTimeout)
+	require.Equal(t, 90*time.Second, httpServer.IdleTimeout)
+
+	//Unset timeouts fall back to the defaults instead of none at all
+	server.config.ServerReadTimeout = 0
+	server.config.ServerWriteTimeout = 0
+	server.config.ServerIdleTimeout = 0
+
+	httpServer = server.httpServer("127.0.0.1:8080")
+	require.Equal(t, defaultServerReadTimeout, httpServer.ReadTimeout)
+	require.Equal(t, defaultServerWriteTimeout, httpServer.WriteTimeout)
+	require.Equal(t, defaultServerIdleTimeout, httpServer.IdleTimeout)
+}
+
+// TestStartShutdown verifies the server stops cleanly once its context is done
+func TestStartShutdown(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Start(ctx, "127.0.0.1:0")
+	}()
+
+	cancel()
+	select {
+	case err := <-errs:
+		require.NoError(t, err)
+	case <-time.After(shutdownTimeout):
+		t.Fatal("server did not shut down")
+	}
+}
+
+// TestStartListenError verifies a listen failure is returned
+func TestStartListenError(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	err := server.Start(context.Background(), "invalid-address")
+	require.Error(t, err)
+}
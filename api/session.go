@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Longest user agent and client IP kept on a session, in bytes
+const (
+	maxSessionUserAgentLength = 512
+	maxSessionClientIPLength  = 64
+)
+
+// sanitizeSessionField drops control characters and invalid UTF-8 from a
+// client-supplied value and truncates it to max bytes without splitting a
+// character
+func sanitizeSessionField(value string, max int) string {
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, value)
+	value = strings.TrimSpace(value)
+	if len(value) <= max {
+		return value
+	}
+
+	cut := max
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut]
+}
+
+// Session response payload. The refresh token itself is never returned.
+type sessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIP  string    `json:"client_ip"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	//Current marks the session the request's access token was issued for
+	Current bool `json:"current"`
+}
+
+// newSessionResponse converts a DB session to its API representation
+func newSessionResponse(session db.Session, accessTokenID uuid.UUID) sessionResponse {
+	return sessionResponse{
+		ID:        session.ID,
+		UserAgent: session.UserAgent,
+		ClientIP:  session.ClientIp,
+		CreatedAt: session.CreatedAt,
+		ExpiresAt: session.ExpiresAt,
+		Current:   session.AccessTokenID.Valid && session.AccessTokenID.UUID == accessTokenID,
+	}
+}
+
+// listSessions returns the authenticated user's active sessions, newest first.
+// Revoked and expired sessions are left out.
+func (server *Server) listSessions(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	sessions, err := server.store.ListSessionsByUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		rsp = append(rsp, newSessionResponse(session, authPayload.ID))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
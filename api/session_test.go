@@ -0,0 +1,147 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestListSessionsAPI tests GET /users/me/sessions
+func TestListSessionsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mock.MockStore, payload *token.Payload)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mock.MockStore, payload *token.Payload) {
+				sessions := []db.Session{
+					{
+						ID:            uuid.New(),
+						Username:      user.Username,
+						RefreshToken:  "secret-refresh-token",
+						UserAgent:     "curl/8.0",
+						ClientIp:      "10.0.0.1",
+						ExpiresAt:     now.Add(time.Hour),
+						CreatedAt:     now,
+						AccessTokenID: uuid.NullUUID{UUID: payload.ID, Valid: true},
+					},
+					{
+						ID:        uuid.New(),
+						Username:  user.Username,
+						UserAgent: "Mozilla/5.0",
+						ClientIp:  "10.0.0.2",
+						ExpiresAt: now.Add(2 * time.Hour),
+						CreatedAt: now.Add(-time.Hour),
+					},
+				}
+				store.EXPECT().ListSessionsByUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(sessions, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "secret-refresh-token")
+
+				var rsp []sessionResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp, 2)
+				require.Equal(t, "curl/8.0", rsp[0].UserAgent)
+				require.Equal(t, "10.0.0.1", rsp[0].ClientIP)
+				require.True(t, now.Add(time.Hour).Equal(rsp[0].ExpiresAt))
+				require.True(t, rsp[0].Current)
+				require.False(t, rsp[1].Current)
+			},
+		},
+		{
+			name: "NoSessions",
+			buildStubs: func(store *mock.MockStore, payload *token.Payload) {
+				store.EXPECT().ListSessionsByUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(nil, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `[]`, recorder.Body.String())
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mock.MockStore, payload *token.Payload) {
+				store.EXPECT().ListSessionsByUser(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/users/me/sessions", nil)
+			require.NoError(t, err)
+
+			accessToken, payload, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole, time.Minute)
+			require.NoError(t, err)
+			request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+			tc.buildStubs(store, payload)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestListSessionsUnauthorized tests the sessions listing requires a token
+func TestListSessionsUnauthorized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().ListSessionsByUser(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/users/me/sessions", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+// TestSanitizeSessionField verifies client values are cleaned and bounded
+func TestSanitizeSessionField(t *testing.T) {
+	require.Equal(t, "curl/8.0", sanitizeSessionField("  curl/8.0\r\n", 64))
+	require.Equal(t, "evilinjected", sanitizeSessionField("evil\x00\ninjected", 64))
+	require.Equal(t, "bad", sanitizeSessionField("b\xffad", 64))
+	require.Equal(t, "abc", sanitizeSessionField("abcdef", 3))
+
+	//Multi-byte characters are never split
+	require.Equal(t, "a", sanitizeSessionField("aé", 2))
+	require.Equal(t, "aé", sanitizeSessionField("aé", 3))
+
+	long := sanitizeSessionField(strings.Repeat("x", 2*maxSessionUserAgentLength), maxSessionUserAgentLength)
+	require.Len(t, long, maxSessionUserAgentLength)
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/gin-gonic/gin"
+)
+
+// Request body for enabling or disabling monthly statements
+type updateAccountStatementsRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// updateAccountStatements toggles monthly statements for an owned account
+func (server *Server) updateAccountStatements(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req updateAccountStatementsRequest
+
+	//Bind URI params and request body
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Check account ownership
+	if _, valid := server.ownedAccount(ctx, uri.ID); !valid {
+		return
+	}
+
+	account, err := server.store.UpdateAccountStatements(ctx, db.UpdateAccountStatementsParams{
+		ID:                uri.ID,
+		StatementsEnabled: *req.Enabled,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
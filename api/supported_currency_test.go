@@ -0,0 +1,128 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestSupportedCurrencies verifies new accounts are limited to the configured currencies
+func TestSupportedCurrencies(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		supported     []string
+		body          string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "ConfiguredCurrency",
+			supported: []string{"usd", "GBP"},
+			body:      `{"currency":"gbp"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Eq(db.CreateAccountParams{Owner: user.Username, Currency: "GBP"})).
+					Times(1).
+					Return(db.Account{ID: 1, Owner: user.Username, Currency: "GBP"}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "DefaultNotConfigured",
+			supported: []string{"usd", "GBP"},
+			body:      `{"currency":"EUR"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DefaultSet",
+			body: `{"currency":"EUR"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetAccountByOwnerAndCurrency(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().
+					CreateAccount(gomock.Any(), gomock.Eq(db.CreateAccountParams{Owner: user.Username, Currency: util.EUR})).
+					Times(1).
+					Return(db.Account{ID: 1, Owner: user.Username, Currency: util.EUR}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownCurrency",
+			body: `{"currency":"XYZ"}`,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			config := util.Config{
+				TokenSymmetricKey:   util.RandomString(32),
+				AccessTokenDuration: time.Minute,
+				SupportedCurrencies: tc.supported,
+			}
+			server, err := NewServer(store, config)
+			require.NoError(t, err)
+			defer util.SetSupportedCurrencies(nil)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestNewServerInvalidSupportedCurrency verifies malformed currency codes fail startup
+func TestNewServerInvalidSupportedCurrency(t *testing.T) {
+	defer util.SetSupportedCurrencies(nil)
+
+	config := util.Config{
+		TokenSymmetricKey:   util.RandomString(32),
+		AccessTokenDuration: time.Minute,
+		SupportedCurrencies: []string{"USD", "dollars"},
+	}
+	_, err := NewServer(mock.NewMockStore(gomock.NewController(t)), config)
+	require.Error(t, err)
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// refreshTokenHeaderKey optionally carries a refresh token to inspect
+const refreshTokenHeaderKey = "X-Refresh-Token"
+
+// Token lifetimes used when none are configured
+const (
+	defaultAccessTokenDuration  = 15 * time.Minute
+	defaultRefreshTokenDuration = 24 * time.Hour
+)
+
+// accessTokenDuration returns the configured access token lifetime or its default
+func (server *Server) accessTokenDuration() time.Duration {
+	if server.config.AccessTokenDuration <= 0 {
+		return defaultAccessTokenDuration
+	}
+	return server.config.AccessTokenDuration
+}
+
+// refreshTokenDuration returns the configured refresh token lifetime or its default
+func (server *Server) refreshTokenDuration() time.Duration {
+	if server.config.RefreshTokenDuration <= 0 {
+		return defaultRefreshTokenDuration
+	}
+	return server.config.RefreshTokenDuration
+}
+
+// Expiry status of a single token
+type tokenStatus struct {
+	ExpiresAt        time.Time `json:"expires_at"`
+	ExpiresInSeconds int64     `json:"expires_in_seconds"`
+	Expired          bool      `json:"expired"`
+}
+
+// newTokenStatus computes the remaining lifetime of a token, which is zero or
+// negative once it has expired
+func newTokenStatus(expiresAt time.Time, now time.Time) tokenStatus {
+	remaining := expiresAt.Sub(now)
+	return tokenStatus{
+		ExpiresAt:        expiresAt,
+		ExpiresInSeconds: int64(remaining / time.Second),
+		Expired:          remaining <= 0,
+	}
+}
+
+// Token status response, refresh status is only set when one is presented
+type tokenStatusResponse struct {
+	AccessToken  tokenStatus  `json:"access_token"`
+	RefreshToken *tokenStatus `json:"refresh_token,omitempty"`
+}
+
+// getTokenStatus reports when the presented tokens expire so clients can
+// schedule a refresh
+func (server *Server) getTokenStatus(ctx *gin.Context) {
+	now := time.Now()
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	rsp := tokenStatusResponse{
+		AccessToken: newTokenStatus(authPayload.ExpiredAt, now),
+	}
+
+	//Inspect the refresh token when one is presented
+	if refreshToken := ctx.GetHeader(refreshTokenHeaderKey); refreshToken != "" {
+		refreshPayload, err := server.tokenMaker.VerifyToken(refreshToken)
+		var expiredErr *token.ExpiredTokenError
+		switch {
+		case err == nil:
+			status := newTokenStatus(refreshPayload.ExpiredAt, now)
+			rsp.RefreshToken = &status
+		case errors.As(err, &expiredErr):
+			status := newTokenStatus(expiredErr.ExpiredAt, now)
+			rsp.RefreshToken = &status
+		default:
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// Request payload for renewing an access token
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Response payload with the renewed access token
+type renewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+// renewAccessToken issues a new access token for a refresh token whose session
+// is still active
+func (server *Server) renewAccessToken(ctx *gin.Context) {
+	var req renewAccessTokenRequest
+
+	//Validate request body
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	//The refresh token must belong to a known session
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = withCode(codeUnauthorized, errors.New("session not found"))
+		}
+		respondError(ctx, err)
+		return
+	}
+
+	//Reject revoked, mismatched and expired sessions
+	var sessionErr error
+	switch {
+	case session.IsBlocked:
+		sessionErr = errors.New("session is revoked")
+	case session.Username != refreshPayload.Username:
+		sessionErr = errors.New("session belongs to another user")
+	case session.RefreshToken != req.RefreshToken:
+		sessionErr = errors.New("refresh token does not match the session")
+	case !time.Now().Before(session.ExpiresAt):
+		sessionErr = errors.New("session has expired")
+	}
+	if sessionErr != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(withCode(codeUnauthorized, sessionErr)))
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+		refreshPayload.Username,
+		refreshPayload.Role,
+		server.accessTokenDuration(),
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	//Logging out with the new access token must find this session
+	err = server.store.SetSessionAccessToken(ctx, db.SetSessionAccessTokenParams{
+		ID:            session.ID,
+		AccessTokenID: uuid.NullUUID{UUID: accessPayload.ID, Valid: true},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, renewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+	})
+}
+
+// Request payload for inspecting a token
+type introspectTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// Decoded claims of an introspected token
+type tokenClaims struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+	IssuedAt time.Time `json:"issued_at"`
+	tokenStatus
+}
+
+// Token introspection response, claims are omitted when the token could not
+// be authenticated at all
+type introspectTokenResponse struct {
+	Active bool         `json:"active"`
+	Claims *tokenClaims `json:"claims,omitempty"`
+}
+
+// introspectToken decodes a token for operators. Expired tokens still report
+// their claims, only inactive; the token itself is never echoed back.
+func (server *Server) introspectToken(ctx *gin.Context) {
+	var req introspectTokenRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(req.Token)
+	var expiredErr *token.ExpiredTokenError
+	if errors.As(err, &expiredErr) {
+		payload = expiredErr.Payload
+	} else if err != nil {
+		ctx.JSON(http.StatusOK, introspectTokenResponse{Active: false})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, introspectTokenResponse{
+		Active: err == nil,
+		Claims: &tokenClaims{
+			ID:          payload.ID,
+			Username:    payload.Username,
+			Role:        payload.Role,
+			IssuedAt:    payload.IssueAt,
+			tokenStatus: newTokenStatus(payload.ExpiredAt, time.Now()),
+		},
+	})
+}
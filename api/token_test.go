@@ -0,0 +1,368 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestGetTokenStatusAPI tests GET /tokens/status for access and refresh tokens
+func TestGetTokenStatusAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		setupRefresh  func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "AccessTokenOnly",
+			setupRefresh: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp tokenStatusResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.InDelta(t, time.Minute.Seconds(), rsp.AccessToken.ExpiresInSeconds, 2)
+				require.WithinDuration(t, time.Now().Add(time.Minute), rsp.AccessToken.ExpiresAt, 2*time.Second)
+				require.False(t, rsp.AccessToken.Expired)
+				require.Nil(t, rsp.RefreshToken)
+			},
+		},
+		{
+			name: "WithRefreshToken",
+			setupRefresh: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				refreshToken, _, err := tokenMaker.CreateToken(user.Username, util.DepositorRole, time.Hour)
+				require.NoError(t, err)
+				request.Header.Set(refreshTokenHeaderKey, refreshToken)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp tokenStatusResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotNil(t, rsp.RefreshToken)
+				require.InDelta(t, time.Hour.Seconds(), rsp.RefreshToken.ExpiresInSeconds, 2)
+				require.False(t, rsp.RefreshToken.Expired)
+			},
+		},
+		{
+			name: "ExpiredRefreshToken",
+			setupRefresh: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				refreshToken, _, err := tokenMaker.CreateToken(user.Username, util.DepositorRole, -time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(refreshTokenHeaderKey, refreshToken)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp tokenStatusResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotNil(t, rsp.RefreshToken)
+				require.True(t, rsp.RefreshToken.Expired)
+				require.Negative(t, rsp.RefreshToken.ExpiresInSeconds)
+			},
+		},
+		{
+			name: "InvalidRefreshToken",
+			setupRefresh: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				request.Header.Set(refreshTokenHeaderKey, "invalid")
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/tokens/status", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			tc.setupRefresh(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestIntrospectTokenAPI tests POST /tokens/introspect for active, expired and malformed tokens
+func TestIntrospectTokenAPI(t *testing.T) {
+	banker, _ := randomUser(t)
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		role          string
+		buildToken    func(t *testing.T, tokenMaker token.Maker) string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Active",
+			role: util.BankerRole,
+			buildToken: func(t *testing.T, tokenMaker token.Maker) string {
+				token, _, err := tokenMaker.CreateToken(user.Username, util.DepositorRole, time.Hour)
+				require.NoError(t, err)
+				return token
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp introspectTokenResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.True(t, rsp.Active)
+				require.NotNil(t, rsp.Claims)
+				require.Equal(t, user.Username, rsp.Claims.Username)
+				require.Equal(t, util.DepositorRole, rsp.Claims.Role)
+				require.False(t, rsp.Claims.Expired)
+				require.InDelta(t, time.Hour.Seconds(), rsp.Claims.ExpiresInSeconds, 5)
+			},
+		},
+		{
+			name: "Expired",
+			role: util.BankerRole,
+			buildToken: func(t *testing.T, tokenMaker token.Maker) string {
+				token, _, err := tokenMaker.CreateToken(user.Username, util.DepositorRole, -time.Minute)
+				require.NoError(t, err)
+				return token
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp introspectTokenResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.False(t, rsp.Active)
+				require.NotNil(t, rsp.Claims)
+				require.Equal(t, user.Username, rsp.Claims.Username)
+				require.True(t, rsp.Claims.Expired)
+				require.LessOrEqual(t, rsp.Claims.ExpiresInSeconds, int64(-59))
+			},
+		},
+		{
+			name: "Malformed",
+			role: util.BankerRole,
+			buildToken: func(t *testing.T, tokenMaker token.Maker) string {
+				return "not-a-token"
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"active":false}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "Forbidden",
+			role: util.DepositorRole,
+			buildToken: func(t *testing.T, tokenMaker token.Maker) string {
+				token, _, err := tokenMaker.CreateToken(user.Username, util.DepositorRole, time.Hour)
+				require.NoError(t, err)
+				return token
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "MissingToken",
+			role: util.BankerRole,
+			buildToken: func(t *testing.T, tokenMaker token.Maker) string {
+				return ""
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"token": tc.buildToken(t, server.tokenMaker)})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/tokens/introspect", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, banker.Username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestNewTokenStatus verifies remaining lifetime near and past expiry
+func TestNewTokenStatus(t *testing.T) {
+	now := time.Now()
+
+	//Less than a second left still counts as valid
+	status := newTokenStatus(now.Add(500*time.Millisecond), now)
+	require.Zero(t, status.ExpiresInSeconds)
+	require.False(t, status.Expired)
+
+	//Expiring exactly now is expired
+	status = newTokenStatus(now, now)
+	require.Zero(t, status.ExpiresInSeconds)
+	require.True(t, status.Expired)
+
+	//Past expiry reports negative seconds
+	status = newTokenStatus(now.Add(-90*time.Second), now)
+	require.Equal(t, int64(-90), status.ExpiresInSeconds)
+	require.True(t, status.Expired)
+}
+
+// TestRenewAccessTokenAPI tests POST /tokens/renew_access
+func TestRenewAccessTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		refreshToken  func(token string) string
+		buildSession  func(session *db.Session) error
+		renewed       bool
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			renewed: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp renewAccessTokenResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotEmpty(t, rsp.AccessToken)
+				require.WithinDuration(t, time.Now().Add(time.Minute), rsp.AccessTokenExpiresAt, 2*time.Second)
+			},
+		},
+		{
+			name: "RevokedSession",
+			buildSession: func(session *db.Session) error {
+				session.IsBlocked = true
+				return nil
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "revoked")
+			},
+		},
+		{
+			name: "ExpiredSession",
+			buildSession: func(session *db.Session) error {
+				session.ExpiresAt = time.Now().Add(-time.Second)
+				return nil
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "expired")
+			},
+		},
+		{
+			name: "MismatchedRefreshToken",
+			buildSession: func(session *db.Session) error {
+				session.RefreshToken = "other"
+				return nil
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildSession: func(session *db.Session) error {
+				return sql.ErrNoRows
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidRefreshToken",
+			refreshToken: func(token string) string {
+				return token + "x"
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole, time.Hour)
+			require.NoError(t, err)
+
+			//Sessions are only looked up for tokens that verify
+			if tc.refreshToken != nil {
+				refreshToken = tc.refreshToken(refreshToken)
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			} else {
+				session := db.Session{
+					ID:           refreshPayload.ID,
+					Username:     user.Username,
+					RefreshToken: refreshToken,
+					ExpiresAt:    refreshPayload.ExpiredAt,
+				}
+				var sessionErr error
+				if tc.buildSession != nil {
+					sessionErr = tc.buildSession(&session)
+				}
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(refreshPayload.ID)).
+					Times(1).
+					Return(session, sessionErr)
+			}
+
+			//A renewed access token is recorded on its session for logout
+			var renewals int
+			if tc.renewed {
+				renewals = 1
+			}
+			store.EXPECT().
+				SetSessionAccessToken(gomock.Any(), gomock.Any()).
+				Times(renewals).
+				DoAndReturn(func(_ any, arg db.SetSessionAccessTokenParams) error {
+					require.Equal(t, refreshPayload.ID, arg.ID)
+					require.True(t, arg.AccessTokenID.Valid)
+					return nil
+				})
+
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -1,31 +1,51 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
+	"time"
 
 	db "github.com/codercollo/simple_bank/db/sqlc"
 	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
 	"github.com/gin-gonic/gin"
 )
 
-// Transfer request payload
+// Transfer request payload. Amount is in minor units of Currency, e.g. cents
+// for USD, and any converted amount is in minor units of the destination.
 type transferRequest struct {
 	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
 	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	Amount        Amount `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,known_currency"`
+	Memo          string `json:"memo" binding:"max=140"`
+
+	//Destination units per source unit, only for cross-currency transfers
+	ExchangeRate *ExchangeRate `json:"exchange_rate"`
+
+	//Reject the transfer unless the source keeps at least this balance
+	MinSourceBalanceAfter *int64 `json:"min_source_balance_after"`
 }
 
 // createTransfer handles money transfer between accounts
 func (server *Server) createTransfer(ctx *gin.Context) {
 	var req transferRequest
 
+	//Count the transfer as failed if it ends with an error response
+	defer func() {
+		server.metrics.recordTransferFailure(ctx.Writer.Status(), lastError(ctx))
+	}()
+
 	//Validate request body
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		rejectTransfer(ctx, http.StatusBadRequest, err)
+		return
+	}
+	req.Currency = util.NormalizeCurrency(req.Currency)
+
+	if !server.withinMaxTransferAmount(ctx, int64(req.Amount)) {
 		return
 	}
 
@@ -37,54 +57,316 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("from account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		err := withCode(codeUnauthorized, errors.New("from account doesn't belong to the authenticated user"))
+		rejectTransfer(ctx, http.StatusUnauthorized, err)
 		return
 	}
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	toAccount, valid := server.existingAccount(ctx, req.ToAccountID)
 	if !valid {
 		return
 	}
 
+	//Convert the amount when the destination holds another currency
+	var convertedAmount int64
+	var roundingMode util.RoundingMode
+	if toAccount.Currency != req.Currency {
+		if !util.IsEnabledCurrency(toAccount.Currency) {
+			err := withCode(codeCurrencyDisabled, fmt.Errorf("currency %s is disabled: it cannot receive transfers", toAccount.Currency))
+			rejectTransfer(ctx, http.StatusBadRequest, err)
+			return
+		}
+		convertedAmount, roundingMode, valid = server.convertAmount(ctx, int64(req.Amount), (*big.Rat)(req.ExchangeRate), req.Currency, toAccount.Currency)
+		if !valid {
+			return
+		}
+	} else if req.ExchangeRate != nil {
+		err := withCode(codeValidationError, errors.New("exchange_rate only applies between accounts of different currencies"))
+		rejectTransfer(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !server.verifiedOwners(ctx, fromAccount.Owner, toAccount.Owner) {
+		return
+	}
+
+	//Disabled currencies only allow sweeping the whole balance out
+	minSourceBalanceAfter := req.MinSourceBalanceAfter
+	if !util.IsEnabledCurrency(req.Currency) {
+		if int64(req.Amount) != fromAccount.Balance {
+			err := withCode(codeCurrencyDisabled, fmt.Errorf("currency %s is disabled: only a transfer of the full balance is allowed", req.Currency))
+			rejectTransfer(ctx, http.StatusBadRequest, err)
+			return
+		}
+		zero := int64(0)
+		minSourceBalanceAfter = &zero
+	}
+
 	//Execute transfer transaction
 	arg := db.TransferTxParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+		FromAccountID:   req.FromAccountID,
+		ToAccountID:     req.ToAccountID,
+		Amount:          int64(req.Amount),
+		Memo:            req.Memo,
+		ConvertedAmount: convertedAmount,
+		RoundingMode:    string(roundingMode),
+
+		MinSourceBalanceAfter: minSourceBalanceAfter,
 	}
+	arg.DailyLimit, arg.DailyLimitSince = server.dailyTransferLimit()
 
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			err = fmt.Errorf("account [%d] cannot cover the transfer of %s: %w", req.FromAccountID, util.FormatMoney(int64(req.Amount), req.Currency), err)
+		}
+		rejectTransfer(ctx, httpStatusForError(err), err)
 		return
 	}
 
+	server.metrics.recordTransfer(req.Currency, int64(req.Amount))
+
+	//Deliver any balance alerts fired by the transfer
+	server.notifyBalanceAlerts(ctx, result.Alerts)
+
 	//Success response
-	ctx.JSON(http.StatusOK, result)
+	ctx.JSON(http.StatusOK, newTransferTxResponse(result))
+}
+
+// rejectTransfer responds with the error and attaches it to the request, so a
+// failed transfer is counted by its cause rather than only its status
+func rejectTransfer(ctx *gin.Context, status int, err error) {
+	_ = ctx.Error(err)
+	ctx.JSON(status, errorResponse(err))
+}
+
+// lastError returns the last error attached to the request, if any
+func lastError(ctx *gin.Context) error {
+	if last := ctx.Errors.Last(); last != nil {
+		return last.Err
+	}
+	return nil
+}
+
+// dailyTransferWindow is the rolling window the daily transfer limit applies to
+const dailyTransferWindow = 24 * time.Hour
+
+// dailyTransferLimit returns the configured daily limit and the start of the
+// window it applies to. The store checks it once the source account is locked,
+// so concurrent transfers cannot exceed it together.
+func (server *Server) dailyTransferLimit() (int64, time.Time) {
+	limit := server.config.DailyTransferLimit
+	if limit <= 0 {
+		return 0, time.Time{}
+	}
+	return limit, time.Now().Add(-dailyTransferWindow)
+}
+
+// Transfer result payload with labelled entries
+type transferTxResponse struct {
+	db.TransferTxResult
+	FromEntry entryResponse `json:"from_entry"`
+	ToEntry   entryResponse `json:"to_entry"`
+}
+
+// newTransferTxResponse converts a transfer result to its API representation
+func newTransferTxResponse(result db.TransferTxResult) transferTxResponse {
+	return transferTxResponse{
+		TransferTxResult: result,
+		FromEntry:        newEntryResponse(result.FromEntry),
+		ToEntry:          newEntryResponse(result.ToEntry),
+	}
 }
 
 // validAccount verifies account existence and currency consistency
 func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
-
-	//Fetch account by ID
-	account, err := server.store.GetAccount(ctx, accountID)
-	if err != nil {
-		//Account not found
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return account, false
-		}
-		//Database error
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	account, valid := server.existingAccount(ctx, accountID)
+	if !valid {
 		return account, false
 	}
 
 	//Validate currency match
 	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		err := withCode(codeCurrencyMismatch, fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency))
+		rejectTransfer(ctx, http.StatusBadRequest, err)
 		return account, false
 	}
 
 	return account, true
 }
+
+// existingAccount fetches an account taking part in a transfer, responding
+// with an error if it is missing or frozen
+func (server *Server) existingAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		//Missing accounts are a 404, database errors a 500
+		rejectTransfer(ctx, httpStatusForError(err), err)
+		return account, false
+	}
+
+	return account, activeAccount(ctx, account)
+}
+
+// activeAccount responds with an error if the account is frozen, since frozen
+// accounts can neither send nor receive money
+func activeAccount(ctx *gin.Context, account db.Account) bool {
+	if account.IsFrozen() {
+		err := withCode(codeAccountFrozen, fmt.Errorf("account [%d] is frozen: transfers are blocked", account.ID))
+		rejectTransfer(ctx, http.StatusForbidden, err)
+		return false
+	}
+	return true
+}
+
+// withinMaxTransferAmount rejects single transfers above the configured maximum
+func (server *Server) withinMaxTransferAmount(ctx *gin.Context, amount int64) bool {
+	if maxAmount := server.config.MaxTransferAmount; maxAmount > 0 && amount > maxAmount {
+		err := withCode(codeTransferLimit, fmt.Errorf("amount %d exceeds the maximum transfer amount of %d", amount, maxAmount))
+		rejectTransfer(ctx, http.StatusBadRequest, err)
+		return false
+	}
+	return true
+}
+
+// verifiedOwners checks both owners have verified their email when the policy is on
+func (server *Server) verifiedOwners(ctx *gin.Context, fromOwner string, toOwner string) bool {
+	if !server.config.RequireVerifiedEmailForTransfers {
+		return true
+	}
+	return server.verifiedOwner(ctx, fromOwner, "sender") &&
+		server.verifiedOwner(ctx, toOwner, "recipient")
+}
+
+// verifiedOwner checks that an account owner has verified their email.
+// The owner is named only by role so the recipient's identity isn't leaked.
+func (server *Server) verifiedOwner(ctx *gin.Context, owner string, role string) bool {
+	user, err := server.store.GetUser(ctx, owner)
+	if err != nil {
+		rejectTransfer(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+
+	if !user.IsEmailVerified {
+		err := withCode(codeEmailNotVerified, fmt.Errorf("transfer blocked: the %s's email is not verified", role))
+		rejectTransfer(ctx, http.StatusForbidden, err)
+		return false
+	}
+	return true
+}
+
+// expandOwners asks transfer reads to include the counterparties' usernames
+const expandOwners = "owners"
+
+// Transfer response payload, owners are only set when expanded
+type transferResponse struct {
+	ID              int64     `json:"id"`
+	FromAccountID   int64     `json:"from_account_id"`
+	ToAccountID     int64     `json:"to_account_id"`
+	Amount          int64     `json:"amount"`
+	ConvertedAmount int64     `json:"converted_amount"`
+	RoundingMode    string    `json:"rounding_mode,omitempty"`
+	RefundedAmount  int64     `json:"refunded_amount"`
+	Memo            string    `json:"memo,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	FromOwner       string    `json:"from_owner,omitempty"`
+	ToOwner         string    `json:"to_owner,omitempty"`
+}
+
+// newTransferResponse converts a transfer row, resolving owners when expanded
+func newTransferResponse(transfer db.GetTransferWithOwnersRow, expand string) transferResponse {
+	rsp := transferResponse{
+		ID:              transfer.ID,
+		FromAccountID:   transfer.FromAccountID,
+		ToAccountID:     transfer.ToAccountID,
+		Amount:          transfer.Amount,
+		ConvertedAmount: transfer.ConvertedAmount,
+		RoundingMode:    transfer.RoundingMode,
+		RefundedAmount:  transfer.RefundedAmount,
+		Memo:            transfer.Memo,
+		CreatedAt:       transfer.CreatedAt,
+		UpdatedAt:       transfer.UpdatedAt,
+	}
+	if expand == expandOwners {
+		rsp.FromOwner = transfer.FromOwner
+		rsp.ToOwner = transfer.ToOwner
+	}
+	return rsp
+}
+
+// Query params for listing transfers
+type listTransfersRequest struct {
+	PageID   int32  `form:"page_id" binding:"required,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Expand   string `form:"expand" binding:"omitempty,oneof=owners"`
+}
+
+// listTransfers lists transfers touching the authenticated user's accounts
+func (server *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+
+	//Bind query params
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	//Only transfers with an owned side are returned
+	transfers, err := server.store.ListOwnerTransfers(ctx, db.ListOwnerTransfersParams{
+		Owner:  authPayload.Username,
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]transferResponse, 0, len(transfers))
+	for _, transfer := range transfers {
+		rsp = append(rsp, newTransferResponse(db.GetTransferWithOwnersRow(transfer), req.Expand))
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// URI and query params for fetching a transfer
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type getTransferQuery struct {
+	Expand string `form:"expand" binding:"omitempty,oneof=owners"`
+}
+
+// getTransfer returns a transfer if the authenticated user owns either side
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	var query getTransferQuery
+
+	//Bind URI and query params
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransferWithOwners(ctx, req.ID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	//Hide transfers of other users as if they did not exist
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if transfer.FromOwner != authPayload.Username && transfer.ToOwner != authPayload.Username {
+		respondError(ctx, db.ErrRecordNotFound)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferResponse(transfer, query.Expand))
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/notify"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultTransferRequestTTL is how long a recipient has to answer when no TTL is configured
+const defaultTransferRequestTTL = 24 * time.Hour
+
+// Request body for asking another user to accept a transfer
+type createTransferRequestRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        Amount `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+}
+
+// Transfer request payload with its status at response time
+type transferRequestResponse struct {
+	ID            int64     `json:"id"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	Status        string    `json:"status"`
+	TransferID    *int64    `json:"transfer_id,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// newTransferRequestResponse converts a DB transfer request to its API representation
+func newTransferRequestResponse(request db.TransferRequest) transferRequestResponse {
+	rsp := transferRequestResponse{
+		ID:            request.ID,
+		FromAccountID: request.FromAccountID,
+		ToAccountID:   request.ToAccountID,
+		Amount:        request.Amount,
+		Status:        request.StatusAt(time.Now()),
+		ExpiresAt:     request.ExpiresAt,
+		CreatedAt:     request.CreatedAt,
+		UpdatedAt:     request.UpdatedAt,
+	}
+	if request.TransferID.Valid {
+		rsp.TransferID = &request.TransferID.Int64
+	}
+	return rsp
+}
+
+// Accepted transfer request payload
+type acceptTransferRequestResponse struct {
+	TransferRequest transferRequestResponse `json:"transfer_request"`
+	Transfer        transferTxResponse      `json:"transfer"`
+}
+
+// createTransferRequest asks the owner of another account to accept a transfer
+func (server *Server) createTransferRequest(ctx *gin.Context) {
+	var req createTransferRequestRequest
+
+	//Validate request body
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	req.Currency = util.NormalizeCurrency(req.Currency)
+
+	//Validate source and destination accounts
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		err := withCode(codeUnauthorized, errors.New("from account doesn't belong to the authenticated user"))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	toAccount, valid := server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !valid {
+		return
+	}
+
+	//Transfers between own accounts need no confirmation
+	if toAccount.Owner == authPayload.Username {
+		err := withCode(codeValidationError, errors.New("to account belongs to the authenticated user: use a direct transfer instead"))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ttl := server.config.TransferRequestTTL
+	if ttl <= 0 {
+		ttl = defaultTransferRequestTTL
+	}
+
+	request, err := server.store.CreateTransferRequest(ctx, db.CreateTransferRequestParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        int64(req.Amount),
+		ExpiresAt:     time.Now().Add(ttl),
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	//Ask the recipient to answer, the request stands even if delivery fails
+	notification := notify.Notification{
+		Username: toAccount.Owner,
+		Subject:  fmt.Sprintf("Transfer request %d", request.ID),
+		Content: fmt.Sprintf("%s wants to send %d %s to your account %d. Accept or decline before %s.",
+			authPayload.Username, request.Amount, req.Currency, request.ToAccountID, request.ExpiresAt.UTC().Format(time.RFC3339)),
+	}
+	if err := server.notifier.Notify(ctx, notification); err != nil {
+		log.Printf("cannot notify recipient of transfer request %d: %v", request.ID, err)
+	}
+
+	ctx.JSON(http.StatusOK, newTransferRequestResponse(request))
+}
+
+// pendingTransferRequest fetches a transfer request the authenticated user can
+// still answer as its recipient, along with the recipient's account
+func (server *Server) pendingTransferRequest(ctx *gin.Context) (db.TransferRequest, db.Account, bool) {
+	var uri getTransferRequest
+
+	//Bind URI params
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.TransferRequest{}, db.Account{}, false
+	}
+
+	request, err := server.store.GetTransferRequest(ctx, uri.ID)
+	if err != nil {
+		respondError(ctx, err)
+		return request, db.Account{}, false
+	}
+
+	//Only the named recipient may answer. A closed destination reads as missing.
+	toAccount, err := server.store.GetAccount(ctx, request.ToAccountID)
+	if err != nil {
+		respondError(ctx, err)
+		return request, toAccount, false
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if toAccount.Owner != authPayload.Username {
+		err := withCode(codeUnauthorized, errors.New("only the recipient can answer the transfer request"))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return request, toAccount, false
+	}
+
+	if err := request.CheckPending(time.Now()); err != nil {
+		respondError(ctx, err)
+		return request, toAccount, false
+	}
+	return request, toAccount, true
+}
+
+// acceptTransferRequest executes a pending transfer request. Accepting moves
+// money like a transfer, so the same policies apply to it.
+func (server *Server) acceptTransferRequest(ctx *gin.Context) {
+	request, toAccount, ok := server.pendingTransferRequest(ctx)
+	if !ok {
+		return
+	}
+
+	if !server.withinMaxTransferAmount(ctx, request.Amount) {
+		return
+	}
+	fromAccount, valid := server.existingAccount(ctx, request.FromAccountID)
+	if !valid || !activeAccount(ctx, toAccount) {
+		return
+	}
+	if !server.verifiedOwners(ctx, fromAccount.Owner, toAccount.Owner) {
+		return
+	}
+
+	//Execute the transfer, the store re-checks the request under lock
+	arg := db.AcceptTransferRequestTxParams{ID: request.ID}
+	arg.DailyLimit, arg.DailyLimitSince = server.dailyTransferLimit()
+
+	result, err := server.store.AcceptTransferRequestTx(ctx, arg)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	//Deliver any balance alerts fired by the transfer
+	server.notifyBalanceAlerts(ctx, result.Alerts)
+
+	ctx.JSON(http.StatusOK, acceptTransferRequestResponse{
+		TransferRequest: newTransferRequestResponse(result.TransferRequest),
+		Transfer:        newTransferTxResponse(result.TransferTxResult),
+	})
+}
+
+// declineTransferRequest refuses a pending transfer request
+func (server *Server) declineTransferRequest(ctx *gin.Context) {
+	request, _, ok := server.pendingTransferRequest(ctx)
+	if !ok {
+		return
+	}
+
+	//The update only matches while the request is still pending
+	declined, err := server.store.DeclineTransferRequest(ctx, request.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err = db.ErrTransferRequestNotPending
+		}
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferRequestResponse(declined))
+}
@@ -0,0 +1,417 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/notify"
+	mocknotify "github.com/codercollo/simple_bank/notify/mock"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestCreateTransferRequestAPI tests POST /transfer_requests
+func TestCreateTransferRequestAPI(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+	account1 := randomAccount(sender.Username)
+	account2 := randomAccount(recipient.Username)
+	account2.ID = account1.ID + 1
+	account2.Currency = account1.Currency
+	ownAccount := randomAccount(sender.Username)
+	ownAccount.ID = account1.ID + 2
+	ownAccount.Currency = account1.Currency
+
+	testCases := []struct {
+		name          string
+		toAccount     db.Account
+		buildStubs    func(store *mock.MockStore, notifier *mocknotify.MockNotifier)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			toAccount: account2,
+			buildStubs: func(store *mock.MockStore, notifier *mocknotify.MockNotifier) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					CreateTransferRequest(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateTransferRequestParams) (db.TransferRequest, error) {
+						require.Equal(t, account1.ID, arg.FromAccountID)
+						require.Equal(t, account2.ID, arg.ToAccountID)
+						require.Equal(t, int64(10), arg.Amount)
+						require.WithinDuration(t, time.Now().Add(defaultTransferRequestTTL), arg.ExpiresAt, time.Second)
+						return db.TransferRequest{
+							ID:            1,
+							FromAccountID: arg.FromAccountID,
+							ToAccountID:   arg.ToAccountID,
+							Amount:        arg.Amount,
+							Status:        db.TransferRequestPending,
+							ExpiresAt:     arg.ExpiresAt,
+						}, nil
+					})
+
+				//The recipient is asked to answer
+				notifier.EXPECT().
+					Notify(gomock.Any(), gomock.AssignableToTypeOf(notify.Notification{})).
+					Times(1).
+					DoAndReturn(func(_ any, notification notify.Notification) error {
+						require.Equal(t, recipient.Username, notification.Username)
+						return nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferRequestResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.TransferRequestPending, rsp.Status)
+				require.Nil(t, rsp.TransferID)
+			},
+		},
+		{
+			name:      "OwnAccount",
+			toAccount: ownAccount,
+			buildStubs: func(store *mock.MockStore, notifier *mocknotify.MockNotifier) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(ownAccount.ID)).Times(1).Return(ownAccount, nil)
+				store.EXPECT().CreateTransferRequest(gomock.Any(), gomock.Any()).Times(0)
+				notifier.EXPECT().Notify(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			notifier := mocknotify.NewMockNotifier(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			tc.buildStubs(store, notifier)
+
+			server := newTestServer(t, store)
+			server.notifier = notifier
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   tc.toAccount.ID,
+				"amount":          10,
+				"currency":        account1.Currency,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfer_requests", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, sender.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestAnswerTransferRequestAPI tests accepting and declining transfer requests
+func TestAnswerTransferRequestAPI(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+	account1 := randomAccount(sender.Username)
+	account2 := randomAccount(recipient.Username)
+	account2.ID = account1.ID + 1
+	account2.Currency = account1.Currency
+
+	pending := db.TransferRequest{
+		ID:            3,
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Status:        db.TransferRequestPending,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	}
+	expired := pending
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	accepted := pending
+	accepted.Status = db.TransferRequestAccepted
+	frozenAccount1 := account1
+	frozenAccount1.Status = db.AccountFrozen
+	frozenAccount2 := account2
+	frozenAccount2.Status = db.AccountFrozen
+
+	testCases := []struct {
+		name          string
+		action        string
+		username      string
+		request       db.TransferRequest
+		toAccount     *db.Account
+		toAccountErr  error
+		config        func(config *util.Config)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "AcceptExecutesTransfer",
+			action:   "accept",
+			username: recipient.Username,
+			request:  pending,
+			buildStubs: func(store *mock.MockStore) {
+				done := accepted
+				done.TransferID.Int64, done.TransferID.Valid = 9, true
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().
+					AcceptTransferRequestTx(gomock.Any(), gomock.Eq(db.AcceptTransferRequestTxParams{ID: pending.ID})).
+					Times(1).
+					Return(db.AcceptTransferRequestTxResult{
+						TransferTxResult: db.TransferTxResult{
+							Transfer: db.Transfer{ID: 9, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10},
+						},
+						TransferRequest: done,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp acceptTransferRequestResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.TransferRequestAccepted, rsp.TransferRequest.Status)
+				require.Equal(t, int64(9), *rsp.TransferRequest.TransferID)
+				require.Equal(t, int64(9), rsp.Transfer.Transfer.ID)
+			},
+		},
+		{
+			name:     "DeclineLeavesBalances",
+			action:   "decline",
+			username: recipient.Username,
+			request:  pending,
+			buildStubs: func(store *mock.MockStore) {
+				declined := pending
+				declined.Status = db.TransferRequestDeclined
+				store.EXPECT().DeclineTransferRequest(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(declined, nil)
+				store.EXPECT().AcceptTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferRequestResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, db.TransferRequestDeclined, rsp.Status)
+			},
+		},
+		{
+			name:     "AcceptExpired",
+			action:   "accept",
+			username: recipient.Username,
+			request:  expired,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().AcceptTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusGone, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeRequestExpired)
+			},
+		},
+		{
+			name:     "DeclineExpired",
+			action:   "decline",
+			username: recipient.Username,
+			request:  expired,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().DeclineTransferRequest(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusGone, recorder.Code)
+			},
+		},
+		{
+			name:     "AcceptAlreadyAnswered",
+			action:   "accept",
+			username: recipient.Username,
+			request:  accepted,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().AcceptTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeRequestAnswered)
+			},
+		},
+		{
+			name:     "AcceptRaceExpired",
+			action:   "accept",
+			username: recipient.Username,
+			request:  pending,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().
+					AcceptTransferRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AcceptTransferRequestTxResult{}, db.ErrTransferRequestExpired)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusGone, recorder.Code)
+			},
+		},
+		{
+			name:     "AcceptPassesDailyLimit",
+			action:   "accept",
+			username: recipient.Username,
+			request:  pending,
+			config: func(config *util.Config) {
+				config.DailyTransferLimit = 50
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().
+					AcceptTransferRequestTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.AcceptTransferRequestTxParams) (db.AcceptTransferRequestTxResult, error) {
+						require.Equal(t, pending.ID, arg.ID)
+						require.Equal(t, int64(50), arg.DailyLimit)
+						require.WithinDuration(t, time.Now().Add(-24*time.Hour), arg.DailyLimitSince, time.Second)
+						return db.AcceptTransferRequestTxResult{}, fmt.Errorf("account [%d]: %w", arg.ID, db.ErrDailyTransferLimit)
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeDailyLimit)
+			},
+		},
+		{
+			name:     "AcceptExceedsMaxAmount",
+			action:   "accept",
+			username: recipient.Username,
+			request:  pending,
+			config: func(config *util.Config) {
+				config.MaxTransferAmount = 5
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().AcceptTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeTransferLimit)
+			},
+		},
+		{
+			name:     "AcceptFromFrozenAccount",
+			action:   "accept",
+			username: recipient.Username,
+			request:  pending,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(frozenAccount1, nil)
+				store.EXPECT().AcceptTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeAccountFrozen)
+			},
+		},
+		{
+			name:      "AcceptToFrozenAccount",
+			action:    "accept",
+			username:  recipient.Username,
+			request:   pending,
+			toAccount: &frozenAccount2,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().AcceptTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeAccountFrozen)
+			},
+		},
+		{
+			name:     "AcceptUnverifiedSender",
+			action:   "accept",
+			username: recipient.Username,
+			request:  pending,
+			config: func(config *util.Config) {
+				config.RequireVerifiedEmailForTransfers = true
+			},
+			buildStubs: func(store *mock.MockStore) {
+				unverified := sender
+				unverified.IsEmailVerified = false
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(sender.Username)).Times(1).Return(unverified, nil)
+				store.EXPECT().AcceptTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeEmailNotVerified)
+			},
+		},
+		{
+			name:         "AcceptClosedDestination",
+			action:       "accept",
+			username:     recipient.Username,
+			request:      pending,
+			toAccountErr: db.ErrRecordNotFound,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().AcceptTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "SenderCannotAccept",
+			action:   "accept",
+			username: sender.Username,
+			request:  pending,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().AcceptTransferRequestTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			store.EXPECT().GetTransferRequest(gomock.Any(), gomock.Eq(tc.request.ID)).Times(1).Return(tc.request, nil)
+			toAccount := account2
+			if tc.toAccount != nil {
+				toAccount = *tc.toAccount
+			}
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(toAccount, tc.toAccountErr)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			if tc.config != nil {
+				tc.config(&server.config)
+			}
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfer_requests/%d/%s", tc.request.ID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
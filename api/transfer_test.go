@@ -1 +1,770 @@
 package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
+	"github.com/codercollo/simple_bank/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestCreateTransferAPI tests POST /transfers endpoint
+func TestCreateTransferAPI(t *testing.T) {
+	amount := int64(10)
+
+	//Create test users and accounts in the same currency
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.ID = 1
+	account2.ID = 2
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	//Define test cases
+	testCases := []struct {
+		name          string
+		body          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD"}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+				}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.TransferTxResult{
+					FromBalanceBefore: 100,
+					FromBalanceAfter:  90,
+					ToBalanceBefore:   50,
+					ToBalanceAfter:    60,
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp db.TransferTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, int64(100), rsp.FromBalanceBefore)
+				require.Equal(t, int64(90), rsp.FromBalanceAfter)
+				require.Equal(t, int64(50), rsp.ToBalanceBefore)
+				require.Equal(t, int64(60), rsp.ToBalanceAfter)
+			},
+		},
+		{
+			name: "StringAmount",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":"10","currency":"USD"}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        amount,
+				}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NonNumericStringAmount",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":"ten","currency":"USD"}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NegativeAmount",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":-10,"currency":"USD"}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "UnauthorizedUser",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD"}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user2.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "FromAccountNotFound",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD"}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"EUR"}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "MinSourceBalancePreconditionFailed",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD","min_source_balance_after":500}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				minBalance := int64(500)
+				arg := db.TransferTxParams{
+					FromAccountID:         account1.ID,
+					ToAccountID:           account2.ID,
+					Amount:                amount,
+					MinSourceBalanceAfter: &minBalance,
+				}
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.TransferTxResult{}, db.ErrMinBalancePrecondition)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD"}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeInsufficientFunds)
+				require.Contains(t, recorder.Body.String(), "cannot cover the transfer")
+			},
+		},
+		{
+			name: "TransferTxError",
+			body: `{"from_account_id":1,"to_account_id":2,"amount":10,"currency":"USD"}`,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, sql.ErrTxDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	//Run all test cases
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestAmountUnmarshalJSON verifies numeric and string amounts decode alike
+func TestAmountUnmarshalJSON(t *testing.T) {
+	var amount Amount
+
+	require.NoError(t, json.Unmarshal([]byte(`1000`), &amount))
+	require.Equal(t, Amount(1000), amount)
+
+	require.NoError(t, json.Unmarshal([]byte(`"9007199254740993"`), &amount))
+	require.Equal(t, Amount(9007199254740993), amount)
+
+	require.Error(t, json.Unmarshal([]byte(`"1000abc"`), &amount))
+	require.Error(t, json.Unmarshal([]byte(`10.5`), &amount))
+}
+
+// TestCreateTransferReportsAllFieldErrors verifies every invalid field is reported at once
+func TestCreateTransferReportsAllFieldErrors(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	body := `{"to_account_id":2,"amount":-10,"currency":"XYZ"}`
+	request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	var rsp struct {
+		Code   string       `json:"code"`
+		Fields []fieldError `json:"fields"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Equal(t, codeValidationError, rsp.Code)
+
+	tags := map[string]string{}
+	for _, field := range rsp.Fields {
+		require.NotEmpty(t, field.Message)
+		tags[field.Field] = field.Tag
+	}
+	require.Equal(t, map[string]string{
+		"from_account_id": "required",
+		"amount":          "gt",
+		"currency":        "known_currency",
+	}, tags)
+}
+
+// TestListTransfersAPI tests GET /transfers pagination, owner scoping and expansion
+func TestListTransfersAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	transfers := []db.ListOwnerTransfersRow{
+		{ID: 2, FromAccountID: 1, ToAccountID: 2, Amount: 10, FromOwner: user1.Username, ToOwner: user2.Username},
+		{ID: 1, FromAccountID: 2, ToAccountID: 1, Amount: 5, FromOwner: user2.Username, ToOwner: user1.Username},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "ExpandOwners",
+			query: "?page_id=1&page_size=5&expand=owners",
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.ListOwnerTransfersParams{Owner: user1.Username, Limit: 5, Offset: 0}
+				store.EXPECT().ListOwnerTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return(transfers, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp []transferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp, 2)
+				require.Equal(t, user1.Username, rsp[0].FromOwner)
+				require.Equal(t, user2.Username, rsp[0].ToOwner)
+			},
+		},
+		{
+			name:  "NoExpand",
+			query: "?page_id=2&page_size=5",
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.ListOwnerTransfersParams{Owner: user1.Username, Limit: 5, Offset: 5}
+				store.EXPECT().ListOwnerTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return(transfers, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "from_owner")
+				require.NotContains(t, recorder.Body.String(), "to_owner")
+			},
+		},
+		{
+			name:  "EmptyPage",
+			query: "?page_id=9&page_size=10",
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.ListOwnerTransfersParams{Owner: user1.Username, Limit: 10, Offset: 80}
+				store.EXPECT().ListOwnerTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, "[]", recorder.Body.String())
+			},
+		},
+		{
+			name:  "InvalidPageID",
+			query: "?page_id=0&page_size=5",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListOwnerTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "PageSizeTooSmall",
+			query: "?page_id=1&page_size=4",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListOwnerTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "PageSizeTooLarge",
+			query: "?page_id=1&page_size=11",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListOwnerTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: "?page_id=1&page_size=5",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListOwnerTransfers(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidExpand",
+			query: "?page_id=1&page_size=5&expand=accounts",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().ListOwnerTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/transfers"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestGetTransferAPI tests GET /transfers/:id authorization and owner expansion
+func TestGetTransferAPI(t *testing.T) {
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	transfer := db.GetTransferWithOwnersRow{
+		ID:            7,
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        10,
+		CreatedAt:     time.Now().Add(-time.Hour).Truncate(time.Second),
+		UpdatedAt:     time.Now().Truncate(time.Second),
+		FromOwner:     user1.Username,
+		ToOwner:       user2.Username,
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		username      string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "SourceOwnerExpanded",
+			query:    "?expand=owners",
+			username: user1.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp transferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, transfer.ID, rsp.ID)
+				require.WithinDuration(t, transfer.CreatedAt, rsp.CreatedAt, 0)
+				require.WithinDuration(t, transfer.UpdatedAt, rsp.UpdatedAt, 0)
+				require.Equal(t, user1.Username, rsp.FromOwner)
+				require.Equal(t, user2.Username, rsp.ToOwner)
+			},
+		},
+		{
+			name:     "DestinationOwner",
+			username: user2.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "from_owner")
+			},
+		},
+		{
+			name:     "ThirdParty",
+			query:    "?expand=owners",
+			username: "third_party",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), user1.Username)
+
+				//Indistinguishable from a transfer that does not exist
+				requireErrorMessage(t, recorder, codeNotFound, sql.ErrNoRows.Error())
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user1.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(db.GetTransferWithOwnersRow{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireErrorMessage(t, recorder, codeNotFound, sql.ErrNoRows.Error())
+			},
+		},
+		{
+			name:     "InternalError",
+			username: user1.Username,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetTransferWithOwners(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(db.GetTransferWithOwnersRow{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d%s", transfer.ID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestCreateTransferVerifiedEmailPolicy tests blocking transfers of unverified owners
+func TestCreateTransferVerifiedEmailPolicy(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+	account1 := randomAccount(sender.Username)
+	account2 := randomAccount(recipient.Username)
+	account2.ID = account1.ID + 1
+	account2.Currency = account1.Currency
+
+	testCases := []struct {
+		name              string
+		requireVerified   bool
+		senderVerified    bool
+		recipientVerified bool
+		buildStubs        func(store *mock.MockStore, sender, recipient db.User)
+		checkResponse     func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:              "UnverifiedSender",
+			requireVerified:   true,
+			recipientVerified: true,
+			buildStubs: func(store *mock.MockStore, sender, recipient db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(sender.Username)).Times(1).Return(sender, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeEmailNotVerified)
+				require.Contains(t, recorder.Body.String(), "sender")
+			},
+		},
+		{
+			name:            "UnverifiedRecipient",
+			requireVerified: true,
+			senderVerified:  true,
+			buildStubs: func(store *mock.MockStore, sender, recipient db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(sender.Username)).Times(1).Return(sender, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(recipient.Username)).Times(1).Return(recipient, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeEmailNotVerified)
+				require.Contains(t, recorder.Body.String(), "recipient")
+				require.NotContains(t, recorder.Body.String(), recipient.Username)
+			},
+		},
+		{
+			name:              "BothVerified",
+			requireVerified:   true,
+			senderVerified:    true,
+			recipientVerified: true,
+			buildStubs: func(store *mock.MockStore, sender, recipient db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(sender.Username)).Times(1).Return(sender, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(recipient.Username)).Times(1).Return(recipient, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "PolicyDisabled",
+			buildStubs: func(store *mock.MockStore, sender, recipient db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sender.IsEmailVerified = tc.senderVerified
+			recipient.IsEmailVerified = tc.recipientVerified
+
+			store := mock.NewMockStore(ctrl)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			tc.buildStubs(store, sender, recipient)
+
+			server := newTestServer(t, store)
+			server.config.RequireVerifiedEmailForTransfers = tc.requireVerified
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        account1.Currency,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, sender.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestCreateTransferLimits tests the maximum single transfer and the rolling daily limit
+func TestCreateTransferLimits(t *testing.T) {
+	sender, _ := randomUser(t)
+	recipient, _ := randomUser(t)
+	account1 := randomAccount(sender.Username)
+	account2 := randomAccount(recipient.Username)
+	account2.ID = account1.ID + 1
+	account2.Currency = account1.Currency
+
+	//newRequest builds an authorized transfer of amount from account1 to account2
+	newRequest := func(t *testing.T, server *Server, amount int64) *http.Request {
+		data, err := json.Marshal(gin.H{
+			"from_account_id": account1.ID,
+			"to_account_id":   account2.ID,
+			"amount":          amount,
+			"currency":        account1.Currency,
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, sender.Username, util.DepositorRole, time.Minute)
+		return request
+	}
+
+	t.Run("OverMaxAmount", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mock.NewMockStore(ctrl)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+		store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+
+		server := newTestServer(t, store)
+		server.config.MaxTransferAmount = 100
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, newRequest(t, server, 101))
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+		require.Contains(t, recorder.Body.String(), codeTransferLimit)
+	})
+
+	t.Run("CrossesDailyLimit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		//The store keeps a running total of what account1 sent and enforces
+		//the limit passed by the handler
+		var sent int64
+		store := mock.NewMockStore(ctrl)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).AnyTimes().Return(account1, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).AnyTimes().Return(account2, nil)
+		store.EXPECT().
+			TransferTx(gomock.Any(), gomock.Any()).
+			Times(3).
+			DoAndReturn(func(_ any, arg db.TransferTxParams) (db.TransferTxResult, error) {
+				require.Equal(t, int64(150), arg.DailyLimit)
+				require.WithinDuration(t, time.Now().Add(-24*time.Hour), arg.DailyLimitSince, time.Second)
+				if sent+arg.Amount > arg.DailyLimit {
+					return db.TransferTxResult{}, fmt.Errorf("account [%d]: %w", arg.FromAccountID, db.ErrDailyTransferLimit)
+				}
+				sent += arg.Amount
+				return db.TransferTxResult{}, nil
+			})
+
+		server := newTestServer(t, store)
+		server.config.MaxTransferAmount = 100
+		server.config.DailyTransferLimit = 150
+
+		//60 then 90 reach the limit exactly, one more unit crosses it
+		for _, step := range []struct {
+			amount int64
+			code   int
+		}{
+			{amount: 60, code: http.StatusOK},
+			{amount: 90, code: http.StatusOK},
+			{amount: 1, code: http.StatusForbidden},
+		} {
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, newRequest(t, server, step.amount))
+			require.Equal(t, step.code, recorder.Code, "amount %d", step.amount)
+			if step.code == http.StatusForbidden {
+				require.Contains(t, recorder.Body.String(), codeDailyLimit)
+			}
+		}
+		require.Equal(t, int64(150), sent)
+	})
+
+	t.Run("NoDailyLimit", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		store := mock.NewMockStore(ctrl)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+		store.EXPECT().
+			TransferTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ any, arg db.TransferTxParams) (db.TransferTxResult, error) {
+				require.Zero(t, arg.DailyLimit)
+				require.True(t, arg.DailyLimitSince.IsZero())
+				return db.TransferTxResult{}, nil
+			})
+
+		server := newTestServer(t, store)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, newRequest(t, server, 10))
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
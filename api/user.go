@@ -2,14 +2,16 @@ package api
 
 import (
 	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"time"
 
 	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/token"
 	"github.com/codercollo/simple_bank/util"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/lib/pq"
 )
 
 // Request payload body for creating a user (registration)
@@ -18,6 +20,8 @@ type createUserRequest struct {
 	Password string `json:"password" binding:"required,min=6"`
 	Fullname string `json:"full_name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
+
+	DefaultCurrency string `json:"default_currency" binding:"omitempty,currency"`
 }
 
 // Response payload body after user creation
@@ -26,16 +30,28 @@ type userResponse struct {
 	HashedPassword    string    `json:"hashed_password"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	DefaultCurrency   string    `json:"default_currency,omitempty"`
+	Role              string    `json:"role"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
 
+// Response payload body after registration, with the initial account when one was opened
+type createUserResponse struct {
+	userResponse
+	Account *db.Account `json:"account,omitempty"`
+}
+
 // Convert DB user model to API response
 func newUserResponse(user db.User) userResponse {
 	return userResponse{
 		Username:          user.Username,
 		FullName:          user.FullName,
 		Email:             user.Email,
+		DefaultCurrency:   user.DefaultCurrency.String,
+		Role:              user.Role,
+		IsEmailVerified:   user.IsEmailVerified,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 	}
@@ -50,9 +66,10 @@ func (server *Server) createUser(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	req.DefaultCurrency = util.NormalizeCurrency(req.DefaultCurrency)
 
 	//Hash the plain-text password
-	hashedPassword, err := util.HashPassword(req.Password)
+	hashedPassword, pepperVersion, err := server.passwords.Hash(req.Password)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
@@ -64,30 +81,54 @@ func (server *Server) createUser(ctx *gin.Context) {
 		HashedPassword: hashedPassword,
 		FullName:       req.Fullname,
 		Email:          req.Email,
+
+		PasswordPepperVersion: pepperVersion,
+		DefaultCurrency:       sql.NullString{String: req.DefaultCurrency, Valid: req.DefaultCurrency != ""},
 	}
 
-	//Insert user into database
-	user, err := server.store.CreateUser(ctx, arg)
+	//Insert user into database, opening the initial account in the same transaction
+	var rsp createUserResponse
+	var user db.User
+	if server.config.AutoCreateDefaultAccount && arg.DefaultCurrency.Valid {
+		var result db.CreateUserTxResult
+		result, err = server.store.CreateUserTx(ctx, arg)
+		user, rsp.Account = result.User, &result.Account
+	} else {
+		user, err = server.store.CreateUser(ctx, arg)
+	}
 	if err != nil {
-		//Handle duplicate username/email
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
+		//Tell the client whether the username or the email is taken
+		if errors.Is(err, db.ErrUniqueViolation) || db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(duplicateUserError(err)))
+			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	server.metrics.newUsers.Inc()
+
+	//Ask the new user to confirm their email
+	server.sendVerifyEmail(ctx, user)
+
 	//Prepare response
-	rsp := newUserResponse(user)
+	rsp.userResponse = newUserResponse(user)
 
 	//Respond with success and created user
 	ctx.JSON(http.StatusOK, rsp)
 }
 
+// duplicateUserError names the field behind a unique violation on a new user
+func duplicateUserError(err error) error {
+	switch db.ConstraintName(err) {
+	case db.UsersEmailConstraint, db.UsersEmailIndexConstraint:
+		return withCode(codeAlreadyExists, errors.New("email already in use"))
+	case db.UsersUsernameConstraint:
+		return withCode(codeAlreadyExists, errors.New("username already taken"))
+	}
+	return withCode(codeAlreadyExists, errors.New("username or email already in use"))
+}
+
 // Request payload for login
 type loginUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
@@ -117,26 +158,27 @@ func (server *Server) loginUser(ctx *gin.Context) {
 	//Fetch user from the database
 	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
-
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, err)
 		return
 	}
 
 	//Verify password
-	err = util.CheckPassword(req.Password, user.HashedPassword)
+	err = server.passwords.Check(req.Password, user.HashedPassword, user.PasswordPepperVersion)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		ctx.JSON(http.StatusUnauthorized, errorResponse(withCode(codeInvalidCredentials, err)))
 		return
 	}
 
+	//Move the hash to the current pepper while the plain password is at hand
+	if server.passwords.NeedsRehash(user.PasswordPepperVersion) {
+		server.rehashPassword(ctx, user.Username, req.Password)
+	}
+
 	//Generate access token
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
-		server.config.AccessTokenDuration,
+		user.Role,
+		server.accessTokenDuration(),
 	)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -145,7 +187,8 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
-		server.config.RefreshTokenDuration,
+		user.Role,
+		server.refreshTokenDuration(),
 	)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -153,19 +196,33 @@ func (server *Server) loginUser(ctx *gin.Context) {
 	}
 
 	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
-		ID:           refreshPayload.ID,
-		Username:     user.Username,
-		RefreshToken: refreshToken,
-		UserAgent:    "",
-		ClientIp:     "",
-		IsBlocked:    false,
-		ExpiresAt:    refreshPayload.ExpiredAt,
+		ID:            refreshPayload.ID,
+		Username:      user.Username,
+		RefreshToken:  refreshToken,
+		UserAgent:     sanitizeSessionField(ctx.Request.UserAgent(), maxSessionUserAgentLength),
+		ClientIp:      sanitizeSessionField(ctx.ClientIP(), maxSessionClientIPLength),
+		IsBlocked:     false,
+		ExpiresAt:     refreshPayload.ExpiredAt,
+		AccessTokenID: uuid.NullUUID{UUID: accessPayload.ID, Valid: true},
 	})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	//Hand browsers the access token as an httpOnly cookie
+	if server.config.AuthCookieName != "" {
+		http.SetCookie(ctx.Writer, &http.Cookie{
+			Name:     server.config.AuthCookieName,
+			Value:    accessToken,
+			Path:     "/",
+			Expires:  accessPayload.ExpiredAt,
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
+
 	//Prepare login response
 	rsp := loginUserResponse{
 		SessionID:             session.ID,
@@ -180,3 +237,143 @@ func (server *Server) loginUser(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, rsp)
 
 }
+
+// Verify-password attempts allowed per user before throttling
+const (
+	verifyPasswordBurst    = 5
+	verifyPasswordInterval = time.Minute
+)
+
+// Request payload for verifying the current user's password
+type verifyPasswordRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// Response payload for password verification
+type verifyPasswordResponse struct {
+	Valid bool `json:"valid"`
+}
+
+// verifyPassword checks the authenticated user's password without issuing tokens.
+// Attempts are rate-limited per user so it can't serve as a brute-force oracle.
+func (server *Server) verifyPassword(ctx *gin.Context) {
+	var req verifyPasswordRequest
+
+	//Validate request body
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	//Throttle repeated attempts for the same user
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !server.verifyPasswordLimiter.allow(authPayload.Username) {
+		err := withCode(codeRateLimited, errors.New("too many password verification attempts"))
+		ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+		return
+	}
+
+	//Fetch user from the database
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	//Report the result without distinguishing by status code
+	err = server.passwords.Check(req.Password, user.HashedPassword, user.PasswordPepperVersion)
+	ctx.JSON(http.StatusOK, verifyPasswordResponse{Valid: err == nil})
+}
+
+// rehashPassword stores the password hashed with the current pepper.
+// Login already succeeded, so failures are only logged and retried next login.
+func (server *Server) rehashPassword(ctx *gin.Context, username string, password string) {
+	hashedPassword, pepperVersion, err := server.passwords.Hash(password)
+	if err == nil {
+		_, err = server.store.UpdateUserPasswordHash(ctx, db.UpdateUserPasswordHashParams{
+			Username:              username,
+			HashedPassword:        hashedPassword,
+			PasswordPepperVersion: pepperVersion,
+		})
+	}
+	if err != nil {
+		log.Printf("cannot rehash password for user %s: %v", username, err)
+	}
+}
+
+// getCurrentUser returns the authenticated user's profile
+func (server *Server) getCurrentUser(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		//The user may have been deleted after the token was issued
+		if errors.Is(err, db.ErrRecordNotFound) {
+			err := withCode(codeNotFound, errors.New("user not found"))
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// Request payload for updating the authenticated user's profile, omitted fields are kept
+type updateCurrentUserRequest struct {
+	FullName *string `json:"full_name" binding:"omitempty,min=1"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+}
+
+// updateCurrentUser updates the provided profile fields of the authenticated user
+func (server *Server) updateCurrentUser(ctx *gin.Context) {
+	var req updateCurrentUserRequest
+
+	//An empty body changes nothing
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	//No fields given, return the profile as it is
+	if req.FullName == nil && req.Email == nil {
+		server.getCurrentUser(ctx)
+		return
+	}
+
+	arg := db.UpdateUserParams{Username: authPayload.Username}
+	if req.FullName != nil {
+		arg.FullName = sql.NullString{String: *req.FullName, Valid: true}
+	}
+	if req.Email != nil {
+		//A new email has to be verified again
+		arg.Email = sql.NullString{String: *req.Email, Valid: true}
+		arg.ResetEmailVerified = true
+	}
+
+	user, err := server.store.UpdateUser(ctx, arg)
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrRecordNotFound):
+			err := withCode(codeNotFound, errors.New("user not found"))
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+		case errors.Is(err, db.ErrUniqueViolation):
+			err := withCode(codeAlreadyExists, errors.New("email is already in use"))
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	if req.Email != nil {
+		server.sendVerifyEmail(ctx, user)
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
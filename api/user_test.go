@@ -9,7 +9,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/codercollo/simple_bank/db/mock"
 	db "github.com/codercollo/simple_bank/db/sqlc"
@@ -90,6 +92,16 @@ func TestCreatedUserAPI(t *testing.T) {
 					CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).
 					Times(1).
 					Return(user, nil)
+				store.EXPECT().
+					CreateVerifyEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateVerifyEmailParams) (db.VerifyEmail, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, user.Email, arg.Email)
+						require.NotEmpty(t, arg.SecretCode)
+						require.WithinDuration(t, time.Now().Add(defaultVerifyEmailTTL), arg.ExpiresAt, time.Minute)
+						return db.VerifyEmail{ID: 1, Username: arg.Username, Email: arg.Email, SecretCode: arg.SecretCode, ExpiresAt: arg.ExpiresAt}, nil
+					})
 			},
 			//Verify HTTP 200 and response body
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -125,16 +137,74 @@ func TestCreatedUserAPI(t *testing.T) {
 				"full_name": user.FullName,
 				"email":     user.Email,
 			},
-			//Simulate unique constraint violation
+			//Simulate a primary key violation on the username
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, &pq.Error{Code: "23505", Constraint: db.UsersUsernameConstraint})
+			},
+			//Expect HTTP 409 naming the username
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorMessage(t, recorder, codeAlreadyExists, "username already taken")
+			},
+		},
+		{
+			name: "DuplicateEmail",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, &pq.Error{Code: "23505", Constraint: db.UsersEmailConstraint})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorMessage(t, recorder, codeAlreadyExists, "email already in use")
+			},
+		},
+		{
+			name: "DuplicateEmailIndex",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
 			buildStubs: func(store *mock.MockStore) {
 				store.EXPECT().
 					CreateUser(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, &pq.Error{Code: "23505"})
+					Return(db.User{}, &pq.Error{Code: "23505", Constraint: db.UsersEmailIndexConstraint})
 			},
-			//Expect HTTP 403 Forbidden
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorMessage(t, recorder, codeAlreadyExists, "email already in use")
+			},
+		},
+		{
+			name: "DuplicateUnknownConstraint",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireErrorMessage(t, recorder, codeAlreadyExists, "username or email already in use")
 			},
 		},
 		{
@@ -231,6 +301,156 @@ func TestCreatedUserAPI(t *testing.T) {
 	}
 }
 
+// TestCreateUserDefaultAccount tests opening an initial account at registration
+func TestCreateUserDefaultAccount(t *testing.T) {
+	user, password := randomUser(t)
+	currency := util.USD
+
+	testCases := []struct {
+		name          string
+		autoCreate    bool
+		currency      string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "CreatesUserAndAccount",
+			autoCreate: true,
+			currency:   currency,
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.CreateUserParams{
+					Username:        user.Username,
+					FullName:        user.FullName,
+					Email:           user.Email,
+					DefaultCurrency: sql.NullString{String: currency, Valid: true},
+				}
+				created := user
+				created.DefaultCurrency = arg.DefaultCurrency
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), EqCreateUserParams(arg, password)).
+					Times(1).
+					Return(db.CreateUserTxResult{
+						User:    created,
+						Account: db.Account{ID: 1, Owner: user.Username, Currency: currency},
+					}, nil)
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp createUserResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, user.Username, rsp.Username)
+				require.Equal(t, currency, rsp.DefaultCurrency)
+				require.NotNil(t, rsp.Account)
+				require.Equal(t, user.Username, rsp.Account.Owner)
+				require.Equal(t, currency, rsp.Account.Currency)
+				require.Zero(t, rsp.Account.Balance)
+			},
+		},
+		{
+			name:       "AccountCreationFails",
+			autoCreate: true,
+			currency:   currency,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreateUserTxResult{}, sql.ErrConnDone)
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:       "UnsupportedCurrency",
+			autoCreate: true,
+			currency:   "XYZ",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "FeatureOffCreatesOnlyUser",
+			currency: currency,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateUserParams) (db.User, error) {
+						require.Equal(t, currency, arg.DefaultCurrency.String)
+						created := user
+						created.DefaultCurrency = arg.DefaultCurrency
+						return created, nil
+					})
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp createUserResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, currency, rsp.DefaultCurrency)
+				require.Nil(t, rsp.Account)
+			},
+		},
+		{
+			name:       "NoDefaultCurrency",
+			autoCreate: true,
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), `"account"`)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.AutoCreateDefaultAccount = tc.autoCreate
+			recorder := httptest.NewRecorder()
+
+			body := gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			}
+			if tc.currency != "" {
+				body["default_currency"] = tc.currency
+			}
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
 // randomUser generates a valid random user and plaintext password for testing
 func randomUser(t *testing.T) (user db.User, password string) {
 	//Generate random plaintext password
@@ -246,6 +466,7 @@ func randomUser(t *testing.T) (user db.User, password string) {
 		HashedPassword: hashedPassword,
 		FullName:       util.RandomOwner(),
 		Email:          util.RandomEmail(),
+		Role:           util.DepositorRole,
 	}
 	return
 }
@@ -270,3 +491,765 @@ func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	require.Empty(t, gotUser.HashedPassword)
 
 }
+
+// TestVerifyPasswordAPI tests the POST /users/verify_password endpoint
+func TestVerifyPasswordAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	//Define test cases
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "CorrectPassword",
+			body: gin.H{"password": password},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"valid":true}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "WrongPassword",
+			body: gin.H{"password": "wrong-password"},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"valid":false}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "MissingPassword",
+			body: gin.H{},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	//Execute each test case
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/verify_password", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+// TestVerifyPasswordRateLimited ensures repeated attempts are throttled
+func TestVerifyPasswordRateLimited(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	//Only the attempts within the burst reach the store
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Eq(user.Username)).
+		Times(verifyPasswordBurst).
+		Return(user, nil)
+
+	server := newTestServer(t, store)
+
+	for i := 0; i <= verifyPasswordBurst; i++ {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, "/users/verify_password", bytes.NewReader([]byte(`{"password":"wrong-password"}`)))
+		require.NoError(t, err)
+
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+
+		if i < verifyPasswordBurst {
+			require.Equal(t, http.StatusOK, recorder.Code)
+		} else {
+			require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		}
+	}
+}
+
+// TestLoginUserSessionClient verifies login stores the client's user agent and IP
+func TestLoginUserSessionClient(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name      string
+		userAgent string
+		stored    string
+	}{
+		{name: "CustomUserAgent", userAgent: "simple-bank-cli/1.2 (linux)", stored: "simple-bank-cli/1.2 (linux)"},
+		{name: "LongUserAgent", userAgent: strings.Repeat("a", 1000), stored: strings.Repeat("a", maxSessionUserAgentLength)},
+		{name: "ControlCharacters", userAgent: "agent\x00\x7fv2", stored: "agentv2"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			store.EXPECT().
+				CreateSession(gomock.Any(), gomock.Any()).
+				Times(1).
+				DoAndReturn(func(_ any, arg db.CreateSessionParams) (db.Session, error) {
+					require.Equal(t, tc.stored, arg.UserAgent)
+					require.Equal(t, "192.0.2.10", arg.ClientIp)
+					return db.Session{ID: arg.ID, Username: arg.Username}, nil
+				})
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("User-Agent", tc.userAgent)
+			request.RemoteAddr = "192.0.2.10:54321"
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+		})
+	}
+}
+
+// TestLoginUserAPI tests the POST /users/login endpoint
+func TestLoginUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"username": user.Username, "password": password},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					CreateSession(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateSessionParams) (db.Session, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.True(t, arg.AccessTokenID.Valid)
+						require.NotEqual(t, arg.ID, arg.AccessTokenID.UUID)
+						return db.Session{ID: arg.ID, Username: arg.Username}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp loginUserResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
+				require.NotZero(t, rsp.SessionID)
+				require.Equal(t, user.Username, rsp.User.Username)
+				require.Equal(t, user.Role, rsp.User.Role)
+
+				//The user is returned without its password hash
+				require.Empty(t, rsp.User.HashedPassword)
+			},
+		},
+		{
+			name: "WrongPassword",
+			body: gin.H{"username": user.Username, "password": "wrong-password"},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeInvalidCredentials)
+			},
+		},
+		{
+			name: "UserNotFound",
+			body: gin.H{"username": "nobody", "password": password},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{"username": user.Username, "password": password},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidUsername",
+			body: gin.H{"username": "invalid-user#1", "password": password},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// TestLoginUserSetsAuthCookie verifies login sets the access token cookie when configured
+func TestLoginUserSetsAuthCookie(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name         string
+		cookieName   string
+		checkCookies func(t *testing.T, cookies []*http.Cookie)
+	}{
+		{
+			name:       "CookieConfigured",
+			cookieName: "access_token",
+			checkCookies: func(t *testing.T, cookies []*http.Cookie) {
+				require.Len(t, cookies, 1)
+				cookie := cookies[0]
+				require.Equal(t, "access_token", cookie.Name)
+				require.NotEmpty(t, cookie.Value)
+				require.Equal(t, "/", cookie.Path)
+				require.True(t, cookie.Secure)
+				require.True(t, cookie.HttpOnly)
+				require.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+			},
+		},
+		{
+			name:       "CookieDisabled",
+			cookieName: "",
+			checkCookies: func(t *testing.T, cookies []*http.Cookie) {
+				require.Empty(t, cookies)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUser(gomock.Any(), gomock.Eq(user.Username)).
+				Times(1).
+				Return(user, nil)
+			store.EXPECT().
+				CreateSession(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(db.Session{}, nil)
+
+			server := newTestServer(t, store)
+			server.config.AuthCookieName = tc.cookieName
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			tc.checkCookies(t, recorder.Result().Cookies())
+		})
+	}
+}
+
+// TestLoginUserTokenDuration verifies login issues tokens with the configured lifetimes
+func TestLoginUserTokenDuration(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name            string
+		accessDuration  time.Duration
+		refreshDuration time.Duration
+		wantAccess      time.Duration
+		wantRefresh     time.Duration
+	}{
+		{
+			name:            "Configured",
+			accessDuration:  30 * time.Second,
+			refreshDuration: time.Hour,
+			wantAccess:      30 * time.Second,
+			wantRefresh:     time.Hour,
+		},
+		{
+			name:        "Default",
+			wantAccess:  defaultAccessTokenDuration,
+			wantRefresh: defaultRefreshTokenDuration,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			store.EXPECT().
+				GetUser(gomock.Any(), gomock.Eq(user.Username)).
+				Times(1).
+				Return(user, nil)
+			store.EXPECT().
+				CreateSession(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(db.Session{}, nil)
+
+			server := newTestServer(t, store)
+			server.config.AccessTokenDuration = tc.accessDuration
+			server.config.RefreshTokenDuration = tc.refreshDuration
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+
+			var rsp loginUserResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+
+			//The expiry is read back from the tokens themselves
+			accessPayload, err := server.tokenMaker.VerifyToken(rsp.AccessToken)
+			require.NoError(t, err)
+			require.WithinDuration(t, time.Now().Add(tc.wantAccess), accessPayload.ExpiredAt, time.Second)
+
+			refreshPayload, err := server.tokenMaker.VerifyToken(rsp.RefreshToken)
+			require.NoError(t, err)
+			require.WithinDuration(t, time.Now().Add(tc.wantRefresh), refreshPayload.ExpiredAt, time.Second)
+		})
+	}
+}
+
+// TestLoginUserRehashesPassword verifies login moves hashes to the current pepper
+func TestLoginUserRehashesPassword(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name        string
+		buildUser   func(t *testing.T, passwords *util.PasswordHasher) db.User
+		rehashCalls int
+	}{
+		{
+			name: "UnpepperedHash",
+			buildUser: func(t *testing.T, passwords *util.PasswordHasher) db.User {
+				return user
+			},
+			rehashCalls: 1,
+		},
+		{
+			name: "CurrentPepper",
+			buildUser: func(t *testing.T, passwords *util.PasswordHasher) db.User {
+				hashedPassword, version, err := passwords.Hash(password)
+				require.NoError(t, err)
+
+				peppered := user
+				peppered.HashedPassword = hashedPassword
+				peppered.PasswordPepperVersion = version
+				return peppered
+			},
+			rehashCalls: 0,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			passwords, err := util.NewPasswordHasher(util.Config{PasswordPepper: util.RandomString(32)})
+			require.NoError(t, err)
+			server.passwords = passwords
+
+			store.EXPECT().
+				GetUser(gomock.Any(), gomock.Eq(user.Username)).
+				Times(1).
+				Return(tc.buildUser(t, passwords), nil)
+			store.EXPECT().
+				UpdateUserPasswordHash(gomock.Any(), gomock.Any()).
+				Times(tc.rehashCalls).
+				DoAndReturn(func(_ any, arg db.UpdateUserPasswordHashParams) (db.User, error) {
+					require.Equal(t, user.Username, arg.Username)
+					require.Equal(t, int32(1), arg.PasswordPepperVersion)
+					require.NoError(t, passwords.Check(password, arg.HashedPassword, arg.PasswordPepperVersion))
+					return db.User{}, nil
+				})
+			store.EXPECT().
+				CreateSession(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(db.Session{}, nil)
+
+			recorder := httptest.NewRecorder()
+			data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+		})
+	}
+}
+
+// TestGetCurrentUserAPI tests GET /users/me
+func TestGetCurrentUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "DeletedUser",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeNotFound)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/users/me", nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateCurrentUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.IsEmailVerified = true
+	newFullName := util.RandomOwner()
+	newEmail := util.RandomEmail()
+
+	testCases := []struct {
+		name          string
+		body          string
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "FullNameOnly",
+			body: fmt.Sprintf(`{"full_name":%q}`, newFullName),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.UpdateUserParams{
+					Username: user.Username,
+					FullName: sql.NullString{String: newFullName, Valid: true},
+				}
+				updated := user
+				updated.FullName = newFullName
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp userResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, newFullName, rsp.FullName)
+				require.Equal(t, user.Email, rsp.Email)
+				require.True(t, rsp.IsEmailVerified)
+			},
+		},
+		{
+			name: "NewEmail",
+			body: fmt.Sprintf(`{"email":%q}`, newEmail),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				arg := db.UpdateUserParams{
+					Username:           user.Username,
+					Email:              sql.NullString{String: newEmail, Valid: true},
+					ResetEmailVerified: true,
+				}
+				updated := user
+				updated.Email = newEmail
+				updated.IsEmailVerified = false
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp userResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, newEmail, rsp.Email)
+				require.False(t, rsp.IsEmailVerified)
+			},
+		},
+		{
+			name: "EmptyBody",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "EmptyObject",
+			body: `{}`,
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "DuplicateEmail",
+			body: fmt.Sprintf(`{"email":%q}`, newEmail),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, db.ErrUniqueViolation)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeAlreadyExists)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: `{"email":"invalid-email"}`,
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeValidationError)
+			},
+		},
+		{
+			name: "EmptyFullName",
+			body: `{"full_name":""}`,
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: fmt.Sprintf(`{"full_name":%q}`, newFullName),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			body:      fmt.Sprintf(`{"full_name":%q}`, newFullName),
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {},
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPatch, "/users/me", bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// requireErrorMessage checks the error code and message of an error response
+func requireErrorMessage(t *testing.T, recorder *httptest.ResponseRecorder, code string, message string) {
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+	require.Equal(t, code, body["code"])
+	require.Equal(t, message, body["error"])
+}
@@ -1,15 +1,40 @@
 package api
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/codercollo/simple_bank/util"
 	"github.com/go-playground/validator/v10"
 )
 
-// validCurrency validates supported currency values
+// validCurrency validates currencies open for new accounts and transfers
 var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if currency, ok := fieldLevel.Field().Interface().(string); ok {
+		return util.IsEnabledCurrency(currency)
+	}
+	return false
+
+}
+
+// knownCurrency validates supported currencies, including disabled ones
+var knownCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	if currency, ok := fieldLevel.Field().Interface().(string); ok {
 		return util.IsSupportedCurrency(currency)
 	}
 	return false
+}
 
+// requestFieldName reports fields by the name clients send them under
+func requestFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "uri", "form"} {
+		name := strings.SplitN(field.Tag.Get(key), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
 }
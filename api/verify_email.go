@@ -0,0 +1,79 @@
+package api
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/notify"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultVerifyEmailTTL is how long a verification code stays valid when no TTL is configured
+const defaultVerifyEmailTTL = 24 * time.Hour
+
+// sendVerifyEmail creates a verification code for a user and sends it to them.
+// Failures are only logged, the user is created either way.
+func (server *Server) sendVerifyEmail(ctx *gin.Context, user db.User) {
+	ttl := server.config.VerifyEmailTTL
+	if ttl <= 0 {
+		ttl = defaultVerifyEmailTTL
+	}
+
+	verifyEmail, err := server.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      user.Email,
+		SecretCode: rand.Text(),
+		ExpiresAt:  time.Now().Add(ttl),
+	})
+	if err != nil {
+		log.Printf("cannot create verification code for user %s: %v", user.Username, err)
+		return
+	}
+
+	notification := notify.Notification{
+		Username: user.Username,
+		Subject:  "Verify your email",
+		Content: fmt.Sprintf("Confirm %s by opening /users/verify_email?id=%d&code=%s before %s.",
+			verifyEmail.Email, verifyEmail.ID, verifyEmail.SecretCode, verifyEmail.ExpiresAt.UTC().Format(time.RFC3339)),
+	}
+	if err := server.notifier.Notify(ctx, notification); err != nil {
+		log.Printf("cannot send verification code %d: %v", verifyEmail.ID, err)
+	}
+}
+
+// Query params for verifying an email
+type verifyEmailRequest struct {
+	ID   int64  `form:"id" binding:"required,min=1"`
+	Code string `form:"code" binding:"required"`
+}
+
+// Email verification response payload
+type verifyEmailResponse struct {
+	IsEmailVerified bool `json:"is_email_verified"`
+}
+
+// verifyEmail uses a verification code to mark its user's email verified
+func (server *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+
+	//Bind query params
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.VerifyEmailTx(ctx, db.VerifyEmailTxParams{
+		ID:         req.ID,
+		SecretCode: req.Code,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, verifyEmailResponse{IsEmailVerified: result.User.IsEmailVerified})
+}
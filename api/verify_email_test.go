@@ -0,0 +1,142 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestVerifyEmailAPI tests GET /users/verify_email
+func TestVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	code := "secret-code"
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mock.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: fmt.Sprintf("id=1&code=%s", code),
+			buildStubs: func(store *mock.MockStore) {
+				verified := user
+				verified.IsEmailVerified = true
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Eq(db.VerifyEmailTxParams{ID: 1, SecretCode: code})).
+					Times(1).
+					Return(db.VerifyEmailTxResult{User: verified, VerifyEmail: db.VerifyEmail{ID: 1, IsUsed: true}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp verifyEmailResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.True(t, rsp.IsEmailVerified)
+			},
+		},
+		{
+			name:  "UsedCode",
+			query: fmt.Sprintf("id=1&code=%s", code),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(1).Return(db.VerifyEmailTxResult{}, db.ErrVerifyEmailUsed)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeVerifyEmailUsed)
+			},
+		},
+		{
+			name:  "ExpiredCode",
+			query: fmt.Sprintf("id=1&code=%s", code),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(1).Return(db.VerifyEmailTxResult{}, db.ErrVerifyEmailExpired)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusGone, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeVerifyEmailExpired)
+			},
+		},
+		{
+			name:  "EmailChanged",
+			query: fmt.Sprintf("id=1&code=%s", code),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(1).Return(db.VerifyEmailTxResult{}, db.ErrVerifyEmailChanged)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusGone, recorder.Code)
+				require.Contains(t, recorder.Body.String(), codeVerifyEmailExpired)
+			},
+		},
+		{
+			name:  "WrongCode",
+			query: "id=1&code=wrong",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(1).Return(db.VerifyEmailTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:  "MissingCode",
+			query: "id=1",
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidID",
+			query: fmt.Sprintf("id=0&code=%s", code),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: fmt.Sprintf("id=1&code=%s", code),
+			buildStubs: func(store *mock.MockStore) {
+				store.EXPECT().VerifyEmailTx(gomock.Any(), gomock.Any()).Times(1).Return(db.VerifyEmailTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mock.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/users/verify_email?"+tc.query, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
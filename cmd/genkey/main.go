@@ -0,0 +1,32 @@
+// Command genkey prints a random key suitable for TOKEN_SYMMETRIC_KEY, or with
+// -public an Ed25519 key pair for TOKEN_PRIVATE_KEY and TOKEN_PUBLIC_KEY
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/codercollo/simple_bank/token"
+)
+
+func main() {
+	public := flag.Bool("public", false, "generate a key pair for PASETO public tokens")
+	flag.Parse()
+
+	if *public {
+		privateKey, publicKey, err := token.NewAsymmetricKeyPair()
+		if err != nil {
+			log.Fatal("cannot generate key pair:", err)
+		}
+		fmt.Println("TOKEN_PRIVATE_KEY=" + privateKey)
+		fmt.Println("TOKEN_PUBLIC_KEY=" + publicKey)
+		return
+	}
+
+	key, err := token.NewSymmetricKey()
+	if err != nil {
+		log.Fatal("cannot generate key:", err)
+	}
+	fmt.Println(key)
+}
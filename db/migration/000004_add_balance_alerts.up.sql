@@ -0,0 +1,11 @@
+CREATE TABLE "balance_alerts" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint UNIQUE NOT NULL,
+  "low_threshold" bigint,
+  "high_threshold" bigint,
+  "low_triggered" boolean NOT NULL DEFAULT false,
+  "high_triggered" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "balance_alerts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
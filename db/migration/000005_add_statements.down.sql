@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "statements";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "statements_enabled";
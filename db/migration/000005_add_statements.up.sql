@@ -0,0 +1,14 @@
+ALTER TABLE "accounts" ADD COLUMN "statements_enabled" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "statements" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "period_start" timestamptz NOT NULL,
+  "period_end" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "statements" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+-- One statement per account and period
+ALTER TABLE "statements" ADD CONSTRAINT "account_period_key" UNIQUE ("account_id", "period_start");
@@ -0,0 +1,10 @@
+CREATE TABLE "account_tags" (
+  "account_id" bigint NOT NULL,
+  "tag" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "tag")
+);
+
+ALTER TABLE "account_tags" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "account_tags" ("tag");
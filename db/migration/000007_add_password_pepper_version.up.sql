@@ -0,0 +1,2 @@
+-- Version of the server-side pepper mixed into hashed_password, 0 means unpeppered
+ALTER TABLE "users" ADD COLUMN "password_pepper_version" integer NOT NULL DEFAULT 0;
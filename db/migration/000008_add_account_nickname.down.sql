@@ -0,0 +1,4 @@
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_nickname_key";
+ALTER TABLE "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "nickname";
@@ -0,0 +1,6 @@
+ALTER TABLE "accounts" ADD COLUMN "nickname" varchar NOT NULL DEFAULT '';
+
+-- Accounts are unique per owner, currency and nickname. With the default empty
+-- nickname this keeps a single account per currency.
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_key";
+ALTER TABLE "accounts" ADD CONSTRAINT "owner_currency_nickname_key" UNIQUE ("owner", "currency", "nickname");
@@ -0,0 +1,3 @@
+ALTER TABLE "transfers" DROP CONSTRAINT IF EXISTS "refunded_amount_check";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "refunded_amount";
@@ -0,0 +1,4 @@
+ALTER TABLE "transfers" ADD COLUMN "refunded_amount" bigint NOT NULL DEFAULT 0;
+
+-- Refunds can never exceed the original transfer
+ALTER TABLE "transfers" ADD CONSTRAINT "refunded_amount_check" CHECK ("refunded_amount" >= 0 AND "refunded_amount" <= "amount");
@@ -0,0 +1,12 @@
+CREATE TABLE "account_owner_changes" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "previous_owner" varchar NOT NULL,
+  "new_owner" varchar NOT NULL,
+  "changed_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "account_owner_changes" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "account_owner_changes" ("account_id");
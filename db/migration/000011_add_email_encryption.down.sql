@@ -0,0 +1,6 @@
+-- Encrypted emails must be decrypted back into plaintext before rolling back
+
+ALTER TABLE "users" DROP CONSTRAINT IF EXISTS "email_blind_index_key";
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "email_blind_index";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "email_key_version";
@@ -0,0 +1,6 @@
+-- Version 0 marks emails stored in plaintext
+ALTER TABLE "users" ADD COLUMN "email_key_version" integer NOT NULL DEFAULT 0;
+
+-- Keyed hash of the plaintext email for lookups and uniqueness of encrypted emails
+ALTER TABLE "users" ADD COLUMN "email_blind_index" varchar;
+ALTER TABLE "users" ADD CONSTRAINT "email_blind_index_key" UNIQUE ("email_blind_index");
@@ -0,0 +1,3 @@
+ALTER TABLE "balance_alerts" DROP COLUMN IF EXISTS "updated_at";
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "updated_at";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "updated_at";
@@ -0,0 +1,8 @@
+ALTER TABLE "accounts" ADD COLUMN "updated_at" timestamptz NOT NULL DEFAULT (now());
+ALTER TABLE "transfers" ADD COLUMN "updated_at" timestamptz NOT NULL DEFAULT (now());
+ALTER TABLE "balance_alerts" ADD COLUMN "updated_at" timestamptz NOT NULL DEFAULT (now());
+
+-- Existing rows were last touched when they were created
+UPDATE "accounts" SET "updated_at" = "created_at";
+UPDATE "transfers" SET "updated_at" = "created_at";
+UPDATE "balance_alerts" SET "updated_at" = "created_at";
@@ -0,0 +1 @@
+ALTER TABLE "users" ADD COLUMN "default_currency" varchar;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "transfers_memo_prefix_idx";
+DROP INDEX IF EXISTS "accounts_nickname_prefix_idx";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "memo";
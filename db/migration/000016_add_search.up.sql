@@ -0,0 +1,5 @@
+ALTER TABLE "transfers" ADD COLUMN "memo" varchar NOT NULL DEFAULT '';
+
+-- Prefix searches compare lowercased text with LIKE 'prefix%'
+CREATE INDEX "accounts_nickname_prefix_idx" ON "accounts" (lower("nickname") text_pattern_ops);
+CREATE INDEX "transfers_memo_prefix_idx" ON "transfers" (lower("memo") text_pattern_ops);
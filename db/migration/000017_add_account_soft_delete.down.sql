@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "owner_currency_nickname_key";
+ALTER TABLE "accounts" ADD CONSTRAINT "owner_currency_nickname_key" UNIQUE ("owner", "currency", "nickname");
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "deleted_at";
@@ -0,0 +1,6 @@
+ALTER TABLE "accounts" ADD COLUMN "deleted_at" timestamptz;
+
+-- Closed accounts keep their history but no longer block a new account with
+-- the same owner, currency and nickname
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_nickname_key";
+CREATE UNIQUE INDEX "owner_currency_nickname_key" ON "accounts" ("owner", "currency", "nickname") WHERE "deleted_at" IS NULL;
@@ -0,0 +1,5 @@
+-- Amount credited to the destination account, in its own currency. It equals
+-- the amount for transfers between accounts of the same currency.
+ALTER TABLE "transfers" ADD COLUMN "converted_amount" bigint;
+UPDATE "transfers" SET "converted_amount" = "amount";
+ALTER TABLE "transfers" ALTER COLUMN "converted_amount" SET NOT NULL;
@@ -0,0 +1,2 @@
+-- Every existing user keeps the default depositor privileges
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
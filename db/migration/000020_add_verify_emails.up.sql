@@ -0,0 +1,14 @@
+-- One row per verification link sent to a user. The email is stored the same
+-- way as users.email, encrypted under email_key_version when encryption is on.
+CREATE TABLE "verify_emails" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "email" varchar NOT NULL,
+  "email_key_version" integer NOT NULL DEFAULT 0,
+  "secret_code" varchar NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expires_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "verify_emails" ("username");
@@ -0,0 +1,12 @@
+-- Single-use password reset tokens. Only a SHA-256 hash of each token is
+-- stored, so a leaked table cannot be used to take over accounts.
+CREATE TABLE "password_resets" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL REFERENCES "users" ("username"),
+  "token_hash" varchar UNIQUE NOT NULL,
+  "is_used" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expires_at" timestamptz NOT NULL
+);
+
+CREATE INDEX ON "password_resets" ("username");
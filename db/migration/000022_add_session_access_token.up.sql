@@ -0,0 +1,5 @@
+-- Track the latest access token issued for each session, so a logout made
+-- with that access token can find and revoke its session.
+ALTER TABLE "sessions" ADD COLUMN "access_token_id" uuid;
+
+CREATE INDEX ON "sessions" ("access_token_id");
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "transfers_reversed_of_key";
+
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "reversed_of";
@@ -0,0 +1,5 @@
+-- A reversal is an offsetting transfer pointing at the transfer it undoes
+ALTER TABLE "transfers" ADD COLUMN "reversed_of" bigint REFERENCES "transfers" ("id");
+
+-- Each transfer can be reversed at most once
+CREATE UNIQUE INDEX "transfers_reversed_of_key" ON "transfers" ("reversed_of") WHERE "reversed_of" IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Row version, bumped by every update so writers can detect concurrent changes
+ALTER TABLE "accounts" ADD COLUMN "version" integer NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+UPDATE "users" SET "default_currency" = 'Ksh' WHERE "default_currency" = 'KES';
+UPDATE "accounts" SET "currency" = 'Ksh', "version" = "version" + 1 WHERE "currency" = 'KES';
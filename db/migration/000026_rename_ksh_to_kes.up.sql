@@ -0,0 +1,3 @@
+-- "Ksh" is not an ISO 4217 code; store the Kenyan shilling as "KES"
+UPDATE "accounts" SET "currency" = 'KES', "version" = "version" + 1 WHERE "currency" = 'Ksh';
+UPDATE "users" SET "default_currency" = 'KES' WHERE "default_currency" = 'Ksh';
@@ -0,0 +1,2 @@
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "account_status_check";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "status";
@@ -0,0 +1,5 @@
+-- Frozen accounts stay readable but cannot send or receive transfers
+ALTER TABLE "accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+ALTER TABLE "accounts" ADD CONSTRAINT "account_status_check" CHECK ("status" IN ('active', 'frozen'));
+
+COMMENT ON COLUMN "accounts"."status" IS 'active or frozen';
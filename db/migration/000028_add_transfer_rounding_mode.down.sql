@@ -0,0 +1,2 @@
+ALTER TABLE "transfers" DROP CONSTRAINT IF EXISTS "transfer_rounding_mode_check";
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "rounding_mode";
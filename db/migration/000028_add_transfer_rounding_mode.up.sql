@@ -0,0 +1,4 @@
+-- Rounding mode used to compute converted_amount, empty when the transfer
+-- needed no conversion or was made before the mode was recorded
+ALTER TABLE "transfers" ADD COLUMN "rounding_mode" varchar NOT NULL DEFAULT '';
+ALTER TABLE "transfers" ADD CONSTRAINT "transfer_rounding_mode_check" CHECK ("rounding_mode" IN ('', 'half_up', 'half_even', 'down'));
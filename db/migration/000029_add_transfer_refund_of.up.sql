@@ -0,0 +1,4 @@
+-- A refund is a transfer back to the sender pointing at the transfer it refunds
+ALTER TABLE "transfers" ADD COLUMN "refund_of" bigint REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfers" ("refund_of");
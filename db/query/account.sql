@@ -2,41 +2,123 @@
 INSERT INTO accounts (
     owner,
     balance,
-    currency
+    currency,
+    nickname
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4
 ) RETURNING *;
 
 -- name: GetAccount :one
 SELECT * FROM accounts
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
+LIMIT 1;
+
+-- name: GetAccountByOwnerAndCurrency :one
+SELECT * FROM accounts
+WHERE owner = $1 AND currency = $2 AND nickname = $3 AND deleted_at IS NULL
 LIMIT 1;
 
 -- name: GetAccountForUpdate :one
 SELECT * FROM accounts
-WHERE id = $1 LIMIT 1
+WHERE id = $1 AND deleted_at IS NULL
+LIMIT 1
 FOR NO KEY UPDATE;
 
+-- name: UpdateAccountOwner :one
+UPDATE accounts
+SET owner = $2,
+    version = version + 1,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: GetAccountsByIDs :many
+SELECT * FROM accounts
+WHERE id = ANY(sqlc.arg(ids)::bigint[])
+AND owner = sqlc.arg(owner)
+AND deleted_at IS NULL
+ORDER BY id;
 
 -- name: ListAccounts :many
 SELECT * FROM accounts
-WHERE owner = $1
+WHERE owner = $1 AND deleted_at IS NULL
 ORDER BY id
 LIMIT $2
 OFFSET $3;
 
 -- name: UpdateAccount :one
 UPDATE accounts
-SET balance = $2
+SET balance = $2,
+    version = version + 1,
+    updated_at = now()
 WHERE id = $1
 RETURNING *;
 
 -- name: AddAccountBalance :one
 UPDATE accounts
-SET balance = balance + sqlc.arg(amount)
+SET balance = balance + sqlc.arg(amount),
+    version = version + 1,
+    updated_at = now()
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
+-- name: AddAccountBalanceWithVersion :one
+UPDATE accounts
+SET balance = balance + sqlc.arg(amount),
+    version = version + 1,
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+  AND version = sqlc.arg(version)
+RETURNING *;
+
+-- name: FreezeAccount :one
+UPDATE accounts
+SET status = 'frozen',
+    version = version + 1,
+    updated_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: UnfreezeAccount :one
+UPDATE accounts
+SET status = 'active',
+    version = version + 1,
+    updated_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
 -- name: DeleteAccount :exec
 DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: CountAccounts :one
+SELECT count(*) FROM accounts
+WHERE deleted_at IS NULL AND status = 'active';
+
+-- name: ListOwnerCurrencies :many
+SELECT currency, count(*) AS account_count
+FROM accounts
+WHERE owner = $1 AND deleted_at IS NULL AND status = 'active'
+GROUP BY currency
+ORDER BY currency;
+
+-- name: UpdateAccountCurrency :one
+UPDATE accounts
+SET currency = sqlc.arg(currency),
+    version = version + 1,
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+  AND balance = 0
+  AND deleted_at IS NULL
+RETURNING *;
+
+-- name: SoftDeleteAccount :one
+UPDATE accounts
+SET deleted_at = now(),
+    version = version + 1,
+    updated_at = now()
+WHERE id = $1
+  AND balance = 0
+  AND deleted_at IS NULL
+RETURNING *;
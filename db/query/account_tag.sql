@@ -0,0 +1,25 @@
+-- name: AddAccountTag :exec
+INSERT INTO account_tags (
+  account_id,
+  tag
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (account_id, tag) DO NOTHING;
+
+-- name: DeleteAccountTag :exec
+DELETE FROM account_tags
+WHERE account_id = $1 AND tag = $2;
+
+-- name: ListAccountTags :many
+SELECT tag FROM account_tags
+WHERE account_id = $1
+ORDER BY tag;
+
+-- name: ListAccountsByTag :many
+SELECT a.* FROM accounts a
+JOIN account_tags t ON t.account_id = a.id
+WHERE a.owner = sqlc.arg(owner) AND t.tag = sqlc.arg(tag) AND a.deleted_at IS NULL
+ORDER BY a.id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
@@ -0,0 +1,37 @@
+-- name: CreateBalanceAlert :one
+INSERT INTO balance_alerts (
+    account_id,
+    low_threshold,
+    high_threshold
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetBalanceAlert :one
+SELECT * FROM balance_alerts
+WHERE account_id = $1
+LIMIT 1;
+
+-- name: UpdateBalanceAlert :one
+UPDATE balance_alerts
+SET
+    low_threshold = $2,
+    high_threshold = $3,
+    low_triggered = false,
+    high_triggered = false,
+    updated_at = now()
+WHERE account_id = $1
+RETURNING *;
+
+-- name: UpdateBalanceAlertState :one
+UPDATE balance_alerts
+SET
+    low_triggered = $2,
+    high_triggered = $3,
+    updated_at = now()
+WHERE account_id = $1
+RETURNING *;
+
+-- name: DeleteBalanceAlert :exec
+DELETE FROM balance_alerts
+WHERE account_id = $1;
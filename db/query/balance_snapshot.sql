@@ -0,0 +1,15 @@
+-- name: CreateBalanceSnapshot :one
+INSERT INTO balance_snapshots (
+    account_id,
+    balance
+) VALUES (
+    $1, $2
+) RETURNING *;
+
+-- name: ListBalanceSnapshots :many
+SELECT * FROM balance_snapshots
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(from_time)
+  AND created_at < sqlc.arg(to_time)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
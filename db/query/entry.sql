@@ -13,6 +13,51 @@ WHERE id = $1 LIMIT 1;
 -- name: ListEntries :many
 SELECT * FROM entries
 WHERE account_id = $1
-ORDER BY id
+ORDER BY created_at DESC, id DESC
 LIMIT $2
 OFFSET $3;
+
+-- name: ListEntriesInRange :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(from_time)
+  AND created_at < sqlc.arg(to_time)
+ORDER BY id;
+
+-- name: SumEntriesSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(since);
+
+-- name: SumEntriesUntil :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at <= sqlc.arg(until);
+
+-- name: ListEntriesByAccountAndDate :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(from_time)
+  AND created_at < sqlc.arg(to_time)
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: SumEntriesBeforeEntry :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND (created_at < sqlc.arg(created_at)
+    OR (created_at = sqlc.arg(created_at) AND id < sqlc.arg(id)));
+
+-- name: SummarizeEntriesInRange :one
+SELECT
+  COALESCE(SUM(-amount) FILTER (WHERE amount < 0), 0)::bigint AS total_debit,
+  COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0)::bigint AS total_credit,
+  COUNT(*) AS entry_count
+FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(from_time)
+  AND created_at < sqlc.arg(to_time);
@@ -0,0 +1,32 @@
+-- name: SearchOwnerAccounts :many
+SELECT * FROM accounts
+WHERE owner = sqlc.arg(owner)
+  AND deleted_at IS NULL
+  AND (
+    lower(nickname) LIKE sqlc.arg(prefix)::text
+    OR lower(currency) LIKE sqlc.arg(prefix)::text
+    OR id::text LIKE sqlc.arg(prefix)::text
+  )
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: SearchOwnerTransfers :many
+SELECT t.*, fa.owner AS from_owner, ta.owner AS to_owner
+FROM transfers t
+JOIN accounts fa ON fa.id = t.from_account_id
+JOIN accounts ta ON ta.id = t.to_account_id
+WHERE (fa.owner = sqlc.arg(owner) OR ta.owner = sqlc.arg(owner))
+  AND (
+    lower(t.memo) LIKE sqlc.arg(prefix)::text
+    OR t.id::text LIKE sqlc.arg(prefix)::text
+  )
+ORDER BY t.created_at DESC, t.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: SearchAccountsByOwner :many
+SELECT * FROM accounts
+WHERE owner ILIKE sqlc.arg(pattern)::text
+  AND deleted_at IS NULL
+ORDER BY owner, id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
@@ -6,12 +6,40 @@ INSERT INTO sessions (
     user_agent,
     client_ip,
     is_blocked,
-    expires_at
+    expires_at,
+    access_token_id
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
+    $1, $2, $3, $4, $5, $6, $7, $8
 ) RETURNING *;
 
 -- name: GetSession :one
 SELECT * FROM sessions
 WHERE id = $1
 LIMIT 1;
+
+-- name: BlockUserSessions :execrows
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE username = $1 AND is_blocked = FALSE;
+
+-- name: GetSessionByAccessToken :one
+SELECT * FROM sessions
+WHERE access_token_id = $1
+LIMIT 1;
+
+-- name: SetSessionAccessToken :exec
+UPDATE sessions
+SET access_token_id = sqlc.arg(access_token_id)
+WHERE id = sqlc.arg(id);
+
+-- name: RevokeSession :execrows
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE id = $1 AND is_blocked = FALSE;
+
+-- name: ListSessionsByUser :many
+SELECT * FROM sessions
+WHERE username = $1
+  AND is_blocked = FALSE
+  AND expires_at > now()
+ORDER BY created_at DESC;
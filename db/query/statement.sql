@@ -0,0 +1,27 @@
+-- name: CreateStatement :one
+INSERT INTO statements (
+  account_id,
+  period_start,
+  period_end
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (account_id, period_start) DO NOTHING
+RETURNING *;
+
+-- name: DeleteStatement :exec
+DELETE FROM statements
+WHERE id = $1;
+
+-- name: ListStatementAccounts :many
+SELECT * FROM accounts
+WHERE statements_enabled = true AND deleted_at IS NULL
+ORDER BY id;
+
+-- name: UpdateAccountStatements :one
+UPDATE accounts
+SET statements_enabled = $2,
+    version = version + 1,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
@@ -2,15 +2,30 @@
 INSERT INTO transfers (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    memo,
+    converted_amount,
+    rounding_mode
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5, $6
 )  RETURNING *;
 
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: AddTransferRefundedAmount :one
+UPDATE transfers
+SET refunded_amount = refunded_amount + sqlc.arg(amount),
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
 -- name: ListTransfers :many
 SELECT * FROM transfers
 WHERE 
@@ -18,4 +33,48 @@ WHERE
     to_account_id = $2
 ORDER BY id
 LIMIT $3
-OFFSET $4;    
\ No newline at end of file
+OFFSET $4;    
+-- name: ListOwnerTransfers :many
+SELECT t.*, fa.owner AS from_owner, ta.owner AS to_owner
+FROM transfers t
+JOIN accounts fa ON fa.id = t.from_account_id
+JOIN accounts ta ON ta.id = t.to_account_id
+WHERE fa.owner = sqlc.arg(owner) OR ta.owner = sqlc.arg(owner)
+ORDER BY t.created_at DESC, t.id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: GetTransferWithOwners :one
+SELECT t.*, fa.owner AS from_owner, ta.owner AS to_owner
+FROM transfers t
+JOIN accounts fa ON fa.id = t.from_account_id
+JOIN accounts ta ON ta.id = t.to_account_id
+WHERE t.id = $1
+LIMIT 1;
+
+-- name: SumTransfersSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM transfers
+WHERE from_account_id = sqlc.arg(from_account_id)
+  AND created_at >= sqlc.arg(since)
+  AND reversed_of IS NULL
+  AND refund_of IS NULL;
+
+-- name: GetTransferReversal :one
+SELECT * FROM transfers
+WHERE reversed_of = sqlc.arg(transfer_id)::bigint
+LIMIT 1;
+
+-- name: SetTransferReversedOf :one
+UPDATE transfers
+SET reversed_of = sqlc.arg(reversed_of),
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: SetTransferRefundOf :one
+UPDATE transfers
+SET refund_of = sqlc.arg(refund_of),
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,33 @@
+-- name: CreateTransferRequest :one
+INSERT INTO transfer_requests (
+    from_account_id,
+    to_account_id,
+    amount,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetTransferRequest :one
+SELECT * FROM transfer_requests
+WHERE id = $1 LIMIT 1;
+
+-- name: GetTransferRequestForUpdate :one
+SELECT * FROM transfer_requests
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: AcceptTransferRequest :one
+UPDATE transfer_requests
+SET status = 'accepted',
+    transfer_id = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeclineTransferRequest :one
+UPDATE transfer_requests
+SET status = 'declined',
+    updated_at = now()
+WHERE id = $1 AND status = 'pending' AND expires_at > now()
+RETURNING *;
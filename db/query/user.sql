@@ -3,12 +3,65 @@ INSERT INTO users (
     username,
     hashed_password,
     full_name,
-    email
+    email,
+    password_pepper_version,
+    email_key_version,
+    email_blind_index,
+    default_currency
 ) VALUES (
-    $1, $2, $3, $4
+    $1, $2, $3, $4, $5, $6, $7, $8
 ) RETURNING *;
 
 -- name: GetUser :one
 SELECT * FROM users
 WHERE username = $1
-LIMIT 1;
\ No newline at end of file
+LIMIT 1;
+
+-- name: GetUserForUpdate :one
+SELECT * FROM users
+WHERE username = $1
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetUserByEmailIndex :one
+SELECT * FROM users
+WHERE email_blind_index = sqlc.narg(email_blind_index)
+   OR (email_key_version = 0 AND email = sqlc.arg(email))
+LIMIT 1;
+
+-- name: UpdateUserPasswordHash :one
+UPDATE users
+SET hashed_password = $2,
+    password_pepper_version = $3
+WHERE username = $1
+RETURNING *;
+
+-- name: MarkUserEmailVerified :one
+UPDATE users
+SET is_email_verified = TRUE
+WHERE username = $1
+RETURNING *;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2,
+    password_pepper_version = $3,
+    password_changed_at = now()
+WHERE username = $1
+RETURNING *;
+
+-- name: UpdateUser :one
+UPDATE users
+SET full_name = COALESCE(sqlc.narg(full_name), full_name),
+    email = COALESCE(sqlc.narg(email), email),
+    email_key_version = COALESCE(sqlc.narg(email_key_version), email_key_version),
+    email_blind_index = CASE
+        WHEN sqlc.narg(email)::varchar IS NULL THEN email_blind_index
+        ELSE sqlc.narg(email_blind_index)
+    END,
+    is_email_verified = CASE
+        WHEN sqlc.narg(email)::varchar IS NOT NULL AND sqlc.arg(reset_email_verified)::bool THEN FALSE
+        ELSE is_email_verified
+    END
+WHERE username = sqlc.arg(username)
+RETURNING *;
@@ -0,0 +1,21 @@
+-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+    username,
+    email,
+    email_key_version,
+    secret_code,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetVerifyEmailForUpdate :one
+SELECT * FROM verify_emails
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: MarkVerifyEmailUsed :one
+UPDATE verify_emails
+SET is_used = TRUE
+WHERE id = $1
+RETURNING *;
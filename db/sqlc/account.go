@@ -0,0 +1,34 @@
+package db
+
+import "context"
+
+// Account statuses. Frozen accounts stay readable but are blocked from transfers.
+const (
+	AccountActive = "active"
+	AccountFrozen = "frozen"
+)
+
+// IsFrozen reports whether the account is blocked from transfers
+func (account Account) IsFrozen() bool {
+	return account.Status == AccountFrozen
+}
+
+// CreateAccount opens an account, reporting constraint violations as sentinels
+func (store *SQLStore) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	account, err := store.Queries.CreateAccount(ctx, arg)
+	return account, translateError(err)
+}
+
+// UpdateAccountCurrency changes the currency of an empty account, reporting
+// constraint violations as sentinels
+func (store *SQLStore) UpdateAccountCurrency(ctx context.Context, arg UpdateAccountCurrencyParams) (Account, error) {
+	account, err := store.Queries.UpdateAccountCurrency(ctx, arg)
+	return account, translateError(err)
+}
+
+// CreateBalanceAlert configures an account's alert, reporting constraint
+// violations as sentinels
+func (store *SQLStore) CreateBalanceAlert(ctx context.Context, arg CreateBalanceAlertParams) (BalanceAlert, error) {
+	alert, err := store.Queries.CreateBalanceAlert(ctx, arg)
+	return alert, translateError(err)
+}
@@ -7,13 +7,17 @@ package db
 
 import (
 	"context"
+
+	"github.com/lib/pq"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
-SET balance = balance + $1
+SET balance = balance + $1,
+    version = version + 1,
+    updated_at = now()
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status
 `
 
 type AddAccountBalanceParams struct {
@@ -30,28 +34,88 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
+	)
+	return i, err
+}
+
+const addAccountBalanceWithVersion = `-- name: AddAccountBalanceWithVersion :one
+UPDATE accounts
+SET balance = balance + $1,
+    version = version + 1,
+    updated_at = now()
+WHERE id = $2
+  AND version = $3
+RETURNING id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status
+`
+
+type AddAccountBalanceWithVersionParams struct {
+	Amount  int64 `json:"amount"`
+	ID      int64 `json:"id"`
+	Version int32 `json:"version"`
+}
+
+func (q *Queries) AddAccountBalanceWithVersion(ctx context.Context, arg AddAccountBalanceWithVersionParams) (Account, error) {
+	row := q.queryRow(ctx, q.addAccountBalanceWithVersionStmt, addAccountBalanceWithVersion, arg.Amount, arg.ID, arg.Version)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
 	)
 	return i, err
 }
 
+const countAccounts = `-- name: CountAccounts :one
+SELECT count(*) FROM accounts
+WHERE deleted_at IS NULL AND status = 'active'
+`
+
+func (q *Queries) CountAccounts(ctx context.Context) (int64, error) {
+	row := q.queryRow(ctx, q.countAccountsStmt, countAccounts)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
     owner,
     balance,
-    currency
+    currency,
+    nickname
 ) VALUES (
-    $1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+    $1, $2, $3, $4
+) RETURNING id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status
 `
 
 type CreateAccountParams struct {
 	Owner    string `json:"owner"`
 	Balance  int64  `json:"balance"`
 	Currency string `json:"currency"`
+	Nickname string `json:"nickname"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.queryRow(ctx, q.createAccountStmt, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.queryRow(ctx, q.createAccountStmt, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.Nickname,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -59,6 +123,12 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
 	)
 	return i, err
 }
@@ -73,9 +143,37 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 	return err
 }
 
+const freezeAccount = `-- name: FreezeAccount :one
+UPDATE accounts
+SET status = 'frozen',
+    version = version + 1,
+    updated_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status
+`
+
+func (q *Queries) FreezeAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.queryRow(ctx, q.freezeAccountStmt, freezeAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
+	)
+	return i, err
+}
+
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
-WHERE id = $1
+SELECT id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status FROM accounts
+WHERE id = $1 AND deleted_at IS NULL
 LIMIT 1
 `
 
@@ -88,13 +186,51 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
+	)
+	return i, err
+}
+
+const getAccountByOwnerAndCurrency = `-- name: GetAccountByOwnerAndCurrency :one
+SELECT id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status FROM accounts
+WHERE owner = $1 AND currency = $2 AND nickname = $3 AND deleted_at IS NULL
+LIMIT 1
+`
+
+type GetAccountByOwnerAndCurrencyParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+	Nickname string `json:"nickname"`
+}
+
+func (q *Queries) GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error) {
+	row := q.queryRow(ctx, q.getAccountByOwnerAndCurrencyStmt, getAccountByOwnerAndCurrency, arg.Owner, arg.Currency, arg.Nickname)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
-WHERE id = $1 LIMIT 1
+SELECT id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status FROM accounts
+WHERE id = $1 AND deleted_at IS NULL
+LIMIT 1
 FOR NO KEY UPDATE
 `
 
@@ -107,13 +243,67 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
 	)
 	return i, err
 }
 
+const getAccountsByIDs = `-- name: GetAccountsByIDs :many
+SELECT id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status FROM accounts
+WHERE id = ANY($1::bigint[])
+AND owner = $2
+AND deleted_at IS NULL
+ORDER BY id
+`
+
+type GetAccountsByIDsParams struct {
+	Ids   []int64 `json:"ids"`
+	Owner string  `json:"owner"`
+}
+
+func (q *Queries) GetAccountsByIDs(ctx context.Context, arg GetAccountsByIDsParams) ([]Account, error) {
+	rows, err := q.query(ctx, q.getAccountsByIDsStmt, getAccountsByIDs, pq.Array(arg.Ids), arg.Owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.StatementsEnabled,
+			&i.Nickname,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
-WHERE owner = $1
+SELECT id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status FROM accounts
+WHERE owner = $1 AND deleted_at IS NULL
 ORDER BY id
 LIMIT $2
 OFFSET $3
@@ -140,6 +330,12 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.StatementsEnabled,
+			&i.Nickname,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.Status,
 		); err != nil {
 			return nil, err
 		}
@@ -154,11 +350,107 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const listOwnerCurrencies = `-- name: ListOwnerCurrencies :many
+SELECT currency, count(*) AS account_count
+FROM accounts
+WHERE owner = $1 AND deleted_at IS NULL AND status = 'active'
+GROUP BY currency
+ORDER BY currency
+`
+
+type ListOwnerCurrenciesRow struct {
+	Currency     string `json:"currency"`
+	AccountCount int64  `json:"account_count"`
+}
+
+func (q *Queries) ListOwnerCurrencies(ctx context.Context, owner string) ([]ListOwnerCurrenciesRow, error) {
+	rows, err := q.query(ctx, q.listOwnerCurrenciesStmt, listOwnerCurrencies, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOwnerCurrenciesRow{}
+	for rows.Next() {
+		var i ListOwnerCurrenciesRow
+		if err := rows.Scan(&i.Currency, &i.AccountCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const softDeleteAccount = `-- name: SoftDeleteAccount :one
+UPDATE accounts
+SET deleted_at = now(),
+    version = version + 1,
+    updated_at = now()
+WHERE id = $1
+  AND balance = 0
+  AND deleted_at IS NULL
+RETURNING id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status
+`
+
+func (q *Queries) SoftDeleteAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.queryRow(ctx, q.softDeleteAccountStmt, softDeleteAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
+	)
+	return i, err
+}
+
+const unfreezeAccount = `-- name: UnfreezeAccount :one
+UPDATE accounts
+SET status = 'active',
+    version = version + 1,
+    updated_at = now()
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status
+`
+
+func (q *Queries) UnfreezeAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.queryRow(ctx, q.unfreezeAccountStmt, unfreezeAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
+	)
+	return i, err
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
-SET balance = $2
+SET balance = $2,
+    version = version + 1,
+    updated_at = now()
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status
 `
 
 type UpdateAccountParams struct {
@@ -175,6 +467,80 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
+	)
+	return i, err
+}
+
+const updateAccountCurrency = `-- name: UpdateAccountCurrency :one
+UPDATE accounts
+SET currency = $1,
+    version = version + 1,
+    updated_at = now()
+WHERE id = $2
+  AND balance = 0
+  AND deleted_at IS NULL
+RETURNING id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status
+`
+
+type UpdateAccountCurrencyParams struct {
+	Currency string `json:"currency"`
+	ID       int64  `json:"id"`
+}
+
+func (q *Queries) UpdateAccountCurrency(ctx context.Context, arg UpdateAccountCurrencyParams) (Account, error) {
+	row := q.queryRow(ctx, q.updateAccountCurrencyStmt, updateAccountCurrency, arg.Currency, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
+	)
+	return i, err
+}
+
+const updateAccountOwner = `-- name: UpdateAccountOwner :one
+UPDATE accounts
+SET owner = $2,
+    version = version + 1,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status
+`
+
+type UpdateAccountOwnerParams struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+}
+
+func (q *Queries) UpdateAccountOwner(ctx context.Context, arg UpdateAccountOwnerParams) (Account, error) {
+	row := q.queryRow(ctx, q.updateAccountOwnerStmt, updateAccountOwner, arg.ID, arg.Owner)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
 	)
 	return i, err
 }
@@ -0,0 +1,83 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: account_owner_change.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createAccountOwnerChange = `-- name: CreateAccountOwnerChange :one
+INSERT INTO account_owner_changes (
+    account_id,
+    previous_owner,
+    new_owner,
+    changed_by
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING id, account_id, previous_owner, new_owner, changed_by, created_at
+`
+
+type CreateAccountOwnerChangeParams struct {
+	AccountID     int64  `json:"account_id"`
+	PreviousOwner string `json:"previous_owner"`
+	NewOwner      string `json:"new_owner"`
+	ChangedBy     string `json:"changed_by"`
+}
+
+func (q *Queries) CreateAccountOwnerChange(ctx context.Context, arg CreateAccountOwnerChangeParams) (AccountOwnerChange, error) {
+	row := q.queryRow(ctx, q.createAccountOwnerChangeStmt, createAccountOwnerChange,
+		arg.AccountID,
+		arg.PreviousOwner,
+		arg.NewOwner,
+		arg.ChangedBy,
+	)
+	var i AccountOwnerChange
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PreviousOwner,
+		&i.NewOwner,
+		&i.ChangedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAccountOwnerChanges = `-- name: ListAccountOwnerChanges :many
+SELECT id, account_id, previous_owner, new_owner, changed_by, created_at FROM account_owner_changes
+WHERE account_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListAccountOwnerChanges(ctx context.Context, accountID int64) ([]AccountOwnerChange, error) {
+	rows, err := q.query(ctx, q.listAccountOwnerChangesStmt, listAccountOwnerChanges, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountOwnerChange{}
+	for rows.Next() {
+		var i AccountOwnerChange
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.PreviousOwner,
+			&i.NewOwner,
+			&i.ChangedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
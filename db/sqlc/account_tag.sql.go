@@ -0,0 +1,130 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: account_tag.sql
+
+package db
+
+import (
+	"context"
+)
+
+const addAccountTag = `-- name: AddAccountTag :exec
+INSERT INTO account_tags (
+  account_id,
+  tag
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (account_id, tag) DO NOTHING
+`
+
+type AddAccountTagParams struct {
+	AccountID int64  `json:"account_id"`
+	Tag       string `json:"tag"`
+}
+
+func (q *Queries) AddAccountTag(ctx context.Context, arg AddAccountTagParams) error {
+	_, err := q.exec(ctx, q.addAccountTagStmt, addAccountTag, arg.AccountID, arg.Tag)
+	return err
+}
+
+const deleteAccountTag = `-- name: DeleteAccountTag :exec
+DELETE FROM account_tags
+WHERE account_id = $1 AND tag = $2
+`
+
+type DeleteAccountTagParams struct {
+	AccountID int64  `json:"account_id"`
+	Tag       string `json:"tag"`
+}
+
+func (q *Queries) DeleteAccountTag(ctx context.Context, arg DeleteAccountTagParams) error {
+	_, err := q.exec(ctx, q.deleteAccountTagStmt, deleteAccountTag, arg.AccountID, arg.Tag)
+	return err
+}
+
+const listAccountTags = `-- name: ListAccountTags :many
+SELECT tag FROM account_tags
+WHERE account_id = $1
+ORDER BY tag
+`
+
+func (q *Queries) ListAccountTags(ctx context.Context, accountID int64) ([]string, error) {
+	rows, err := q.query(ctx, q.listAccountTagsStmt, listAccountTags, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		items = append(items, tag)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsByTag = `-- name: ListAccountsByTag :many
+SELECT a.id, a.owner, a.balance, a.currency, a.created_at, a.statements_enabled, a.nickname, a.updated_at, a.deleted_at, a.version, a.status FROM accounts a
+JOIN account_tags t ON t.account_id = a.id
+WHERE a.owner = $1 AND t.tag = $2 AND a.deleted_at IS NULL
+ORDER BY a.id
+LIMIT $4
+OFFSET $3
+`
+
+type ListAccountsByTagParams struct {
+	Owner  string `json:"owner"`
+	Tag    string `json:"tag"`
+	Offset int32  `json:"offset"`
+	Limit  int32  `json:"limit"`
+}
+
+func (q *Queries) ListAccountsByTag(ctx context.Context, arg ListAccountsByTagParams) ([]Account, error) {
+	rows, err := q.query(ctx, q.listAccountsByTagStmt, listAccountsByTag,
+		arg.Owner,
+		arg.Tag,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.StatementsEnabled,
+			&i.Nickname,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestAccountTags verifies tags dedupe and filter the owner's accounts
+func TestAccountTags(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	//Adding the same tag twice is a no-op
+	for i := 0; i < 2; i++ {
+		err := testQueries.AddAccountTag(context.Background(), AddAccountTagParams{AccountID: account1.ID, Tag: "business"})
+		require.NoError(t, err)
+	}
+	err := testQueries.AddAccountTag(context.Background(), AddAccountTagParams{AccountID: account2.ID, Tag: "business"})
+	require.NoError(t, err)
+
+	tags, err := testQueries.ListAccountTags(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"business"}, tags)
+
+	//Only the owner's tagged accounts are returned
+	accounts, err := testQueries.ListAccountsByTag(context.Background(), ListAccountsByTagParams{
+		Owner:  account1.Owner,
+		Tag:    "business",
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account1.ID, accounts[0].ID)
+
+	//Removing the tag drops it from the filter
+	err = testQueries.DeleteAccountTag(context.Background(), DeleteAccountTagParams{AccountID: account1.ID, Tag: "business"})
+	require.NoError(t, err)
+
+	tags, err = testQueries.ListAccountTags(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Empty(t, tags)
+}
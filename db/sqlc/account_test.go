@@ -10,13 +10,16 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// minRandomAccountBalance funds random accounts enough for the transfers tests make
+const minRandomAccountBalance = 1000
+
 // createRandomAccount creates and verifies a random account
 func createRandomAccount(t *testing.T) Account {
 	user := createRandomUser(t)
 
 	arg := CreateAccountParams{
 		Owner:    user.Username,
-		Balance:  util.RandomMoney(),
+		Balance:  minRandomAccountBalance + util.RandomMoney(),
 		Currency: util.RandomCurrency(),
 	}
 
@@ -27,6 +30,7 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, AccountActive, account.Status)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -110,3 +114,251 @@ func TestListAccounts(t *testing.T) {
 		require.Equal(t, lastAccount.Owner, account.Owner)
 	}
 }
+
+// TestGetAccountsByIDs tests fetching several owned accounts at once
+func TestGetAccountsByIDs(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	arg := GetAccountsByIDsParams{
+		Ids:   []int64{account1.ID, account2.ID},
+		Owner: account1.Owner,
+	}
+
+	//Only the account owned by the given owner is returned
+	accounts, err := testQueries.GetAccountsByIDs(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account1.ID, accounts[0].ID)
+}
+
+// TestGetAccountByOwnerAndCurrency tests looking up an owner's account by currency
+func TestGetAccountByOwnerAndCurrency(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	account2, err := testQueries.GetAccountByOwnerAndCurrency(context.Background(), GetAccountByOwnerAndCurrencyParams{
+		Owner:    account1.Owner,
+		Currency: account1.Currency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+}
+
+// TestCreateAccountNickname verifies uniqueness is scoped to owner, currency and nickname
+func TestCreateAccountNickname(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	arg := CreateAccountParams{
+		Owner:    account1.Owner,
+		Balance:  0,
+		Currency: account1.Currency,
+	}
+
+	//A second unnamed account in the same currency is rejected
+	_, err := testQueries.CreateAccount(context.Background(), arg)
+	require.Error(t, err)
+
+	//A distinct nickname is allowed
+	arg.Nickname = "savings"
+	account2, err := testQueries.CreateAccount(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, "savings", account2.Nickname)
+
+	//Reusing the nickname is rejected
+	_, err = testQueries.CreateAccount(context.Background(), arg)
+	require.Error(t, err)
+}
+
+// TestListOwnerCurrencies tests counting an owner's accounts per currency
+func TestListOwnerCurrencies(t *testing.T) {
+	user := createRandomUser(t)
+
+	//No accounts yet
+	currencies, err := testQueries.ListOwnerCurrencies(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, currencies)
+
+	for _, arg := range []CreateAccountParams{
+		{Owner: user.Username, Currency: util.USD},
+		{Owner: user.Username, Currency: util.USD, Nickname: "savings"},
+		{Owner: user.Username, Currency: util.EUR},
+	} {
+		_, err := testQueries.CreateAccount(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	//Other owners' accounts are not counted
+	createRandomAccount(t)
+
+	//Neither are frozen or closed accounts
+	frozen, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{Owner: user.Username, Currency: util.EUR, Nickname: "frozen"})
+	require.NoError(t, err)
+	_, err = testQueries.FreezeAccount(context.Background(), frozen.ID)
+	require.NoError(t, err)
+	closed, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{Owner: user.Username, Currency: util.USD, Nickname: "closed"})
+	require.NoError(t, err)
+	_, err = testQueries.SoftDeleteAccount(context.Background(), closed.ID)
+	require.NoError(t, err)
+
+	currencies, err = testQueries.ListOwnerCurrencies(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, []ListOwnerCurrenciesRow{
+		{Currency: util.EUR, AccountCount: 1},
+		{Currency: util.USD, AccountCount: 2},
+	}, currencies)
+}
+
+// TestUpdateAccountCurrency verifies the currency only changes on empty accounts
+func TestUpdateAccountCurrency(t *testing.T) {
+	user := createRandomUser(t)
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	updated, err := testQueries.UpdateAccountCurrency(context.Background(), UpdateAccountCurrencyParams{
+		ID:       account.ID,
+		Currency: util.EUR,
+	})
+	require.NoError(t, err)
+	require.Equal(t, util.EUR, updated.Currency)
+	require.Zero(t, updated.Balance)
+
+	//A funded account keeps its currency
+	funded := createRandomAccount(t)
+	_, err = testQueries.UpdateAccountCurrency(context.Background(), UpdateAccountCurrencyParams{
+		ID:       funded.ID,
+		Currency: util.KES,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+// TestSoftDeleteAccount verifies deleted accounts disappear from reads but keep their history
+func TestSoftDeleteAccount(t *testing.T) {
+	funded := createRandomAccount(t)
+
+	//Funded accounts cannot be closed
+	_, err := testQueries.SoftDeleteAccount(context.Background(), funded.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    funded.Owner,
+		Balance:  0,
+		Currency: funded.Currency,
+		Nickname: "closing",
+	})
+	require.NoError(t, err)
+	entry := createRandomEntry(t, account)
+
+	deleted, err := testQueries.SoftDeleteAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.NotNil(t, deleted.DeletedAt)
+
+	_, err = testQueries.GetAccount(context.Background(), account.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = testQueries.GetAccountForUpdate(context.Background(), account.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	accounts, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Owner: funded.Owner,
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, funded.ID, accounts[0].ID)
+
+	//History is kept
+	kept, err := testQueries.GetEntry(context.Background(), entry.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, kept.AccountID)
+
+	//The closed account no longer blocks a new one with the same nickname
+	reopened, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    funded.Owner,
+		Balance:  0,
+		Currency: funded.Currency,
+		Nickname: "closing",
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, account.ID, reopened.ID)
+}
+
+// TestAddAccountBalanceWithVersion tests updates only apply at the expected version
+func TestAddAccountBalanceWithVersion(t *testing.T) {
+	account := createRandomAccount(t)
+	require.Zero(t, account.Version)
+
+	updated, err := testQueries.AddAccountBalanceWithVersion(context.Background(), AddAccountBalanceWithVersionParams{
+		ID:      account.ID,
+		Amount:  10,
+		Version: account.Version,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+10, updated.Balance)
+	require.Equal(t, account.Version+1, updated.Version)
+
+	//The version read before the update is now stale
+	_, err = testQueries.AddAccountBalanceWithVersion(context.Background(), AddAccountBalanceWithVersionParams{
+		ID:      account.ID,
+		Amount:  10,
+		Version: account.Version,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	//Regular updates bump the version too
+	updated, err = testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: -10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, updated.Balance)
+	require.Equal(t, account.Version+2, updated.Version)
+}
+
+// TestCountAccounts verifies only open, unfrozen accounts are counted
+func TestCountAccounts(t *testing.T) {
+	before, err := testQueries.CountAccounts(context.Background())
+	require.NoError(t, err)
+
+	frozen := createRandomAccount(t)
+	_, err = testQueries.FreezeAccount(context.Background(), frozen.ID)
+	require.NoError(t, err)
+
+	closed, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    frozen.Owner,
+		Balance:  0,
+		Currency: frozen.Currency,
+		Nickname: "closing",
+	})
+	require.NoError(t, err)
+	_, err = testQueries.SoftDeleteAccount(context.Background(), closed.ID)
+	require.NoError(t, err)
+
+	createRandomAccount(t)
+
+	after, err := testQueries.CountAccounts(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, before+1, after)
+}
+
+// TestFreezeAccount verifies accounts can be frozen and unfrozen
+func TestFreezeAccount(t *testing.T) {
+	account := createRandomAccount(t)
+
+	frozen, err := testQueries.FreezeAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, AccountFrozen, frozen.Status)
+	require.True(t, frozen.IsFrozen())
+	require.Equal(t, account.Balance, frozen.Balance)
+	require.Equal(t, account.Version+1, frozen.Version)
+
+	active, err := testQueries.UnfreezeAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, AccountActive, active.Status)
+	require.False(t, active.IsFrozen())
+
+	_, err = testQueries.FreezeAccount(context.Background(), -1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Balance alert kinds
+const (
+	BalanceAlertLow  = "low"
+	BalanceAlertHigh = "high"
+)
+
+// BalanceAlertEvent describes a threshold crossed by a balance change
+type BalanceAlertEvent struct {
+	AccountID int64  `json:"account_id"`
+	Owner     string `json:"owner"`
+	Currency  string `json:"currency"`
+	Kind      string `json:"kind"`
+	Threshold int64  `json:"threshold"`
+	Balance   int64  `json:"balance"`
+}
+
+// evaluateBalanceAlert compares a balance against the alert thresholds.
+// An alert fires only when a threshold is first crossed and re-arms once
+// the balance moves back to the other side of it.
+func evaluateBalanceAlert(alert BalanceAlert, account Account) (lowTriggered bool, highTriggered bool, events []BalanceAlertEvent) {
+	lowTriggered = alert.LowTriggered
+	highTriggered = alert.HighTriggered
+
+	//Low threshold: fire when the balance drops below it
+	if alert.LowThreshold.Valid {
+		below := account.Balance < alert.LowThreshold.Int64
+		if below && !lowTriggered {
+			events = append(events, newBalanceAlertEvent(account, BalanceAlertLow, alert.LowThreshold.Int64))
+		}
+		lowTriggered = below
+	}
+
+	//High threshold: fire when the balance rises above it
+	if alert.HighThreshold.Valid {
+		above := account.Balance > alert.HighThreshold.Int64
+		if above && !highTriggered {
+			events = append(events, newBalanceAlertEvent(account, BalanceAlertHigh, alert.HighThreshold.Int64))
+		}
+		highTriggered = above
+	}
+
+	return
+}
+
+// newBalanceAlertEvent builds an event for the given account and threshold
+func newBalanceAlertEvent(account Account, kind string, threshold int64) BalanceAlertEvent {
+	return BalanceAlertEvent{
+		AccountID: account.ID,
+		Owner:     account.Owner,
+		Currency:  account.Currency,
+		Kind:      kind,
+		Threshold: threshold,
+		Balance:   account.Balance,
+	}
+}
+
+// checkBalanceAlert evaluates the account's alert and persists its new state.
+// It must run in the same transaction that changed the balance, which already
+// holds the account row lock and so serializes concurrent evaluations.
+func checkBalanceAlert(ctx context.Context, q *Queries, account Account) ([]BalanceAlertEvent, error) {
+	alert, err := q.GetBalanceAlert(ctx, account.ID)
+	if err != nil {
+		//No alert configured for this account
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	lowTriggered, highTriggered, events := evaluateBalanceAlert(alert, account)
+
+	//Only write when the state actually changed
+	if lowTriggered != alert.LowTriggered || highTriggered != alert.HighTriggered {
+		_, err = q.UpdateBalanceAlertState(ctx, UpdateBalanceAlertStateParams{
+			AccountID:     account.ID,
+			LowTriggered:  lowTriggered,
+			HighTriggered: highTriggered,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
@@ -0,0 +1,141 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: balance_alert.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createBalanceAlert = `-- name: CreateBalanceAlert :one
+INSERT INTO balance_alerts (
+    account_id,
+    low_threshold,
+    high_threshold
+) VALUES (
+    $1, $2, $3
+) RETURNING id, account_id, low_threshold, high_threshold, low_triggered, high_triggered, created_at, updated_at
+`
+
+type CreateBalanceAlertParams struct {
+	AccountID     int64         `json:"account_id"`
+	LowThreshold  sql.NullInt64 `json:"low_threshold"`
+	HighThreshold sql.NullInt64 `json:"high_threshold"`
+}
+
+func (q *Queries) CreateBalanceAlert(ctx context.Context, arg CreateBalanceAlertParams) (BalanceAlert, error) {
+	row := q.queryRow(ctx, q.createBalanceAlertStmt, createBalanceAlert, arg.AccountID, arg.LowThreshold, arg.HighThreshold)
+	var i BalanceAlert
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.LowThreshold,
+		&i.HighThreshold,
+		&i.LowTriggered,
+		&i.HighTriggered,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteBalanceAlert = `-- name: DeleteBalanceAlert :exec
+DELETE FROM balance_alerts
+WHERE account_id = $1
+`
+
+func (q *Queries) DeleteBalanceAlert(ctx context.Context, accountID int64) error {
+	_, err := q.exec(ctx, q.deleteBalanceAlertStmt, deleteBalanceAlert, accountID)
+	return err
+}
+
+const getBalanceAlert = `-- name: GetBalanceAlert :one
+SELECT id, account_id, low_threshold, high_threshold, low_triggered, high_triggered, created_at, updated_at FROM balance_alerts
+WHERE account_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetBalanceAlert(ctx context.Context, accountID int64) (BalanceAlert, error) {
+	row := q.queryRow(ctx, q.getBalanceAlertStmt, getBalanceAlert, accountID)
+	var i BalanceAlert
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.LowThreshold,
+		&i.HighThreshold,
+		&i.LowTriggered,
+		&i.HighTriggered,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateBalanceAlert = `-- name: UpdateBalanceAlert :one
+UPDATE balance_alerts
+SET
+    low_threshold = $2,
+    high_threshold = $3,
+    low_triggered = false,
+    high_triggered = false,
+    updated_at = now()
+WHERE account_id = $1
+RETURNING id, account_id, low_threshold, high_threshold, low_triggered, high_triggered, created_at, updated_at
+`
+
+type UpdateBalanceAlertParams struct {
+	AccountID     int64         `json:"account_id"`
+	LowThreshold  sql.NullInt64 `json:"low_threshold"`
+	HighThreshold sql.NullInt64 `json:"high_threshold"`
+}
+
+func (q *Queries) UpdateBalanceAlert(ctx context.Context, arg UpdateBalanceAlertParams) (BalanceAlert, error) {
+	row := q.queryRow(ctx, q.updateBalanceAlertStmt, updateBalanceAlert, arg.AccountID, arg.LowThreshold, arg.HighThreshold)
+	var i BalanceAlert
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.LowThreshold,
+		&i.HighThreshold,
+		&i.LowTriggered,
+		&i.HighTriggered,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateBalanceAlertState = `-- name: UpdateBalanceAlertState :one
+UPDATE balance_alerts
+SET
+    low_triggered = $2,
+    high_triggered = $3,
+    updated_at = now()
+WHERE account_id = $1
+RETURNING id, account_id, low_threshold, high_threshold, low_triggered, high_triggered, created_at, updated_at
+`
+
+type UpdateBalanceAlertStateParams struct {
+	AccountID     int64 `json:"account_id"`
+	LowTriggered  bool  `json:"low_triggered"`
+	HighTriggered bool  `json:"high_triggered"`
+}
+
+func (q *Queries) UpdateBalanceAlertState(ctx context.Context, arg UpdateBalanceAlertStateParams) (BalanceAlert, error) {
+	row := q.queryRow(ctx, q.updateBalanceAlertStateStmt, updateBalanceAlertState, arg.AccountID, arg.LowTriggered, arg.HighTriggered)
+	var i BalanceAlert
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.LowThreshold,
+		&i.HighThreshold,
+		&i.LowTriggered,
+		&i.HighTriggered,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
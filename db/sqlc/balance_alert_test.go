@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestEvaluateBalanceAlert verifies thresholds fire once per crossing
+func TestEvaluateBalanceAlert(t *testing.T) {
+	alert := BalanceAlert{
+		LowThreshold:  sql.NullInt64{Int64: 100, Valid: true},
+		HighThreshold: sql.NullInt64{Int64: 1000, Valid: true},
+	}
+	account := Account{ID: 1, Owner: "owner", Currency: "USD"}
+
+	//Balance between thresholds fires nothing
+	account.Balance = 500
+	low, high, events := evaluateBalanceAlert(alert, account)
+	require.False(t, low)
+	require.False(t, high)
+	require.Empty(t, events)
+
+	//Dropping below the low threshold fires once
+	account.Balance = 50
+	low, high, events = evaluateBalanceAlert(alert, account)
+	require.True(t, low)
+	require.False(t, high)
+	require.Len(t, events, 1)
+	require.Equal(t, BalanceAlertLow, events[0].Kind)
+	require.Equal(t, int64(100), events[0].Threshold)
+	require.Equal(t, int64(50), events[0].Balance)
+
+	//Staying below does not fire again
+	alert.LowTriggered = low
+	account.Balance = 10
+	low, _, events = evaluateBalanceAlert(alert, account)
+	require.True(t, low)
+	require.Empty(t, events)
+
+	//Recovering re-arms the alert
+	account.Balance = 100
+	low, _, events = evaluateBalanceAlert(alert, account)
+	require.False(t, low)
+	require.Empty(t, events)
+
+	//Rising above the high threshold fires once
+	alert.LowTriggered = low
+	account.Balance = 1001
+	_, high, events = evaluateBalanceAlert(alert, account)
+	require.True(t, high)
+	require.Len(t, events, 1)
+	require.Equal(t, BalanceAlertHigh, events[0].Kind)
+}
+
+// TestTransferTxBalanceAlert verifies a low alert fires once while staying below
+func TestTransferTxBalanceAlert(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	//Set the low threshold just under the current balance
+	amount := int64(10)
+	_, err := testQueries.CreateBalanceAlert(context.Background(), CreateBalanceAlertParams{
+		AccountID:    account1.ID,
+		LowThreshold: sql.NullInt64{Int64: account1.Balance - amount + 1, Valid: true},
+	})
+	require.NoError(t, err)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	}
+
+	//First transfer crosses the threshold
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, result.Alerts, 1)
+	require.Equal(t, account1.ID, result.Alerts[0].AccountID)
+	require.Equal(t, BalanceAlertLow, result.Alerts[0].Kind)
+	require.Equal(t, result.FromAccount.Balance, result.Alerts[0].Balance)
+
+	//Second transfer stays below and must not fire again
+	result, err = store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, result.Alerts)
+
+	alert, err := testQueries.GetBalanceAlert(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.True(t, alert.LowTriggered)
+}
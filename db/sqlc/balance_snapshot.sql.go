@@ -0,0 +1,86 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: balance_snapshot.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createBalanceSnapshot = `-- name: CreateBalanceSnapshot :one
+INSERT INTO balance_snapshots (
+    account_id,
+    balance
+) VALUES (
+    $1, $2
+) RETURNING id, account_id, balance, created_at
+`
+
+type CreateBalanceSnapshotParams struct {
+	AccountID int64 `json:"account_id"`
+	Balance   int64 `json:"balance"`
+}
+
+func (q *Queries) CreateBalanceSnapshot(ctx context.Context, arg CreateBalanceSnapshotParams) (BalanceSnapshot, error) {
+	row := q.queryRow(ctx, q.createBalanceSnapshotStmt, createBalanceSnapshot, arg.AccountID, arg.Balance)
+	var i BalanceSnapshot
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Balance,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listBalanceSnapshots = `-- name: ListBalanceSnapshots :many
+SELECT id, account_id, balance, created_at FROM balance_snapshots
+WHERE account_id = $1
+  AND created_at >= $2
+  AND created_at < $3
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListBalanceSnapshotsParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+	Limit     int32     `json:"limit"`
+}
+
+func (q *Queries) ListBalanceSnapshots(ctx context.Context, arg ListBalanceSnapshotsParams) ([]BalanceSnapshot, error) {
+	rows, err := q.query(ctx, q.listBalanceSnapshotsStmt, listBalanceSnapshots,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []BalanceSnapshot{}
+	for rows.Next() {
+		var i BalanceSnapshot
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Balance,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
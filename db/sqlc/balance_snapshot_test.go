@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// listAllBalanceSnapshots returns every snapshot of an account
+func listAllBalanceSnapshots(t *testing.T, accountID int64) []BalanceSnapshot {
+	snapshots, err := testQueries.ListBalanceSnapshots(context.Background(), ListBalanceSnapshotsParams{
+		AccountID: accountID,
+		FromTime:  time.Now().Add(-time.Hour),
+		ToTime:    time.Now().Add(time.Hour),
+		Limit:     100,
+	})
+	require.NoError(t, err)
+	return snapshots
+}
+
+// TestTransferTxBalanceSnapshots tests each transfer snapshots both new balances
+func TestTransferTxBalanceSnapshots(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	var results []TransferTxResult
+	for _, amount := range []int64{10, 20} {
+		result, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+		require.NoError(t, err)
+		results = append(results, result)
+	}
+
+	snapshots := listAllBalanceSnapshots(t, account1.ID)
+	require.Len(t, snapshots, 2)
+	require.Equal(t, results[0].FromAccount.Balance, snapshots[0].Balance)
+	require.Equal(t, results[1].FromAccount.Balance, snapshots[1].Balance)
+
+	snapshots = listAllBalanceSnapshots(t, account2.ID)
+	require.Len(t, snapshots, 2)
+	require.Equal(t, results[1].ToAccount.Balance, snapshots[1].Balance)
+}
+
+// TestTransferTxBalanceSnapshotsRolledBack tests a failed transfer leaves no snapshot
+func TestTransferTxBalanceSnapshotsRolledBack(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	//The first balance update and its snapshot are undone with the transfer
+	store := newStoreWithBalanceUpdateHook(StoreOptions{}, func(update int) {
+		if update == 2 {
+			cancel()
+		}
+	})
+
+	_, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	require.Empty(t, listAllBalanceSnapshots(t, account1.ID))
+	require.Empty(t, listAllBalanceSnapshots(t, account2.ID))
+}
+
+// TestListBalanceSnapshotsRange tests snapshots are filtered by time and limited
+func TestListBalanceSnapshotsRange(t *testing.T) {
+	account := createRandomAccount(t)
+
+	for _, balance := range []int64{1, 2, 3} {
+		_, err := testQueries.CreateBalanceSnapshot(context.Background(), CreateBalanceSnapshotParams{
+			AccountID: account.ID,
+			Balance:   balance,
+		})
+		require.NoError(t, err)
+	}
+
+	snapshots, err := testQueries.ListBalanceSnapshots(context.Background(), ListBalanceSnapshotsParams{
+		AccountID: account.ID,
+		FromTime:  time.Now().Add(-time.Hour),
+		ToTime:    time.Now().Add(time.Hour),
+		Limit:     2,
+	})
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	require.Equal(t, int64(1), snapshots[0].Balance)
+	require.Equal(t, int64(2), snapshots[1].Balance)
+
+	//Nothing was recorded in the future
+	snapshots, err = testQueries.ListBalanceSnapshots(context.Background(), ListBalanceSnapshotsParams{
+		AccountID: account.ID,
+		FromTime:  time.Now().Add(time.Hour),
+		ToTime:    time.Now().Add(2 * time.Hour),
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, snapshots)
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// A single transfer of a batch, all sent from the same account
+type BatchTransferLeg struct {
+	ToAccountID int64  `json:"to_account_id"`
+	Amount      int64  `json:"amount"`
+	Memo        string `json:"memo"`
+}
+
+// Batch transfer transaction input parameters
+type BatchTransferTxParams struct {
+	FromAccountID int64              `json:"from_account_id"`
+	Legs          []BatchTransferLeg `json:"legs"`
+
+	//Optional cap on what the source may send since DailyLimitSince, checked
+	//against the batch total once the source account is locked
+	DailyLimit      int64     `json:"daily_limit,omitempty"`
+	DailyLimitSince time.Time `json:"daily_limit_since,omitempty"`
+}
+
+// Batch transfer transaction result data
+type BatchTransferTxResult struct {
+	//Results of each leg, in the order of the request
+	Legs []TransferTxResult `json:"legs"`
+
+	//Source account after the last leg and the total it sent
+	FromAccount Account `json:"from_account"`
+	Total       int64   `json:"total"`
+}
+
+// BatchTransferLegError reports which leg of a batch failed, wrapping the cause
+type BatchTransferLegError struct {
+	Leg int
+	Err error
+}
+
+func (e *BatchTransferLegError) Error() string {
+	return fmt.Sprintf("transfers[%d]: %v", e.Leg, e.Err)
+}
+
+func (e *BatchTransferLegError) Unwrap() error { return e.Err }
+
+// BatchTransferTx sends several transfers from one account in a single
+// transaction, so either every leg is applied or none is. The source must
+// cover the total of the batch before any leg runs.
+func (store *SQLStore) BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (result BatchTransferTxResult, err error) {
+	ctx, span := startSpan(ctx, "BatchTransferTx")
+	defer func() { endSpan(span, err) }()
+
+	opts := &sql.TxOptions{Isolation: store.options.TransferIsolation}
+
+	//Execute the batch in a transaction, retried if Postgres aborts it
+	err = store.execTx(ctx, opts, func(q *Queries) error {
+		result = BatchTransferTxResult{Legs: make([]TransferTxResult, 0, len(arg.Legs))}
+
+		//Lock every account of the batch in id order to avoid deadlocks
+		ids := []int64{arg.FromAccountID}
+		for _, leg := range arg.Legs {
+			ids = append(ids, leg.ToAccountID)
+			result.Total += leg.Amount
+		}
+		slices.Sort(ids)
+
+		var fromBalance int64
+		for _, id := range slices.Compact(ids) {
+			account, err := q.GetAccountForUpdate(ctx, id)
+			if err != nil {
+				return err
+			}
+			if id == arg.FromAccountID {
+				fromBalance = account.Balance
+			}
+		}
+
+		//Reject the whole batch before writing anything
+		if fromBalance < result.Total {
+			return ErrInsufficientFunds
+		}
+		if err := checkDailyLimit(ctx, q, arg.FromAccountID, result.Total, arg.DailyLimit, arg.DailyLimitSince); err != nil {
+			return err
+		}
+
+		for i, leg := range arg.Legs {
+			legResult, err := runTransfer(ctx, q, TransferTxParams{
+				FromAccountID: arg.FromAccountID,
+				ToAccountID:   leg.ToAccountID,
+				Amount:        leg.Amount,
+				Memo:          leg.Memo,
+			})
+			if err != nil {
+				return &BatchTransferLegError{Leg: i, Err: err}
+			}
+			result.Legs = append(result.Legs, legResult)
+			result.FromAccount = legResult.FromAccount
+		}
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestBatchTransferTx tests every leg of a batch is applied
+func TestBatchTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createRandomAccount(t)
+	to1 := createRandomAccount(t)
+	to2 := createRandomAccount(t)
+
+	result, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		FromAccountID: from.ID,
+		Legs: []BatchTransferLeg{
+			{ToAccountID: to1.ID, Amount: 10, Memo: "salary"},
+			{ToAccountID: to2.ID, Amount: 20},
+			{ToAccountID: to1.ID, Amount: 5},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Legs, 3)
+	require.Equal(t, int64(35), result.Total)
+	require.Equal(t, from.Balance-35, result.FromAccount.Balance)
+
+	require.Equal(t, to1.ID, result.Legs[0].Transfer.ToAccountID)
+	require.Equal(t, "salary", result.Legs[0].Transfer.Memo)
+	require.Equal(t, to2.Balance+20, result.Legs[1].ToAccount.Balance)
+	require.Equal(t, to1.Balance+15, result.Legs[2].ToAccount.Balance)
+}
+
+// TestBatchTransferTxOverBalance tests a batch larger than the source balance is rejected up front
+func TestBatchTransferTxOverBalance(t *testing.T) {
+	store := NewStore(testDB)
+
+	from := createRandomAccount(t)
+	to1 := createRandomAccount(t)
+	to2 := createRandomAccount(t)
+
+	//Each leg fits on its own but not together
+	_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+		FromAccountID: from.ID,
+		Legs: []BatchTransferLeg{
+			{ToAccountID: to1.ID, Amount: from.Balance},
+			{ToAccountID: to2.ID, Amount: 1},
+		},
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	current, err := testQueries.GetAccount(context.Background(), from.ID)
+	require.NoError(t, err)
+	require.Equal(t, from.Balance, current.Balance)
+}
+
+// TestBatchTransferTxRollsBack tests a failing leg undoes the legs before it
+func TestBatchTransferTxRollsBack(t *testing.T) {
+	from := createRandomAccount(t)
+	to1 := createRandomAccount(t)
+	to2 := createRandomAccount(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	//The second leg fails between its balance updates
+	store := newStoreWithBalanceUpdateHook(StoreOptions{}, func(update int) {
+		if update == 4 {
+			cancel()
+		}
+	})
+
+	_, err := store.BatchTransferTx(ctx, BatchTransferTxParams{
+		FromAccountID: from.ID,
+		Legs: []BatchTransferLeg{
+			{ToAccountID: to1.ID, Amount: 10},
+			{ToAccountID: to2.ID, Amount: 10},
+		},
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	var legErr *BatchTransferLegError
+	require.ErrorAs(t, err, &legErr)
+	require.Equal(t, 1, legErr.Leg)
+
+	//No balance moved and the first leg left no transfer behind
+	for _, account := range []Account{from, to1, to2} {
+		current, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, current.Balance)
+	}
+
+	transfers, err := testQueries.ListTransfers(context.Background(), ListTransfersParams{
+		FromAccountID: from.ID,
+		ToAccountID:   from.ID,
+		Limit:         5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/codercollo/simple_bank/util"
+)
+
+// Pool settings used when none are configured
+const (
+	defaultDBMaxOpenConns    = 25
+	defaultDBMaxIdleConns    = 25
+	defaultDBConnMaxLifetime = 5 * time.Minute
+)
+
+// Connection retry settings used when none are configured
+const (
+	defaultDBConnectAttempts = 10
+	defaultDBConnectBackoff  = 500 * time.Millisecond
+	defaultDBConnectTimeout  = 30 * time.Second
+)
+
+// Open connects to the configured database with the pool settings applied,
+// and pings it so an unreachable database fails at startup. A database that
+// is still starting up is waited for, see pingWithRetry.
+func Open(ctx context.Context, config util.Config) (*sql.DB, error) {
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open db: %w", err)
+	}
+	configurePool(conn, config)
+
+	if err := pingWithRetry(ctx, conn, config); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("cannot reach db: %w", err)
+	}
+	return conn, nil
+}
+
+// pingWithRetry pings the database until it answers, waiting between attempts
+// with a backoff doubled after each one. It gives up once the attempts or the
+// connect timeout run out, whichever comes first.
+func pingWithRetry(ctx context.Context, conn *sql.DB, config util.Config) error {
+	attempts := config.DBConnectAttempts
+	if attempts <= 0 {
+		attempts = defaultDBConnectAttempts
+	}
+	backoff := config.DBConnectBackoff
+	if backoff <= 0 {
+		backoff = defaultDBConnectBackoff
+	}
+	timeout := config.DBConnectTimeout
+	if timeout <= 0 {
+		timeout = defaultDBConnectTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		pingCtx, cancelPing := context.WithTimeout(ctx, pingTimeout)
+		err = conn.PingContext(pingCtx)
+		cancelPing()
+		if err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		//Wait before retrying, unless the connect timeout runs out first
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up after attempt %d: %w", attempt, err)
+		case <-time.After(backoff << (attempt - 1)):
+		}
+	}
+	return fmt.Errorf("gave up after attempt %d: %w", attempts, err)
+}
+
+// configurePool applies the configured pool limits, falling back to the defaults
+func configurePool(conn *sql.DB, config util.Config) {
+	maxOpen := config.DBMaxOpenConns
+	if maxOpen == 0 {
+		maxOpen = defaultDBMaxOpenConns
+	}
+	maxIdle := config.DBMaxIdleConns
+	if maxIdle == 0 {
+		maxIdle = defaultDBMaxIdleConns
+	}
+	lifetime := config.DBConnMaxLifetime
+	if lifetime == 0 {
+		lifetime = defaultDBConnMaxLifetime
+	}
+
+	conn.SetMaxOpenConns(maxOpen)
+	conn.SetMaxIdleConns(maxIdle)
+	conn.SetConnMaxLifetime(lifetime)
+}
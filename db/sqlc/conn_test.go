@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// stubDriver opens connections that do nothing, so pool behaviour can be
+// tested without a database. Sources named "down" fail to connect, and
+// "flaky" fails while stubFlakyFailures is above zero.
+type stubDriver struct{}
+
+type stubConn struct{}
+
+type stubTx struct{}
+
+// Transactions finished through the stub driver
+var stubCommits, stubRollbacks atomic.Int64
+
+// Failed connection attempts, and failures left for the "flaky" source
+var stubFailedDials, stubFlakyFailures atomic.Int64
+
+func (stubDriver) Open(name string) (driver.Conn, error) {
+	if name == "down" || (name == "flaky" && stubFlakyFailures.Add(-1) >= 0) {
+		stubFailedDials.Add(1)
+		return nil, errors.New("connection refused")
+	}
+	return stubConn{}, nil
+}
+
+func (stubConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (stubConn) Close() error                        { return nil }
+func (stubConn) Begin() (driver.Tx, error)           { return stubTx{}, nil }
+
+func (stubTx) Commit() error   { stubCommits.Add(1); return nil }
+func (stubTx) Rollback() error { stubRollbacks.Add(1); return nil }
+
+func init() {
+	sql.Register("stub", stubDriver{})
+}
+
+// TestOpenAppliesPoolConfig verifies the configured pool limits are applied
+func TestOpenAppliesPoolConfig(t *testing.T) {
+	conn, err := Open(context.Background(), util.Config{
+		DBDriver:          "stub",
+		DBSource:          "up",
+		DBMaxOpenConns:    3,
+		DBMaxIdleConns:    1,
+		DBConnMaxLifetime: 20 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.Equal(t, 3, conn.Stats().MaxOpenConnections)
+
+	//Only one of the three released connections is kept idle
+	conns := make([]*sql.Conn, 3)
+	for i := range conns {
+		conns[i], err = conn.Conn(context.Background())
+		require.NoError(t, err)
+	}
+	for _, c := range conns {
+		require.NoError(t, c.Close())
+	}
+	stats := conn.Stats()
+	require.Equal(t, 1, stats.Idle)
+	require.Equal(t, int64(2), stats.MaxIdleClosed)
+
+	//The idle connection is retired once it outlives its lifetime
+	time.Sleep(50 * time.Millisecond)
+	c, err := conn.Conn(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+	require.Equal(t, int64(1), conn.Stats().MaxLifetimeClosed)
+}
+
+// TestOpenDefaults verifies unset pool settings fall back to the defaults
+func TestOpenDefaults(t *testing.T) {
+	conn, err := Open(context.Background(), util.Config{DBDriver: "stub", DBSource: "up"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.Equal(t, defaultDBMaxOpenConns, conn.Stats().MaxOpenConnections)
+}
+
+// TestOpenUnreachable verifies startup fails when the database cannot be reached
+func TestOpenUnreachable(t *testing.T) {
+	_, err := Open(context.Background(), util.Config{DBDriver: "stub", DBSource: "down", DBConnectAttempts: 1})
+	require.ErrorContains(t, err, "cannot reach db")
+
+	_, err = Open(context.Background(), util.Config{DBDriver: "missing"})
+	require.ErrorContains(t, err, "cannot open db")
+}
+
+// TestOpenRetries verifies a database that comes up late is waited for
+func TestOpenRetries(t *testing.T) {
+	stubFailedDials.Store(0)
+	stubFlakyFailures.Store(2)
+
+	conn, err := Open(context.Background(), util.Config{
+		DBDriver:          "stub",
+		DBSource:          "flaky",
+		DBConnectAttempts: 5,
+		DBConnectBackoff:  time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.Equal(t, int64(2), stubFailedDials.Load())
+}
+
+// TestOpenGivesUp verifies startup stops retrying after the configured attempts
+func TestOpenGivesUp(t *testing.T) {
+	stubFailedDials.Store(0)
+
+	_, err := Open(context.Background(), util.Config{
+		DBDriver:          "stub",
+		DBSource:          "down",
+		DBConnectAttempts: 3,
+		DBConnectBackoff:  time.Millisecond,
+	})
+	require.ErrorContains(t, err, "gave up after attempt 3")
+	require.ErrorContains(t, err, "connection refused")
+	require.Equal(t, int64(3), stubFailedDials.Load())
+}
+
+// TestOpenConnectTimeout verifies the connect timeout cuts the retries short
+func TestOpenConnectTimeout(t *testing.T) {
+	start := time.Now()
+	_, err := Open(context.Background(), util.Config{
+		DBDriver:          "stub",
+		DBSource:          "down",
+		DBConnectAttempts: 100,
+		DBConnectBackoff:  time.Hour,
+		DBConnectTimeout:  20 * time.Millisecond,
+	})
+	require.ErrorContains(t, err, "gave up after attempt 1")
+	require.Less(t, time.Since(start), time.Second)
+}
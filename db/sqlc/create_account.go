@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Errors returned when a new account cannot be funded
+var (
+	ErrFundingAccountNotOwned  = errors.New("funding account does not belong to the new account's owner")
+	ErrFundingCurrencyMismatch = errors.New("funding account currency does not match the new account")
+)
+
+// Funded account creation input parameters
+type CreateAccountWithFundingTxParams struct {
+	Account          CreateAccountParams `json:"account"`
+	FundingAccountID int64               `json:"funding_account_id"`
+	InitialBalance   int64               `json:"initial_balance"`
+
+	//Optional cap on what the funding account may send since DailyLimitSince
+	DailyLimit      int64     `json:"daily_limit,omitempty"`
+	DailyLimitSince time.Time `json:"daily_limit_since,omitempty"`
+}
+
+// Funded account creation result data
+type CreateAccountWithFundingTxResult struct {
+	Account        Account  `json:"account"`
+	FundingAccount Account  `json:"funding_account"`
+	Transfer       Transfer `json:"transfer"`
+
+	//Alerts fired by the new balances, delivered after commit
+	Alerts []BalanceAlertEvent `json:"-"`
+}
+
+// CreateAccountWithFundingTx opens an account and moves its initial balance
+// from another account of the same owner. Neither is kept if the funding
+// account cannot cover the amount.
+func (store *SQLStore) CreateAccountWithFundingTx(ctx context.Context, arg CreateAccountWithFundingTxParams) (CreateAccountWithFundingTxResult, error) {
+	var result CreateAccountWithFundingTxResult
+	opts := &sql.TxOptions{Isolation: store.options.TransferIsolation}
+
+	err := store.execTx(ctx, opts, func(q *Queries) error {
+		//The new account always starts empty, the funding transfer fills it
+		account := arg.Account
+		account.Balance = 0
+		created, err := q.CreateAccount(ctx, account)
+		if err != nil {
+			return err
+		}
+
+		funding, err := q.GetAccount(ctx, arg.FundingAccountID)
+		if err != nil {
+			return err
+		}
+		if funding.Owner != created.Owner {
+			return ErrFundingAccountNotOwned
+		}
+		if funding.Currency != created.Currency {
+			return ErrFundingCurrencyMismatch
+		}
+
+		//Move the money the same way a transfer does, under row locks
+		transfer, err := runTransfer(ctx, q, TransferTxParams{
+			FromAccountID:   funding.ID,
+			ToAccountID:     created.ID,
+			Amount:          arg.InitialBalance,
+			Memo:            "Initial deposit",
+			DailyLimit:      arg.DailyLimit,
+			DailyLimitSince: arg.DailyLimitSince,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Account = transfer.ToAccount
+		result.FundingAccount = transfer.FromAccount
+		result.Transfer = transfer.Transfer
+		result.Alerts = transfer.Alerts
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateAccountWithFundingTx tests opening an account funded from another one
+func TestCreateAccountWithFundingTx(t *testing.T) {
+	store := NewStore(testDB)
+	funding := createRandomAccount(t)
+	amount := funding.Balance / 2
+
+	result, err := store.CreateAccountWithFundingTx(context.Background(), CreateAccountWithFundingTxParams{
+		Account: CreateAccountParams{
+			Owner:    funding.Owner,
+			Currency: funding.Currency,
+			Nickname: util.RandomString(8),
+		},
+		FundingAccountID: funding.ID,
+		InitialBalance:   amount,
+	})
+	require.NoError(t, err)
+	require.Equal(t, funding.Owner, result.Account.Owner)
+	require.Equal(t, amount, result.Account.Balance)
+	require.Equal(t, funding.Balance-amount, result.FundingAccount.Balance)
+
+	//The deposit is recorded like any other transfer
+	require.Equal(t, funding.ID, result.Transfer.FromAccountID)
+	require.Equal(t, result.Account.ID, result.Transfer.ToAccountID)
+	require.Equal(t, amount, result.Transfer.Amount)
+
+	entries, err := testQueries.ListEntries(context.Background(), ListEntriesParams{
+		AccountID: result.Account.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, amount, entries[0].Amount)
+}
+
+// TestCreateAccountWithFundingTxInsufficientFunds tests that nothing is kept
+// when the funding account cannot cover the initial balance
+func TestCreateAccountWithFundingTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	funding := createRandomAccount(t)
+	nickname := util.RandomString(8)
+
+	_, err := store.CreateAccountWithFundingTx(context.Background(), CreateAccountWithFundingTxParams{
+		Account: CreateAccountParams{
+			Owner:    funding.Owner,
+			Currency: funding.Currency,
+			Nickname: nickname,
+		},
+		FundingAccountID: funding.ID,
+		InitialBalance:   funding.Balance + 1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	//The new account was rolled back and the funding balance is untouched
+	_, err = testQueries.GetAccountByOwnerAndCurrency(context.Background(), GetAccountByOwnerAndCurrencyParams{
+		Owner:    funding.Owner,
+		Currency: funding.Currency,
+		Nickname: nickname,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	stored, err := testQueries.GetAccount(context.Background(), funding.ID)
+	require.NoError(t, err)
+	require.Equal(t, funding.Balance, stored.Balance)
+}
+
+// TestCreateAccountWithFundingTxNotOwned tests rejecting another user's funding account
+func TestCreateAccountWithFundingTxNotOwned(t *testing.T) {
+	store := NewStore(testDB)
+	funding := createRandomAccount(t)
+	owner := createRandomUser(t)
+
+	_, err := store.CreateAccountWithFundingTx(context.Background(), CreateAccountWithFundingTxParams{
+		Account: CreateAccountParams{
+			Owner:    owner.Username,
+			Currency: funding.Currency,
+		},
+		FundingAccountID: funding.ID,
+		InitialBalance:   1,
+	})
+	require.ErrorIs(t, err, ErrFundingAccountNotOwned)
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoDefaultCurrency is returned when a user is created with an initial account but without a currency for it
+var ErrNoDefaultCurrency = errors.New("default currency is required to create an initial account")
+
+// User creation result data
+type CreateUserTxResult struct {
+	User    User    `json:"user"`
+	Account Account `json:"account"`
+}
+
+// CreateUserTx creates a user together with an empty account in the user's
+// default currency. Neither is kept if either insert fails.
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+	if !arg.DefaultCurrency.Valid || arg.DefaultCurrency.String == "" {
+		return result, ErrNoDefaultCurrency
+	}
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		var err error
+
+		result.User, err = store.createUser(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.CreateAccount(ctx, CreateAccountParams{
+			Owner:    result.User.Username,
+			Balance:  0,
+			Currency: arg.DefaultCurrency.String,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// randomCreateUserParams builds params for a new user with a default currency
+func randomCreateUserParams(t *testing.T) CreateUserParams {
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	return CreateUserParams{
+		Username:        util.RandomOwner(),
+		HashedPassword:  hashedPassword,
+		FullName:        util.RandomOwner(),
+		Email:           util.RandomEmail(),
+		DefaultCurrency: sql.NullString{String: util.RandomCurrency(), Valid: true},
+	}
+}
+
+// TestCreateUserTx tests that the user and the initial account are created together
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+	arg := randomCreateUserParams(t)
+
+	result, err := store.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, result.User.Username)
+	require.Equal(t, arg.DefaultCurrency, result.User.DefaultCurrency)
+	require.Equal(t, arg.Username, result.Account.Owner)
+	require.Equal(t, arg.DefaultCurrency.String, result.Account.Currency)
+	require.Zero(t, result.Account.Balance)
+
+	accounts, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Owner:  arg.Username,
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+}
+
+// TestCreateUserTxRollback tests that a failed user insert leaves no account behind
+func TestCreateUserTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+	existing := createRandomUser(t)
+
+	arg := randomCreateUserParams(t)
+	arg.Username = existing.Username
+	_, err := store.CreateUserTx(context.Background(), arg)
+	require.Error(t, err)
+
+	accounts, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Owner:  existing.Username,
+		Limit:  5,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	//An initial account needs a currency
+	arg = randomCreateUserParams(t)
+	arg.DefaultCurrency = sql.NullString{}
+	_, err = store.CreateUserTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrNoDefaultCurrency)
+}
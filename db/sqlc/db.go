@@ -24,147 +24,675 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.acceptTransferRequestStmt, err = db.PrepareContext(ctx, acceptTransferRequest); err != nil {
+		return nil, fmt.Errorf("error preparing query AcceptTransferRequest: %w", err)
+	}
 	if q.addAccountBalanceStmt, err = db.PrepareContext(ctx, addAccountBalance); err != nil {
 		return nil, fmt.Errorf("error preparing query AddAccountBalance: %w", err)
 	}
+	if q.addAccountBalanceWithVersionStmt, err = db.PrepareContext(ctx, addAccountBalanceWithVersion); err != nil {
+		return nil, fmt.Errorf("error preparing query AddAccountBalanceWithVersion: %w", err)
+	}
+	if q.addAccountTagStmt, err = db.PrepareContext(ctx, addAccountTag); err != nil {
+		return nil, fmt.Errorf("error preparing query AddAccountTag: %w", err)
+	}
+	if q.addTransferRefundedAmountStmt, err = db.PrepareContext(ctx, addTransferRefundedAmount); err != nil {
+		return nil, fmt.Errorf("error preparing query AddTransferRefundedAmount: %w", err)
+	}
+	if q.blockUserSessionsStmt, err = db.PrepareContext(ctx, blockUserSessions); err != nil {
+		return nil, fmt.Errorf("error preparing query BlockUserSessions: %w", err)
+	}
+	if q.countAccountsStmt, err = db.PrepareContext(ctx, countAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query CountAccounts: %w", err)
+	}
 	if q.createAccountStmt, err = db.PrepareContext(ctx, createAccount); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateAccount: %w", err)
 	}
+	if q.createAccountOwnerChangeStmt, err = db.PrepareContext(ctx, createAccountOwnerChange); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateAccountOwnerChange: %w", err)
+	}
+	if q.createBalanceAlertStmt, err = db.PrepareContext(ctx, createBalanceAlert); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateBalanceAlert: %w", err)
+	}
+	if q.createBalanceSnapshotStmt, err = db.PrepareContext(ctx, createBalanceSnapshot); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateBalanceSnapshot: %w", err)
+	}
 	if q.createEntryStmt, err = db.PrepareContext(ctx, createEntry); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateEntry: %w", err)
 	}
+	if q.createPasswordResetStmt, err = db.PrepareContext(ctx, createPasswordReset); err != nil {
+		return nil, fmt.Errorf("error preparing query CreatePasswordReset: %w", err)
+	}
 	if q.createSessionStmt, err = db.PrepareContext(ctx, createSession); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSession: %w", err)
 	}
+	if q.createStatementStmt, err = db.PrepareContext(ctx, createStatement); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateStatement: %w", err)
+	}
 	if q.createTransferStmt, err = db.PrepareContext(ctx, createTransfer); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateTransfer: %w", err)
 	}
+	if q.createTransferRequestStmt, err = db.PrepareContext(ctx, createTransferRequest); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateTransferRequest: %w", err)
+	}
 	if q.createUserStmt, err = db.PrepareContext(ctx, createUser); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateUser: %w", err)
 	}
+	if q.createVerifyEmailStmt, err = db.PrepareContext(ctx, createVerifyEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateVerifyEmail: %w", err)
+	}
+	if q.declineTransferRequestStmt, err = db.PrepareContext(ctx, declineTransferRequest); err != nil {
+		return nil, fmt.Errorf("error preparing query DeclineTransferRequest: %w", err)
+	}
 	if q.deleteAccountStmt, err = db.PrepareContext(ctx, deleteAccount); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteAccount: %w", err)
 	}
+	if q.deleteAccountTagStmt, err = db.PrepareContext(ctx, deleteAccountTag); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAccountTag: %w", err)
+	}
+	if q.deleteBalanceAlertStmt, err = db.PrepareContext(ctx, deleteBalanceAlert); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteBalanceAlert: %w", err)
+	}
+	if q.deleteStatementStmt, err = db.PrepareContext(ctx, deleteStatement); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteStatement: %w", err)
+	}
+	if q.freezeAccountStmt, err = db.PrepareContext(ctx, freezeAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query FreezeAccount: %w", err)
+	}
 	if q.getAccountStmt, err = db.PrepareContext(ctx, getAccount); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAccount: %w", err)
 	}
+	if q.getAccountByOwnerAndCurrencyStmt, err = db.PrepareContext(ctx, getAccountByOwnerAndCurrency); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAccountByOwnerAndCurrency: %w", err)
+	}
 	if q.getAccountForUpdateStmt, err = db.PrepareContext(ctx, getAccountForUpdate); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAccountForUpdate: %w", err)
 	}
+	if q.getAccountsByIDsStmt, err = db.PrepareContext(ctx, getAccountsByIDs); err != nil {
+		return nil, fmt.Errorf("error preparing query GetAccountsByIDs: %w", err)
+	}
+	if q.getBalanceAlertStmt, err = db.PrepareContext(ctx, getBalanceAlert); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBalanceAlert: %w", err)
+	}
 	if q.getEntryStmt, err = db.PrepareContext(ctx, getEntry); err != nil {
 		return nil, fmt.Errorf("error preparing query GetEntry: %w", err)
 	}
+	if q.getPasswordResetForUpdateStmt, err = db.PrepareContext(ctx, getPasswordResetForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetPasswordResetForUpdate: %w", err)
+	}
 	if q.getSessionStmt, err = db.PrepareContext(ctx, getSession); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSession: %w", err)
 	}
+	if q.getSessionByAccessTokenStmt, err = db.PrepareContext(ctx, getSessionByAccessToken); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSessionByAccessToken: %w", err)
+	}
 	if q.getTransferStmt, err = db.PrepareContext(ctx, getTransfer); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTransfer: %w", err)
 	}
+	if q.getTransferForUpdateStmt, err = db.PrepareContext(ctx, getTransferForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTransferForUpdate: %w", err)
+	}
+	if q.getTransferRequestStmt, err = db.PrepareContext(ctx, getTransferRequest); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTransferRequest: %w", err)
+	}
+	if q.getTransferRequestForUpdateStmt, err = db.PrepareContext(ctx, getTransferRequestForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTransferRequestForUpdate: %w", err)
+	}
+	if q.getTransferReversalStmt, err = db.PrepareContext(ctx, getTransferReversal); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTransferReversal: %w", err)
+	}
+	if q.getTransferWithOwnersStmt, err = db.PrepareContext(ctx, getTransferWithOwners); err != nil {
+		return nil, fmt.Errorf("error preparing query GetTransferWithOwners: %w", err)
+	}
 	if q.getUserStmt, err = db.PrepareContext(ctx, getUser); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUser: %w", err)
 	}
+	if q.getUserByEmailIndexStmt, err = db.PrepareContext(ctx, getUserByEmailIndex); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserByEmailIndex: %w", err)
+	}
+	if q.getUserForUpdateStmt, err = db.PrepareContext(ctx, getUserForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserForUpdate: %w", err)
+	}
+	if q.getVerifyEmailForUpdateStmt, err = db.PrepareContext(ctx, getVerifyEmailForUpdate); err != nil {
+		return nil, fmt.Errorf("error preparing query GetVerifyEmailForUpdate: %w", err)
+	}
+	if q.listAccountOwnerChangesStmt, err = db.PrepareContext(ctx, listAccountOwnerChanges); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccountOwnerChanges: %w", err)
+	}
+	if q.listAccountTagsStmt, err = db.PrepareContext(ctx, listAccountTags); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccountTags: %w", err)
+	}
 	if q.listAccountsStmt, err = db.PrepareContext(ctx, listAccounts); err != nil {
 		return nil, fmt.Errorf("error preparing query ListAccounts: %w", err)
 	}
+	if q.listAccountsByTagStmt, err = db.PrepareContext(ctx, listAccountsByTag); err != nil {
+		return nil, fmt.Errorf("error preparing query ListAccountsByTag: %w", err)
+	}
+	if q.listBalanceSnapshotsStmt, err = db.PrepareContext(ctx, listBalanceSnapshots); err != nil {
+		return nil, fmt.Errorf("error preparing query ListBalanceSnapshots: %w", err)
+	}
 	if q.listEntriesStmt, err = db.PrepareContext(ctx, listEntries); err != nil {
 		return nil, fmt.Errorf("error preparing query ListEntries: %w", err)
 	}
+	if q.listEntriesByAccountAndDateStmt, err = db.PrepareContext(ctx, listEntriesByAccountAndDate); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEntriesByAccountAndDate: %w", err)
+	}
+	if q.listEntriesInRangeStmt, err = db.PrepareContext(ctx, listEntriesInRange); err != nil {
+		return nil, fmt.Errorf("error preparing query ListEntriesInRange: %w", err)
+	}
+	if q.listOwnerCurrenciesStmt, err = db.PrepareContext(ctx, listOwnerCurrencies); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOwnerCurrencies: %w", err)
+	}
+	if q.listOwnerTransfersStmt, err = db.PrepareContext(ctx, listOwnerTransfers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOwnerTransfers: %w", err)
+	}
+	if q.listSessionsByUserStmt, err = db.PrepareContext(ctx, listSessionsByUser); err != nil {
+		return nil, fmt.Errorf("error preparing query ListSessionsByUser: %w", err)
+	}
+	if q.listStatementAccountsStmt, err = db.PrepareContext(ctx, listStatementAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query ListStatementAccounts: %w", err)
+	}
 	if q.listTransfersStmt, err = db.PrepareContext(ctx, listTransfers); err != nil {
 		return nil, fmt.Errorf("error preparing query ListTransfers: %w", err)
 	}
+	if q.markPasswordResetUsedStmt, err = db.PrepareContext(ctx, markPasswordResetUsed); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkPasswordResetUsed: %w", err)
+	}
+	if q.markUserEmailVerifiedStmt, err = db.PrepareContext(ctx, markUserEmailVerified); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkUserEmailVerified: %w", err)
+	}
+	if q.markVerifyEmailUsedStmt, err = db.PrepareContext(ctx, markVerifyEmailUsed); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkVerifyEmailUsed: %w", err)
+	}
+	if q.revokeSessionStmt, err = db.PrepareContext(ctx, revokeSession); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeSession: %w", err)
+	}
+	if q.searchAccountsByOwnerStmt, err = db.PrepareContext(ctx, searchAccountsByOwner); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchAccountsByOwner: %w", err)
+	}
+	if q.searchOwnerAccountsStmt, err = db.PrepareContext(ctx, searchOwnerAccounts); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchOwnerAccounts: %w", err)
+	}
+	if q.searchOwnerTransfersStmt, err = db.PrepareContext(ctx, searchOwnerTransfers); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchOwnerTransfers: %w", err)
+	}
+	if q.setSessionAccessTokenStmt, err = db.PrepareContext(ctx, setSessionAccessToken); err != nil {
+		return nil, fmt.Errorf("error preparing query SetSessionAccessToken: %w", err)
+	}
+	if q.setTransferRefundOfStmt, err = db.PrepareContext(ctx, setTransferRefundOf); err != nil {
+		return nil, fmt.Errorf("error preparing query SetTransferRefundOf: %w", err)
+	}
+	if q.setTransferReversedOfStmt, err = db.PrepareContext(ctx, setTransferReversedOf); err != nil {
+		return nil, fmt.Errorf("error preparing query SetTransferReversedOf: %w", err)
+	}
+	if q.softDeleteAccountStmt, err = db.PrepareContext(ctx, softDeleteAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query SoftDeleteAccount: %w", err)
+	}
+	if q.sumEntriesBeforeEntryStmt, err = db.PrepareContext(ctx, sumEntriesBeforeEntry); err != nil {
+		return nil, fmt.Errorf("error preparing query SumEntriesBeforeEntry: %w", err)
+	}
+	if q.sumEntriesSinceStmt, err = db.PrepareContext(ctx, sumEntriesSince); err != nil {
+		return nil, fmt.Errorf("error preparing query SumEntriesSince: %w", err)
+	}
+	if q.sumEntriesUntilStmt, err = db.PrepareContext(ctx, sumEntriesUntil); err != nil {
+		return nil, fmt.Errorf("error preparing query SumEntriesUntil: %w", err)
+	}
+	if q.sumTransfersSinceStmt, err = db.PrepareContext(ctx, sumTransfersSince); err != nil {
+		return nil, fmt.Errorf("error preparing query SumTransfersSince: %w", err)
+	}
+	if q.summarizeEntriesInRangeStmt, err = db.PrepareContext(ctx, summarizeEntriesInRange); err != nil {
+		return nil, fmt.Errorf("error preparing query SummarizeEntriesInRange: %w", err)
+	}
+	if q.unfreezeAccountStmt, err = db.PrepareContext(ctx, unfreezeAccount); err != nil {
+		return nil, fmt.Errorf("error preparing query UnfreezeAccount: %w", err)
+	}
 	if q.updateAccountStmt, err = db.PrepareContext(ctx, updateAccount); err != nil {
 		return nil, fmt.Errorf("error preparing query UpdateAccount: %w", err)
 	}
+	if q.updateAccountCurrencyStmt, err = db.PrepareContext(ctx, updateAccountCurrency); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAccountCurrency: %w", err)
+	}
+	if q.updateAccountOwnerStmt, err = db.PrepareContext(ctx, updateAccountOwner); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAccountOwner: %w", err)
+	}
+	if q.updateAccountStatementsStmt, err = db.PrepareContext(ctx, updateAccountStatements); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateAccountStatements: %w", err)
+	}
+	if q.updateBalanceAlertStmt, err = db.PrepareContext(ctx, updateBalanceAlert); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateBalanceAlert: %w", err)
+	}
+	if q.updateBalanceAlertStateStmt, err = db.PrepareContext(ctx, updateBalanceAlertState); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateBalanceAlertState: %w", err)
+	}
+	if q.updateUserStmt, err = db.PrepareContext(ctx, updateUser); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUser: %w", err)
+	}
+	if q.updateUserPasswordStmt, err = db.PrepareContext(ctx, updateUserPassword); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserPassword: %w", err)
+	}
+	if q.updateUserPasswordHashStmt, err = db.PrepareContext(ctx, updateUserPasswordHash); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserPasswordHash: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.acceptTransferRequestStmt != nil {
+		if cerr := q.acceptTransferRequestStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing acceptTransferRequestStmt: %w", cerr)
+		}
+	}
 	if q.addAccountBalanceStmt != nil {
 		if cerr := q.addAccountBalanceStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing addAccountBalanceStmt: %w", cerr)
 		}
 	}
+	if q.addAccountBalanceWithVersionStmt != nil {
+		if cerr := q.addAccountBalanceWithVersionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addAccountBalanceWithVersionStmt: %w", cerr)
+		}
+	}
+	if q.addAccountTagStmt != nil {
+		if cerr := q.addAccountTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addAccountTagStmt: %w", cerr)
+		}
+	}
+	if q.addTransferRefundedAmountStmt != nil {
+		if cerr := q.addTransferRefundedAmountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addTransferRefundedAmountStmt: %w", cerr)
+		}
+	}
+	if q.blockUserSessionsStmt != nil {
+		if cerr := q.blockUserSessionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing blockUserSessionsStmt: %w", cerr)
+		}
+	}
+	if q.countAccountsStmt != nil {
+		if cerr := q.countAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countAccountsStmt: %w", cerr)
+		}
+	}
 	if q.createAccountStmt != nil {
 		if cerr := q.createAccountStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createAccountStmt: %w", cerr)
 		}
 	}
+	if q.createAccountOwnerChangeStmt != nil {
+		if cerr := q.createAccountOwnerChangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createAccountOwnerChangeStmt: %w", cerr)
+		}
+	}
+	if q.createBalanceAlertStmt != nil {
+		if cerr := q.createBalanceAlertStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createBalanceAlertStmt: %w", cerr)
+		}
+	}
+	if q.createBalanceSnapshotStmt != nil {
+		if cerr := q.createBalanceSnapshotStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createBalanceSnapshotStmt: %w", cerr)
+		}
+	}
 	if q.createEntryStmt != nil {
 		if cerr := q.createEntryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createEntryStmt: %w", cerr)
 		}
 	}
+	if q.createPasswordResetStmt != nil {
+		if cerr := q.createPasswordResetStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createPasswordResetStmt: %w", cerr)
+		}
+	}
 	if q.createSessionStmt != nil {
 		if cerr := q.createSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createSessionStmt: %w", cerr)
 		}
 	}
+	if q.createStatementStmt != nil {
+		if cerr := q.createStatementStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createStatementStmt: %w", cerr)
+		}
+	}
 	if q.createTransferStmt != nil {
 		if cerr := q.createTransferStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createTransferStmt: %w", cerr)
 		}
 	}
+	if q.createTransferRequestStmt != nil {
+		if cerr := q.createTransferRequestStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createTransferRequestStmt: %w", cerr)
+		}
+	}
 	if q.createUserStmt != nil {
 		if cerr := q.createUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createUserStmt: %w", cerr)
 		}
 	}
+	if q.createVerifyEmailStmt != nil {
+		if cerr := q.createVerifyEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createVerifyEmailStmt: %w", cerr)
+		}
+	}
+	if q.declineTransferRequestStmt != nil {
+		if cerr := q.declineTransferRequestStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing declineTransferRequestStmt: %w", cerr)
+		}
+	}
 	if q.deleteAccountStmt != nil {
 		if cerr := q.deleteAccountStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteAccountStmt: %w", cerr)
 		}
 	}
+	if q.deleteAccountTagStmt != nil {
+		if cerr := q.deleteAccountTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAccountTagStmt: %w", cerr)
+		}
+	}
+	if q.deleteBalanceAlertStmt != nil {
+		if cerr := q.deleteBalanceAlertStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteBalanceAlertStmt: %w", cerr)
+		}
+	}
+	if q.deleteStatementStmt != nil {
+		if cerr := q.deleteStatementStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteStatementStmt: %w", cerr)
+		}
+	}
+	if q.freezeAccountStmt != nil {
+		if cerr := q.freezeAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing freezeAccountStmt: %w", cerr)
+		}
+	}
 	if q.getAccountStmt != nil {
 		if cerr := q.getAccountStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAccountStmt: %w", cerr)
 		}
 	}
+	if q.getAccountByOwnerAndCurrencyStmt != nil {
+		if cerr := q.getAccountByOwnerAndCurrencyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAccountByOwnerAndCurrencyStmt: %w", cerr)
+		}
+	}
 	if q.getAccountForUpdateStmt != nil {
 		if cerr := q.getAccountForUpdateStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAccountForUpdateStmt: %w", cerr)
 		}
 	}
+	if q.getAccountsByIDsStmt != nil {
+		if cerr := q.getAccountsByIDsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getAccountsByIDsStmt: %w", cerr)
+		}
+	}
+	if q.getBalanceAlertStmt != nil {
+		if cerr := q.getBalanceAlertStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBalanceAlertStmt: %w", cerr)
+		}
+	}
 	if q.getEntryStmt != nil {
 		if cerr := q.getEntryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getEntryStmt: %w", cerr)
 		}
 	}
+	if q.getPasswordResetForUpdateStmt != nil {
+		if cerr := q.getPasswordResetForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getPasswordResetForUpdateStmt: %w", cerr)
+		}
+	}
 	if q.getSessionStmt != nil {
 		if cerr := q.getSessionStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getSessionStmt: %w", cerr)
 		}
 	}
+	if q.getSessionByAccessTokenStmt != nil {
+		if cerr := q.getSessionByAccessTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSessionByAccessTokenStmt: %w", cerr)
+		}
+	}
 	if q.getTransferStmt != nil {
 		if cerr := q.getTransferStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getTransferStmt: %w", cerr)
 		}
 	}
+	if q.getTransferForUpdateStmt != nil {
+		if cerr := q.getTransferForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTransferForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getTransferRequestStmt != nil {
+		if cerr := q.getTransferRequestStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTransferRequestStmt: %w", cerr)
+		}
+	}
+	if q.getTransferRequestForUpdateStmt != nil {
+		if cerr := q.getTransferRequestForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTransferRequestForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getTransferReversalStmt != nil {
+		if cerr := q.getTransferReversalStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTransferReversalStmt: %w", cerr)
+		}
+	}
+	if q.getTransferWithOwnersStmt != nil {
+		if cerr := q.getTransferWithOwnersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getTransferWithOwnersStmt: %w", cerr)
+		}
+	}
 	if q.getUserStmt != nil {
 		if cerr := q.getUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUserStmt: %w", cerr)
 		}
 	}
+	if q.getUserByEmailIndexStmt != nil {
+		if cerr := q.getUserByEmailIndexStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserByEmailIndexStmt: %w", cerr)
+		}
+	}
+	if q.getUserForUpdateStmt != nil {
+		if cerr := q.getUserForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.getVerifyEmailForUpdateStmt != nil {
+		if cerr := q.getVerifyEmailForUpdateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getVerifyEmailForUpdateStmt: %w", cerr)
+		}
+	}
+	if q.listAccountOwnerChangesStmt != nil {
+		if cerr := q.listAccountOwnerChangesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccountOwnerChangesStmt: %w", cerr)
+		}
+	}
+	if q.listAccountTagsStmt != nil {
+		if cerr := q.listAccountTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccountTagsStmt: %w", cerr)
+		}
+	}
 	if q.listAccountsStmt != nil {
 		if cerr := q.listAccountsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listAccountsStmt: %w", cerr)
 		}
 	}
+	if q.listAccountsByTagStmt != nil {
+		if cerr := q.listAccountsByTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listAccountsByTagStmt: %w", cerr)
+		}
+	}
+	if q.listBalanceSnapshotsStmt != nil {
+		if cerr := q.listBalanceSnapshotsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listBalanceSnapshotsStmt: %w", cerr)
+		}
+	}
 	if q.listEntriesStmt != nil {
 		if cerr := q.listEntriesStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listEntriesStmt: %w", cerr)
 		}
 	}
+	if q.listEntriesByAccountAndDateStmt != nil {
+		if cerr := q.listEntriesByAccountAndDateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEntriesByAccountAndDateStmt: %w", cerr)
+		}
+	}
+	if q.listEntriesInRangeStmt != nil {
+		if cerr := q.listEntriesInRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listEntriesInRangeStmt: %w", cerr)
+		}
+	}
+	if q.listOwnerCurrenciesStmt != nil {
+		if cerr := q.listOwnerCurrenciesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOwnerCurrenciesStmt: %w", cerr)
+		}
+	}
+	if q.listOwnerTransfersStmt != nil {
+		if cerr := q.listOwnerTransfersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOwnerTransfersStmt: %w", cerr)
+		}
+	}
+	if q.listSessionsByUserStmt != nil {
+		if cerr := q.listSessionsByUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listSessionsByUserStmt: %w", cerr)
+		}
+	}
+	if q.listStatementAccountsStmt != nil {
+		if cerr := q.listStatementAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listStatementAccountsStmt: %w", cerr)
+		}
+	}
 	if q.listTransfersStmt != nil {
 		if cerr := q.listTransfersStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listTransfersStmt: %w", cerr)
 		}
 	}
+	if q.markPasswordResetUsedStmt != nil {
+		if cerr := q.markPasswordResetUsedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markPasswordResetUsedStmt: %w", cerr)
+		}
+	}
+	if q.markUserEmailVerifiedStmt != nil {
+		if cerr := q.markUserEmailVerifiedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markUserEmailVerifiedStmt: %w", cerr)
+		}
+	}
+	if q.markVerifyEmailUsedStmt != nil {
+		if cerr := q.markVerifyEmailUsedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markVerifyEmailUsedStmt: %w", cerr)
+		}
+	}
+	if q.revokeSessionStmt != nil {
+		if cerr := q.revokeSessionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeSessionStmt: %w", cerr)
+		}
+	}
+	if q.searchAccountsByOwnerStmt != nil {
+		if cerr := q.searchAccountsByOwnerStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchAccountsByOwnerStmt: %w", cerr)
+		}
+	}
+	if q.searchOwnerAccountsStmt != nil {
+		if cerr := q.searchOwnerAccountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchOwnerAccountsStmt: %w", cerr)
+		}
+	}
+	if q.searchOwnerTransfersStmt != nil {
+		if cerr := q.searchOwnerTransfersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchOwnerTransfersStmt: %w", cerr)
+		}
+	}
+	if q.setSessionAccessTokenStmt != nil {
+		if cerr := q.setSessionAccessTokenStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setSessionAccessTokenStmt: %w", cerr)
+		}
+	}
+	if q.setTransferRefundOfStmt != nil {
+		if cerr := q.setTransferRefundOfStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setTransferRefundOfStmt: %w", cerr)
+		}
+	}
+	if q.setTransferReversedOfStmt != nil {
+		if cerr := q.setTransferReversedOfStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setTransferReversedOfStmt: %w", cerr)
+		}
+	}
+	if q.softDeleteAccountStmt != nil {
+		if cerr := q.softDeleteAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing softDeleteAccountStmt: %w", cerr)
+		}
+	}
+	if q.sumEntriesBeforeEntryStmt != nil {
+		if cerr := q.sumEntriesBeforeEntryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumEntriesBeforeEntryStmt: %w", cerr)
+		}
+	}
+	if q.sumEntriesSinceStmt != nil {
+		if cerr := q.sumEntriesSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumEntriesSinceStmt: %w", cerr)
+		}
+	}
+	if q.sumEntriesUntilStmt != nil {
+		if cerr := q.sumEntriesUntilStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumEntriesUntilStmt: %w", cerr)
+		}
+	}
+	if q.sumTransfersSinceStmt != nil {
+		if cerr := q.sumTransfersSinceStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing sumTransfersSinceStmt: %w", cerr)
+		}
+	}
+	if q.summarizeEntriesInRangeStmt != nil {
+		if cerr := q.summarizeEntriesInRangeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing summarizeEntriesInRangeStmt: %w", cerr)
+		}
+	}
+	if q.unfreezeAccountStmt != nil {
+		if cerr := q.unfreezeAccountStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing unfreezeAccountStmt: %w", cerr)
+		}
+	}
 	if q.updateAccountStmt != nil {
 		if cerr := q.updateAccountStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing updateAccountStmt: %w", cerr)
 		}
 	}
+	if q.updateAccountCurrencyStmt != nil {
+		if cerr := q.updateAccountCurrencyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAccountCurrencyStmt: %w", cerr)
+		}
+	}
+	if q.updateAccountOwnerStmt != nil {
+		if cerr := q.updateAccountOwnerStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAccountOwnerStmt: %w", cerr)
+		}
+	}
+	if q.updateAccountStatementsStmt != nil {
+		if cerr := q.updateAccountStatementsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateAccountStatementsStmt: %w", cerr)
+		}
+	}
+	if q.updateBalanceAlertStmt != nil {
+		if cerr := q.updateBalanceAlertStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateBalanceAlertStmt: %w", cerr)
+		}
+	}
+	if q.updateBalanceAlertStateStmt != nil {
+		if cerr := q.updateBalanceAlertStateStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateBalanceAlertStateStmt: %w", cerr)
+		}
+	}
+	if q.updateUserStmt != nil {
+		if cerr := q.updateUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserStmt: %w", cerr)
+		}
+	}
+	if q.updateUserPasswordStmt != nil {
+		if cerr := q.updateUserPasswordStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserPasswordStmt: %w", cerr)
+		}
+	}
+	if q.updateUserPasswordHashStmt != nil {
+		if cerr := q.updateUserPasswordHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserPasswordHashStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -202,47 +730,179 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                      DBTX
-	tx                      *sql.Tx
-	addAccountBalanceStmt   *sql.Stmt
-	createAccountStmt       *sql.Stmt
-	createEntryStmt         *sql.Stmt
-	createSessionStmt       *sql.Stmt
-	createTransferStmt      *sql.Stmt
-	createUserStmt          *sql.Stmt
-	deleteAccountStmt       *sql.Stmt
-	getAccountStmt          *sql.Stmt
-	getAccountForUpdateStmt *sql.Stmt
-	getEntryStmt            *sql.Stmt
-	getSessionStmt          *sql.Stmt
-	getTransferStmt         *sql.Stmt
-	getUserStmt             *sql.Stmt
-	listAccountsStmt        *sql.Stmt
-	listEntriesStmt         *sql.Stmt
-	listTransfersStmt       *sql.Stmt
-	updateAccountStmt       *sql.Stmt
+	db                               DBTX
+	tx                               *sql.Tx
+	acceptTransferRequestStmt        *sql.Stmt
+	addAccountBalanceStmt            *sql.Stmt
+	addAccountBalanceWithVersionStmt *sql.Stmt
+	addAccountTagStmt                *sql.Stmt
+	addTransferRefundedAmountStmt    *sql.Stmt
+	blockUserSessionsStmt            *sql.Stmt
+	countAccountsStmt                *sql.Stmt
+	createAccountStmt                *sql.Stmt
+	createAccountOwnerChangeStmt     *sql.Stmt
+	createBalanceAlertStmt           *sql.Stmt
+	createBalanceSnapshotStmt        *sql.Stmt
+	createEntryStmt                  *sql.Stmt
+	createPasswordResetStmt          *sql.Stmt
+	createSessionStmt                *sql.Stmt
+	createStatementStmt              *sql.Stmt
+	createTransferStmt               *sql.Stmt
+	createTransferRequestStmt        *sql.Stmt
+	createUserStmt                   *sql.Stmt
+	createVerifyEmailStmt            *sql.Stmt
+	declineTransferRequestStmt       *sql.Stmt
+	deleteAccountStmt                *sql.Stmt
+	deleteAccountTagStmt             *sql.Stmt
+	deleteBalanceAlertStmt           *sql.Stmt
+	deleteStatementStmt              *sql.Stmt
+	freezeAccountStmt                *sql.Stmt
+	getAccountStmt                   *sql.Stmt
+	getAccountByOwnerAndCurrencyStmt *sql.Stmt
+	getAccountForUpdateStmt          *sql.Stmt
+	getAccountsByIDsStmt             *sql.Stmt
+	getBalanceAlertStmt              *sql.Stmt
+	getEntryStmt                     *sql.Stmt
+	getPasswordResetForUpdateStmt    *sql.Stmt
+	getSessionStmt                   *sql.Stmt
+	getSessionByAccessTokenStmt      *sql.Stmt
+	getTransferStmt                  *sql.Stmt
+	getTransferForUpdateStmt         *sql.Stmt
+	getTransferRequestStmt           *sql.Stmt
+	getTransferRequestForUpdateStmt  *sql.Stmt
+	getTransferReversalStmt          *sql.Stmt
+	getTransferWithOwnersStmt        *sql.Stmt
+	getUserStmt                      *sql.Stmt
+	getUserByEmailIndexStmt          *sql.Stmt
+	getUserForUpdateStmt             *sql.Stmt
+	getVerifyEmailForUpdateStmt      *sql.Stmt
+	listAccountOwnerChangesStmt      *sql.Stmt
+	listAccountTagsStmt              *sql.Stmt
+	listAccountsStmt                 *sql.Stmt
+	listAccountsByTagStmt            *sql.Stmt
+	listBalanceSnapshotsStmt         *sql.Stmt
+	listEntriesStmt                  *sql.Stmt
+	listEntriesByAccountAndDateStmt  *sql.Stmt
+	listEntriesInRangeStmt           *sql.Stmt
+	listOwnerCurrenciesStmt          *sql.Stmt
+	listOwnerTransfersStmt           *sql.Stmt
+	listSessionsByUserStmt           *sql.Stmt
+	listStatementAccountsStmt        *sql.Stmt
+	listTransfersStmt                *sql.Stmt
+	markPasswordResetUsedStmt        *sql.Stmt
+	markUserEmailVerifiedStmt        *sql.Stmt
+	markVerifyEmailUsedStmt          *sql.Stmt
+	revokeSessionStmt                *sql.Stmt
+	searchAccountsByOwnerStmt        *sql.Stmt
+	searchOwnerAccountsStmt          *sql.Stmt
+	searchOwnerTransfersStmt         *sql.Stmt
+	setSessionAccessTokenStmt        *sql.Stmt
+	setTransferRefundOfStmt          *sql.Stmt
+	setTransferReversedOfStmt        *sql.Stmt
+	softDeleteAccountStmt            *sql.Stmt
+	sumEntriesBeforeEntryStmt        *sql.Stmt
+	sumEntriesSinceStmt              *sql.Stmt
+	sumEntriesUntilStmt              *sql.Stmt
+	sumTransfersSinceStmt            *sql.Stmt
+	summarizeEntriesInRangeStmt      *sql.Stmt
+	unfreezeAccountStmt              *sql.Stmt
+	updateAccountStmt                *sql.Stmt
+	updateAccountCurrencyStmt        *sql.Stmt
+	updateAccountOwnerStmt           *sql.Stmt
+	updateAccountStatementsStmt      *sql.Stmt
+	updateBalanceAlertStmt           *sql.Stmt
+	updateBalanceAlertStateStmt      *sql.Stmt
+	updateUserStmt                   *sql.Stmt
+	updateUserPasswordStmt           *sql.Stmt
+	updateUserPasswordHashStmt       *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                      tx,
-		tx:                      tx,
-		addAccountBalanceStmt:   q.addAccountBalanceStmt,
-		createAccountStmt:       q.createAccountStmt,
-		createEntryStmt:         q.createEntryStmt,
-		createSessionStmt:       q.createSessionStmt,
-		createTransferStmt:      q.createTransferStmt,
-		createUserStmt:          q.createUserStmt,
-		deleteAccountStmt:       q.deleteAccountStmt,
-		getAccountStmt:          q.getAccountStmt,
-		getAccountForUpdateStmt: q.getAccountForUpdateStmt,
-		getEntryStmt:            q.getEntryStmt,
-		getSessionStmt:          q.getSessionStmt,
-		getTransferStmt:         q.getTransferStmt,
-		getUserStmt:             q.getUserStmt,
-		listAccountsStmt:        q.listAccountsStmt,
-		listEntriesStmt:         q.listEntriesStmt,
-		listTransfersStmt:       q.listTransfersStmt,
-		updateAccountStmt:       q.updateAccountStmt,
+		db:                               tx,
+		tx:                               tx,
+		acceptTransferRequestStmt:        q.acceptTransferRequestStmt,
+		addAccountBalanceStmt:            q.addAccountBalanceStmt,
+		addAccountBalanceWithVersionStmt: q.addAccountBalanceWithVersionStmt,
+		addAccountTagStmt:                q.addAccountTagStmt,
+		addTransferRefundedAmountStmt:    q.addTransferRefundedAmountStmt,
+		blockUserSessionsStmt:            q.blockUserSessionsStmt,
+		countAccountsStmt:                q.countAccountsStmt,
+		createAccountStmt:                q.createAccountStmt,
+		createAccountOwnerChangeStmt:     q.createAccountOwnerChangeStmt,
+		createBalanceAlertStmt:           q.createBalanceAlertStmt,
+		createBalanceSnapshotStmt:        q.createBalanceSnapshotStmt,
+		createEntryStmt:                  q.createEntryStmt,
+		createPasswordResetStmt:          q.createPasswordResetStmt,
+		createSessionStmt:                q.createSessionStmt,
+		createStatementStmt:              q.createStatementStmt,
+		createTransferStmt:               q.createTransferStmt,
+		createTransferRequestStmt:        q.createTransferRequestStmt,
+		createUserStmt:                   q.createUserStmt,
+		createVerifyEmailStmt:            q.createVerifyEmailStmt,
+		declineTransferRequestStmt:       q.declineTransferRequestStmt,
+		deleteAccountStmt:                q.deleteAccountStmt,
+		deleteAccountTagStmt:             q.deleteAccountTagStmt,
+		deleteBalanceAlertStmt:           q.deleteBalanceAlertStmt,
+		deleteStatementStmt:              q.deleteStatementStmt,
+		freezeAccountStmt:                q.freezeAccountStmt,
+		getAccountStmt:                   q.getAccountStmt,
+		getAccountByOwnerAndCurrencyStmt: q.getAccountByOwnerAndCurrencyStmt,
+		getAccountForUpdateStmt:          q.getAccountForUpdateStmt,
+		getAccountsByIDsStmt:             q.getAccountsByIDsStmt,
+		getBalanceAlertStmt:              q.getBalanceAlertStmt,
+		getEntryStmt:                     q.getEntryStmt,
+		getPasswordResetForUpdateStmt:    q.getPasswordResetForUpdateStmt,
+		getSessionStmt:                   q.getSessionStmt,
+		getSessionByAccessTokenStmt:      q.getSessionByAccessTokenStmt,
+		getTransferStmt:                  q.getTransferStmt,
+		getTransferForUpdateStmt:         q.getTransferForUpdateStmt,
+		getTransferRequestStmt:           q.getTransferRequestStmt,
+		getTransferRequestForUpdateStmt:  q.getTransferRequestForUpdateStmt,
+		getTransferReversalStmt:          q.getTransferReversalStmt,
+		getTransferWithOwnersStmt:        q.getTransferWithOwnersStmt,
+		getUserStmt:                      q.getUserStmt,
+		getUserByEmailIndexStmt:          q.getUserByEmailIndexStmt,
+		getUserForUpdateStmt:             q.getUserForUpdateStmt,
+		getVerifyEmailForUpdateStmt:      q.getVerifyEmailForUpdateStmt,
+		listAccountOwnerChangesStmt:      q.listAccountOwnerChangesStmt,
+		listAccountTagsStmt:              q.listAccountTagsStmt,
+		listAccountsStmt:                 q.listAccountsStmt,
+		listAccountsByTagStmt:            q.listAccountsByTagStmt,
+		listBalanceSnapshotsStmt:         q.listBalanceSnapshotsStmt,
+		listEntriesStmt:                  q.listEntriesStmt,
+		listEntriesByAccountAndDateStmt:  q.listEntriesByAccountAndDateStmt,
+		listEntriesInRangeStmt:           q.listEntriesInRangeStmt,
+		listOwnerCurrenciesStmt:          q.listOwnerCurrenciesStmt,
+		listOwnerTransfersStmt:           q.listOwnerTransfersStmt,
+		listSessionsByUserStmt:           q.listSessionsByUserStmt,
+		listStatementAccountsStmt:        q.listStatementAccountsStmt,
+		listTransfersStmt:                q.listTransfersStmt,
+		markPasswordResetUsedStmt:        q.markPasswordResetUsedStmt,
+		markUserEmailVerifiedStmt:        q.markUserEmailVerifiedStmt,
+		markVerifyEmailUsedStmt:          q.markVerifyEmailUsedStmt,
+		revokeSessionStmt:                q.revokeSessionStmt,
+		searchAccountsByOwnerStmt:        q.searchAccountsByOwnerStmt,
+		searchOwnerAccountsStmt:          q.searchOwnerAccountsStmt,
+		searchOwnerTransfersStmt:         q.searchOwnerTransfersStmt,
+		setSessionAccessTokenStmt:        q.setSessionAccessTokenStmt,
+		setTransferRefundOfStmt:          q.setTransferRefundOfStmt,
+		setTransferReversedOfStmt:        q.setTransferReversedOfStmt,
+		softDeleteAccountStmt:            q.softDeleteAccountStmt,
+		sumEntriesBeforeEntryStmt:        q.sumEntriesBeforeEntryStmt,
+		sumEntriesSinceStmt:              q.sumEntriesSinceStmt,
+		sumEntriesUntilStmt:              q.sumEntriesUntilStmt,
+		sumTransfersSinceStmt:            q.sumTransfersSinceStmt,
+		summarizeEntriesInRangeStmt:      q.summarizeEntriesInRangeStmt,
+		unfreezeAccountStmt:              q.unfreezeAccountStmt,
+		updateAccountStmt:                q.updateAccountStmt,
+		updateAccountCurrencyStmt:        q.updateAccountCurrencyStmt,
+		updateAccountOwnerStmt:           q.updateAccountOwnerStmt,
+		updateAccountStatementsStmt:      q.updateAccountStatementsStmt,
+		updateBalanceAlertStmt:           q.updateBalanceAlertStmt,
+		updateBalanceAlertStateStmt:      q.updateBalanceAlertStateStmt,
+		updateUserStmt:                   q.updateUserStmt,
+		updateUserPasswordStmt:           q.updateUserPasswordStmt,
+		updateUserPasswordHashStmt:       q.updateUserPasswordHashStmt,
 	}
 }
@@ -0,0 +1,24 @@
+package db
+
+// Entry directions derived from the sign of the amount
+const (
+	EntryDebit  = "debit"
+	EntryCredit = "credit"
+)
+
+// Direction reports whether the entry takes money out of or into the account.
+// Amounts are stored signed: negative for debits, positive for credits.
+func (entry Entry) Direction() string {
+	if entry.Amount < 0 {
+		return EntryDebit
+	}
+	return EntryCredit
+}
+
+// AbsAmount returns the unsigned amount of the entry
+func (entry Entry) AbsAmount() int64 {
+	if entry.Amount < 0 {
+		return -entry.Amount
+	}
+	return entry.Amount
+}
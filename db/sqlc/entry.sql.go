@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
@@ -55,7 +56,7 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 const listEntries = `-- name: ListEntries :many
 SELECT id, account_id, amount, created_at FROM entries
 WHERE account_id = $1
-ORDER BY id
+ORDER BY created_at DESC, id DESC
 LIMIT $2
 OFFSET $3
 `
@@ -93,3 +94,186 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 	}
 	return items, nil
 }
+
+const listEntriesByAccountAndDate = `-- name: ListEntriesByAccountAndDate :many
+SELECT id, account_id, amount, created_at FROM entries
+WHERE account_id = $1
+  AND created_at >= $2
+  AND created_at < $3
+ORDER BY created_at, id
+LIMIT $5
+OFFSET $4
+`
+
+type ListEntriesByAccountAndDateParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+	Offset    int32     `json:"offset"`
+	Limit     int32     `json:"limit"`
+}
+
+func (q *Queries) ListEntriesByAccountAndDate(ctx context.Context, arg ListEntriesByAccountAndDateParams) ([]Entry, error) {
+	rows, err := q.query(ctx, q.listEntriesByAccountAndDateStmt, listEntriesByAccountAndDate,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntriesInRange = `-- name: ListEntriesInRange :many
+SELECT id, account_id, amount, created_at FROM entries
+WHERE account_id = $1
+  AND created_at >= $2
+  AND created_at < $3
+ORDER BY id
+`
+
+type ListEntriesInRangeParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+}
+
+func (q *Queries) ListEntriesInRange(ctx context.Context, arg ListEntriesInRangeParams) ([]Entry, error) {
+	rows, err := q.query(ctx, q.listEntriesInRangeStmt, listEntriesInRange, arg.AccountID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sumEntriesBeforeEntry = `-- name: SumEntriesBeforeEntry :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = $1
+  AND (created_at < $2
+    OR (created_at = $2 AND id < $3))
+`
+
+type SumEntriesBeforeEntryParams struct {
+	AccountID int64     `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+	ID        int64     `json:"id"`
+}
+
+func (q *Queries) SumEntriesBeforeEntry(ctx context.Context, arg SumEntriesBeforeEntryParams) (int64, error) {
+	row := q.queryRow(ctx, q.sumEntriesBeforeEntryStmt, sumEntriesBeforeEntry, arg.AccountID, arg.CreatedAt, arg.ID)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const sumEntriesSince = `-- name: SumEntriesSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = $1
+  AND created_at >= $2
+`
+
+type SumEntriesSinceParams struct {
+	AccountID int64     `json:"account_id"`
+	Since     time.Time `json:"since"`
+}
+
+func (q *Queries) SumEntriesSince(ctx context.Context, arg SumEntriesSinceParams) (int64, error) {
+	row := q.queryRow(ctx, q.sumEntriesSinceStmt, sumEntriesSince, arg.AccountID, arg.Since)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const sumEntriesUntil = `-- name: SumEntriesUntil :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM entries
+WHERE account_id = $1
+  AND created_at <= $2
+`
+
+type SumEntriesUntilParams struct {
+	AccountID int64     `json:"account_id"`
+	Until     time.Time `json:"until"`
+}
+
+func (q *Queries) SumEntriesUntil(ctx context.Context, arg SumEntriesUntilParams) (int64, error) {
+	row := q.queryRow(ctx, q.sumEntriesUntilStmt, sumEntriesUntil, arg.AccountID, arg.Until)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
+
+const summarizeEntriesInRange = `-- name: SummarizeEntriesInRange :one
+SELECT
+  COALESCE(SUM(-amount) FILTER (WHERE amount < 0), 0)::bigint AS total_debit,
+  COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0)::bigint AS total_credit,
+  COUNT(*) AS entry_count
+FROM entries
+WHERE account_id = $1
+  AND created_at >= $2
+  AND created_at < $3
+`
+
+type SummarizeEntriesInRangeParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+}
+
+type SummarizeEntriesInRangeRow struct {
+	TotalDebit  int64 `json:"total_debit"`
+	TotalCredit int64 `json:"total_credit"`
+	EntryCount  int64 `json:"entry_count"`
+}
+
+func (q *Queries) SummarizeEntriesInRange(ctx context.Context, arg SummarizeEntriesInRangeParams) (SummarizeEntriesInRangeRow, error) {
+	row := q.queryRow(ctx, q.summarizeEntriesInRangeStmt, summarizeEntriesInRange, arg.AccountID, arg.FromTime, arg.ToTime)
+	var i SummarizeEntriesInRangeRow
+	err := row.Scan(&i.TotalDebit, &i.TotalCredit, &i.EntryCount)
+	return i, err
+}
@@ -66,8 +66,117 @@ func TestListEntries(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, entries, 5)
 
-	for _, entry := range entries {
+	for i, entry := range entries {
 		require.NotEmpty(t, entry)
 		require.Equal(t, arg.AccountID, entry.AccountID)
+
+		//Newest first
+		if i > 0 {
+			require.Less(t, entry.ID, entries[i-1].ID)
+		}
+	}
+}
+
+// TestEntryDirection verifies direction and unsigned amount follow the sign
+func TestEntryDirection(t *testing.T) {
+	debit := Entry{Amount: -50}
+	require.Equal(t, EntryDebit, debit.Direction())
+	require.Equal(t, int64(50), debit.AbsAmount())
+
+	credit := Entry{Amount: 50}
+	require.Equal(t, EntryCredit, credit.Direction())
+	require.Equal(t, int64(50), credit.AbsAmount())
+}
+
+// TestSumEntriesUntil tests that only entries booked up to the cutoff are summed
+func TestSumEntriesUntil(t *testing.T) {
+	account := createRandomAccount(t)
+
+	entries := make([]Entry, 3)
+	for i := range entries {
+		entries[i] = createRandomEntry(t, account)
 	}
+
+	//Before the first entry nothing was booked
+	total, err := testQueries.SumEntriesUntil(context.Background(), SumEntriesUntilParams{
+		AccountID: account.ID,
+		Until:     entries[0].CreatedAt.Add(-time.Microsecond),
+	})
+	require.NoError(t, err)
+	require.Zero(t, total)
+
+	//Each cutoff includes the entries booked at or before it
+	var expected int64
+	for _, entry := range entries {
+		expected += entry.Amount
+
+		total, err := testQueries.SumEntriesUntil(context.Background(), SumEntriesUntilParams{
+			AccountID: account.ID,
+			Until:     entry.CreatedAt,
+		})
+		require.NoError(t, err)
+		require.Equal(t, expected, total)
+	}
+}
+
+// TestListEntriesByAccountAndDate tests range filtering, running sums and the range summary
+func TestListEntriesByAccountAndDate(t *testing.T) {
+	account := createRandomAccount(t)
+
+	entries := make([]Entry, 3)
+	for i := range entries {
+		entries[i] = createRandomEntry(t, account)
+	}
+	from := entries[0].CreatedAt
+	to := entries[len(entries)-1].CreatedAt.Add(time.Microsecond)
+
+	listed, err := testQueries.ListEntriesByAccountAndDate(context.Background(), ListEntriesByAccountAndDateParams{
+		AccountID: account.ID,
+		FromTime:  from,
+		ToTime:    to,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Len(t, listed, len(entries))
+
+	var debit, credit, before int64
+	for i, entry := range listed {
+		require.Equal(t, entries[i].ID, entry.ID)
+
+		sum, err := testQueries.SumEntriesBeforeEntry(context.Background(), SumEntriesBeforeEntryParams{
+			AccountID: account.ID,
+			CreatedAt: entry.CreatedAt,
+			ID:        entry.ID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, before, sum)
+		before += entry.Amount
+
+		if entry.Amount < 0 {
+			debit -= entry.Amount
+		} else {
+			credit += entry.Amount
+		}
+	}
+
+	summary, err := testQueries.SummarizeEntriesInRange(context.Background(), SummarizeEntriesInRangeParams{
+		AccountID: account.ID,
+		FromTime:  from,
+		ToTime:    to,
+	})
+	require.NoError(t, err)
+	require.Equal(t, debit, summary.TotalDebit)
+	require.Equal(t, credit, summary.TotalCredit)
+	require.Equal(t, int64(len(entries)), summary.EntryCount)
+
+	//A range ending before the first entry is empty
+	listed, err = testQueries.ListEntriesByAccountAndDate(context.Background(), ListEntriesByAccountAndDateParams{
+		AccountID: account.ID,
+		FromTime:  from.Add(-time.Hour),
+		ToTime:    from,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, listed)
 }
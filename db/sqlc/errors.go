@@ -0,0 +1,70 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error code names the store translates into sentinels
+const (
+	UniqueViolation     = "unique_violation"
+	ForeignKeyViolation = "foreign_key_violation"
+)
+
+// Unique constraints on users, reported by ConstraintName
+const (
+	UsersUsernameConstraint   = "users_pkey"
+	UsersEmailConstraint      = "users_email_key"
+	UsersEmailIndexConstraint = "email_blind_index_key"
+)
+
+// Sentinel errors returned by the store, so callers need no driver types
+var (
+	ErrRecordNotFound      = sql.ErrNoRows
+	ErrUniqueViolation     = errors.New("unique violation")
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+)
+
+// constraintError marks a driver error with its sentinel. It keeps the
+// driver's message and still unwraps to the *pq.Error.
+type constraintError struct {
+	sentinel error
+	err      error
+}
+
+func (e *constraintError) Error() string { return e.err.Error() }
+
+func (e *constraintError) Unwrap() []error { return []error{e.sentinel, e.err} }
+
+// ErrorCode returns the Postgres error code name behind err, or an empty
+// string when err did not come from Postgres
+func ErrorCode(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code.Name()
+	}
+	return ""
+}
+
+// ConstraintName returns the name of the constraint behind err, or an empty
+// string when err did not come from Postgres or names no constraint
+func ConstraintName(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Constraint
+	}
+	return ""
+}
+
+// translateError maps constraint violations to the store's sentinel errors
+func translateError(err error) error {
+	switch ErrorCode(err) {
+	case UniqueViolation:
+		return &constraintError{sentinel: ErrUniqueViolation, err: err}
+	case ForeignKeyViolation:
+		return &constraintError{sentinel: ErrForeignKeyViolation, err: err}
+	}
+	return err
+}
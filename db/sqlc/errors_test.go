@@ -0,0 +1,55 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		sentinel error
+		code     string
+	}{
+		{name: "Nil", err: nil},
+		{name: "NotFound", err: ErrRecordNotFound, sentinel: ErrRecordNotFound},
+		{name: "UniqueViolation", err: &pq.Error{Code: "23505", Message: "duplicate key"}, sentinel: ErrUniqueViolation, code: UniqueViolation},
+		{name: "ForeignKeyViolation", err: &pq.Error{Code: "23503", Message: "missing owner"}, sentinel: ErrForeignKeyViolation, code: ForeignKeyViolation},
+		{name: "OtherDriverError", err: &pq.Error{Code: "23514", Message: "check failed"}, code: "check_violation"},
+		{name: "OtherError", err: errors.New("boom")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := translateError(tc.err)
+			require.Equal(t, tc.code, ErrorCode(err))
+
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			//The driver's message and error stay reachable
+			require.EqualError(t, err, tc.err.Error())
+			require.ErrorIs(t, err, tc.err)
+			if tc.sentinel != nil {
+				require.ErrorIs(t, err, tc.sentinel)
+			}
+			if tc.sentinel != ErrUniqueViolation {
+				require.NotErrorIs(t, err, ErrUniqueViolation)
+			}
+		})
+	}
+}
+
+func TestConstraintName(t *testing.T) {
+	err := translateError(&pq.Error{Code: "23505", Constraint: UsersEmailConstraint})
+	require.Equal(t, UsersEmailConstraint, ConstraintName(err))
+	require.Equal(t, UsersUsernameConstraint, ConstraintName(&pq.Error{Code: "23505", Constraint: "users_pkey"}))
+	require.Empty(t, ConstraintName(ErrUniqueViolation))
+	require.Empty(t, ConstraintName(nil))
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// newStubStore creates a store over the stub driver, whose transactions
+// always begin and commit
+func newStubStore(t *testing.T, options StoreOptions) *SQLStore {
+	conn, err := sql.Open("stub", "up")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	options.TxRetryBackoff = time.Millisecond
+	return NewStoreWithOptions(conn, options).(*SQLStore)
+}
+
+// TestExecTxRetriesSerializationFailure verifies an aborted transaction is run again
+func TestExecTxRetriesSerializationFailure(t *testing.T) {
+	store := newStubStore(t, StoreOptions{})
+
+	var runs int
+	err := store.execTx(context.Background(), nil, func(q *Queries) error {
+		runs++
+		if runs == 1 {
+			return &pq.Error{Code: "40001"}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, runs)
+}
+
+// TestExecTxRetriesConcurrentModification verifies a lost optimistic update is run again
+func TestExecTxRetriesConcurrentModification(t *testing.T) {
+	store := newStubStore(t, StoreOptions{})
+
+	var runs int
+	err := store.execTx(context.Background(), nil, func(q *Queries) error {
+		runs++
+		if runs == 1 {
+			return ErrConcurrentModification
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, runs)
+}
+
+// TestExecTxRetryLimit verifies retries stop at the configured number of attempts
+func TestExecTxRetryLimit(t *testing.T) {
+	store := newStubStore(t, StoreOptions{MaxTxAttempts: 3})
+
+	var runs int
+	err := store.execTx(context.Background(), nil, func(q *Queries) error {
+		runs++
+		return &pq.Error{Code: "40P01"}
+	})
+	require.Equal(t, 3, runs)
+
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	require.Equal(t, "deadlock_detected", pqErr.Code.Name())
+}
+
+// TestExecTxNoRetry verifies other errors are returned after a single run
+func TestExecTxNoRetry(t *testing.T) {
+	store := newStubStore(t, StoreOptions{})
+
+	var runs int
+	err := store.execTx(context.Background(), nil, func(q *Queries) error {
+		runs++
+		return ErrInsufficientFunds
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	require.Equal(t, 1, runs)
+}
+
+// TestExecTxRetryCanceled verifies a canceled context stops further retries
+func TestExecTxRetryCanceled(t *testing.T) {
+	store := newStubStore(t, StoreOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var runs int
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		runs++
+		cancel()
+		return &pq.Error{Code: "40001"}
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1, runs)
+}
+
+// TestExecTxCanceledRollsBack verifies work done for a caller that went away is rolled back
+func TestExecTxCanceledRollsBack(t *testing.T) {
+	store := newStubStore(t, StoreOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	commits, rollbacks := stubCommits.Load(), stubRollbacks.Load()
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		cancel()
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, commits, stubCommits.Load())
+
+	//database/sql may be the one rolling back, from its own goroutine
+	require.Eventually(t, func() bool {
+		return stubRollbacks.Load() == rollbacks+1
+	}, time.Second, time.Millisecond)
+
+	//An already canceled context does not begin a transaction at all
+	var runs int
+	err = store.execTx(ctx, nil, func(q *Queries) error {
+		runs++
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Zero(t, runs)
+}
@@ -5,16 +5,58 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Account struct {
+	ID                int64      `json:"id"`
+	Owner             string     `json:"owner"`
+	Balance           int64      `json:"balance"`
+	Currency          string     `json:"currency"`
+	CreatedAt         time.Time  `json:"created_at"`
+	StatementsEnabled bool       `json:"statements_enabled"`
+	Nickname          string     `json:"nickname"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	DeletedAt         *time.Time `json:"deleted_at"`
+	Version           int32      `json:"version"`
+	// active or frozen
+	Status string `json:"status"`
+}
+
+type AccountOwnerChange struct {
+	ID            int64     `json:"id"`
+	AccountID     int64     `json:"account_id"`
+	PreviousOwner string    `json:"previous_owner"`
+	NewOwner      string    `json:"new_owner"`
+	ChangedBy     string    `json:"changed_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type AccountTag struct {
+	AccountID int64     `json:"account_id"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BalanceAlert struct {
+	ID            int64         `json:"id"`
+	AccountID     int64         `json:"account_id"`
+	LowThreshold  sql.NullInt64 `json:"low_threshold"`
+	HighThreshold sql.NullInt64 `json:"high_threshold"`
+	LowTriggered  bool          `json:"low_triggered"`
+	HighTriggered bool          `json:"high_triggered"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// account balance after each transfer, written in the same transaction
+type BalanceSnapshot struct {
 	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
+	AccountID int64     `json:"account_id"`
 	Balance   int64     `json:"balance"`
-	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -26,15 +68,33 @@ type Entry struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type PasswordReset struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	TokenHash string    `json:"token_hash"`
+	IsUsed    bool      `json:"is_used"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 type Session struct {
-	ID           uuid.UUID `json:"id"`
-	Username     string    `json:"username"`
-	RefreshToken string    `json:"refresh_token"`
-	UserAgent    string    `json:"user_agent"`
-	ClientIp     string    `json:"client_ip"`
-	IsBlocked    bool      `json:"is_blocked"`
-	ExpiresAt    time.Time `json:"expires_at"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID            uuid.UUID     `json:"id"`
+	Username      string        `json:"username"`
+	RefreshToken  string        `json:"refresh_token"`
+	UserAgent     string        `json:"user_agent"`
+	ClientIp      string        `json:"client_ip"`
+	IsBlocked     bool          `json:"is_blocked"`
+	ExpiresAt     time.Time     `json:"expires_at"`
+	CreatedAt     time.Time     `json:"created_at"`
+	AccessTokenID uuid.NullUUID `json:"access_token_id"`
+}
+
+type Statement struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type Transfer struct {
@@ -42,15 +102,52 @@ type Transfer struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	// must be positive
-	Amount    int64     `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount          int64         `json:"amount"`
+	CreatedAt       time.Time     `json:"created_at"`
+	RefundedAmount  int64         `json:"refunded_amount"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	Memo            string        `json:"memo"`
+	ConvertedAmount int64         `json:"converted_amount"`
+	ReversedOf      sql.NullInt64 `json:"reversed_of"`
+	RoundingMode    string        `json:"rounding_mode"`
+	RefundOf        sql.NullInt64 `json:"refund_of"`
+}
+
+type TransferRequest struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	Amount        int64 `json:"amount"`
+	// pending, accepted or declined; pending requests past expires_at are expired
+	Status     string        `json:"status"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
 }
 
 type User struct {
-	Username          string    `json:"username"`
-	HashedPassword    string    `json:"hashed_password"`
-	FullName          string    `json:"full_name"`
-	Email             string    `json:"email"`
-	PasswordChangedAt time.Time `json:"password_changed_at"`
-	CreatedAt         time.Time `json:"created_at"`
+	Username              string         `json:"username"`
+	HashedPassword        string         `json:"hashed_password"`
+	FullName              string         `json:"full_name"`
+	Email                 string         `json:"email"`
+	PasswordChangedAt     time.Time      `json:"password_changed_at"`
+	CreatedAt             time.Time      `json:"created_at"`
+	PasswordPepperVersion int32          `json:"password_pepper_version"`
+	EmailKeyVersion       int32          `json:"email_key_version"`
+	EmailBlindIndex       sql.NullString `json:"email_blind_index"`
+	IsEmailVerified       bool           `json:"is_email_verified"`
+	DefaultCurrency       sql.NullString `json:"default_currency"`
+	Role                  string         `json:"role"`
+}
+
+type VerifyEmail struct {
+	ID              int64     `json:"id"`
+	Username        string    `json:"username"`
+	Email           string    `json:"email"`
+	EmailKeyVersion int32     `json:"email_key_version"`
+	SecretCode      string    `json:"secret_code"`
+	IsUsed          bool      `json:"is_used"`
+	CreatedAt       time.Time `json:"created_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
 }
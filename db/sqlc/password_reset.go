@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Errors returned when a password reset token can no longer be used
+var (
+	ErrPasswordResetUsed    = errors.New("password reset token has already been used")
+	ErrPasswordResetExpired = errors.New("password reset token has expired")
+)
+
+// HashResetToken returns the stored form of a password reset token
+func HashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckUsable reports why the token cannot reset the password at the given time, if it can't
+func (reset PasswordReset) CheckUsable(now time.Time) error {
+	if reset.IsUsed {
+		return ErrPasswordResetUsed
+	}
+	if !now.Before(reset.ExpiresAt) {
+		return ErrPasswordResetExpired
+	}
+	return nil
+}
+
+// Password reset input parameters
+type ResetPasswordTxParams struct {
+	Token                 string `json:"token"`
+	HashedPassword        string `json:"hashed_password"`
+	PasswordPepperVersion int32  `json:"password_pepper_version"`
+}
+
+// Password reset result data
+type ResetPasswordTxResult struct {
+	User          User          `json:"user"`
+	PasswordReset PasswordReset `json:"password_reset"`
+}
+
+// ResetPasswordTx uses up a reset token and sets its user's new password.
+// An unknown token is reported as ErrRecordNotFound.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		//Lock the token so it can only be used once
+		reset, err := q.GetPasswordResetForUpdate(ctx, HashResetToken(arg.Token))
+		if err != nil {
+			return err
+		}
+		if err := reset.CheckUsable(time.Now()); err != nil {
+			return err
+		}
+
+		result.PasswordReset, err = q.MarkPasswordResetUsed(ctx, reset.ID)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			Username:              reset.Username,
+			HashedPassword:        arg.HashedPassword,
+			PasswordPepperVersion: arg.PasswordPepperVersion,
+		})
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	result.User, err = store.decryptUser(result.User)
+	return result, err
+}
@@ -0,0 +1,82 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: password_reset.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createPasswordReset = `-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+    username,
+    token_hash,
+    expires_at
+) VALUES (
+    $1, $2, $3
+) RETURNING id, username, token_hash, is_used, created_at, expires_at
+`
+
+type CreatePasswordResetParams struct {
+	Username  string    `json:"username"`
+	TokenHash string    `json:"token_hash"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error) {
+	row := q.queryRow(ctx, q.createPasswordResetStmt, createPasswordReset, arg.Username, arg.TokenHash, arg.ExpiresAt)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getPasswordResetForUpdate = `-- name: GetPasswordResetForUpdate :one
+SELECT id, username, token_hash, is_used, created_at, expires_at FROM password_resets
+WHERE token_hash = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetPasswordResetForUpdate(ctx context.Context, tokenHash string) (PasswordReset, error) {
+	row := q.queryRow(ctx, q.getPasswordResetForUpdateStmt, getPasswordResetForUpdate, tokenHash)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const markPasswordResetUsed = `-- name: MarkPasswordResetUsed :one
+UPDATE password_resets
+SET is_used = TRUE
+WHERE id = $1
+RETURNING id, username, token_hash, is_used, created_at, expires_at
+`
+
+func (q *Queries) MarkPasswordResetUsed(ctx context.Context, id int64) (PasswordReset, error) {
+	row := q.queryRow(ctx, q.markPasswordResetUsedStmt, markPasswordResetUsed, id)
+	var i PasswordReset
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.TokenHash,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
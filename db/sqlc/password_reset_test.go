@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomPasswordReset creates a reset token for a new user and returns it in plain text
+func createRandomPasswordReset(t *testing.T, expiresAt time.Time) (User, string) {
+	user := createRandomUser(t)
+	token := util.RandomString(32)
+
+	reset, err := testQueries.CreatePasswordReset(context.Background(), CreatePasswordResetParams{
+		Username:  user.Username,
+		TokenHash: HashResetToken(token),
+		ExpiresAt: expiresAt,
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, token, reset.TokenHash)
+	require.False(t, reset.IsUsed)
+
+	return user, token
+}
+
+// TestResetPasswordTx tests a token sets the new password only once
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user, token := createRandomPasswordReset(t, time.Now().Add(time.Minute))
+
+	hashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+
+	arg := ResetPasswordTxParams{Token: token, HashedPassword: hashedPassword}
+	result, err := store.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.PasswordReset.IsUsed)
+	require.Equal(t, user.Username, result.User.Username)
+	require.Equal(t, user.Email, result.User.Email)
+	require.Equal(t, hashedPassword, result.User.HashedPassword)
+	require.True(t, result.User.PasswordChangedAt.After(user.PasswordChangedAt))
+
+	//The token cannot be used again
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrPasswordResetUsed)
+}
+
+// TestResetPasswordTxExpired tests expired tokens keep the old password
+func TestResetPasswordTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user, token := createRandomPasswordReset(t, time.Now().Add(-time.Minute))
+
+	_, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{Token: token, HashedPassword: "new-hash"})
+	require.ErrorIs(t, err, ErrPasswordResetExpired)
+
+	unchanged, err := store.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.HashedPassword, unchanged.HashedPassword)
+}
+
+// TestResetPasswordTxUnknownToken tests tokens that were never issued
+func TestResetPasswordTxUnknownToken(t *testing.T) {
+	store := NewStore(testDB)
+
+	_, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{Token: util.RandomString(32), HashedPassword: "new-hash"})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+// TestPasswordResetCheckUsable tests the reasons a token can't be used
+func TestPasswordResetCheckUsable(t *testing.T) {
+	now := time.Now()
+
+	require.NoError(t, PasswordReset{ExpiresAt: now.Add(time.Minute)}.CheckUsable(now))
+	require.ErrorIs(t, PasswordReset{ExpiresAt: now}.CheckUsable(now), ErrPasswordResetExpired)
+	require.ErrorIs(t, PasswordReset{IsUsed: true, ExpiresAt: now.Add(time.Minute)}.CheckUsable(now), ErrPasswordResetUsed)
+}
@@ -11,23 +11,89 @@ import (
 )
 
 type Querier interface {
+	AcceptTransferRequest(ctx context.Context, arg AcceptTransferRequestParams) (TransferRequest, error)
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddAccountBalanceWithVersion(ctx context.Context, arg AddAccountBalanceWithVersionParams) (Account, error)
+	AddAccountTag(ctx context.Context, arg AddAccountTagParams) error
+	AddTransferRefundedAmount(ctx context.Context, arg AddTransferRefundedAmountParams) (Transfer, error)
+	BlockUserSessions(ctx context.Context, username string) (int64, error)
+	CountAccounts(ctx context.Context) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountOwnerChange(ctx context.Context, arg CreateAccountOwnerChangeParams) (AccountOwnerChange, error)
+	CreateBalanceAlert(ctx context.Context, arg CreateBalanceAlertParams) (BalanceAlert, error)
+	CreateBalanceSnapshot(ctx context.Context, arg CreateBalanceSnapshotParams) (BalanceSnapshot, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateStatement(ctx context.Context, arg CreateStatementParams) (Statement, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferRequest(ctx context.Context, arg CreateTransferRequestParams) (TransferRequest, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	DeclineTransferRequest(ctx context.Context, id int64) (TransferRequest, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteAccountTag(ctx context.Context, arg DeleteAccountTagParams) error
+	DeleteBalanceAlert(ctx context.Context, accountID int64) error
+	DeleteStatement(ctx context.Context, id int64) error
+	FreezeAccount(ctx context.Context, id int64) (Account, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountsByIDs(ctx context.Context, arg GetAccountsByIDsParams) ([]Account, error)
+	GetBalanceAlert(ctx context.Context, accountID int64) (BalanceAlert, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetPasswordResetForUpdate(ctx context.Context, tokenHash string) (PasswordReset, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetSessionByAccessToken(ctx context.Context, accessTokenID uuid.NullUUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
+	GetTransferRequest(ctx context.Context, id int64) (TransferRequest, error)
+	GetTransferRequestForUpdate(ctx context.Context, id int64) (TransferRequest, error)
+	GetTransferReversal(ctx context.Context, transferID int64) (Transfer, error)
+	GetTransferWithOwners(ctx context.Context, id int64) (GetTransferWithOwnersRow, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmailIndex(ctx context.Context, arg GetUserByEmailIndexParams) (User, error)
+	GetUserForUpdate(ctx context.Context, username string) (User, error)
+	GetVerifyEmailForUpdate(ctx context.Context, id int64) (VerifyEmail, error)
+	ListAccountOwnerChanges(ctx context.Context, accountID int64) ([]AccountOwnerChange, error)
+	ListAccountTags(ctx context.Context, accountID int64) ([]string, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsByTag(ctx context.Context, arg ListAccountsByTagParams) ([]Account, error)
+	ListBalanceSnapshots(ctx context.Context, arg ListBalanceSnapshotsParams) ([]BalanceSnapshot, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListEntriesByAccountAndDate(ctx context.Context, arg ListEntriesByAccountAndDateParams) ([]Entry, error)
+	ListEntriesInRange(ctx context.Context, arg ListEntriesInRangeParams) ([]Entry, error)
+	ListOwnerCurrencies(ctx context.Context, owner string) ([]ListOwnerCurrenciesRow, error)
+	ListOwnerTransfers(ctx context.Context, arg ListOwnerTransfersParams) ([]ListOwnerTransfersRow, error)
+	ListSessionsByUser(ctx context.Context, username string) ([]Session, error)
+	ListStatementAccounts(ctx context.Context) ([]Account, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	MarkPasswordResetUsed(ctx context.Context, id int64) (PasswordReset, error)
+	MarkUserEmailVerified(ctx context.Context, username string) (User, error)
+	MarkVerifyEmailUsed(ctx context.Context, id int64) (VerifyEmail, error)
+	RevokeSession(ctx context.Context, id uuid.UUID) (int64, error)
+	SearchAccountsByOwner(ctx context.Context, arg SearchAccountsByOwnerParams) ([]Account, error)
+	SearchOwnerAccounts(ctx context.Context, arg SearchOwnerAccountsParams) ([]Account, error)
+	SearchOwnerTransfers(ctx context.Context, arg SearchOwnerTransfersParams) ([]SearchOwnerTransfersRow, error)
+	SetSessionAccessToken(ctx context.Context, arg SetSessionAccessTokenParams) error
+	SetTransferRefundOf(ctx context.Context, arg SetTransferRefundOfParams) (Transfer, error)
+	SetTransferReversedOf(ctx context.Context, arg SetTransferReversedOfParams) (Transfer, error)
+	SoftDeleteAccount(ctx context.Context, id int64) (Account, error)
+	SumEntriesBeforeEntry(ctx context.Context, arg SumEntriesBeforeEntryParams) (int64, error)
+	SumEntriesSince(ctx context.Context, arg SumEntriesSinceParams) (int64, error)
+	SumEntriesUntil(ctx context.Context, arg SumEntriesUntilParams) (int64, error)
+	SumTransfersSince(ctx context.Context, arg SumTransfersSinceParams) (int64, error)
+	SummarizeEntriesInRange(ctx context.Context, arg SummarizeEntriesInRangeParams) (SummarizeEntriesInRangeRow, error)
+	UnfreezeAccount(ctx context.Context, id int64) (Account, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountCurrency(ctx context.Context, arg UpdateAccountCurrencyParams) (Account, error)
+	UpdateAccountOwner(ctx context.Context, arg UpdateAccountOwnerParams) (Account, error)
+	UpdateAccountStatements(ctx context.Context, arg UpdateAccountStatementsParams) (Account, error)
+	UpdateBalanceAlert(ctx context.Context, arg UpdateBalanceAlertParams) (BalanceAlert, error)
+	UpdateBalanceAlertState(ctx context.Context, arg UpdateBalanceAlertStateParams) (BalanceAlert, error)
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	UpdateUserPasswordHash(ctx context.Context, arg UpdateUserPasswordHashParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
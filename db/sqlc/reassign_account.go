@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Errors returned when an account cannot be reassigned
+var (
+	ErrNewOwnerNotFound   = errors.New("new owner does not exist")
+	ErrOwnerUnchanged     = errors.New("account already belongs to the new owner")
+	ErrNewOwnerHasAccount = errors.New("new owner already has an account in this currency")
+)
+
+// Account reassignment input parameters
+type ReassignAccountTxParams struct {
+	AccountID int64  `json:"account_id"`
+	NewOwner  string `json:"new_owner"`
+	ChangedBy string `json:"changed_by"`
+}
+
+// Account reassignment result data
+type ReassignAccountTxResult struct {
+	Account     Account            `json:"account"`
+	OwnerChange AccountOwnerChange `json:"owner_change"`
+}
+
+// ReassignAccountTx moves an account to another existing user and records the
+// change in the audit trail
+func (store *SQLStore) ReassignAccountTx(ctx context.Context, arg ReassignAccountTxParams) (ReassignAccountTxResult, error) {
+	var result ReassignAccountTxResult
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		//The new owner must be an existing user
+		if _, err := q.GetUser(ctx, arg.NewOwner); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNewOwnerNotFound
+			}
+			return err
+		}
+
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if account.Owner == arg.NewOwner {
+			return ErrOwnerUnchanged
+		}
+
+		//Keep the new owner to one account per currency and nickname
+		_, err = q.GetAccountByOwnerAndCurrency(ctx, GetAccountByOwnerAndCurrencyParams{
+			Owner:    arg.NewOwner,
+			Currency: account.Currency,
+			Nickname: account.Nickname,
+		})
+		if err == nil {
+			return ErrNewOwnerHasAccount
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		result.Account, err = q.UpdateAccountOwner(ctx, UpdateAccountOwnerParams{
+			ID:    arg.AccountID,
+			Owner: arg.NewOwner,
+		})
+		if err != nil {
+			//A concurrent insert may still win the unique constraint
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+				return ErrNewOwnerHasAccount
+			}
+			return err
+		}
+
+		result.OwnerChange, err = q.CreateAccountOwnerChange(ctx, CreateAccountOwnerChangeParams{
+			AccountID:     arg.AccountID,
+			PreviousOwner: account.Owner,
+			NewOwner:      arg.NewOwner,
+			ChangedBy:     arg.ChangedBy,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestReassignAccountTx tests moving an account to another user with an audit entry
+func TestReassignAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	newOwner := createRandomUser(t)
+	admin := createRandomUser(t)
+
+	result, err := store.ReassignAccountTx(context.Background(), ReassignAccountTxParams{
+		AccountID: account.ID,
+		NewOwner:  newOwner.Username,
+		ChangedBy: admin.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, newOwner.Username, result.Account.Owner)
+	require.Equal(t, account.Balance, result.Account.Balance)
+
+	//Audit entry records both owners and the admin
+	require.Equal(t, account.ID, result.OwnerChange.AccountID)
+	require.Equal(t, account.Owner, result.OwnerChange.PreviousOwner)
+	require.Equal(t, newOwner.Username, result.OwnerChange.NewOwner)
+	require.Equal(t, admin.Username, result.OwnerChange.ChangedBy)
+
+	changes, err := testQueries.ListAccountOwnerChanges(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, result.OwnerChange, changes[0])
+}
+
+// TestReassignAccountTxNewOwnerNotFound tests rejecting a nonexistent target user
+func TestReassignAccountTxNewOwnerNotFound(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	_, err := store.ReassignAccountTx(context.Background(), ReassignAccountTxParams{
+		AccountID: account.ID,
+		NewOwner:  "missing" + account.Owner,
+		ChangedBy: account.Owner,
+	})
+	require.ErrorIs(t, err, ErrNewOwnerNotFound)
+
+	unchanged, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Owner, unchanged.Owner)
+}
+
+// TestReassignAccountTxConflict tests rejecting a target that already has the currency
+func TestReassignAccountTxConflict(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	target := createRandomUser(t)
+
+	//Give the target an account in the same currency
+	_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    target.Username,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ReassignAccountTx(context.Background(), ReassignAccountTxParams{
+		AccountID: account.ID,
+		NewOwner:  target.Username,
+		ChangedBy: account.Owner,
+	})
+	require.ErrorIs(t, err, ErrNewOwnerHasAccount)
+
+	changes, err := testQueries.ListAccountOwnerChanges(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
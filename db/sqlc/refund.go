@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/big"
+)
+
+// ErrRefundExceedsRemainder is returned when a refund is larger than the part
+// of the original transfer that has not been refunded yet
+var ErrRefundExceedsRemainder = errors.New("refund exceeds the refundable remainder of the transfer")
+
+// Refund transaction input parameters
+type RefundTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	Amount     int64 `json:"amount"`
+}
+
+// Refund transaction result data
+type RefundTransferTxResult struct {
+	TransferTxResult
+
+	//Original transfer with its updated refunded amount
+	OriginalTransfer Transfer `json:"original_transfer"`
+}
+
+// RefundTransferTx sends part of a transfer back to its sender and records the
+// refunded amount on the original transfer
+func (store *SQLStore) RefundTransferTx(ctx context.Context, arg RefundTransferTxParams) (RefundTransferTxResult, error) {
+	var result RefundTransferTxResult
+	opts := &sql.TxOptions{Isolation: store.options.TransferIsolation}
+
+	//Execute refund in a transaction, retried if Postgres aborts it
+	err := store.execTx(ctx, opts, func(q *Queries) error {
+		//Lock the original transfer so concurrent refunds see each other
+		original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+		if arg.Amount > original.Amount-original.RefundedAmount {
+			return ErrRefundExceedsRemainder
+		}
+
+		//Move the money back from the recipient to the sender
+		result.TransferTxResult, err = runTransfer(ctx, q, TransferTxParams{
+			FromAccountID:   original.ToAccountID,
+			ToAccountID:     original.FromAccountID,
+			Amount:          refundDebit(original, arg.Amount),
+			ConvertedAmount: arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		//Link the refund to the transfer it refunds
+		result.Transfer, err = q.SetTransferRefundOf(ctx, SetTransferRefundOfParams{
+			ID:       result.Transfer.ID,
+			RefundOf: sql.NullInt64{Int64: original.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		//Track the cumulative refund on the original transfer
+		result.OriginalTransfer, err = q.AddTransferRefundedAmount(ctx, AddTransferRefundedAmountParams{
+			ID:     arg.TransferID,
+			Amount: arg.Amount,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// refundDebit returns what the recipient gives back for a refund in the
+// sender's currency. Converted transfers are debited in proportion to what the
+// recipient received, rounded up so a refund never creates money. The product
+// is taken in big integers since it can overflow int64 even though the debit,
+// at most the converted amount, cannot.
+func refundDebit(original Transfer, amount int64) int64 {
+	debit := new(big.Int).Mul(big.NewInt(amount), big.NewInt(original.ConvertedAmount))
+	debit.Add(debit, big.NewInt(original.Amount-1))
+	return debit.Quo(debit, big.NewInt(original.Amount)).Int64()
+}
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestRefundTransferTx tests partial refunds up to the transfer amount
+func TestRefundTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	transferred, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	//Partial refund sends money back in the reverse direction
+	result, err := store.RefundTransferTx(context.Background(), RefundTransferTxParams{
+		TransferID: transferred.Transfer.ID,
+		Amount:     40,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account2.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, result.Transfer.ToAccountID)
+	require.Equal(t, int64(40), result.Transfer.Amount)
+	require.Equal(t, int64(40), result.OriginalTransfer.RefundedAmount)
+	require.Equal(t, transferred.ToAccount.Balance-40, result.FromAccount.Balance)
+	require.Equal(t, transferred.FromAccount.Balance+40, result.ToAccount.Balance)
+
+	//Second refund may use up the remainder
+	result, err = store.RefundTransferTx(context.Background(), RefundTransferTxParams{
+		TransferID: transferred.Transfer.ID,
+		Amount:     60,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), result.OriginalTransfer.RefundedAmount)
+
+	//Nothing is left to refund
+	_, err = store.RefundTransferTx(context.Background(), RefundTransferTxParams{
+		TransferID: transferred.Transfer.ID,
+		Amount:     1,
+	})
+	require.ErrorIs(t, err, ErrRefundExceedsRemainder)
+
+	//Balances are back where they started
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+// TestRefundTransferTxInsufficientFunds tests a refund the recipient cannot cover
+func TestRefundTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	transferred, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	//Recipient spends everything it holds
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        transferred.ToAccount.Balance,
+	})
+	require.NoError(t, err)
+
+	_, err = store.RefundTransferTx(context.Background(), RefundTransferTxParams{
+		TransferID: transferred.Transfer.ID,
+		Amount:     10,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	//Rolled back refund leaves the original untouched
+	original, err := testQueries.GetTransfer(context.Background(), transferred.Transfer.ID)
+	require.NoError(t, err)
+	require.Zero(t, original.RefundedAmount)
+}
+
+// TestRefundTransferTxConverted tests refunding a converted transfer
+func TestRefundTransferTxConverted(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	transferred, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:   account1.ID,
+		ToAccountID:     account2.ID,
+		Amount:          100,
+		ConvertedAmount: 250,
+	})
+	require.NoError(t, err)
+
+	//The recipient gives back its share of the converted amount
+	result, err := store.RefundTransferTx(context.Background(), RefundTransferTxParams{
+		TransferID: transferred.Transfer.ID,
+		Amount:     40,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), result.Transfer.Amount)
+	require.Equal(t, int64(40), result.Transfer.ConvertedAmount)
+	require.Equal(t, transferred.ToAccount.Balance-100, result.FromAccount.Balance)
+	require.Equal(t, transferred.FromAccount.Balance+40, result.ToAccount.Balance)
+	require.Equal(t, int64(40), result.OriginalTransfer.RefundedAmount)
+}
+
+// TestRefundDebit tests the recipient's share of a refund
+func TestRefundDebit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		original Transfer
+		amount   int64
+		debit    int64
+	}{
+		{name: "SameCurrency", original: Transfer{Amount: 100, ConvertedAmount: 100}, amount: 40, debit: 40},
+		{name: "Converted", original: Transfer{Amount: 100, ConvertedAmount: 250}, amount: 40, debit: 100},
+		{name: "RoundsUp", original: Transfer{Amount: 3, ConvertedAmount: 1}, amount: 1, debit: 1},
+		{name: "LargeAmounts", original: Transfer{Amount: math.MaxInt64, ConvertedAmount: math.MaxInt64 / 2}, amount: math.MaxInt64, debit: math.MaxInt64 / 2},
+		{name: "LargeProductRoundsUp", original: Transfer{Amount: math.MaxInt64 - 1, ConvertedAmount: math.MaxInt64}, amount: 2, debit: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.debit, refundDebit(tc.original, tc.amount))
+		})
+	}
+}
+
+// TestRefundTransferTxFrozenAccount tests that a refund cannot move money into a frozen account
+func TestRefundTransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	transferred, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	_, err = testQueries.FreezeAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+
+	_, err = store.RefundTransferTx(context.Background(), RefundTransferTxParams{
+		TransferID: transferred.Transfer.ID,
+		Amount:     10,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	//Nothing was refunded
+	original, err := testQueries.GetTransfer(context.Background(), transferred.Transfer.ID)
+	require.NoError(t, err)
+	require.Zero(t, original.RefundedAmount)
+
+	updated, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, transferred.ToAccount.Balance, updated.Balance)
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrTransferAlreadyReversed is returned when a transfer has been reversed before
+var ErrTransferAlreadyReversed = errors.New("transfer has already been reversed")
+
+// Reversal transaction result data
+type ReverseTransferTxResult struct {
+	TransferTxResult
+
+	//Original transfer, now fully refunded
+	OriginalTransfer Transfer `json:"original_transfer"`
+}
+
+// ReverseTransferTx undoes a transfer with an offsetting transfer that sends
+// back whatever has not been refunded yet. The original is marked fully
+// refunded, so later refunds or reversals can't pay it back twice.
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, transferID int64) (ReverseTransferTxResult, error) {
+	var result ReverseTransferTxResult
+	opts := &sql.TxOptions{Isolation: store.options.TransferIsolation}
+
+	//Execute reversal in a transaction, retried if Postgres aborts it
+	err := store.execTx(ctx, opts, func(q *Queries) error {
+		//Lock the original transfer so concurrent reversals and refunds see each other
+		original, err := q.GetTransferForUpdate(ctx, transferID)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.GetTransferReversal(ctx, transferID)
+		if err == nil {
+			return ErrTransferAlreadyReversed
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		remainder := original.Amount - original.RefundedAmount
+		if remainder <= 0 {
+			return ErrRefundExceedsRemainder
+		}
+
+		//Move the money back from the recipient to the sender
+		result.TransferTxResult, err = runTransfer(ctx, q, TransferTxParams{
+			FromAccountID:   original.ToAccountID,
+			ToAccountID:     original.FromAccountID,
+			Amount:          refundDebit(original, remainder),
+			ConvertedAmount: remainder,
+			Memo:            fmt.Sprintf("Reversal of transfer %d", original.ID),
+		})
+		if err != nil {
+			return err
+		}
+
+		//Link the offsetting transfer to the one it reverses
+		result.Transfer, err = q.SetTransferReversedOf(ctx, SetTransferReversedOfParams{
+			ID:         result.Transfer.ID,
+			ReversedOf: sql.NullInt64{Int64: original.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.OriginalTransfer, err = q.AddTransferRefundedAmount(ctx, AddTransferRefundedAmountParams{
+			ID:     original.ID,
+			Amount: remainder,
+		})
+		return err
+	})
+
+	return result, err
+}
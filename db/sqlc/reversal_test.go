@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestReverseTransferTx tests a reversal restores balances and can only happen once
+func TestReverseTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	transferred, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	result, err := store.ReverseTransferTx(context.Background(), transferred.Transfer.ID)
+	require.NoError(t, err)
+
+	//The offsetting transfer runs the other way and points at the original
+	require.Equal(t, account2.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, result.Transfer.ToAccountID)
+	require.Equal(t, int64(100), result.Transfer.Amount)
+	require.True(t, result.Transfer.ReversedOf.Valid)
+	require.Equal(t, transferred.Transfer.ID, result.Transfer.ReversedOf.Int64)
+	require.Equal(t, int64(-100), result.FromEntry.Amount)
+	require.Equal(t, int64(100), result.ToEntry.Amount)
+	require.Equal(t, int64(100), result.OriginalTransfer.RefundedAmount)
+
+	//Balances are back where they started
+	require.Equal(t, account2.Balance, result.FromAccount.Balance)
+	require.Equal(t, account1.Balance, result.ToAccount.Balance)
+
+	//A second reversal is rejected
+	_, err = store.ReverseTransferTx(context.Background(), transferred.Transfer.ID)
+	require.ErrorIs(t, err, ErrTransferAlreadyReversed)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+// TestReverseTransferTxAfterRefund tests only the unrefunded part is reversed
+func TestReverseTransferTxAfterRefund(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	transferred, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+	})
+	require.NoError(t, err)
+
+	_, err = store.RefundTransferTx(context.Background(), RefundTransferTxParams{
+		TransferID: transferred.Transfer.ID,
+		Amount:     30,
+	})
+	require.NoError(t, err)
+
+	result, err := store.ReverseTransferTx(context.Background(), transferred.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(70), result.Transfer.Amount)
+	require.Equal(t, account1.Balance, result.ToAccount.Balance)
+	require.Equal(t, account2.Balance, result.FromAccount.Balance)
+
+	//Nothing is left to refund afterwards
+	_, err = store.RefundTransferTx(context.Background(), RefundTransferTxParams{
+		TransferID: transferred.Transfer.ID,
+		Amount:     1,
+	})
+	require.ErrorIs(t, err, ErrRefundExceedsRemainder)
+}
+
+// TestReverseTransferTxInsufficientFunds tests a reversal the recipient cannot cover
+func TestReverseTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	transferred, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	//Recipient spends everything it holds
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        transferred.ToAccount.Balance,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ReverseTransferTx(context.Background(), transferred.Transfer.ID)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	//Rolled back reversal leaves no trace
+	_, err = testQueries.GetTransferReversal(context.Background(), transferred.Transfer.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	original, err := testQueries.GetTransfer(context.Background(), transferred.Transfer.ID)
+	require.NoError(t, err)
+	require.Zero(t, original.RefundedAmount)
+}
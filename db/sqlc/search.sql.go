@@ -0,0 +1,191 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: search.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const searchAccountsByOwner = `-- name: SearchAccountsByOwner :many
+SELECT id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status FROM accounts
+WHERE owner ILIKE $1::text
+  AND deleted_at IS NULL
+ORDER BY owner, id
+LIMIT $3
+OFFSET $2
+`
+
+type SearchAccountsByOwnerParams struct {
+	Pattern string `json:"pattern"`
+	Offset  int32  `json:"offset"`
+	Limit   int32  `json:"limit"`
+}
+
+func (q *Queries) SearchAccountsByOwner(ctx context.Context, arg SearchAccountsByOwnerParams) ([]Account, error) {
+	rows, err := q.query(ctx, q.searchAccountsByOwnerStmt, searchAccountsByOwner, arg.Pattern, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.StatementsEnabled,
+			&i.Nickname,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchOwnerAccounts = `-- name: SearchOwnerAccounts :many
+SELECT id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status FROM accounts
+WHERE owner = $1
+  AND deleted_at IS NULL
+  AND (
+    lower(nickname) LIKE $2::text
+    OR lower(currency) LIKE $2::text
+    OR id::text LIKE $2::text
+  )
+ORDER BY id
+LIMIT $3
+`
+
+type SearchOwnerAccountsParams struct {
+	Owner  string `json:"owner"`
+	Prefix string `json:"prefix"`
+	Limit  int32  `json:"limit"`
+}
+
+func (q *Queries) SearchOwnerAccounts(ctx context.Context, arg SearchOwnerAccountsParams) ([]Account, error) {
+	rows, err := q.query(ctx, q.searchOwnerAccountsStmt, searchOwnerAccounts, arg.Owner, arg.Prefix, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.StatementsEnabled,
+			&i.Nickname,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchOwnerTransfers = `-- name: SearchOwnerTransfers :many
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.refunded_amount, t.updated_at, t.memo, t.converted_amount, t.reversed_of, t.rounding_mode, t.refund_of, fa.owner AS from_owner, ta.owner AS to_owner
+FROM transfers t
+JOIN accounts fa ON fa.id = t.from_account_id
+JOIN accounts ta ON ta.id = t.to_account_id
+WHERE (fa.owner = $1 OR ta.owner = $1)
+  AND (
+    lower(t.memo) LIKE $2::text
+    OR t.id::text LIKE $2::text
+  )
+ORDER BY t.created_at DESC, t.id DESC
+LIMIT $3
+`
+
+type SearchOwnerTransfersParams struct {
+	Owner  string `json:"owner"`
+	Prefix string `json:"prefix"`
+	Limit  int32  `json:"limit"`
+}
+
+type SearchOwnerTransfersRow struct {
+	ID              int64         `json:"id"`
+	FromAccountID   int64         `json:"from_account_id"`
+	ToAccountID     int64         `json:"to_account_id"`
+	Amount          int64         `json:"amount"`
+	CreatedAt       time.Time     `json:"created_at"`
+	RefundedAmount  int64         `json:"refunded_amount"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	Memo            string        `json:"memo"`
+	ConvertedAmount int64         `json:"converted_amount"`
+	ReversedOf      sql.NullInt64 `json:"reversed_of"`
+	RoundingMode    string        `json:"rounding_mode"`
+	RefundOf        sql.NullInt64 `json:"refund_of"`
+	FromOwner       string        `json:"from_owner"`
+	ToOwner         string        `json:"to_owner"`
+}
+
+func (q *Queries) SearchOwnerTransfers(ctx context.Context, arg SearchOwnerTransfersParams) ([]SearchOwnerTransfersRow, error) {
+	rows, err := q.query(ctx, q.searchOwnerTransfersStmt, searchOwnerTransfers, arg.Owner, arg.Prefix, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchOwnerTransfersRow{}
+	for rows.Next() {
+		var i SearchOwnerTransfersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.RefundedAmount,
+			&i.UpdatedAt,
+			&i.Memo,
+			&i.ConvertedAmount,
+			&i.ReversedOf,
+			&i.RoundingMode,
+			&i.RefundOf,
+			&i.FromOwner,
+			&i.ToOwner,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSearchOwner tests that searches match nicknames and memos of the owner only
+func TestSearchOwner(t *testing.T) {
+	prefix := strings.ToLower(util.RandomString(8))
+	user := createRandomUser(t)
+	other := createRandomUser(t)
+
+	account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+		Nickname: prefix + " savings",
+	})
+	require.NoError(t, err)
+	otherAccount, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    other.Username,
+		Currency: util.USD,
+		Nickname: prefix + " savings",
+	})
+	require.NoError(t, err)
+
+	transfer, err := testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: account.ID,
+		ToAccountID:   otherAccount.ID,
+		Amount:        10,
+		Memo:          prefix + " dinner",
+	})
+	require.NoError(t, err)
+
+	//Another user's transfer with a matching memo between their own accounts
+	otherSecond, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    other.Username,
+		Currency: util.EUR,
+	})
+	require.NoError(t, err)
+	_, err = testQueries.CreateTransfer(context.Background(), CreateTransferParams{
+		FromAccountID: otherAccount.ID,
+		ToAccountID:   otherSecond.ID,
+		Amount:        10,
+		Memo:          prefix + " private",
+	})
+	require.NoError(t, err)
+
+	accounts, err := testQueries.SearchOwnerAccounts(context.Background(), SearchOwnerAccountsParams{
+		Owner:  user.Username,
+		Prefix: prefix + "%",
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	transfers, err := testQueries.SearchOwnerTransfers(context.Background(), SearchOwnerTransfersParams{
+		Owner:  user.Username,
+		Prefix: prefix + "%",
+		Limit:  10,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, transfer.ID, transfers[0].ID)
+	require.Equal(t, transfer.Memo, transfers[0].Memo)
+}
+
+// TestSearchAccountsByOwner tests a case-insensitive, paginated owner substring search
+func TestSearchAccountsByOwner(t *testing.T) {
+	user := createRandomUser(t)
+	other := createRandomUser(t)
+
+	var accounts []Account
+	for _, currency := range []string{util.USD, util.EUR, util.KES} {
+		account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    user.Username,
+			Currency: currency,
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+	_, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    other.Username,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	//The owner's name in another case still matches, one page at a time
+	pattern := "%" + strings.ToUpper(user.Username) + "%"
+	page1, err := testQueries.SearchAccountsByOwner(context.Background(), SearchAccountsByOwnerParams{
+		Pattern: pattern,
+		Limit:   2,
+	})
+	require.NoError(t, err)
+	page2, err := testQueries.SearchAccountsByOwner(context.Background(), SearchAccountsByOwnerParams{
+		Pattern: pattern,
+		Limit:   2,
+		Offset:  2,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, page1, 2)
+	require.Len(t, page2, 1)
+	require.Equal(t, accounts[0].ID, page1[0].ID)
+	require.Equal(t, accounts[1].ID, page1[1].ID)
+	require.Equal(t, accounts[2].ID, page2[0].ID)
+
+	//No owner matches an escaped wildcard
+	none, err := testQueries.SearchAccountsByOwner(context.Background(), SearchAccountsByOwnerParams{
+		Pattern: `%\%%`,
+		Limit:   5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, none)
+}
@@ -12,6 +12,20 @@ import (
 	"github.com/google/uuid"
 )
 
+const blockUserSessions = `-- name: BlockUserSessions :execrows
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE username = $1 AND is_blocked = FALSE
+`
+
+func (q *Queries) BlockUserSessions(ctx context.Context, username string) (int64, error) {
+	result, err := q.exec(ctx, q.blockUserSessionsStmt, blockUserSessions, username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createSession = `-- name: CreateSession :one
 INSERT INTO sessions (
     id,
@@ -20,20 +34,22 @@ INSERT INTO sessions (
     user_agent,
     client_ip,
     is_blocked,
-    expires_at
+    expires_at,
+    access_token_id
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7
-) RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, access_token_id
 `
 
 type CreateSessionParams struct {
-	ID           uuid.UUID `json:"id"`
-	Username     string    `json:"username"`
-	RefreshToken string    `json:"refresh_token"`
-	UserAgent    string    `json:"user_agent"`
-	ClientIp     string    `json:"client_ip"`
-	IsBlocked    bool      `json:"is_blocked"`
-	ExpiresAt    time.Time `json:"expires_at"`
+	ID            uuid.UUID     `json:"id"`
+	Username      string        `json:"username"`
+	RefreshToken  string        `json:"refresh_token"`
+	UserAgent     string        `json:"user_agent"`
+	ClientIp      string        `json:"client_ip"`
+	IsBlocked     bool          `json:"is_blocked"`
+	ExpiresAt     time.Time     `json:"expires_at"`
+	AccessTokenID uuid.NullUUID `json:"access_token_id"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
@@ -45,6 +61,7 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		arg.ClientIp,
 		arg.IsBlocked,
 		arg.ExpiresAt,
+		arg.AccessTokenID,
 	)
 	var i Session
 	err := row.Scan(
@@ -56,12 +73,13 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.IsBlocked,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.AccessTokenID,
 	)
 	return i, err
 }
 
 const getSession = `-- name: GetSession :one
-SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at FROM sessions
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, access_token_id FROM sessions
 WHERE id = $1
 LIMIT 1
 `
@@ -78,6 +96,101 @@ func (q *Queries) GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 		&i.IsBlocked,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.AccessTokenID,
 	)
 	return i, err
 }
+
+const getSessionByAccessToken = `-- name: GetSessionByAccessToken :one
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, access_token_id FROM sessions
+WHERE access_token_id = $1
+LIMIT 1
+`
+
+func (q *Queries) GetSessionByAccessToken(ctx context.Context, accessTokenID uuid.NullUUID) (Session, error) {
+	row := q.queryRow(ctx, q.getSessionByAccessTokenStmt, getSessionByAccessToken, accessTokenID)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.RefreshToken,
+		&i.UserAgent,
+		&i.ClientIp,
+		&i.IsBlocked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.AccessTokenID,
+	)
+	return i, err
+}
+
+const listSessionsByUser = `-- name: ListSessionsByUser :many
+SELECT id, username, refresh_token, user_agent, client_ip, is_blocked, expires_at, created_at, access_token_id FROM sessions
+WHERE username = $1
+  AND is_blocked = FALSE
+  AND expires_at > now()
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListSessionsByUser(ctx context.Context, username string) ([]Session, error) {
+	rows, err := q.query(ctx, q.listSessionsByUserStmt, listSessionsByUser, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Session{}
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.RefreshToken,
+			&i.UserAgent,
+			&i.ClientIp,
+			&i.IsBlocked,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.AccessTokenID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeSession = `-- name: RevokeSession :execrows
+UPDATE sessions
+SET is_blocked = TRUE
+WHERE id = $1 AND is_blocked = FALSE
+`
+
+func (q *Queries) RevokeSession(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.exec(ctx, q.revokeSessionStmt, revokeSession, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setSessionAccessToken = `-- name: SetSessionAccessToken :exec
+UPDATE sessions
+SET access_token_id = $1
+WHERE id = $2
+`
+
+type SetSessionAccessTokenParams struct {
+	AccessTokenID uuid.NullUUID `json:"access_token_id"`
+	ID            uuid.UUID     `json:"id"`
+}
+
+func (q *Queries) SetSessionAccessToken(ctx context.Context, arg SetSessionAccessTokenParams) error {
+	_, err := q.exec(ctx, q.setSessionAccessTokenStmt, setSessionAccessToken, arg.AccessTokenID, arg.ID)
+	return err
+}
@@ -0,0 +1,126 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: statement.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createStatement = `-- name: CreateStatement :one
+INSERT INTO statements (
+  account_id,
+  period_start,
+  period_end
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (account_id, period_start) DO NOTHING
+RETURNING id, account_id, period_start, period_end, created_at
+`
+
+type CreateStatementParams struct {
+	AccountID   int64     `json:"account_id"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
+
+func (q *Queries) CreateStatement(ctx context.Context, arg CreateStatementParams) (Statement, error) {
+	row := q.queryRow(ctx, q.createStatementStmt, createStatement, arg.AccountID, arg.PeriodStart, arg.PeriodEnd)
+	var i Statement
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.PeriodStart,
+		&i.PeriodEnd,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteStatement = `-- name: DeleteStatement :exec
+DELETE FROM statements
+WHERE id = $1
+`
+
+func (q *Queries) DeleteStatement(ctx context.Context, id int64) error {
+	_, err := q.exec(ctx, q.deleteStatementStmt, deleteStatement, id)
+	return err
+}
+
+const listStatementAccounts = `-- name: ListStatementAccounts :many
+SELECT id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status FROM accounts
+WHERE statements_enabled = true AND deleted_at IS NULL
+ORDER BY id
+`
+
+func (q *Queries) ListStatementAccounts(ctx context.Context) ([]Account, error) {
+	rows, err := q.query(ctx, q.listStatementAccountsStmt, listStatementAccounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.StatementsEnabled,
+			&i.Nickname,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Version,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateAccountStatements = `-- name: UpdateAccountStatements :one
+UPDATE accounts
+SET statements_enabled = $2,
+    version = version + 1,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, statements_enabled, nickname, updated_at, deleted_at, version, status
+`
+
+type UpdateAccountStatementsParams struct {
+	ID                int64 `json:"id"`
+	StatementsEnabled bool  `json:"statements_enabled"`
+}
+
+func (q *Queries) UpdateAccountStatements(ctx context.Context, arg UpdateAccountStatementsParams) (Account, error) {
+	row := q.queryRow(ctx, q.updateAccountStatementsStmt, updateAccountStatements, arg.ID, arg.StatementsEnabled)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.StatementsEnabled,
+		&i.Nickname,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Version,
+		&i.Status,
+	)
+	return i, err
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateStatementOnce verifies a statement is recorded once per account and period
+func TestCreateStatementOnce(t *testing.T) {
+	account := createRandomAccount(t)
+
+	arg := CreateStatementParams{
+		AccountID:   account.ID,
+		PeriodStart: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	statement, err := testQueries.CreateStatement(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, statement.AccountID)
+	require.WithinDuration(t, arg.PeriodStart, statement.PeriodStart, time.Second)
+
+	//Second insert for the same period is a no-op
+	_, err = testQueries.CreateStatement(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+// TestListStatementAccounts verifies only enabled accounts are listed
+func TestListStatementAccounts(t *testing.T) {
+	account := createRandomAccount(t)
+
+	updated, err := testQueries.UpdateAccountStatements(context.Background(), UpdateAccountStatementsParams{
+		ID:                account.ID,
+		StatementsEnabled: true,
+	})
+	require.NoError(t, err)
+	require.True(t, updated.StatementsEnabled)
+
+	accounts, err := testQueries.ListStatementAccounts(context.Background())
+	require.NoError(t, err)
+
+	found := false
+	for _, a := range accounts {
+		require.True(t, a.StatementsEnabled)
+		if a.ID == account.ID {
+			found = true
+		}
+	}
+	require.True(t, found)
+}
@@ -3,51 +3,219 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// ErrMinBalancePrecondition is returned when a transfer would leave the source
+// account below the minimum balance requested by the caller
+var ErrMinBalancePrecondition = errors.New("transfer would leave source balance below the requested minimum")
+
+// ErrInsufficientFunds is returned when the source account cannot cover the amount
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// ErrAccountFrozen is returned when money would move out of or into a frozen account
+var ErrAccountFrozen = errors.New("account is frozen")
+
+// ErrDailyTransferLimit is returned when a transfer would take what the source
+// account sent over the limit window past its limit
+var ErrDailyTransferLimit = errors.New("daily transfer limit exceeded")
+
+// ErrConcurrentModification is returned when an account changed between being
+// read and being updated under optimistic locking
+var ErrConcurrentModification = errors.New("account was modified concurrently")
+
 // Store interface for DB operations and transactions
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	RefundTransferTx(ctx context.Context, arg RefundTransferTxParams) (RefundTransferTxResult, error)
+	ReverseTransferTx(ctx context.Context, transferID int64) (ReverseTransferTxResult, error)
+	BatchTransferTx(ctx context.Context, arg BatchTransferTxParams) (BatchTransferTxResult, error)
+	ReassignAccountTx(ctx context.Context, arg ReassignAccountTxParams) (ReassignAccountTxResult, error)
+	CreateAccountWithFundingTx(ctx context.Context, arg CreateAccountWithFundingTxParams) (CreateAccountWithFundingTxResult, error)
+	AcceptTransferRequestTx(ctx context.Context, arg AcceptTransferRequestTxParams) (AcceptTransferRequestTxResult, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	CreateUserTx(ctx context.Context, arg CreateUserParams) (CreateUserTxResult, error)
+	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	Ping(ctx context.Context) error
 }
 
 // SQLStore implements Store with transaction support
 type SQLStore struct {
 	*Queries
-	db *sql.DB
+	db      *sql.DB
+	options StoreOptions
+
+	//wrapTx, when set by tests, wraps the transaction each attempt queries
+	wrapTx func(DBTX) DBTX
+}
+
+// StoreOptions tunes how the store runs its transactions
+type StoreOptions struct {
+	//TransferIsolation is the isolation level used by TransferTx and the other
+	//money-moving transactions. At read committed, the Postgres default, the
+	//row locks taken in account id order keep concurrent transfers from
+	//deadlocking. Serializable also rules out anomalies between the locked
+	//reads, at the cost of serialization failures that execTx retries.
+	TransferIsolation sql.IsolationLevel
+
+	//FieldEncryptor encrypts user emails at rest, nil stores them in plaintext
+	FieldEncryptor *util.FieldEncryptor
+
+	//MaxTxAttempts bounds how often a transaction aborted by a serialization
+	//failure or deadlock is run, zero selects defaultMaxTxAttempts
+	MaxTxAttempts int
+
+	//TxRetryBackoff is the wait before the first retry, doubled after each
+	//further attempt, zero selects defaultTxRetryBackoff
+	TxRetryBackoff time.Duration
+
+	//OptimisticTransfers makes TransferTx read accounts without row locks and
+	//update them only if their version is unchanged, retrying on conflict
+	OptimisticTransfers bool
+}
+
+// pingTimeout bounds how long Ping waits for the database
+const pingTimeout = 2 * time.Second
+
+// Ping checks the database answers a trivial query
+func (store *SQLStore) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	var one int
+	return store.db.QueryRowContext(ctx, "SELECT 1").Scan(&one)
 }
 
 // Create a new SQLStore
 func NewStore(db *sql.DB) Store {
+	return NewStoreWithOptions(db, StoreOptions{})
+}
+
+// NewStoreWithOptions creates a new SQLStore with custom transaction options
+func NewStoreWithOptions(db *sql.DB, options StoreOptions) Store {
 	return &SQLStore{
 		db:      db,
 		Queries: New(db),
+		options: options,
+	}
+}
+
+// ParseIsolationLevel maps a configured isolation name to its sql level.
+// An empty name selects the database default.
+func ParseIsolationLevel(name string) (sql.IsolationLevel, error) {
+	switch name {
+	case "", "default":
+		return sql.LevelDefault, nil
+	case "read_committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable_read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	}
+	return sql.LevelDefault, fmt.Errorf("unsupported isolation level %q", name)
+}
+
+// Transaction retry settings used when none are configured
+const (
+	defaultMaxTxAttempts  = 5
+	defaultTxRetryBackoff = 10 * time.Millisecond
+)
+
+// isRetryableTxError reports whether Postgres aborted the transaction due to a
+// serialization failure or deadlock, or an optimistic update lost a race, in
+// which case it is safe to run again
+func isRetryableTxError(err error) bool {
+	if errors.Is(err, ErrConcurrentModification) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "serialization_failure", "deadlock_detected":
+			return true
+		}
+	}
+	return false
+}
+
+// Execute a function within a database transaction. Transactions aborted by a
+// serialization failure or deadlock are run again from the start with backoff,
+// so fn must only keep state it fully rewrites on each run.
+func (store *SQLStore) execTx(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) (err error) {
+	ctx, span := startSpan(ctx, "execTx")
+	defer func() { endSpan(span, err) }()
+
+	attempts := store.options.MaxTxAttempts
+	if attempts <= 0 {
+		attempts = defaultMaxTxAttempts
+	}
+	backoff := store.options.TxRetryBackoff
+	if backoff <= 0 {
+		backoff = defaultTxRetryBackoff
+	}
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		span.SetAttributes(attribute.Int("db.tx.attempt", attempt))
+		err = store.runTx(ctx, opts, fn)
+		if err == nil || !isRetryableTxError(err) || attempt == attempts {
+			break
+		}
+
+		//Wait before retrying, unless the caller gives up first
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff << (attempt - 1)):
+		}
 	}
+	return err
 }
 
-// Execute a function within a database transaction
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+// runTx runs a single attempt of a transaction. It is rolled back instead of
+// committed once ctx is done, even if fn itself succeeded.
+func (store *SQLStore) runTx(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+	//Don't start work for a caller that already gave up
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	//Begin transaction
-	tx, err := store.db.BeginTx(ctx, nil)
+	tx, err := store.db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
 
 	//Use transaction-bound queries
-	q := New(tx)
+	var dbtx DBTX = tx
+	if store.wrapTx != nil {
+		dbtx = store.wrapTx(tx)
+	}
+	q := New(dbtx)
 	err = fn(q)
+	if err == nil {
+		err = ctx.Err()
+	}
 	if err != nil {
 
-		//Rollback on failure
-		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("tx err: %v, rb err: %v", err, rbErr)
+		//Rollback on failure, database/sql already rolled back canceled transactions
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return fmt.Errorf("tx err: %w, rb err: %v", translateError(err), rbErr)
 		}
-		return err
+		return translateError(err)
 	}
 
 	//Commit transaction
-	return tx.Commit()
+	return translateError(tx.Commit())
 }
 
 // Transfer transaction input parameters
@@ -55,6 +223,23 @@ type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	Amount        int64 `json:"amount"`
+
+	//Free-text note shown to both parties
+	Memo string `json:"memo"`
+
+	//Amount credited in the destination currency, zero when no conversion applies
+	ConvertedAmount int64 `json:"converted_amount,omitempty"`
+
+	//Rounding mode the converted amount was computed with, recorded on the transfer
+	RoundingMode string `json:"rounding_mode,omitempty"`
+
+	//Optional minimum source balance required after the transfer
+	MinSourceBalanceAfter *int64 `json:"min_source_balance_after,omitempty"`
+
+	//Optional cap on what the source may send since DailyLimitSince, checked
+	//once the source account is locked
+	DailyLimit      int64     `json:"daily_limit,omitempty"`
+	DailyLimitSince time.Time `json:"daily_limit_since,omitempty"`
 }
 
 // Transfer transaction result data
@@ -64,77 +249,282 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+
+	//Balances captured inside the transaction
+	FromBalanceBefore int64 `json:"from_balance_before"`
+	FromBalanceAfter  int64 `json:"from_balance_after"`
+	ToBalanceBefore   int64 `json:"to_balance_before"`
+	ToBalanceAfter    int64 `json:"to_balance_after"`
+
+	//Alerts fired by the new balances, delivered after commit
+	Alerts []BalanceAlertEvent `json:"-"`
 }
 
 // Perfomr a money transfer transaction
-func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
-	var result TransferTxResult
+func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (result TransferTxResult, err error) {
+	ctx, span := startSpan(ctx, "TransferTx")
+	defer func() { endSpan(span, err) }()
+	span.SetAttributes(
+		attribute.Int64("transfer.from_account_id", arg.FromAccountID),
+		attribute.Int64("transfer.to_account_id", arg.ToAccountID),
+	)
 
-	//Execute transfer in a transaction
-	err := store.execTx(ctx, func(q *Queries) error {
-		var err error
+	opts := &sql.TxOptions{Isolation: store.options.TransferIsolation}
 
-		//Create transfer record
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
-		if err != nil {
-			return err
+	//Execute transfer in a transaction, retried if Postgres aborts it
+	err = store.execTx(ctx, opts, func(q *Queries) error {
+		var err error
+		if store.options.OptimisticTransfers {
+			result, err = runOptimisticTransfer(ctx, q, arg)
+		} else {
+			result, err = runTransfer(ctx, q, arg)
 		}
+		return err
+	})
 
-		//Create debit entry
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+	return result, err
+}
 
-		//Create credit entry
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
+// runTransfer moves money between two accounts using the given transaction,
+// locking both accounts before reading their balances
+func runTransfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	return moveMoney(ctx, q, arg, nil)
+}
+
+// runOptimisticTransfer moves money between two accounts without row locks,
+// failing with ErrConcurrentModification if either changes before its update
+func runOptimisticTransfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	versions := make(map[int64]int32, 2)
+	return moveMoney(ctx, q, arg, versions)
+}
+
+// moveMoney runs a transfer. With a nil versions map the accounts are locked
+// up front, otherwise their versions are recorded in it and checked on update.
+func moveMoney(ctx context.Context, q *Queries, arg TransferTxParams, versions map[int64]int32) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+
+	//Capture the starting balances, locking both accounts in id order unless
+	//their versions are checked instead
+	if versions == nil {
+		result.FromBalanceBefore, result.ToBalanceBefore, err = lockBalances(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	} else {
+		result.FromBalanceBefore, result.ToBalanceBefore, err = readBalances(ctx, q, arg.FromAccountID, arg.ToAccountID, versions)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	//Never let the source account go negative
+	if result.FromBalanceBefore < arg.Amount {
+		return result, ErrInsufficientFunds
+	}
+
+	//Concurrent transfers from the source wait on its lock, so the sum
+	//already includes every transfer committed before this one
+	if err := checkDailyLimit(ctx, q, arg.FromAccountID, arg.Amount, arg.DailyLimit, arg.DailyLimitSince); err != nil {
+		return result, err
+	}
+
+	//Stop before writing anything if the caller went away
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	//The destination receives the converted amount in its own currency
+	credit := arg.Amount
+	if arg.ConvertedAmount != 0 {
+		credit = arg.ConvertedAmount
+	}
+
+	//Create transfer record
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID:   arg.FromAccountID,
+		ToAccountID:     arg.ToAccountID,
+		Amount:          arg.Amount,
+		Memo:            arg.Memo,
+		ConvertedAmount: credit,
+		RoundingMode:    arg.RoundingMode,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	//Create debit entry
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount:    -arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	//Create credit entry
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID: arg.ToAccountID,
+		Amount:    credit,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	//Update account balances (ordered to avoid deadlocks )
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, credit, versions)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, credit, arg.FromAccountID, -arg.Amount, versions)
+	}
+	if err != nil {
+		return result, err
+	}
+	result.FromBalanceAfter = result.FromAccount.Balance
+	result.ToBalanceAfter = result.ToAccount.Balance
+
+	//Enforce the caller's balance buffer while the rows are locked
+	if arg.MinSourceBalanceAfter != nil && result.FromAccount.Balance < *arg.MinSourceBalanceAfter {
+		return result, ErrMinBalancePrecondition
+	}
+
+	//Evaluate balance alerts against the updated balances
+	for _, account := range []Account{result.FromAccount, result.ToAccount} {
+		events, err := checkBalanceAlert(ctx, q, account)
 		if err != nil {
-			return err
+			return result, err
 		}
+		result.Alerts = append(result.Alerts, events...)
+	}
 
-		//Update account balances (ordered to avoid deadlocks )
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-		}
+	return result, nil
+}
 
+// checkDailyLimit fails with ErrDailyTransferLimit when amount on top of what
+// the account sent since the given time exceeds limit. A zero limit disables
+// the check. The caller must hold the account's lock or check its version.
+func checkDailyLimit(ctx context.Context, q *Queries, accountID int64, amount int64, limit int64, since time.Time) error {
+	if limit <= 0 {
 		return nil
+	}
 
+	sent, err := q.SumTransfersSince(ctx, SumTransfersSinceParams{
+		FromAccountID: accountID,
+		Since:         since,
 	})
+	if err != nil {
+		return err
+	}
+	if sent+amount > limit {
+		return fmt.Errorf("account [%d] has sent %d since %s: a transfer of %d exceeds the limit of %d: %w",
+			accountID, sent, since.Format(time.RFC3339), amount, limit, ErrDailyTransferLimit)
+	}
+	return nil
+}
 
-	return result, err
+// lockBalances locks two accounts in id order, the same order addMoney updates
+// them in, and returns their current balances
+func lockBalances(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64) (fromBalance int64, toBalance int64, err error) {
+	firstID, secondID := fromAccountID, toAccountID
+	if firstID > secondID {
+		firstID, secondID = secondID, firstID
+	}
+
+	balances := make(map[int64]int64, 2)
+	for _, id := range []int64{firstID, secondID} {
+		account, err := q.GetAccountForUpdate(ctx, id)
+		if err != nil {
+			return 0, 0, err
+		}
+		//Checked on the locked row, so a freeze can't slip in before the update
+		if err := checkActive(account); err != nil {
+			return 0, 0, err
+		}
+		balances[id] = account.Balance
+	}
+
+	return balances[fromAccountID], balances[toAccountID], nil
+}
+
+// checkActive fails with ErrAccountFrozen when the account is frozen
+func checkActive(account Account) error {
+	if account.IsFrozen() {
+		return fmt.Errorf("account [%d] is frozen: transfers are blocked: %w", account.ID, ErrAccountFrozen)
+	}
+	return nil
 }
 
-// Update balances for two accounts
-func addMoney(ctx context.Context, q *Queries, accountID1 int64, amount1 int64, accountID2 int64, amount2 int64) (account1 Account, account2 Account, err error) {
+// readBalances reads two accounts without locking them, recording their
+// versions, and returns their current balances
+func readBalances(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64, versions map[int64]int32) (fromBalance int64, toBalance int64, err error) {
+	balances := make(map[int64]int64, 2)
+	for _, id := range []int64{fromAccountID, toAccountID} {
+		account, err := q.GetAccount(ctx, id)
+		if err != nil {
+			return 0, 0, err
+		}
+		//A freeze bumps the version, so it also fails the update
+		if err := checkActive(account); err != nil {
+			return 0, 0, err
+		}
+		balances[id] = account.Balance
+		versions[id] = account.Version
+	}
+
+	return balances[fromAccountID], balances[toAccountID], nil
+}
+
+// Update balances for two accounts, only at the recorded versions when given
+func addMoney(ctx context.Context, q *Queries, accountID1 int64, amount1 int64, accountID2 int64, amount2 int64, versions map[int64]int32) (account1 Account, account2 Account, err error) {
 	//Update first account
-	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID1,
-		Amount: amount1,
-	})
+	account1, err = addBalance(ctx, q, accountID1, amount1, versions)
 	if err != nil {
 		return
 	}
 
+	//Abort between the two updates if the caller went away
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
 	//Update second account
-	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID2,
-		Amount: amount2,
-	})
+	account2, err = addBalance(ctx, q, accountID2, amount2, versions)
 	if err != nil {
 		return
 	}
 
 	return
 }
+
+// addBalance adds an amount to an account and snapshots the new balance in
+// the same transaction. With versions it only updates the account at its
+// recorded version, reporting a newer one as a conflict.
+func addBalance(ctx context.Context, q *Queries, accountID int64, amount int64, versions map[int64]int32) (Account, error) {
+	var account Account
+	var err error
+	if versions == nil {
+		account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     accountID,
+			Amount: amount,
+		})
+	} else {
+		account, err = q.AddAccountBalanceWithVersion(ctx, AddAccountBalanceWithVersionParams{
+			ID:      accountID,
+			Amount:  amount,
+			Version: versions[accountID],
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrConcurrentModification
+		}
+	}
+	if err != nil {
+		return account, err
+	}
+
+	_, err = q.CreateBalanceSnapshot(ctx, CreateBalanceSnapshotParams{
+		AccountID: account.ID,
+		Balance:   account.Balance,
+	})
+	return account, err
+}
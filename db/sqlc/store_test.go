@@ -2,9 +2,15 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"math"
+	"math/big"
 	"testing"
+	"time"
 
+	"github.com/codercollo/simple_bank/util"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
@@ -183,3 +189,601 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+// TestTransferTxMinSourceBalanceAfter verifies the balance buffer precondition
+func TestTransferTxMinSourceBalanceAfter(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	amount := int64(10)
+
+	//A buffer the transfer would breach is rejected without mutation
+	minBalance := account1.Balance - amount + 1
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:         account1.ID,
+		ToAccountID:           account2.ID,
+		Amount:                amount,
+		MinSourceBalanceAfter: &minBalance,
+	})
+	require.ErrorIs(t, err, ErrMinBalancePrecondition)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+
+	//A buffer the transfer respects succeeds
+	minBalance = account1.Balance - amount
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:         account1.ID,
+		ToAccountID:           account2.ID,
+		Amount:                amount,
+		MinSourceBalanceAfter: &minBalance,
+	})
+	require.NoError(t, err)
+	require.Equal(t, minBalance, result.FromAccount.Balance)
+}
+
+// TestTransferTxDailyLimit verifies concurrent transfers together never send
+// more than the daily limit
+func TestTransferTxDailyLimit(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	//Only three of the five transfers fit in the limit
+	n := 5
+	amount := int64(10)
+	limit := 3 * amount
+	since := time.Now().Add(-24 * time.Hour)
+
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID:   account1.ID,
+				ToAccountID:     account2.ID,
+				Amount:          amount,
+				DailyLimit:      limit,
+				DailyLimitSince: since,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrDailyTransferLimit)
+	}
+	require.Equal(t, 3, succeeded)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-limit, updatedAccount1.Balance)
+}
+
+// TestTransferTxSerializable verifies conflicting serializable transfers retry and settle
+func TestTransferTxSerializable(t *testing.T) {
+	store := NewStoreWithOptions(testDB, StoreOptions{
+		TransferIsolation: sql.LevelSerializable,
+	})
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	n := 5
+	amount := int64(10)
+	errs := make(chan error)
+
+	//Run concurrent transfers touching the same rows
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	//Every transfer eventually commits
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, account1.Balance-int64(n)*amount, updatedAccount1.Balance)
+	require.Equal(t, account2.Balance+int64(n)*amount, updatedAccount2.Balance)
+}
+
+// TestTransferIsolationApplied verifies the configured level reaches the transaction
+func TestTransferIsolationApplied(t *testing.T) {
+	store := NewStoreWithOptions(testDB, StoreOptions{
+		TransferIsolation: sql.LevelSerializable,
+	}).(*SQLStore)
+
+	opts := &sql.TxOptions{Isolation: store.options.TransferIsolation}
+	var level string
+	err := store.execTx(context.Background(), opts, func(q *Queries) error {
+		return q.db.QueryRowContext(context.Background(), "SHOW transaction_isolation").Scan(&level)
+	})
+	require.NoError(t, err)
+	require.Equal(t, "serializable", level)
+}
+
+// TestMoneyMovingTxIsolation verifies every transaction that moves money runs
+// at the configured level, not only TransferTx
+func TestMoneyMovingTxIsolation(t *testing.T) {
+	//Refunds and reversals need a transfer that actually moved money
+	transferred := func(t *testing.T) Transfer {
+		result, err := NewStore(testDB).TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: createRandomAccount(t).ID,
+			ToAccountID:   createRandomAccount(t).ID,
+			Amount:        1,
+		})
+		require.NoError(t, err)
+		return result.Transfer
+	}
+
+	testCases := []struct {
+		name string
+		run  func(t *testing.T, store Store) error
+	}{
+		{
+			name: "Refund",
+			run: func(t *testing.T, store Store) error {
+				transfer := transferred(t)
+				_, err := store.RefundTransferTx(context.Background(), RefundTransferTxParams{
+					TransferID: transfer.ID,
+					Amount:     1,
+				})
+				return err
+			},
+		},
+		{
+			name: "Reversal",
+			run: func(t *testing.T, store Store) error {
+				transfer := transferred(t)
+				_, err := store.ReverseTransferTx(context.Background(), transfer.ID)
+				return err
+			},
+		},
+		{
+			name: "Batch",
+			run: func(t *testing.T, store Store) error {
+				_, err := store.BatchTransferTx(context.Background(), BatchTransferTxParams{
+					FromAccountID: createRandomAccount(t).ID,
+					Legs:          []BatchTransferLeg{{ToAccountID: createRandomAccount(t).ID, Amount: 1}},
+				})
+				return err
+			},
+		},
+		{
+			name: "AcceptRequest",
+			run: func(t *testing.T, store Store) error {
+				request := createRandomTransferRequest(t, createRandomAccount(t), createRandomAccount(t), time.Now().Add(time.Hour))
+				_, err := store.AcceptTransferRequestTx(context.Background(), AcceptTransferRequestTxParams{ID: request.ID})
+				return err
+			},
+		},
+		{
+			name: "FundedAccount",
+			run: func(t *testing.T, store Store) error {
+				funding := createRandomAccount(t)
+				_, err := store.CreateAccountWithFundingTx(context.Background(), CreateAccountWithFundingTxParams{
+					Account: CreateAccountParams{
+						Owner:    funding.Owner,
+						Currency: funding.Currency,
+						Nickname: util.RandomString(8),
+					},
+					FundingAccountID: funding.ID,
+					InitialBalance:   1,
+				})
+				return err
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := NewStoreWithOptions(testDB, StoreOptions{
+				TransferIsolation: sql.LevelSerializable,
+			}).(*SQLStore)
+
+			//Read the level from inside the transaction as it starts
+			var levels []string
+			store.wrapTx = func(tx DBTX) DBTX {
+				var level string
+				require.NoError(t, tx.QueryRowContext(context.Background(), "SHOW transaction_isolation").Scan(&level))
+				levels = append(levels, level)
+				return tx
+			}
+
+			require.NoError(t, tc.run(t, store))
+			require.NotEmpty(t, levels)
+			for _, level := range levels {
+				require.Equal(t, "serializable", level)
+			}
+		})
+	}
+}
+
+// TestParseIsolationLevel verifies configured isolation names
+func TestParseIsolationLevel(t *testing.T) {
+	level, err := ParseIsolationLevel("")
+	require.NoError(t, err)
+	require.Equal(t, sql.LevelDefault, level)
+
+	level, err = ParseIsolationLevel("serializable")
+	require.NoError(t, err)
+	require.Equal(t, sql.LevelSerializable, level)
+
+	level, err = ParseIsolationLevel("read_committed")
+	require.NoError(t, err)
+	require.Equal(t, sql.LevelReadCommitted, level)
+
+	_, err = ParseIsolationLevel("chaos")
+	require.Error(t, err)
+}
+
+// TestIsRetryableTxError verifies which Postgres errors trigger a retry
+func TestIsRetryableTxError(t *testing.T) {
+	require.True(t, isRetryableTxError(&pq.Error{Code: "40001"}))
+	require.True(t, isRetryableTxError(fmt.Errorf("wrapped: %w", &pq.Error{Code: "40P01"})))
+	require.False(t, isRetryableTxError(&pq.Error{Code: "23505"}))
+	require.False(t, isRetryableTxError(sql.ErrNoRows))
+}
+
+// TestTransferTxBalancesBeforeAfter verifies the captured balances match the amount and persisted state
+func TestTransferTxBalancesBeforeAfter(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	amount := int64(10)
+
+	//Transfer in both directions to cover either lock order
+	for _, arg := range []TransferTxParams{
+		{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount},
+		{FromAccountID: account2.ID, ToAccountID: account1.ID, Amount: amount},
+	} {
+		from, err := testQueries.GetAccount(context.Background(), arg.FromAccountID)
+		require.NoError(t, err)
+		to, err := testQueries.GetAccount(context.Background(), arg.ToAccountID)
+		require.NoError(t, err)
+
+		result, err := store.TransferTx(context.Background(), arg)
+		require.NoError(t, err)
+
+		require.Equal(t, from.Balance, result.FromBalanceBefore)
+		require.Equal(t, to.Balance, result.ToBalanceBefore)
+		require.Equal(t, result.FromBalanceBefore-amount, result.FromBalanceAfter)
+		require.Equal(t, result.ToBalanceBefore+amount, result.ToBalanceAfter)
+
+		//After balances are the persisted ones
+		from, err = testQueries.GetAccount(context.Background(), arg.FromAccountID)
+		require.NoError(t, err)
+		to, err = testQueries.GetAccount(context.Background(), arg.ToAccountID)
+		require.NoError(t, err)
+		require.Equal(t, from.Balance, result.FromBalanceAfter)
+		require.Equal(t, to.Balance, result.ToBalanceAfter)
+	}
+}
+
+// TestTransferTxBumpsUpdatedAt ensures only balance changes move updated_at
+func TestTransferTxBumpsUpdatedAt(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account3 := createRandomAccount(t)
+	require.Equal(t, account1.CreatedAt, account1.UpdatedAt)
+
+	//Reads leave the timestamp alone
+	fetched, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.UpdatedAt, fetched.UpdatedAt)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	//Both sides of the transfer are bumped
+	require.True(t, result.FromAccount.UpdatedAt.After(account1.UpdatedAt))
+	require.True(t, result.ToAccount.UpdatedAt.After(account2.UpdatedAt))
+	require.Equal(t, account1.CreatedAt, result.FromAccount.CreatedAt)
+	require.Equal(t, result.Transfer.CreatedAt, result.Transfer.UpdatedAt)
+
+	//Accounts outside the transfer are not
+	untouched, err := testQueries.GetAccount(context.Background(), account3.ID)
+	require.NoError(t, err)
+	require.Equal(t, account3.UpdatedAt, untouched.UpdatedAt)
+}
+
+// TestTransferTxRollsBackFailedBalanceUpdate verifies a failing second balance
+// update aborts the whole transfer instead of committing half of it
+func TestTransferTxRollsBackFailedBalanceUpdate(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+
+	//Crediting the second account overflows its bigint balance
+	user := createRandomUser(t)
+	account2, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  math.MaxInt64,
+		Currency: account1.Currency,
+	})
+	require.NoError(t, err)
+	require.Greater(t, account2.ID, account1.ID)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	require.Equal(t, "numeric_value_out_of_range", pqErr.Code.Name())
+
+	//The debit of the first account was rolled back with the rest
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	transfers, err := store.ListTransfers(context.Background(), ListTransfersParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Limit:         5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+
+	entries, err := store.ListEntries(context.Background(), ListEntriesParams{
+		AccountID: account1.ID,
+		Limit:     5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+// TestTransferTxInsufficientFunds verifies over-limit transfers leave both balances unchanged
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance + 1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	for _, account := range []Account{account1, account2} {
+		updated, err := store.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, updated.Balance)
+	}
+
+	//The whole balance can still be moved
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.FromAccount.Balance)
+}
+
+// TestTransferTxConverted tests crediting the destination with a converted amount
+func TestTransferTxConverted(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:   account1.ID,
+		ToAccountID:     account2.ID,
+		Amount:          100,
+		ConvertedAmount: 250,
+	})
+	require.NoError(t, err)
+
+	//Both amounts are recorded on the transfer and its entries
+	require.Equal(t, int64(100), result.Transfer.Amount)
+	require.Equal(t, int64(250), result.Transfer.ConvertedAmount)
+	require.Equal(t, int64(-100), result.FromEntry.Amount)
+	require.Equal(t, int64(250), result.ToEntry.Amount)
+	require.Equal(t, account1.Balance-100, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+250, result.ToAccount.Balance)
+
+	//Same-currency transfers convert one to one
+	result, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), result.Transfer.ConvertedAmount)
+}
+
+// TestTransferTxRoundingMode verifies the stored converted amount and rounding
+// mode for each mode at an exact half minor unit
+func TestTransferTxRoundingMode(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	//185 at 0.5 is exactly 92.5
+	amount := int64(185)
+	rate := big.NewRat(1, 2)
+
+	testCases := []struct {
+		mode     util.RoundingMode
+		expected int64
+	}{
+		{util.RoundHalfUp, 93},
+		{util.RoundHalfEven, 92},
+		{util.RoundDown, 92},
+	}
+
+	for _, tc := range testCases {
+		converted, err := util.ConvertAmount(amount, rate, tc.mode)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, converted, tc.mode)
+
+		result, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID:   account1.ID,
+			ToAccountID:     account2.ID,
+			Amount:          amount,
+			ConvertedAmount: converted,
+			RoundingMode:    string(tc.mode),
+		})
+		require.NoError(t, err)
+
+		stored, err := store.GetTransfer(context.Background(), result.Transfer.ID)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, stored.ConvertedAmount, tc.mode)
+		require.Equal(t, string(tc.mode), stored.RoundingMode)
+		require.Equal(t, tc.expected, result.ToEntry.Amount, tc.mode)
+	}
+}
+
+func TestPing(t *testing.T) {
+	store := NewStore(testDB)
+	require.NoError(t, store.Ping(context.Background()))
+}
+
+// balanceUpdateHook is a transaction that calls before ahead of every account
+// balance update, numbered across all transactions of its store
+type balanceUpdateHook struct {
+	DBTX
+	updates *int
+	before  func(update int)
+}
+
+func (h *balanceUpdateHook) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if query == addAccountBalance || query == addAccountBalanceWithVersion {
+		*h.updates++
+		h.before(*h.updates)
+	}
+	return h.DBTX.QueryRowContext(ctx, query, args...)
+}
+
+// newStoreWithBalanceUpdateHook creates a store calling before ahead of every
+// account balance update its transactions make
+func newStoreWithBalanceUpdateHook(options StoreOptions, before func(update int)) Store {
+	store := NewStoreWithOptions(testDB, options).(*SQLStore)
+	var updates int
+	store.wrapTx = func(tx DBTX) DBTX {
+		return &balanceUpdateHook{DBTX: tx, updates: &updates, before: before}
+	}
+	return store
+}
+
+// TestTransferTxCanceledMidway verifies a transfer canceled between its two
+// balance updates is rolled back entirely
+func TestTransferTxCanceledMidway(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := newStoreWithBalanceUpdateHook(StoreOptions{}, func(update int) {
+		if update == 2 {
+			cancel()
+		}
+	})
+
+	_, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	//Neither balance moved and no transfer was recorded
+	for _, account := range []Account{account1, account2} {
+		current, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, current.Balance)
+	}
+
+	transfers, err := testQueries.ListTransfers(context.Background(), ListTransfersParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account1.ID,
+		Limit:         5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
+
+// TestOptimisticTransferTxConflict tests a transfer racing another update of
+// its accounts fails its version check and is retried
+func TestOptimisticTransferTxConflict(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	//addMoney updates the lower id first, change the other one concurrently
+	//before the transfer reaches it
+	raced := max(account1.ID, account2.ID)
+	var updates int
+	race := func(update int) {
+		updates = update
+		if update == 2 {
+			_, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: raced, Amount: 1})
+			require.NoError(t, err)
+		}
+	}
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	}
+
+	//Without retries the conflict is reported
+	store := newStoreWithBalanceUpdateHook(StoreOptions{OptimisticTransfers: true, MaxTxAttempts: 1}, race)
+	_, err := store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrConcurrentModification)
+
+	//The failed attempt left only the concurrent update behind
+	current, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	expected := account1.Balance
+	if raced == account1.ID {
+		expected++
+	}
+	require.Equal(t, expected, current.Balance)
+
+	//With retries the second attempt reads the new version and succeeds
+	store = newStoreWithBalanceUpdateHook(StoreOptions{OptimisticTransfers: true}, race)
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, 4, updates)
+	require.Equal(t, result.FromBalanceBefore-10, result.FromAccount.Balance)
+	require.Equal(t, result.ToBalanceBefore+10, result.ToAccount.Balance)
+}
@@ -0,0 +1,27 @@
+package db
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans started by the store
+const tracerName = "github.com/codercollo/simple_bank/db"
+
+// startSpan starts a span for a store operation, named like db.TransferTx. It
+// does nothing until a tracer provider is installed.
+func startSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "db."+operation)
+}
+
+// endSpan records the outcome of a store operation and ends its span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans installs an in-memory tracer provider for the duration of a test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+// TestTransferTxSpan verifies a transfer is traced with its transaction as a child span
+func TestTransferTxSpan(t *testing.T) {
+	recorder := recordSpans(t)
+	store := newStubStore(t, StoreOptions{MaxTxAttempts: 1})
+
+	//The stub driver can't run queries, so the transfer fails after starting its spans
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        10,
+	})
+	require.Error(t, err)
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	require.Contains(t, spans, "db.TransferTx")
+	require.Contains(t, spans, "db.execTx")
+
+	transfer, tx := spans["db.TransferTx"], spans["db.execTx"]
+	require.Equal(t, transfer.SpanContext().TraceID(), tx.SpanContext().TraceID())
+	require.Equal(t, transfer.SpanContext().SpanID(), tx.Parent().SpanID())
+	require.Equal(t, codes.Error, transfer.Status().Code)
+}
+
+// TestExecTxSpan verifies a successful transaction span carries no error
+func TestExecTxSpan(t *testing.T) {
+	recorder := recordSpans(t)
+	store := newStubStore(t, StoreOptions{})
+
+	err := store.execTx(context.Background(), nil, func(q *Queries) error { return nil })
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "db.execTx", spans[0].Name())
+	require.Equal(t, codes.Unset, spans[0].Status().Code)
+}
@@ -7,26 +7,74 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
+const addTransferRefundedAmount = `-- name: AddTransferRefundedAmount :one
+UPDATE transfers
+SET refunded_amount = refunded_amount + $1,
+    updated_at = now()
+WHERE id = $2
+RETURNING id, from_account_id, to_account_id, amount, created_at, refunded_amount, updated_at, memo, converted_amount, reversed_of, rounding_mode, refund_of
+`
+
+type AddTransferRefundedAmountParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddTransferRefundedAmount(ctx context.Context, arg AddTransferRefundedAmountParams) (Transfer, error) {
+	row := q.queryRow(ctx, q.addTransferRefundedAmountStmt, addTransferRefundedAmount, arg.Amount, arg.ID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.RefundedAmount,
+		&i.UpdatedAt,
+		&i.Memo,
+		&i.ConvertedAmount,
+		&i.ReversedOf,
+		&i.RoundingMode,
+		&i.RefundOf,
+	)
+	return i, err
+}
+
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    memo,
+    converted_amount,
+    rounding_mode
 ) VALUES (
-    $1, $2, $3
-)  RETURNING id, from_account_id, to_account_id, amount, created_at
+    $1, $2, $3, $4, $5, $6
+)  RETURNING id, from_account_id, to_account_id, amount, created_at, refunded_amount, updated_at, memo, converted_amount, reversed_of, rounding_mode, refund_of
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID   int64  `json:"from_account_id"`
+	ToAccountID     int64  `json:"to_account_id"`
+	Amount          int64  `json:"amount"`
+	Memo            string `json:"memo"`
+	ConvertedAmount int64  `json:"converted_amount"`
+	RoundingMode    string `json:"rounding_mode"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.queryRow(ctx, q.createTransferStmt, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.queryRow(ctx, q.createTransferStmt, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Memo,
+		arg.ConvertedAmount,
+		arg.RoundingMode,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -34,12 +82,19 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.RefundedAmount,
+		&i.UpdatedAt,
+		&i.Memo,
+		&i.ConvertedAmount,
+		&i.ReversedOf,
+		&i.RoundingMode,
+		&i.RefundOf,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, refunded_amount, updated_at, memo, converted_amount, reversed_of, rounding_mode, refund_of FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -52,12 +107,191 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.RefundedAmount,
+		&i.UpdatedAt,
+		&i.Memo,
+		&i.ConvertedAmount,
+		&i.ReversedOf,
+		&i.RoundingMode,
+		&i.RefundOf,
 	)
 	return i, err
 }
 
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, refunded_amount, updated_at, memo, converted_amount, reversed_of, rounding_mode, refund_of FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.queryRow(ctx, q.getTransferForUpdateStmt, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.RefundedAmount,
+		&i.UpdatedAt,
+		&i.Memo,
+		&i.ConvertedAmount,
+		&i.ReversedOf,
+		&i.RoundingMode,
+		&i.RefundOf,
+	)
+	return i, err
+}
+
+const getTransferReversal = `-- name: GetTransferReversal :one
+SELECT id, from_account_id, to_account_id, amount, created_at, refunded_amount, updated_at, memo, converted_amount, reversed_of, rounding_mode, refund_of FROM transfers
+WHERE reversed_of = $1::bigint
+LIMIT 1
+`
+
+func (q *Queries) GetTransferReversal(ctx context.Context, transferID int64) (Transfer, error) {
+	row := q.queryRow(ctx, q.getTransferReversalStmt, getTransferReversal, transferID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.RefundedAmount,
+		&i.UpdatedAt,
+		&i.Memo,
+		&i.ConvertedAmount,
+		&i.ReversedOf,
+		&i.RoundingMode,
+		&i.RefundOf,
+	)
+	return i, err
+}
+
+const getTransferWithOwners = `-- name: GetTransferWithOwners :one
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.refunded_amount, t.updated_at, t.memo, t.converted_amount, t.reversed_of, t.rounding_mode, t.refund_of, fa.owner AS from_owner, ta.owner AS to_owner
+FROM transfers t
+JOIN accounts fa ON fa.id = t.from_account_id
+JOIN accounts ta ON ta.id = t.to_account_id
+WHERE t.id = $1
+LIMIT 1
+`
+
+type GetTransferWithOwnersRow struct {
+	ID              int64         `json:"id"`
+	FromAccountID   int64         `json:"from_account_id"`
+	ToAccountID     int64         `json:"to_account_id"`
+	Amount          int64         `json:"amount"`
+	CreatedAt       time.Time     `json:"created_at"`
+	RefundedAmount  int64         `json:"refunded_amount"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	Memo            string        `json:"memo"`
+	ConvertedAmount int64         `json:"converted_amount"`
+	ReversedOf      sql.NullInt64 `json:"reversed_of"`
+	RoundingMode    string        `json:"rounding_mode"`
+	RefundOf        sql.NullInt64 `json:"refund_of"`
+	FromOwner       string        `json:"from_owner"`
+	ToOwner         string        `json:"to_owner"`
+}
+
+func (q *Queries) GetTransferWithOwners(ctx context.Context, id int64) (GetTransferWithOwnersRow, error) {
+	row := q.queryRow(ctx, q.getTransferWithOwnersStmt, getTransferWithOwners, id)
+	var i GetTransferWithOwnersRow
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.RefundedAmount,
+		&i.UpdatedAt,
+		&i.Memo,
+		&i.ConvertedAmount,
+		&i.ReversedOf,
+		&i.RoundingMode,
+		&i.RefundOf,
+		&i.FromOwner,
+		&i.ToOwner,
+	)
+	return i, err
+}
+
+const listOwnerTransfers = `-- name: ListOwnerTransfers :many
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.refunded_amount, t.updated_at, t.memo, t.converted_amount, t.reversed_of, t.rounding_mode, t.refund_of, fa.owner AS from_owner, ta.owner AS to_owner
+FROM transfers t
+JOIN accounts fa ON fa.id = t.from_account_id
+JOIN accounts ta ON ta.id = t.to_account_id
+WHERE fa.owner = $1 OR ta.owner = $1
+ORDER BY t.created_at DESC, t.id DESC
+LIMIT $3
+OFFSET $2
+`
+
+type ListOwnerTransfersParams struct {
+	Owner  string `json:"owner"`
+	Offset int32  `json:"offset"`
+	Limit  int32  `json:"limit"`
+}
+
+type ListOwnerTransfersRow struct {
+	ID              int64         `json:"id"`
+	FromAccountID   int64         `json:"from_account_id"`
+	ToAccountID     int64         `json:"to_account_id"`
+	Amount          int64         `json:"amount"`
+	CreatedAt       time.Time     `json:"created_at"`
+	RefundedAmount  int64         `json:"refunded_amount"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+	Memo            string        `json:"memo"`
+	ConvertedAmount int64         `json:"converted_amount"`
+	ReversedOf      sql.NullInt64 `json:"reversed_of"`
+	RoundingMode    string        `json:"rounding_mode"`
+	RefundOf        sql.NullInt64 `json:"refund_of"`
+	FromOwner       string        `json:"from_owner"`
+	ToOwner         string        `json:"to_owner"`
+}
+
+func (q *Queries) ListOwnerTransfers(ctx context.Context, arg ListOwnerTransfersParams) ([]ListOwnerTransfersRow, error) {
+	rows, err := q.query(ctx, q.listOwnerTransfersStmt, listOwnerTransfers, arg.Owner, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOwnerTransfersRow{}
+	for rows.Next() {
+		var i ListOwnerTransfersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.RefundedAmount,
+			&i.UpdatedAt,
+			&i.Memo,
+			&i.ConvertedAmount,
+			&i.ReversedOf,
+			&i.RoundingMode,
+			&i.RefundOf,
+			&i.FromOwner,
+			&i.ToOwner,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, refunded_amount, updated_at, memo, converted_amount, reversed_of, rounding_mode, refund_of FROM transfers
 WHERE 
     from_account_id = $1 OR
     to_account_id = $2
@@ -93,6 +327,13 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.RefundedAmount,
+			&i.UpdatedAt,
+			&i.Memo,
+			&i.ConvertedAmount,
+			&i.ReversedOf,
+			&i.RoundingMode,
+			&i.RefundOf,
 		); err != nil {
 			return nil, err
 		}
@@ -106,3 +347,90 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	}
 	return items, nil
 }
+
+const setTransferRefundOf = `-- name: SetTransferRefundOf :one
+UPDATE transfers
+SET refund_of = $1,
+    updated_at = now()
+WHERE id = $2
+RETURNING id, from_account_id, to_account_id, amount, created_at, refunded_amount, updated_at, memo, converted_amount, reversed_of, rounding_mode, refund_of
+`
+
+type SetTransferRefundOfParams struct {
+	RefundOf sql.NullInt64 `json:"refund_of"`
+	ID       int64         `json:"id"`
+}
+
+func (q *Queries) SetTransferRefundOf(ctx context.Context, arg SetTransferRefundOfParams) (Transfer, error) {
+	row := q.queryRow(ctx, q.setTransferRefundOfStmt, setTransferRefundOf, arg.RefundOf, arg.ID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.RefundedAmount,
+		&i.UpdatedAt,
+		&i.Memo,
+		&i.ConvertedAmount,
+		&i.ReversedOf,
+		&i.RoundingMode,
+		&i.RefundOf,
+	)
+	return i, err
+}
+
+const setTransferReversedOf = `-- name: SetTransferReversedOf :one
+UPDATE transfers
+SET reversed_of = $1,
+    updated_at = now()
+WHERE id = $2
+RETURNING id, from_account_id, to_account_id, amount, created_at, refunded_amount, updated_at, memo, converted_amount, reversed_of, rounding_mode, refund_of
+`
+
+type SetTransferReversedOfParams struct {
+	ReversedOf sql.NullInt64 `json:"reversed_of"`
+	ID         int64         `json:"id"`
+}
+
+func (q *Queries) SetTransferReversedOf(ctx context.Context, arg SetTransferReversedOfParams) (Transfer, error) {
+	row := q.queryRow(ctx, q.setTransferReversedOfStmt, setTransferReversedOf, arg.ReversedOf, arg.ID)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.RefundedAmount,
+		&i.UpdatedAt,
+		&i.Memo,
+		&i.ConvertedAmount,
+		&i.ReversedOf,
+		&i.RoundingMode,
+		&i.RefundOf,
+	)
+	return i, err
+}
+
+const sumTransfersSince = `-- name: SumTransfersSince :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS total
+FROM transfers
+WHERE from_account_id = $1
+  AND created_at >= $2
+  AND reversed_of IS NULL
+  AND refund_of IS NULL
+`
+
+type SumTransfersSinceParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	Since         time.Time `json:"since"`
+}
+
+func (q *Queries) SumTransfersSince(ctx context.Context, arg SumTransfersSinceParams) (int64, error) {
+	row := q.queryRow(ctx, q.sumTransfersSinceStmt, sumTransfersSince, arg.FromAccountID, arg.Since)
+	var total int64
+	err := row.Scan(&total)
+	return total, err
+}
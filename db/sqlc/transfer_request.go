@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Transfer request statuses. Expired is never stored: it is the status of a
+// pending request read after its expiry.
+const (
+	TransferRequestPending  = "pending"
+	TransferRequestAccepted = "accepted"
+	TransferRequestDeclined = "declined"
+	TransferRequestExpired  = "expired"
+)
+
+// Errors returned when a transfer request can no longer be answered
+var (
+	ErrTransferRequestNotPending = errors.New("transfer request has already been answered")
+	ErrTransferRequestExpired    = errors.New("transfer request has expired")
+)
+
+// StatusAt returns the status of the request at the given time
+func (request TransferRequest) StatusAt(now time.Time) string {
+	if request.Status == TransferRequestPending && !now.Before(request.ExpiresAt) {
+		return TransferRequestExpired
+	}
+	return request.Status
+}
+
+// CheckPending reports why the request cannot be answered at the given time, if it can't
+func (request TransferRequest) CheckPending(now time.Time) error {
+	switch request.StatusAt(now) {
+	case TransferRequestPending:
+		return nil
+	case TransferRequestExpired:
+		return ErrTransferRequestExpired
+	}
+	return ErrTransferRequestNotPending
+}
+
+// Transfer request acceptance input parameters
+type AcceptTransferRequestTxParams struct {
+	ID int64 `json:"id"`
+
+	//Optional cap on what the payer may send since DailyLimitSince, checked
+	//once the payer's account is locked
+	DailyLimit      int64     `json:"daily_limit,omitempty"`
+	DailyLimitSince time.Time `json:"daily_limit_since,omitempty"`
+}
+
+// Transfer request acceptance result data
+type AcceptTransferRequestTxResult struct {
+	TransferTxResult
+
+	//Request marked as accepted and linked to its transfer
+	TransferRequest TransferRequest `json:"transfer_request"`
+}
+
+// AcceptTransferRequestTx executes a pending transfer request and marks it accepted
+func (store *SQLStore) AcceptTransferRequestTx(ctx context.Context, arg AcceptTransferRequestTxParams) (AcceptTransferRequestTxResult, error) {
+	var result AcceptTransferRequestTxResult
+	opts := &sql.TxOptions{Isolation: store.options.TransferIsolation}
+
+	//Execute acceptance in a transaction, retried if Postgres aborts it
+	err := store.execTx(ctx, opts, func(q *Queries) error {
+		//Lock the request so it can only be answered once
+		request, err := q.GetTransferRequestForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if err := request.CheckPending(time.Now()); err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = runTransfer(ctx, q, TransferTxParams{
+			FromAccountID:   request.FromAccountID,
+			ToAccountID:     request.ToAccountID,
+			Amount:          request.Amount,
+			DailyLimit:      arg.DailyLimit,
+			DailyLimitSince: arg.DailyLimitSince,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.TransferRequest, err = q.AcceptTransferRequest(ctx, AcceptTransferRequestParams{
+			ID:         arg.ID,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+
+	return result, err
+}
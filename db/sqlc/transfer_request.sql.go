@@ -0,0 +1,153 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: transfer_request.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const acceptTransferRequest = `-- name: AcceptTransferRequest :one
+UPDATE transfer_requests
+SET status = 'accepted',
+    transfer_id = $2,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, from_account_id, to_account_id, amount, status, transfer_id, expires_at, created_at, updated_at
+`
+
+type AcceptTransferRequestParams struct {
+	ID         int64         `json:"id"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+}
+
+func (q *Queries) AcceptTransferRequest(ctx context.Context, arg AcceptTransferRequestParams) (TransferRequest, error) {
+	row := q.queryRow(ctx, q.acceptTransferRequestStmt, acceptTransferRequest, arg.ID, arg.TransferID)
+	var i TransferRequest
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createTransferRequest = `-- name: CreateTransferRequest :one
+INSERT INTO transfer_requests (
+    from_account_id,
+    to_account_id,
+    amount,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING id, from_account_id, to_account_id, amount, status, transfer_id, expires_at, created_at, updated_at
+`
+
+type CreateTransferRequestParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateTransferRequest(ctx context.Context, arg CreateTransferRequestParams) (TransferRequest, error) {
+	row := q.queryRow(ctx, q.createTransferRequestStmt, createTransferRequest,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ExpiresAt,
+	)
+	var i TransferRequest
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const declineTransferRequest = `-- name: DeclineTransferRequest :one
+UPDATE transfer_requests
+SET status = 'declined',
+    updated_at = now()
+WHERE id = $1 AND status = 'pending' AND expires_at > now()
+RETURNING id, from_account_id, to_account_id, amount, status, transfer_id, expires_at, created_at, updated_at
+`
+
+func (q *Queries) DeclineTransferRequest(ctx context.Context, id int64) (TransferRequest, error) {
+	row := q.queryRow(ctx, q.declineTransferRequestStmt, declineTransferRequest, id)
+	var i TransferRequest
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getTransferRequest = `-- name: GetTransferRequest :one
+SELECT id, from_account_id, to_account_id, amount, status, transfer_id, expires_at, created_at, updated_at FROM transfer_requests
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetTransferRequest(ctx context.Context, id int64) (TransferRequest, error) {
+	row := q.queryRow(ctx, q.getTransferRequestStmt, getTransferRequest, id)
+	var i TransferRequest
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getTransferRequestForUpdate = `-- name: GetTransferRequestForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, status, transfer_id, expires_at, created_at, updated_at FROM transfer_requests
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferRequestForUpdate(ctx context.Context, id int64) (TransferRequest, error) {
+	row := q.queryRow(ctx, q.getTransferRequestForUpdateStmt, getTransferRequestForUpdate, id)
+	var i TransferRequest
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
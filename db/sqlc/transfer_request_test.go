@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomTransferRequest creates a pending transfer request between two accounts
+func createRandomTransferRequest(t *testing.T, from, to Account, expiresAt time.Time) TransferRequest {
+	request, err := testQueries.CreateTransferRequest(context.Background(), CreateTransferRequestParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+		ExpiresAt:     expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, TransferRequestPending, request.Status)
+	require.False(t, request.TransferID.Valid)
+
+	return request
+}
+
+// TestAcceptTransferRequestTx tests that accepting executes the transfer once
+func TestAcceptTransferRequestTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	request := createRandomTransferRequest(t, account1, account2, time.Now().Add(time.Hour))
+
+	result, err := store.AcceptTransferRequestTx(context.Background(), AcceptTransferRequestTxParams{ID: request.ID})
+	require.NoError(t, err)
+	require.Equal(t, TransferRequestAccepted, result.TransferRequest.Status)
+	require.Equal(t, result.Transfer.ID, result.TransferRequest.TransferID.Int64)
+	require.Equal(t, account1.Balance-10, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+10, result.ToAccount.Balance)
+
+	//A second answer is rejected
+	_, err = store.AcceptTransferRequestTx(context.Background(), AcceptTransferRequestTxParams{ID: request.ID})
+	require.ErrorIs(t, err, ErrTransferRequestNotPending)
+	_, err = testQueries.DeclineTransferRequest(context.Background(), request.ID)
+	require.Error(t, err)
+}
+
+// TestAcceptTransferRequestTxDailyLimit tests that accepting counts against the
+// payer's daily limit
+func TestAcceptTransferRequestTxDailyLimit(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	request := createRandomTransferRequest(t, account1, account2, time.Now().Add(time.Hour))
+
+	_, err := store.AcceptTransferRequestTx(context.Background(), AcceptTransferRequestTxParams{
+		ID:              request.ID,
+		DailyLimit:      request.Amount - 1,
+		DailyLimitSince: time.Now().Add(-24 * time.Hour),
+	})
+	require.ErrorIs(t, err, ErrDailyTransferLimit)
+
+	//The request stays pending and no money moved
+	stored, err := testQueries.GetTransferRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferRequestPending, stored.Status)
+
+	updated, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updated.Balance)
+}
+
+// TestDeclineTransferRequest tests that declining leaves balances unchanged
+func TestDeclineTransferRequest(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	request := createRandomTransferRequest(t, account1, account2, time.Now().Add(time.Hour))
+
+	declined, err := testQueries.DeclineTransferRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferRequestDeclined, declined.Status)
+
+	_, err = store.AcceptTransferRequestTx(context.Background(), AcceptTransferRequestTxParams{ID: request.ID})
+	require.ErrorIs(t, err, ErrTransferRequestNotPending)
+
+	for _, account := range []Account{account1, account2} {
+		updated, err := testQueries.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, updated.Balance)
+	}
+}
+
+// TestAcceptExpiredTransferRequestTx tests that expired requests cannot be answered
+func TestAcceptExpiredTransferRequestTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	request := createRandomTransferRequest(t, account1, account2, time.Now().Add(-time.Minute))
+	require.Equal(t, TransferRequestExpired, request.StatusAt(time.Now()))
+
+	_, err := store.AcceptTransferRequestTx(context.Background(), AcceptTransferRequestTxParams{ID: request.ID})
+	require.ErrorIs(t, err, ErrTransferRequestExpired)
+
+	_, err = testQueries.DeclineTransferRequest(context.Background(), request.ID)
+	require.Error(t, err)
+
+	updated, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updated.Balance)
+}
+
+// TestAcceptTransferRequestTxFrozenAccount tests that accepting cannot move money out of a frozen account
+func TestAcceptTransferRequestTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	request := createRandomTransferRequest(t, account1, account2, time.Now().Add(time.Hour))
+
+	_, err := testQueries.FreezeAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+
+	_, err = store.AcceptTransferRequestTx(context.Background(), AcceptTransferRequestTxParams{ID: request.ID})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	//The request stays pending and no money moved
+	pending, err := testQueries.GetTransferRequest(context.Background(), request.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferRequestPending, pending.Status)
+
+	updated, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updated.Balance)
+}
+
+// TestTransferRequestStatusAt verifies pending requests read as expired after their expiry
+func TestTransferRequestStatusAt(t *testing.T) {
+	now := time.Now()
+	request := TransferRequest{Status: TransferRequestPending, ExpiresAt: now}
+
+	require.Equal(t, TransferRequestPending, request.StatusAt(now.Add(-time.Second)))
+	require.NoError(t, request.CheckPending(now.Add(-time.Second)))
+	require.Equal(t, TransferRequestExpired, request.StatusAt(now))
+	require.ErrorIs(t, request.CheckPending(now), ErrTransferRequestExpired)
+
+	//Answered requests keep their status
+	request.Status = TransferRequestDeclined
+	require.Equal(t, TransferRequestDeclined, request.StatusAt(now.Add(time.Hour)))
+	require.ErrorIs(t, request.CheckPending(now.Add(-time.Second)), ErrTransferRequestNotPending)
+}
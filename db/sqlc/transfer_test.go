@@ -80,3 +80,123 @@ func TestListTransfer(t *testing.T) {
 		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
 	}
 }
+
+// TestListOwnerTransfers verifies owners are resolved and results scoped to the owner
+func TestListOwnerTransfers(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account3 := createRandomAccount(t)
+
+	createRandomTransfer(t, account1, account2)
+	createRandomTransfer(t, account2, account1)
+	createRandomTransfer(t, account2, account3)
+
+	transfers, err := testQueries.ListOwnerTransfers(context.Background(), ListOwnerTransfersParams{
+		Owner:  account1.Owner,
+		Limit:  10,
+		Offset: 0,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+
+	for _, transfer := range transfers {
+		require.True(t, transfer.FromOwner == account1.Owner || transfer.ToOwner == account1.Owner)
+		require.Contains(t, []string{account1.Owner, account2.Owner}, transfer.FromOwner)
+		require.Contains(t, []string{account1.Owner, account2.Owner}, transfer.ToOwner)
+	}
+}
+
+// TestGetTransferWithOwners verifies both owners are resolved
+func TestGetTransferWithOwners(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	transfer := createRandomTransfer(t, account1, account2)
+
+	row, err := testQueries.GetTransferWithOwners(context.Background(), transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, transfer.ID, row.ID)
+	require.Equal(t, account1.Owner, row.FromOwner)
+	require.Equal(t, account2.Owner, row.ToOwner)
+}
+
+// TestSumTransfersSince verifies only transfers sent from the account since the cutoff are summed
+func TestSumTransfersSince(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	var expected int64
+	var first Transfer
+	for i := range 3 {
+		transfer := createRandomTransfer(t, account1, account2)
+		if i == 0 {
+			first = transfer
+		}
+		expected += transfer.Amount
+	}
+
+	//Incoming transfers do not count
+	createRandomTransfer(t, account2, account1)
+
+	total, err := testQueries.SumTransfersSince(context.Background(), SumTransfersSinceParams{
+		FromAccountID: account1.ID,
+		Since:         first.CreatedAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, expected, total)
+
+	total, err = testQueries.SumTransfersSince(context.Background(), SumTransfersSinceParams{
+		FromAccountID: account1.ID,
+		Since:         time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Zero(t, total)
+}
+
+// TestSumTransfersSinceSkipsRefunds verifies refunds and reversals do not count
+// against the daily limit of the account sending the money back
+func TestSumTransfersSinceSkipsRefunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	since := time.Now().Add(-time.Minute)
+
+	refunded, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	reversed, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	refund, err := store.RefundTransferTx(context.Background(), RefundTransferTxParams{
+		TransferID: refunded.Transfer.ID,
+		Amount:     5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, refunded.Transfer.ID, refund.Transfer.RefundOf.Int64)
+
+	_, err = store.ReverseTransferTx(context.Background(), reversed.Transfer.ID)
+	require.NoError(t, err)
+
+	//Money sent back by account2 is not a payment it made
+	total, err := testQueries.SumTransfersSince(context.Background(), SumTransfersSinceParams{
+		FromAccountID: account2.ID,
+		Since:         since,
+	})
+	require.NoError(t, err)
+	require.Zero(t, total)
+
+	total, err = testQueries.SumTransfersSince(context.Background(), SumTransfersSinceParams{
+		FromAccountID: account1.ID,
+		Since:         since,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(20), total)
+}
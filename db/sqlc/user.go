@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// The store encrypts user emails on write and decrypts them on read, so callers
+// only ever see plaintext emails.
+
+// CreateUser stores a new user with its email encrypted
+func (store *SQLStore) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	return store.createUser(ctx, store.Queries, arg)
+}
+
+// createUser encrypts the email of a new user and stores it with the given queries
+func (store *SQLStore) createUser(ctx context.Context, q *Queries, arg CreateUserParams) (User, error) {
+	var err error
+	encryptor := store.options.FieldEncryptor
+
+	email := arg.Email
+	arg.Email, arg.EmailKeyVersion, err = encryptor.Encrypt(email)
+	if err != nil {
+		return User{}, fmt.Errorf("cannot encrypt email: %w", err)
+	}
+	arg.EmailBlindIndex = store.emailBlindIndex(email)
+
+	user, err := q.CreateUser(ctx, arg)
+	if err != nil {
+		return user, translateError(err)
+	}
+	return store.decryptUser(user)
+}
+
+// GetUser returns a user with its email decrypted
+func (store *SQLStore) GetUser(ctx context.Context, username string) (User, error) {
+	user, err := store.Queries.GetUser(ctx, username)
+	if err != nil {
+		return user, err
+	}
+	return store.decryptUser(user)
+}
+
+// GetUserByEmail finds a user by plaintext email through its blind index,
+// falling back to emails stored before encryption was enabled
+func (store *SQLStore) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	user, err := store.Queries.GetUserByEmailIndex(ctx, GetUserByEmailIndexParams{
+		EmailBlindIndex: store.emailBlindIndex(email),
+		Email:           email,
+	})
+	if err != nil {
+		return user, err
+	}
+	return store.decryptUser(user)
+}
+
+// UpdateUserPasswordHash updates a user's password hash and returns it with its email decrypted
+func (store *SQLStore) UpdateUserPasswordHash(ctx context.Context, arg UpdateUserPasswordHashParams) (User, error) {
+	user, err := store.Queries.UpdateUserPasswordHash(ctx, arg)
+	if err != nil {
+		return user, err
+	}
+	return store.decryptUser(user)
+}
+
+// UpdateUserPassword replaces a user's password and returns it with its email decrypted
+func (store *SQLStore) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	user, err := store.Queries.UpdateUserPassword(ctx, arg)
+	if err != nil {
+		return user, err
+	}
+	return store.decryptUser(user)
+}
+
+// UpdateUser updates the provided profile fields of a user, encrypting a new email,
+// and returns it with its email decrypted
+func (store *SQLStore) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	if arg.Email.Valid {
+		email := arg.Email.String
+		ciphertext, keyVersion, err := store.options.FieldEncryptor.Encrypt(email)
+		if err != nil {
+			return User{}, fmt.Errorf("cannot encrypt email: %w", err)
+		}
+		arg.Email.String = ciphertext
+		arg.EmailKeyVersion = sql.NullInt32{Int32: keyVersion, Valid: true}
+		arg.EmailBlindIndex = store.emailBlindIndex(email)
+	}
+
+	user, err := store.Queries.UpdateUser(ctx, arg)
+	if err != nil {
+		return user, translateError(err)
+	}
+	return store.decryptUser(user)
+}
+
+// emailBlindIndex returns the lookup index of an email, NULL when encryption is disabled
+func (store *SQLStore) emailBlindIndex(email string) sql.NullString {
+	index := store.options.FieldEncryptor.BlindIndex(email)
+	return sql.NullString{String: index, Valid: index != ""}
+}
+
+// decryptUser replaces the stored email of a user with its plaintext
+func (store *SQLStore) decryptUser(user User) (User, error) {
+	email, err := store.options.FieldEncryptor.Decrypt(user.Email, user.EmailKeyVersion)
+	if err != nil {
+		return user, fmt.Errorf("cannot decrypt email of user %s: %w", user.Username, err)
+	}
+	user.Email = email
+	return user, nil
+}
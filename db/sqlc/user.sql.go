@@ -7,6 +7,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createUser = `-- name: CreateUser :one
@@ -14,17 +15,25 @@ INSERT INTO users (
     username,
     hashed_password,
     full_name,
-    email
+    email,
+    password_pepper_version,
+    email_key_version,
+    email_blind_index,
+    default_currency
 ) VALUES (
-    $1, $2, $3, $4
-) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, password_pepper_version, email_key_version, email_blind_index, is_email_verified, default_currency, role
 `
 
 type CreateUserParams struct {
-	Username       string `json:"username"`
-	HashedPassword string `json:"hashed_password"`
-	FullName       string `json:"full_name"`
-	Email          string `json:"email"`
+	Username              string         `json:"username"`
+	HashedPassword        string         `json:"hashed_password"`
+	FullName              string         `json:"full_name"`
+	Email                 string         `json:"email"`
+	PasswordPepperVersion int32          `json:"password_pepper_version"`
+	EmailKeyVersion       int32          `json:"email_key_version"`
+	EmailBlindIndex       sql.NullString `json:"email_blind_index"`
+	DefaultCurrency       sql.NullString `json:"default_currency"`
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
@@ -33,6 +42,10 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		arg.HashedPassword,
 		arg.FullName,
 		arg.Email,
+		arg.PasswordPepperVersion,
+		arg.EmailKeyVersion,
+		arg.EmailBlindIndex,
+		arg.DefaultCurrency,
 	)
 	var i User
 	err := row.Scan(
@@ -42,12 +55,18 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.PasswordPepperVersion,
+		&i.EmailKeyVersion,
+		&i.EmailBlindIndex,
+		&i.IsEmailVerified,
+		&i.DefaultCurrency,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, password_pepper_version, email_key_version, email_blind_index, is_email_verified, default_currency, role FROM users
 WHERE username = $1
 LIMIT 1
 `
@@ -62,6 +81,220 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.PasswordPepperVersion,
+		&i.EmailKeyVersion,
+		&i.EmailBlindIndex,
+		&i.IsEmailVerified,
+		&i.DefaultCurrency,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUserByEmailIndex = `-- name: GetUserByEmailIndex :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, password_pepper_version, email_key_version, email_blind_index, is_email_verified, default_currency, role FROM users
+WHERE email_blind_index = $1
+   OR (email_key_version = 0 AND email = $2)
+LIMIT 1
+`
+
+type GetUserByEmailIndexParams struct {
+	EmailBlindIndex sql.NullString `json:"email_blind_index"`
+	Email           string         `json:"email"`
+}
+
+func (q *Queries) GetUserByEmailIndex(ctx context.Context, arg GetUserByEmailIndexParams) (User, error) {
+	row := q.queryRow(ctx, q.getUserByEmailIndexStmt, getUserByEmailIndex, arg.EmailBlindIndex, arg.Email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.PasswordPepperVersion,
+		&i.EmailKeyVersion,
+		&i.EmailBlindIndex,
+		&i.IsEmailVerified,
+		&i.DefaultCurrency,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getUserForUpdate = `-- name: GetUserForUpdate :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, password_pepper_version, email_key_version, email_blind_index, is_email_verified, default_currency, role FROM users
+WHERE username = $1
+LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetUserForUpdate(ctx context.Context, username string) (User, error) {
+	row := q.queryRow(ctx, q.getUserForUpdateStmt, getUserForUpdate, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.PasswordPepperVersion,
+		&i.EmailKeyVersion,
+		&i.EmailBlindIndex,
+		&i.IsEmailVerified,
+		&i.DefaultCurrency,
+		&i.Role,
+	)
+	return i, err
+}
+
+const markUserEmailVerified = `-- name: MarkUserEmailVerified :one
+UPDATE users
+SET is_email_verified = TRUE
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, password_pepper_version, email_key_version, email_blind_index, is_email_verified, default_currency, role
+`
+
+func (q *Queries) MarkUserEmailVerified(ctx context.Context, username string) (User, error) {
+	row := q.queryRow(ctx, q.markUserEmailVerifiedStmt, markUserEmailVerified, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.PasswordPepperVersion,
+		&i.EmailKeyVersion,
+		&i.EmailBlindIndex,
+		&i.IsEmailVerified,
+		&i.DefaultCurrency,
+		&i.Role,
+	)
+	return i, err
+}
+
+const updateUser = `-- name: UpdateUser :one
+UPDATE users
+SET full_name = COALESCE($1, full_name),
+    email = COALESCE($2, email),
+    email_key_version = COALESCE($3, email_key_version),
+    email_blind_index = CASE
+        WHEN $2::varchar IS NULL THEN email_blind_index
+        ELSE $4
+    END,
+    is_email_verified = CASE
+        WHEN $2::varchar IS NOT NULL AND $5::bool THEN FALSE
+        ELSE is_email_verified
+    END
+WHERE username = $6
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, password_pepper_version, email_key_version, email_blind_index, is_email_verified, default_currency, role
+`
+
+type UpdateUserParams struct {
+	FullName           sql.NullString `json:"full_name"`
+	Email              sql.NullString `json:"email"`
+	EmailKeyVersion    sql.NullInt32  `json:"email_key_version"`
+	EmailBlindIndex    sql.NullString `json:"email_blind_index"`
+	ResetEmailVerified bool           `json:"reset_email_verified"`
+	Username           string         `json:"username"`
+}
+
+func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
+	row := q.queryRow(ctx, q.updateUserStmt, updateUser,
+		arg.FullName,
+		arg.Email,
+		arg.EmailKeyVersion,
+		arg.EmailBlindIndex,
+		arg.ResetEmailVerified,
+		arg.Username,
+	)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.PasswordPepperVersion,
+		&i.EmailKeyVersion,
+		&i.EmailBlindIndex,
+		&i.IsEmailVerified,
+		&i.DefaultCurrency,
+		&i.Role,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE users
+SET hashed_password = $2,
+    password_pepper_version = $3,
+    password_changed_at = now()
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, password_pepper_version, email_key_version, email_blind_index, is_email_verified, default_currency, role
+`
+
+type UpdateUserPasswordParams struct {
+	Username              string `json:"username"`
+	HashedPassword        string `json:"hashed_password"`
+	PasswordPepperVersion int32  `json:"password_pepper_version"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	row := q.queryRow(ctx, q.updateUserPasswordStmt, updateUserPassword, arg.Username, arg.HashedPassword, arg.PasswordPepperVersion)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.PasswordPepperVersion,
+		&i.EmailKeyVersion,
+		&i.EmailBlindIndex,
+		&i.IsEmailVerified,
+		&i.DefaultCurrency,
+		&i.Role,
+	)
+	return i, err
+}
+
+const updateUserPasswordHash = `-- name: UpdateUserPasswordHash :one
+UPDATE users
+SET hashed_password = $2,
+    password_pepper_version = $3
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, password_pepper_version, email_key_version, email_blind_index, is_email_verified, default_currency, role
+`
+
+type UpdateUserPasswordHashParams struct {
+	Username              string `json:"username"`
+	HashedPassword        string `json:"hashed_password"`
+	PasswordPepperVersion int32  `json:"password_pepper_version"`
+}
+
+func (q *Queries) UpdateUserPasswordHash(ctx context.Context, arg UpdateUserPasswordHashParams) (User, error) {
+	row := q.queryRow(ctx, q.updateUserPasswordHashStmt, updateUserPasswordHash, arg.Username, arg.HashedPassword, arg.PasswordPepperVersion)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.PasswordPepperVersion,
+		&i.EmailKeyVersion,
+		&i.EmailBlindIndex,
+		&i.IsEmailVerified,
+		&i.DefaultCurrency,
+		&i.Role,
 	)
 	return i, err
 }
@@ -2,10 +2,12 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
 	"github.com/codercollo/simple_bank/util"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,6 +35,7 @@ func createRandomUser(t *testing.T) User {
 	require.Equal(t, arg.HashedPassword, user.HashedPassword)
 	require.Equal(t, arg.FullName, user.FullName)
 	require.Equal(t, arg.Email, user.Email)
+	require.Equal(t, util.DepositorRole, user.Role)
 
 	//Timestamps
 	require.True(t, user.PasswordChangedAt.IsZero())
@@ -46,6 +49,28 @@ func TestCreateUser(t *testing.T) {
 	createRandomUser(t)
 }
 
+// TestCreateUserDuplicateConstraint verifies duplicates report the colliding constraint
+func TestCreateUserDuplicateConstraint(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	arg := CreateUserParams{
+		Username:       user.Username,
+		HashedPassword: user.HashedPassword,
+		FullName:       user.FullName,
+		Email:          util.RandomEmail(),
+	}
+	_, err := store.CreateUser(context.Background(), arg)
+	require.ErrorIs(t, err, ErrUniqueViolation)
+	require.Equal(t, UsersUsernameConstraint, ConstraintName(err))
+
+	arg.Username = util.RandomOwner()
+	arg.Email = user.Email
+	_, err = store.CreateUser(context.Background(), arg)
+	require.ErrorIs(t, err, ErrUniqueViolation)
+	require.Equal(t, UsersEmailConstraint, ConstraintName(err))
+}
+
 // TestGetUser ensures a user can be fetched by username
 func TestGetUser(t *testing.T) {
 	//Create user
@@ -66,3 +91,278 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, user1.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 }
+
+// TestUpdateUserPasswordHash verifies the hash and pepper version are replaced together
+func TestUpdateUserPasswordHash(t *testing.T) {
+	user1 := createRandomUser(t)
+	require.Zero(t, user1.PasswordPepperVersion)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	user2, err := testQueries.UpdateUserPasswordHash(context.Background(), UpdateUserPasswordHashParams{
+		Username:              user1.Username,
+		HashedPassword:        hashedPassword,
+		PasswordPepperVersion: 2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, user2.HashedPassword)
+	require.Equal(t, int32(2), user2.PasswordPepperVersion)
+	require.Equal(t, user1.PasswordChangedAt, user2.PasswordChangedAt)
+}
+
+// TestUpdateUserPassword tests a password change stamps password_changed_at
+func TestUpdateUserPassword(t *testing.T) {
+	store := NewStore(testDB)
+	user1 := createRandomUser(t)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	user2, err := store.UpdateUserPassword(context.Background(), UpdateUserPasswordParams{
+		Username:              user1.Username,
+		HashedPassword:        hashedPassword,
+		PasswordPepperVersion: 1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, user2.HashedPassword)
+	require.Equal(t, int32(1), user2.PasswordPepperVersion)
+	require.Equal(t, user1.Email, user2.Email)
+	require.WithinDuration(t, time.Now(), user2.PasswordChangedAt, time.Minute)
+}
+
+// TestBlockUserSessions tests every open session of a user is blocked
+func TestBlockUserSessions(t *testing.T) {
+	user := createRandomUser(t)
+
+	for range 2 {
+		_, err := testQueries.CreateSession(context.Background(), CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     user.Username,
+			RefreshToken: util.RandomString(32),
+			ExpiresAt:    time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+	}
+
+	blocked, err := testQueries.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), blocked)
+
+	//Already blocked sessions are not counted again
+	blocked, err = testQueries.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Zero(t, blocked)
+}
+
+// TestListSessionsByUser verifies only the user's active sessions are listed
+func TestListSessionsByUser(t *testing.T) {
+	user := createRandomUser(t)
+	other := createRandomUser(t)
+
+	createSession := func(username string, expiresAt time.Time, blocked bool) Session {
+		session, err := testQueries.CreateSession(context.Background(), CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     username,
+			RefreshToken: util.RandomString(32),
+			UserAgent:    "test-agent",
+			ClientIp:     "127.0.0.1",
+			IsBlocked:    blocked,
+			ExpiresAt:    expiresAt,
+		})
+		require.NoError(t, err)
+		return session
+	}
+
+	active := createSession(user.Username, time.Now().Add(time.Hour), false)
+	createSession(user.Username, time.Now().Add(time.Hour), true)
+	createSession(user.Username, time.Now().Add(-time.Minute), false)
+	createSession(other.Username, time.Now().Add(time.Hour), false)
+
+	sessions, err := testQueries.ListSessionsByUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, active.ID, sessions[0].ID)
+	require.Equal(t, "test-agent", sessions[0].UserAgent)
+	require.Equal(t, "127.0.0.1", sessions[0].ClientIp)
+
+	//A revoked session drops out of the listing
+	_, err = testQueries.RevokeSession(context.Background(), active.ID)
+	require.NoError(t, err)
+	sessions, err = testQueries.ListSessionsByUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+// TestRevokeSession verifies a session is found by its access token and revoked once
+func TestRevokeSession(t *testing.T) {
+	user := createRandomUser(t)
+	accessTokenID := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+
+	session, err := testQueries.CreateSession(context.Background(), CreateSessionParams{
+		ID:            uuid.New(),
+		Username:      user.Username,
+		RefreshToken:  util.RandomString(32),
+		ExpiresAt:     time.Now().Add(time.Hour),
+		AccessTokenID: accessTokenID,
+	})
+	require.NoError(t, err)
+
+	found, err := testQueries.GetSessionByAccessToken(context.Background(), accessTokenID)
+	require.NoError(t, err)
+	require.Equal(t, session.ID, found.ID)
+
+	//A renewed access token replaces the old one
+	renewedID := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	err = testQueries.SetSessionAccessToken(context.Background(), SetSessionAccessTokenParams{
+		ID:            session.ID,
+		AccessTokenID: renewedID,
+	})
+	require.NoError(t, err)
+	_, err = testQueries.GetSessionByAccessToken(context.Background(), accessTokenID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	revoked, err := testQueries.RevokeSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), revoked)
+
+	found, err = testQueries.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, found.IsBlocked)
+
+	//Revoking again changes nothing
+	revoked, err = testQueries.RevokeSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.Zero(t, revoked)
+}
+
+// TestUserEmailEncryption ensures emails are stored encrypted and read back in plaintext
+func TestUserEmailEncryption(t *testing.T) {
+	encryptor, err := util.NewFieldEncryptor(util.Config{
+		DataEncryptionKey: util.RandomString(32),
+		BlindIndexKey:     util.RandomString(32),
+	})
+	require.NoError(t, err)
+	store := NewStoreWithOptions(testDB, StoreOptions{FieldEncryptor: encryptor})
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+	user, err := store.CreateUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Email, user.Email)
+	require.Equal(t, int32(1), user.EmailKeyVersion)
+
+	//The stored column holds ciphertext
+	stored, err := testQueries.GetUser(context.Background(), arg.Username)
+	require.NoError(t, err)
+	require.NotEqual(t, arg.Email, stored.Email)
+	require.NotContains(t, stored.Email, arg.Email)
+	require.True(t, stored.EmailBlindIndex.Valid)
+
+	//Reads through the store decrypt it
+	fetched, err := store.GetUser(context.Background(), arg.Username)
+	require.NoError(t, err)
+	require.Equal(t, arg.Email, fetched.Email)
+
+	//Lookup by email goes through the blind index
+	found, err := store.GetUserByEmail(context.Background(), arg.Email)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, found.Username)
+	require.Equal(t, arg.Email, found.Email)
+
+	_, err = store.GetUserByEmail(context.Background(), util.RandomEmail())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	//The blind index keeps encrypted emails unique
+	arg.Username = util.RandomOwner()
+	_, err = store.CreateUser(context.Background(), arg)
+	require.ErrorIs(t, err, ErrUniqueViolation)
+	require.Equal(t, UsersEmailIndexConstraint, ConstraintName(err))
+}
+
+// TestGetUserByEmailPlaintext ensures emails stored before encryption can still be found
+func TestGetUserByEmailPlaintext(t *testing.T) {
+	user := createRandomUser(t)
+
+	encryptor, err := util.NewFieldEncryptor(util.Config{
+		DataEncryptionKey: util.RandomString(32),
+		BlindIndexKey:     util.RandomString(32),
+	})
+	require.NoError(t, err)
+	store := NewStoreWithOptions(testDB, StoreOptions{FieldEncryptor: encryptor})
+
+	found, err := store.GetUserByEmail(context.Background(), user.Email)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, found.Username)
+	require.Equal(t, user.Email, found.Email)
+}
+
+// TestUpdateUser ensures only the provided profile fields are updated
+func TestUpdateUser(t *testing.T) {
+	encryptor, err := util.NewFieldEncryptor(util.Config{
+		DataEncryptionKey: util.RandomString(32),
+		BlindIndexKey:     util.RandomString(32),
+	})
+	require.NoError(t, err)
+	store := NewStoreWithOptions(testDB, StoreOptions{FieldEncryptor: encryptor})
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+	user, err := store.CreateUser(context.Background(), CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	_, err = store.MarkUserEmailVerified(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	//Only the full name changes
+	newFullName := util.RandomOwner()
+	updated, err := store.UpdateUser(context.Background(), UpdateUserParams{
+		Username: user.Username,
+		FullName: sql.NullString{String: newFullName, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newFullName, updated.FullName)
+	require.Equal(t, user.Email, updated.Email)
+	require.True(t, updated.IsEmailVerified)
+
+	//A new email is encrypted, indexed and needs verifying again
+	newEmail := util.RandomEmail()
+	updated, err = store.UpdateUser(context.Background(), UpdateUserParams{
+		Username:           user.Username,
+		Email:              sql.NullString{String: newEmail, Valid: true},
+		ResetEmailVerified: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, newFullName, updated.FullName)
+	require.Equal(t, newEmail, updated.Email)
+	require.False(t, updated.IsEmailVerified)
+
+	found, err := store.GetUserByEmail(context.Background(), newEmail)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, found.Username)
+
+	//Another user's email is rejected
+	other, err := store.CreateUser(context.Background(), CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	_, err = store.UpdateUser(context.Background(), UpdateUserParams{
+		Username: user.Username,
+		Email:    sql.NullString{String: other.Email, Valid: true},
+	})
+	require.ErrorIs(t, err, ErrUniqueViolation)
+}
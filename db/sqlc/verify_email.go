@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Errors returned when a verification code can no longer be used
+var (
+	ErrVerifyEmailUsed    = errors.New("verification code has already been used")
+	ErrVerifyEmailExpired = errors.New("verification code has expired")
+
+	//The code was sent to an address the user has since replaced
+	ErrVerifyEmailChanged = errors.New("verification code was sent to a previous email")
+)
+
+// CheckUsable reports why the code cannot verify the email at the given time, if it can't
+func (verifyEmail VerifyEmail) CheckUsable(now time.Time) error {
+	if verifyEmail.IsUsed {
+		return ErrVerifyEmailUsed
+	}
+	if !now.Before(verifyEmail.ExpiresAt) {
+		return ErrVerifyEmailExpired
+	}
+	return nil
+}
+
+// CreateVerifyEmail stores a verification code with its email encrypted
+func (store *SQLStore) CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error) {
+	var err error
+
+	email := arg.Email
+	arg.Email, arg.EmailKeyVersion, err = store.options.FieldEncryptor.Encrypt(email)
+	if err != nil {
+		return VerifyEmail{}, fmt.Errorf("cannot encrypt email: %w", err)
+	}
+
+	verifyEmail, err := store.Queries.CreateVerifyEmail(ctx, arg)
+	if err != nil {
+		return verifyEmail, translateError(err)
+	}
+	verifyEmail.Email = email
+	return verifyEmail, nil
+}
+
+// Email verification input parameters
+type VerifyEmailTxParams struct {
+	ID         int64  `json:"id"`
+	SecretCode string `json:"secret_code"`
+}
+
+// Email verification result data
+type VerifyEmailTxResult struct {
+	User        User        `json:"user"`
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// checkVerifyEmailCurrent fails with ErrVerifyEmailChanged unless the code was
+// sent to the user's current email
+func (store *SQLStore) checkVerifyEmailCurrent(verifyEmail VerifyEmail, user User) error {
+	codeEmail, err := store.options.FieldEncryptor.Decrypt(verifyEmail.Email, verifyEmail.EmailKeyVersion)
+	if err != nil {
+		return fmt.Errorf("cannot decrypt verification email: %w", err)
+	}
+	user, err = store.decryptUser(user)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(codeEmail, user.Email) {
+		return ErrVerifyEmailChanged
+	}
+	return nil
+}
+
+// VerifyEmailTx uses up a verification code and marks its user's email verified.
+// A wrong code is reported as ErrRecordNotFound, like an unknown id.
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
+	var result VerifyEmailTxResult
+
+	err := store.execTx(ctx, nil, func(q *Queries) error {
+		//Lock the code so it can only be used once
+		verifyEmail, err := q.GetVerifyEmailForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(verifyEmail.SecretCode), []byte(arg.SecretCode)) != 1 {
+			return ErrRecordNotFound
+		}
+		if err := verifyEmail.CheckUsable(time.Now()); err != nil {
+			return err
+		}
+
+		//The code only proves ownership of the address it was sent to, so it
+		//can't verify an email changed since. The user row stays locked so the
+		//email can't change before it is marked verified.
+		user, err := q.GetUserForUpdate(ctx, verifyEmail.Username)
+		if err != nil {
+			return err
+		}
+		if err := store.checkVerifyEmailCurrent(verifyEmail, user); err != nil {
+			return err
+		}
+
+		result.VerifyEmail, err = q.MarkVerifyEmailUsed(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.MarkUserEmailVerified(ctx, verifyEmail.Username)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	//Hand back plaintext emails like every other user read
+	result.User, err = store.decryptUser(result.User)
+	if err != nil {
+		return result, err
+	}
+	result.VerifyEmail.Email, err = store.options.FieldEncryptor.Decrypt(result.VerifyEmail.Email, result.VerifyEmail.EmailKeyVersion)
+	return result, err
+}
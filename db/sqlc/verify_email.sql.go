@@ -0,0 +1,98 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: verify_email.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createVerifyEmail = `-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+    username,
+    email,
+    email_key_version,
+    secret_code,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING id, username, email, email_key_version, secret_code, is_used, created_at, expires_at
+`
+
+type CreateVerifyEmailParams struct {
+	Username        string    `json:"username"`
+	Email           string    `json:"email"`
+	EmailKeyVersion int32     `json:"email_key_version"`
+	SecretCode      string    `json:"secret_code"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error) {
+	row := q.queryRow(ctx, q.createVerifyEmailStmt, createVerifyEmail,
+		arg.Username,
+		arg.Email,
+		arg.EmailKeyVersion,
+		arg.SecretCode,
+		arg.ExpiresAt,
+	)
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.EmailKeyVersion,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getVerifyEmailForUpdate = `-- name: GetVerifyEmailForUpdate :one
+SELECT id, username, email, email_key_version, secret_code, is_used, created_at, expires_at FROM verify_emails
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetVerifyEmailForUpdate(ctx context.Context, id int64) (VerifyEmail, error) {
+	row := q.queryRow(ctx, q.getVerifyEmailForUpdateStmt, getVerifyEmailForUpdate, id)
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.EmailKeyVersion,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const markVerifyEmailUsed = `-- name: MarkVerifyEmailUsed :one
+UPDATE verify_emails
+SET is_used = TRUE
+WHERE id = $1
+RETURNING id, username, email, email_key_version, secret_code, is_used, created_at, expires_at
+`
+
+func (q *Queries) MarkVerifyEmailUsed(ctx context.Context, id int64) (VerifyEmail, error) {
+	row := q.queryRow(ctx, q.markVerifyEmailUsedStmt, markVerifyEmailUsed, id)
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.EmailKeyVersion,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
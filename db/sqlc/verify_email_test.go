@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// createRandomVerifyEmail creates a verification code for a new user
+func createRandomVerifyEmail(t *testing.T, store Store, expiresAt time.Time) VerifyEmail {
+	user := createRandomUser(t)
+
+	verifyEmail, err := store.CreateVerifyEmail(context.Background(), CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      user.Email,
+		SecretCode: util.RandomString(32),
+		ExpiresAt:  expiresAt,
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, verifyEmail.Username)
+	require.Equal(t, user.Email, verifyEmail.Email)
+	require.False(t, verifyEmail.IsUsed)
+
+	return verifyEmail
+}
+
+// TestVerifyEmailTx tests a code verifies its user's email only once
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+	verifyEmail := createRandomVerifyEmail(t, store, time.Now().Add(time.Hour))
+
+	arg := VerifyEmailTxParams{ID: verifyEmail.ID, SecretCode: verifyEmail.SecretCode}
+	result, err := store.VerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.VerifyEmail.IsUsed)
+	require.True(t, result.User.IsEmailVerified)
+	require.Equal(t, verifyEmail.Username, result.User.Username)
+	require.Equal(t, verifyEmail.Email, result.User.Email)
+
+	//The code cannot be used again
+	_, err = store.VerifyEmailTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrVerifyEmailUsed)
+}
+
+// TestVerifyEmailTxExpired tests expired codes leave the email unverified
+func TestVerifyEmailTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	verifyEmail := createRandomVerifyEmail(t, store, time.Now().Add(-time.Minute))
+
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{ID: verifyEmail.ID, SecretCode: verifyEmail.SecretCode})
+	require.ErrorIs(t, err, ErrVerifyEmailExpired)
+
+	user, err := store.GetUser(context.Background(), verifyEmail.Username)
+	require.NoError(t, err)
+	require.False(t, user.IsEmailVerified)
+}
+
+// TestVerifyEmailTxEmailChanged tests a code sent to a previous email can't
+// verify the new one
+func TestVerifyEmailTxEmailChanged(t *testing.T) {
+	store := NewStore(testDB)
+	verifyEmail := createRandomVerifyEmail(t, store, time.Now().Add(time.Hour))
+
+	_, err := store.UpdateUser(context.Background(), UpdateUserParams{
+		Username:           verifyEmail.Username,
+		Email:              sql.NullString{String: util.RandomEmail(), Valid: true},
+		ResetEmailVerified: true,
+	})
+	require.NoError(t, err)
+
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{ID: verifyEmail.ID, SecretCode: verifyEmail.SecretCode})
+	require.ErrorIs(t, err, ErrVerifyEmailChanged)
+
+	user, err := store.GetUser(context.Background(), verifyEmail.Username)
+	require.NoError(t, err)
+	require.False(t, user.IsEmailVerified)
+}
+
+// TestVerifyEmailTxWrongCode tests a wrong code is treated like an unknown one
+func TestVerifyEmailTxWrongCode(t *testing.T) {
+	store := NewStore(testDB)
+	verifyEmail := createRandomVerifyEmail(t, store, time.Now().Add(time.Hour))
+
+	_, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{ID: verifyEmail.ID, SecretCode: "wrong"})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+// TestVerifyEmailCheckUsable tests the reasons a code can't be used
+func TestVerifyEmailCheckUsable(t *testing.T) {
+	now := time.Now()
+
+	require.NoError(t, VerifyEmail{ExpiresAt: now.Add(time.Minute)}.CheckUsable(now))
+	require.ErrorIs(t, VerifyEmail{ExpiresAt: now}.CheckUsable(now), ErrVerifyEmailExpired)
+	require.ErrorIs(t, VerifyEmail{IsUsed: true, ExpiresAt: now.Add(time.Minute)}.CheckUsable(now), ErrVerifyEmailUsed)
+}
@@ -1,30 +1,66 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/codercollo/simple_bank/api"
 	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/notify"
+	"github.com/codercollo/simple_bank/statement"
+	"github.com/codercollo/simple_bank/tracing"
 	"github.com/codercollo/simple_bank/util"
 	_ "github.com/lib/pq"
 )
 
 func main() {
+	//Stop gracefully on interrupt or termination
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	//Load config
 	config, err := util.LoadConfig(".")
 	if err != nil {
 		log.Fatal("cannot load config:", err)
 	}
 
-	//Initialize database connection
-	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	//Export traces when an OTLP endpoint is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), config)
+	if err != nil {
+		log.Fatal("cannot set up tracing:", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	//Initialize database connection pool
+	conn, err := db.Open(ctx, config)
 	if err != nil {
 		log.Fatal("cannot connect to db:", err)
 	}
 
 	//Initialize application dependecies
-	store := db.NewStore(conn)
+	transferIsolation, err := db.ParseIsolationLevel(config.TransferIsolation)
+	if err != nil {
+		log.Fatal("invalid transfer isolation:", err)
+	}
+	fieldEncryptor, err := util.NewFieldEncryptor(config)
+	if err != nil {
+		log.Fatal("invalid data encryption config:", err)
+	}
+	store := db.NewStoreWithOptions(conn, db.StoreOptions{
+		TransferIsolation:   transferIsolation,
+		FieldEncryptor:      fieldEncryptor,
+		MaxTxAttempts:       config.DBMaxTxAttempts,
+		OptimisticTransfers: config.OptimisticTransfers,
+	})
+
+	//Schedule monthly statements when enabled
+	if config.StatementJobInterval > 0 {
+		job := statement.NewJob(store, notify.NewLogNotifier())
+		go job.Start(ctx, config.StatementJobInterval)
+	}
 
 	server, err := api.NewServer(store, config)
 	if err != nil {
@@ -32,7 +68,8 @@ func main() {
 
 	}
 
-	if err := server.Start(config.ServerAddress); err != nil {
+	if err := server.Start(ctx, config.ServerAddress); err != nil {
 		log.Fatal("cannot start server:", err)
 	}
+	log.Println("server stopped")
 }
@@ -0,0 +1,20 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// LogNotifier writes notifications to the application log
+type LogNotifier struct{}
+
+// NewLogNotifier creates a notifier that only logs notifications
+func NewLogNotifier() Notifier {
+	return &LogNotifier{}
+}
+
+// Notify logs the notification instead of delivering it
+func (notifier *LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	log.Printf("notify %s: %s - %s", notification.Username, notification.Subject, notification.Content)
+	return nil
+}
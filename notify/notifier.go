@@ -0,0 +1,16 @@
+package notify
+
+import "context"
+
+// Notification is a message addressed to a single user
+type Notification struct {
+	Username string `json:"username"`
+	Subject  string `json:"subject"`
+	Content  string `json:"content"`
+}
+
+// Notifier defines the interface for delivering notifications to users
+type Notifier interface {
+	//Notify delivers a notification to its recipient
+	Notify(ctx context.Context, notification Notification) error
+}
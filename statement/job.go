@@ -0,0 +1,102 @@
+package statement
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/notify"
+)
+
+// Job generates and delivers the monthly statements
+type Job struct {
+	store    db.Store
+	notifier notify.Notifier
+}
+
+// NewJob creates a monthly statement job
+func NewJob(store db.Store, notifier notify.Notifier) *Job {
+	return &Job{
+		store:    store,
+		notifier: notifier,
+	}
+}
+
+// Run generates the prior month's statement for every account with statements
+// enabled. A statement is recorded before delivery, so running the job again
+// for the same month skips the accounts already handled. It returns the
+// number of statements generated.
+func (job *Job) Run(ctx context.Context, now time.Time) (int, error) {
+	start, end := MonthPeriod(now)
+
+	accounts, err := job.store.ListStatementAccounts(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("cannot list statement accounts: %w", err)
+	}
+
+	generated := 0
+	for _, account := range accounts {
+		ok, err := job.generate(ctx, account, start, end)
+		if err != nil {
+			log.Printf("cannot generate statement for account %d: %v", account.ID, err)
+			continue
+		}
+		if ok {
+			generated++
+		}
+	}
+	return generated, nil
+}
+
+// generate records, builds and delivers one statement.
+// It reports false when the statement was already generated.
+func (job *Job) generate(ctx context.Context, account db.Account, start, end time.Time) (bool, error) {
+	//Claim the account and month, skipping if already generated
+	record, err := job.store.CreateStatement(ctx, db.CreateStatementParams{
+		AccountID:   account.ID,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	statement, err := Build(ctx, job.store, account, start, end)
+	if err == nil {
+		err = job.notifier.Notify(ctx, statement.Notification())
+	}
+	if err != nil {
+		//Release the claim so the next run retries
+		if delErr := job.store.DeleteStatement(ctx, record.ID); delErr != nil {
+			log.Printf("cannot release statement %d: %v", record.ID, delErr)
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Start runs the job every interval until ctx is done
+func (job *Job) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := job.Run(ctx, time.Now()); err != nil {
+			log.Printf("statement job failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package statement
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	mocknotify "github.com/codercollo/simple_bank/notify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestJobRun verifies one statement per eligible account and month
+func TestJobRun(t *testing.T) {
+	now := time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC)
+	start, end := MonthPeriod(now)
+
+	account1 := db.Account{ID: 1, Owner: "owner1", Currency: "USD", StatementsEnabled: true}
+	account2 := db.Account{ID: 2, Owner: "owner2", Currency: "EUR", StatementsEnabled: true}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	notifier := mocknotify.NewMockNotifier(ctrl)
+	job := NewJob(store, notifier)
+
+	store.EXPECT().
+		ListStatementAccounts(gomock.Any()).
+		Times(2).
+		Return([]db.Account{account1, account2}, nil)
+	store.EXPECT().ListEntriesInRange(gomock.Any(), gomock.Any()).AnyTimes().Return([]db.Entry{}, nil)
+	store.EXPECT().SumEntriesSince(gomock.Any(), gomock.Any()).AnyTimes().Return(int64(0), nil)
+
+	//First run claims both accounts, the second finds them already generated
+	generated := map[int64]bool{}
+	store.EXPECT().
+		CreateStatement(gomock.Any(), gomock.Any()).
+		Times(4).
+		DoAndReturn(func(_ context.Context, arg db.CreateStatementParams) (db.Statement, error) {
+			require.Equal(t, start, arg.PeriodStart)
+			require.Equal(t, end, arg.PeriodEnd)
+			if generated[arg.AccountID] {
+				return db.Statement{}, sql.ErrNoRows
+			}
+			generated[arg.AccountID] = true
+			return db.Statement{ID: arg.AccountID, AccountID: arg.AccountID}, nil
+		})
+	notifier.EXPECT().Notify(gomock.Any(), gomock.Any()).Times(2).Return(nil)
+
+	count, err := job.Run(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	count, err = job.Run(context.Background(), now.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+}
+
+// TestJobRunReleasesFailedDelivery verifies a failed delivery can be retried
+func TestJobRunReleasesFailedDelivery(t *testing.T) {
+	now := time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC)
+	account := db.Account{ID: 1, Owner: "owner", Currency: "USD", StatementsEnabled: true}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mock.NewMockStore(ctrl)
+	notifier := mocknotify.NewMockNotifier(ctrl)
+	job := NewJob(store, notifier)
+
+	store.EXPECT().ListStatementAccounts(gomock.Any()).Times(1).Return([]db.Account{account}, nil)
+	store.EXPECT().CreateStatement(gomock.Any(), gomock.Any()).Times(1).Return(db.Statement{ID: 7, AccountID: account.ID}, nil)
+	store.EXPECT().ListEntriesInRange(gomock.Any(), gomock.Any()).Times(1).Return([]db.Entry{}, nil)
+	store.EXPECT().SumEntriesSince(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+	notifier.EXPECT().Notify(gomock.Any(), gomock.Any()).Times(1).Return(errors.New("smtp down"))
+	store.EXPECT().DeleteStatement(gomock.Any(), gomock.Eq(int64(7))).Times(1).Return(nil)
+
+	count, err := job.Run(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+}
@@ -0,0 +1,87 @@
+package statement
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/codercollo/simple_bank/notify"
+)
+
+// Statement summarizes the entries of an account over a period
+type Statement struct {
+	Account        db.Account `json:"account"`
+	PeriodStart    time.Time  `json:"period_start"`
+	PeriodEnd      time.Time  `json:"period_end"`
+	OpeningBalance int64      `json:"opening_balance"`
+	ClosingBalance int64      `json:"closing_balance"`
+	Entries        []db.Entry `json:"entries"`
+}
+
+// MonthPeriod returns the calendar month before t as a half-open UTC range
+func MonthPeriod(t time.Time) (start, end time.Time) {
+	t = t.UTC()
+	end = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	start = end.AddDate(0, -1, 0)
+	return start, end
+}
+
+// Build assembles the statement of an account for [start, end).
+// Balances are derived from the current balance and the entries recorded since.
+func Build(ctx context.Context, store db.Store, account db.Account, start, end time.Time) (Statement, error) {
+	//Entries inside the period
+	entries, err := store.ListEntriesInRange(ctx, db.ListEntriesInRangeParams{
+		AccountID: account.ID,
+		FromTime:  start,
+		ToTime:    end,
+	})
+	if err != nil {
+		return Statement{}, fmt.Errorf("cannot list entries: %w", err)
+	}
+
+	//Entries after the period move the current balance away from the closing one
+	after, err := store.SumEntriesSince(ctx, db.SumEntriesSinceParams{
+		AccountID: account.ID,
+		Since:     end,
+	})
+	if err != nil {
+		return Statement{}, fmt.Errorf("cannot sum entries: %w", err)
+	}
+
+	closing := account.Balance - after
+	opening := closing
+	for _, entry := range entries {
+		opening -= entry.Amount
+	}
+
+	return Statement{
+		Account:        account,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		OpeningBalance: opening,
+		ClosingBalance: closing,
+		Entries:        entries,
+	}, nil
+}
+
+// Notification renders the statement as a notification for the account owner
+func (statement Statement) Notification() notify.Notification {
+	var content strings.Builder
+	fmt.Fprintf(&content, "Statement for account %d from %s to %s\n",
+		statement.Account.ID,
+		statement.PeriodStart.Format(time.DateOnly),
+		statement.PeriodEnd.AddDate(0, 0, -1).Format(time.DateOnly))
+	fmt.Fprintf(&content, "Opening balance: %d %s\n", statement.OpeningBalance, statement.Account.Currency)
+	for _, entry := range statement.Entries {
+		fmt.Fprintf(&content, "%s %s %d\n", entry.CreatedAt.UTC().Format(time.DateOnly), entry.Direction(), entry.AbsAmount())
+	}
+	fmt.Fprintf(&content, "Closing balance: %d %s", statement.ClosingBalance, statement.Account.Currency)
+
+	return notify.Notification{
+		Username: statement.Account.Owner,
+		Subject:  fmt.Sprintf("Your %s statement for account %d", statement.PeriodStart.Format("January 2006"), statement.Account.ID),
+		Content:  content.String(),
+	}
+}
@@ -0,0 +1,61 @@
+package statement
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/db/mock"
+	db "github.com/codercollo/simple_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// TestMonthPeriod verifies the prior calendar month is selected
+func TestMonthPeriod(t *testing.T) {
+	start, end := MonthPeriod(time.Date(2024, time.March, 15, 10, 0, 0, 0, time.UTC))
+	require.Equal(t, time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), start)
+	require.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), end)
+
+	//January rolls back into the previous year
+	start, end = MonthPeriod(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	require.Equal(t, time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC), start)
+	require.Equal(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), end)
+}
+
+// TestBuild verifies opening and closing balances are derived from the entries
+func TestBuild(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	account := db.Account{ID: 1, Owner: "owner", Balance: 500, Currency: "USD"}
+	start, end := MonthPeriod(time.Date(2024, time.March, 15, 0, 0, 0, 0, time.UTC))
+	entries := []db.Entry{
+		{ID: 1, AccountID: account.ID, Amount: 200, CreatedAt: start.Add(time.Hour)},
+		{ID: 2, AccountID: account.ID, Amount: -50, CreatedAt: start.Add(2 * time.Hour)},
+	}
+
+	store := mock.NewMockStore(ctrl)
+	store.EXPECT().
+		ListEntriesInRange(gomock.Any(), gomock.Eq(db.ListEntriesInRangeParams{AccountID: account.ID, FromTime: start, ToTime: end})).
+		Times(1).
+		Return(entries, nil)
+	store.EXPECT().
+		SumEntriesSince(gomock.Any(), gomock.Eq(db.SumEntriesSinceParams{AccountID: account.ID, Since: end})).
+		Times(1).
+		Return(int64(100), nil)
+
+	statement, err := Build(context.Background(), store, account, start, end)
+	require.NoError(t, err)
+	require.Equal(t, int64(400), statement.ClosingBalance)
+	require.Equal(t, int64(250), statement.OpeningBalance)
+	require.Len(t, statement.Entries, 2)
+
+	notification := statement.Notification()
+	require.Equal(t, account.Owner, notification.Username)
+	require.Contains(t, notification.Subject, "February 2024")
+	require.Contains(t, notification.Content, "Opening balance: 250 USD")
+	require.Contains(t, notification.Content, "credit 200")
+	require.Contains(t, notification.Content, "debit 50")
+	require.Contains(t, notification.Content, "Closing balance: 400 USD")
+}
@@ -1,7 +1,6 @@
 package token
 
 import (
-	"errors"
 	"fmt"
 	"time"
 
@@ -13,22 +12,36 @@ const minSecretKeySize = 32
 // JWTMaker creates and verifies JWT tokens using HMAC
 type JWTMaker struct {
 	secretKey string
+	options   Options
 }
 
+// JWTMaker must keep satisfying Maker
+var _ Maker = (*JWTMaker)(nil)
+
 // NewJWTMaker initializes a JWT maker with a minimum secret key length
 func NewJWTMaker(secretKey string) (Maker, error) {
+	return NewJWTMakerWithOptions(secretKey, Options{})
+}
+
+// NewJWTMakerWithOptions initializes a JWT maker with custom verification options
+func NewJWTMakerWithOptions(secretKey string, options Options) (Maker, error) {
 	//Enforce minimum secret key length for security
 	if len(secretKey) < minSecretKeySize {
-		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+		return nil, fmt.Errorf("invalid key size: must be at least %d characters, got %d; %s", minSecretKeySize, len(secretKey), keyHint)
 	}
 
-	return &JWTMaker{secretKey}, nil
+	options, err := options.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWTMaker{secretKey: secretKey, options: options}, nil
 }
 
 // CreateToken generates a signed JWT for a given username and duraion
-func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
+func (maker *JWTMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
 	//Create token payload with expiration
-	payload, err := NewPayload(username, duration)
+	payload, err := maker.options.newPayload(username, role, duration)
 	if err != nil {
 		return "", payload, err
 	}
@@ -55,14 +68,10 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 		return []byte(maker.secretKey), nil
 	}
 
-	//Parse and validate token claims
-	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	//Parse the token, claims are validated below with the clock skew applied
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	jwtToken, err := parser.ParseWithClaims(token, &Payload{}, keyFunc)
 	if err != nil {
-		//Distinguish expired token from other errors
-		verr, ok := err.(*jwt.ValidationError)
-		if ok && errors.Is(verr.Inner, ErrExpiredToken) {
-			return nil, ErrExpiredToken
-		}
 		return nil, ErrInvalidToken
 	}
 
@@ -72,6 +81,11 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 		return nil, ErrInvalidToken
 	}
 
+	//Validate payload claims
+	if err := maker.options.validate(payload); err != nil {
+		return nil, err
+	}
+
 	return payload, nil
 
 }
@@ -17,6 +17,7 @@ func TestJWTMaker(t *testing.T) {
 
 	//Prepare test inputs
 	username := util.RandomOwner()
+	role := util.BankerRole
 	duration := time.Minute
 
 	//Expected issue and expiry times
@@ -24,7 +25,7 @@ func TestJWTMaker(t *testing.T) {
 	expiredAt := issuedAt.Add(duration)
 
 	//Create JWT token
-	token, payload, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 
@@ -36,6 +37,7 @@ func TestJWTMaker(t *testing.T) {
 	//Validate payload contents
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
 	require.WithinDuration(t, issuedAt, payload.IssueAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
@@ -47,7 +49,7 @@ func TestExpiredJWTToken(t *testing.T) {
 	require.NoError(t, err)
 
 	//Create token with negative duration (already expired)
-	token, payload, err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -62,7 +64,7 @@ func TestExpiredJWTToken(t *testing.T) {
 // TestInvalidJWTTokenAlgNone ensures unsigned tokens are rejected
 func TestInvalidJWTTokenALgNone(t *testing.T) {
 	//Create valid payload
-	payload, err := NewPayload(util.RandomOwner(), time.Minute)
+	payload, err := NewPayload(util.RandomOwner(), util.DepositorRole, time.Minute)
 	require.NoError(t, err)
 
 	//Create JWT using "none" signing algorithm (insecure)
@@ -80,3 +82,26 @@ func TestInvalidJWTTokenALgNone(t *testing.T) {
 	require.EqualError(t, err, ErrInvalidToken.Error())
 	require.Nil(t, payload)
 }
+
+// TestJWTTokenAudience verifies JWTs carry the issuer and audience and are
+// rejected by makers expecting another audience
+func TestJWTTokenAudience(t *testing.T) {
+	secretKey := util.RandomString(32)
+
+	maker, err := NewJWTMakerWithOptions(secretKey, Options{Issuer: "simple_bank", Audience: "payments"})
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, "simple_bank", payload.Issuer)
+	require.Equal(t, "payments", payload.Audience)
+
+	other, err := NewJWTMakerWithOptions(secretKey, Options{Audience: "reports"})
+	require.NoError(t, err)
+	payload, err = other.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
@@ -0,0 +1,38 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+)
+
+// keyAlphabet holds the characters used in generated keys, so they can be
+// pasted into env files without quoting
+const keyAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// keyHint tells operators how to obtain a valid key
+const keyHint = "generate one with `go run ./cmd/genkey`"
+
+// NewSymmetricKey generates a cryptographically random key accepted by NewPasetoMaker
+func NewSymmetricKey() (string, error) {
+	key := make([]byte, symmetricKeySize)
+	max := big.NewInt(int64(len(keyAlphabet)))
+	for i := range key {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		key[i] = keyAlphabet[n.Int64()]
+	}
+	return string(key), nil
+}
+
+// NewAsymmetricKeyPair generates a hex encoded Ed25519 key pair accepted by NewPasetoV2PublicMaker
+func NewAsymmetricKeyPair() (privateKeyHex string, publicKeyHex string, err error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return hex.EncodeToString(privateKey), hex.EncodeToString(publicKey), nil
+}
@@ -0,0 +1,39 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewSymmetricKey verifies generated keys are accepted by both makers
+func TestNewSymmetricKey(t *testing.T) {
+	key, err := NewSymmetricKey()
+	require.NoError(t, err)
+	require.Len(t, key, symmetricKeySize)
+
+	_, err = NewPasetoMaker(key)
+	require.NoError(t, err)
+	_, err = NewJWTMaker(key)
+	require.NoError(t, err)
+
+	//Each call returns a fresh key
+	other, err := NewSymmetricKey()
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+}
+
+// TestInvalidKeySizeMessage verifies key errors report the received length and a hint
+func TestInvalidKeySizeMessage(t *testing.T) {
+	_, err := NewPasetoMaker(util.RandomString(40))
+	require.ErrorContains(t, err, "got 40")
+	require.ErrorContains(t, err, "cmd/genkey")
+
+	_, err = NewPasetoMaker("")
+	require.ErrorContains(t, err, "got 0")
+
+	_, err = NewJWTMaker(util.RandomString(12))
+	require.ErrorContains(t, err, "got 12")
+	require.ErrorContains(t, err, "cmd/genkey")
+}
@@ -1,12 +1,67 @@
 package token
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 //Maker defines the interface for token creation and verification
 type Maker interface {
-	//CreateToken generates a signed token for a user with a given duration
-	CreateToken(username string, duration time.Duration) (string, *Payload, error)
+	//CreateToken generates a signed token for a user and role with a given duration
+	CreateToken(username string, role string, duration time.Duration) (string, *Payload, error)
 
 	//VerifyToken validates a token and returns its payload
 	VerifyToken(token string) (*Payload, error)
 }
+
+// DefaultClockSkew is the clock difference tolerated when none is configured
+const DefaultClockSkew = 30 * time.Second
+
+// Options tunes how makers verify tokens
+type Options struct {
+	//ClockSkew is how long a token stays valid past its expiry, and how far in
+	//the future it may have been issued, to absorb clock differences.
+	//Defaults to DefaultClockSkew.
+	ClockSkew time.Duration
+
+	//Now returns the current time, defaults to time.Now
+	Now func() time.Time
+
+	//Issuer and Audience are stamped on created tokens. When Audience is set,
+	//tokens minted for another audience are rejected.
+	Issuer   string
+	Audience string
+}
+
+// withDefaults validates the options and fills in the defaults
+func (options Options) withDefaults() (Options, error) {
+	if options.ClockSkew < 0 {
+		return options, fmt.Errorf("invalid clock skew %s: must not be negative", options.ClockSkew)
+	}
+	if options.ClockSkew == 0 {
+		options.ClockSkew = DefaultClockSkew
+	}
+	if options.Now == nil {
+		options.Now = time.Now
+	}
+	return options, nil
+}
+
+// newPayload creates a payload carrying the configured issuer and audience
+func (options Options) newPayload(username string, role string, duration time.Duration) (*Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return nil, err
+	}
+	payload.Issuer = options.Issuer
+	payload.Audience = options.Audience
+	return payload, nil
+}
+
+// validate checks the audience and timestamps of an authenticated payload
+func (options Options) validate(payload *Payload) error {
+	if options.Audience != "" && payload.Audience != options.Audience {
+		return ErrInvalidToken
+	}
+	return payload.ValidAt(options.Now(), options.ClockSkew)
+}
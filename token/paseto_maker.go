@@ -8,31 +8,49 @@ import (
 	"golang.org/x/crypto/chacha20poly1305"
 )
 
+// symmetricKeySize is the key length required by ChaCha20-Poly1305
+const symmetricKeySize = chacha20poly1305.KeySize
+
 // PasetoMaker creates and verifies PASETO tokens using symmetric encryption
 type PasetoMaker struct {
 	paseto      *paseto.V2
 	symetrickey []byte
+	options     Options
 }
 
+// PasetoMaker must keep satisfying Maker
+var _ Maker = (*PasetoMaker)(nil)
+
 // NewPasetoMaker initializes a PasetoMaker with a valid symmmetric key
 func NewPasetoMaker(symmetricKey string) (Maker, error) {
+	return NewPasetoMakerWithOptions(symmetricKey, Options{})
+}
+
+// NewPasetoMakerWithOptions initializes a PasetoMaker with custom verification options
+func NewPasetoMakerWithOptions(symmetricKey string, options Options) (Maker, error) {
 	//Ensure key size matches ChaCha20-Poly1305 requirements
-	if len(symmetricKey) != chacha20poly1305.KeySize {
-		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
+	if len(symmetricKey) != symmetricKeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d characters, got %d; %s", symmetricKeySize, len(symmetricKey), keyHint)
+	}
+
+	options, err := options.withDefaults()
+	if err != nil {
+		return nil, err
 	}
 
 	maker := &PasetoMaker{
 		paseto:      paseto.NewV2(),
 		symetrickey: []byte(symmetricKey),
+		options:     options,
 	}
 
 	return maker, nil
 }
 
 // CreateToken generates an encrypted PASETO token for a user
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, *Payload, error) {
+func (maker *PasetoMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
 	//Build token payload
-	payload, err := NewPayload(username, duration)
+	payload, err := maker.options.newPayload(username, role, duration)
 	if err != nil {
 		return "", payload, err
 	}
@@ -54,7 +72,7 @@ func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 	}
 
 	//Validate payload claims
-	err = payload.Valid()
+	err = maker.options.validate(payload)
 	if err != nil {
 		return nil, err
 	}
@@ -16,13 +16,14 @@ func TestPasetoMaker(t *testing.T) {
 
 	//Token inputs
 	username := util.RandomOwner()
+	role := util.BankerRole
 	duration := time.Minute
 
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
 	//Create token
-	token, payload, err := maker.CreateToken(username, duration)
+	token, payload, err := maker.CreateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -35,6 +36,7 @@ func TestPasetoMaker(t *testing.T) {
 	//Validate Payload
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
 	require.WithinDuration(t, issuedAt, payload.IssueAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
@@ -46,7 +48,7 @@ func TestExpiredPasetoToken(t *testing.T) {
 	require.NoError(t, err)
 
 	//Create expired token
-	token, payload, err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -59,10 +61,69 @@ func TestExpiredPasetoToken(t *testing.T) {
 
 }
 
+// TestMalformedPasetoToken verifies malformed tokens are reported as invalid, not expired
+func TestMalformedPasetoToken(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	for _, token := range []string{"", "not-a-token", "v2.local.AAAA"} {
+		payload, err := maker.VerifyToken(token)
+		require.ErrorIs(t, err, ErrInvalidToken)
+		require.NotErrorIs(t, err, ErrExpiredToken)
+		require.EqualError(t, err, "token is invalid")
+		require.Nil(t, payload)
+	}
+
+	//Expired tokens keep their own error
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrExpiredToken)
+	require.NotErrorIs(t, err, ErrInvalidToken)
+	require.EqualError(t, err, "token has expired")
+}
+
 // func TestPasetoWrongTokenType(t *testing.T){
 // 	maker, err := NewPasetoMaker(util.RandomString(32))
 // 	require.NoError(t, err)
 
-// 	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute, TokenTypeAccessToken)
+// 	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, util.DepositorRole, time.Minute, TokenTypeAccessToken)
 
 // }
+
+// TestPasetoTokenAudience verifies tokens carry the issuer and audience and
+// are rejected by makers expecting another audience
+func TestPasetoTokenAudience(t *testing.T) {
+	key := util.RandomString(32)
+	options := Options{Issuer: "simple_bank", Audience: "payments"}
+
+	maker, err := NewPasetoMakerWithOptions(key, options)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, "simple_bank", payload.Issuer)
+	require.Equal(t, "payments", payload.Audience)
+
+	//Another service sharing the key must not accept the token
+	other, err := NewPasetoMakerWithOptions(key, Options{Issuer: "simple_bank", Audience: "reports"})
+	require.NoError(t, err)
+	payload, err = other.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+
+	//Without a configured audience any audience is accepted
+	anyAudience, err := NewPasetoMaker(key)
+	require.NoError(t, err)
+	_, err = anyAudience.VerifyToken(token)
+	require.NoError(t, err)
+
+	//A token without an audience does not match a configured one
+	token, _, err = anyAudience.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -0,0 +1,119 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/o1egl/paseto"
+)
+
+// ErrCannotSign is returned when a verify-only maker is asked to create a token
+var ErrCannotSign = errors.New("token maker has no private key")
+
+// PasetoPublicMaker creates and verifies PASETO v2.public tokens. Tokens are
+// signed with an Ed25519 private key, so services that only verify tokens
+// need nothing more than the public key.
+type PasetoPublicMaker struct {
+	paseto     *paseto.V2
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+	options    Options
+}
+
+// PasetoPublicMaker must keep satisfying Maker
+var _ Maker = (*PasetoPublicMaker)(nil)
+
+// NewPasetoV2PublicMaker initializes a PasetoPublicMaker from hex encoded
+// Ed25519 keys. An empty private key gives a maker that can only verify.
+func NewPasetoV2PublicMaker(privateKeyHex, publicKeyHex string) (Maker, error) {
+	return NewPasetoV2PublicMakerWithOptions(privateKeyHex, publicKeyHex, Options{})
+}
+
+// NewPasetoV2PublicMakerWithOptions initializes a PasetoPublicMaker with custom verification options
+func NewPasetoV2PublicMakerWithOptions(privateKeyHex, publicKeyHex string, options Options) (Maker, error) {
+	key, err := decodeKey(publicKeyHex, ed25519.PublicKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	publicKey := ed25519.PublicKey(key)
+
+	var privateKey ed25519.PrivateKey
+	if privateKeyHex != "" {
+		key, err = decodeKey(privateKeyHex, ed25519.PrivateKeySize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+		privateKey = ed25519.PrivateKey(key)
+
+		//Tokens signed with a mismatched pair would never verify
+		if !publicKey.Equal(privateKey.Public()) {
+			return nil, errors.New("public key does not match the private key")
+		}
+	}
+
+	options, err = options.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	maker := &PasetoPublicMaker{
+		paseto:     paseto.NewV2(),
+		privateKey: privateKey,
+		publicKey:  publicKey,
+		options:    options,
+	}
+
+	return maker, nil
+}
+
+// CreateToken generates a signed PASETO token for a user
+func (maker *PasetoPublicMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	if maker.privateKey == nil {
+		return "", nil, ErrCannotSign
+	}
+
+	//Build token payload
+	payload, err := maker.options.newPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	//Sign payload into token
+	token, err := maker.paseto.Sign(maker.privateKey, payload, nil)
+	return token, payload, err
+}
+
+// VerifyToken checks the signature of a PASETO token and validates it
+func (maker *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+
+	payload := &Payload{}
+
+	//Verify signature and decode payload
+	err := maker.paseto.Verify(token, maker.publicKey, payload, nil)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	//Validate payload claims
+	err = maker.options.validate(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// decodeKey decodes a hex encoded key of the expected size in bytes
+func decodeKey(keyHex string, size int) ([]byte, error) {
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("must be hex encoded; %s", keyHint)
+	}
+	if len(key) != size {
+		return nil, fmt.Errorf("must be exactly %d bytes, got %d; %s", size, len(key), keyHint)
+	}
+	return key, nil
+}
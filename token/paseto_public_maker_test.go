@@ -0,0 +1,139 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPublicMaker creates a PasetoPublicMaker with a fresh key pair
+func newTestPublicMaker(t *testing.T) Maker {
+	privateKey, publicKey, err := NewAsymmetricKeyPair()
+	require.NoError(t, err)
+
+	maker, err := NewPasetoV2PublicMaker(privateKey, publicKey)
+	require.NoError(t, err)
+	return maker
+}
+
+// TestPasetoPublicMaker verifies successful PASETO public token creation and validation
+func TestPasetoPublicMaker(t *testing.T) {
+	//Create token maker
+	maker := newTestPublicMaker(t)
+
+	//Token inputs
+	username := util.RandomOwner()
+	role := util.BankerRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	//Create token
+	token, payload, err := maker.CreateToken(username, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+	require.Regexp(t, `^v2\.public\.`, token)
+
+	//Verify token
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	//Validate Payload
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.WithinDuration(t, issuedAt, payload.IssueAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+// TestExpiredPasetoPublicToken verifies that expired tokens are rejected
+func TestExpiredPasetoPublicToken(t *testing.T) {
+	maker := newTestPublicMaker(t)
+
+	//Create expired token
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	//Verify token fails
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+// TestMalformedPasetoPublicToken verifies malformed tokens are reported as invalid
+func TestMalformedPasetoPublicToken(t *testing.T) {
+	maker := newTestPublicMaker(t)
+
+	for _, token := range []string{"", "not-a-token", "v2.public.AAAA"} {
+		payload, err := maker.VerifyToken(token)
+		require.ErrorIs(t, err, ErrInvalidToken)
+		require.Nil(t, payload)
+	}
+
+	//Local tokens are not accepted as public ones
+	local, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+	token, _, err := local.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// TestPasetoPublicWrongKey verifies tokens signed by another key pair are rejected
+func TestPasetoPublicWrongKey(t *testing.T) {
+	maker := newTestPublicMaker(t)
+	other := newTestPublicMaker(t)
+
+	token, _, err := other.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
+
+// TestPasetoPublicVerifyOnly verifies a maker with only the public key verifies but cannot sign
+func TestPasetoPublicVerifyOnly(t *testing.T) {
+	privateKey, publicKey, err := NewAsymmetricKeyPair()
+	require.NoError(t, err)
+
+	signer, err := NewPasetoV2PublicMaker(privateKey, publicKey)
+	require.NoError(t, err)
+	verifier, err := NewPasetoV2PublicMaker("", publicKey)
+	require.NoError(t, err)
+
+	token, _, err := signer.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	_, err = verifier.VerifyToken(token)
+	require.NoError(t, err)
+
+	_, _, err = verifier.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.ErrorIs(t, err, ErrCannotSign)
+}
+
+// TestNewPasetoV2PublicMakerInvalidKeys rejects malformed and mismatched keys
+func TestNewPasetoV2PublicMakerInvalidKeys(t *testing.T) {
+	privateKey, publicKey, err := NewAsymmetricKeyPair()
+	require.NoError(t, err)
+	_, otherPublicKey, err := NewAsymmetricKeyPair()
+	require.NoError(t, err)
+
+	_, err = NewPasetoV2PublicMaker(privateKey, "zz")
+	require.ErrorContains(t, err, "invalid public key")
+
+	_, err = NewPasetoV2PublicMaker(privateKey, publicKey[:10])
+	require.ErrorContains(t, err, "got 5")
+
+	_, err = NewPasetoV2PublicMaker(privateKey[:64], publicKey)
+	require.ErrorContains(t, err, "invalid private key")
+
+	_, err = NewPasetoV2PublicMaker(privateKey, otherPublicKey)
+	require.ErrorContains(t, err, "does not match")
+}
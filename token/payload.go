@@ -9,11 +9,26 @@ import (
 
 // ErrExpiredToken indicates the token has passed its expiration time
 // ErrInvalidToken indicates the token is malformed or invalid
+// ErrTokenNotActiveYet indicates the token is used before its not-before time
 var (
-	ErrExpiredToken = errors.New("token has exprired")
-	ErrInvalidToken = errors.New("token has expired")
+	ErrExpiredToken      = errors.New("token has expired")
+	ErrInvalidToken      = errors.New("token is invalid")
+	ErrTokenNotActiveYet = errors.New("token is not active yet")
 )
 
+// ExpiredTokenError reports an expired token along with its expiry time,
+// so clients can tell a refresh is needed. It matches ErrExpiredToken.
+// Payload holds the decoded claims, which were authenticated by the maker
+// before the expiry check.
+type ExpiredTokenError struct {
+	ExpiredAt time.Time
+	Payload   *Payload
+}
+
+func (e *ExpiredTokenError) Error() string { return ErrExpiredToken.Error() }
+
+func (e *ExpiredTokenError) Is(target error) bool { return target == ErrExpiredToken }
+
 type TokenType byte
 
 const (
@@ -25,12 +40,28 @@ const (
 type Payload struct {
 	ID        uuid.UUID `json:"id"`
 	Username  string    `json:"username"`
+	Role      string    `json:"role"`
 	IssueAt   time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
+	NotBefore time.Time `json:"not_before"`
+	Issuer    string    `json:"iss,omitempty"`
+	Audience  string    `json:"aud,omitempty"`
+}
+
+// PayloadOptions sets optional claims of a new payload
+type PayloadOptions struct {
+	//NotBefore post-dates the token: it is rejected until then, and its
+	//duration counts from then. The zero time makes it active at once.
+	NotBefore time.Time
 }
 
 // NewPayload creates a new token payload with a unique ID and expiry
-func NewPayload(username string, duration time.Duration) (*Payload, error) {
+func NewPayload(username string, role string, duration time.Duration) (*Payload, error) {
+	return NewPayloadWithOptions(username, role, duration, PayloadOptions{})
+}
+
+// NewPayloadWithOptions creates a new token payload with optional claims
+func NewPayloadWithOptions(username string, role string, duration time.Duration, options PayloadOptions) (*Payload, error) {
 	//Generate unique token ID
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
@@ -38,21 +69,45 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 	}
 
 	//Initialize payload timestamps
+	now := time.Now()
+	activeAt := now
+	if options.NotBefore.After(now) {
+		activeAt = options.NotBefore
+	}
 	payload := &Payload{
 		ID:        tokenID,
 		Username:  username,
-		IssueAt:   time.Now(),
-		ExpiredAt: time.Now().Add(duration),
+		Role:      role,
+		IssueAt:   now,
+		NotBefore: options.NotBefore,
+		ExpiredAt: activeAt.Add(duration),
 	}
 
 	return payload, nil
 }
 
-// Valid validates the payload by checking token expiration
+// Valid validates the payload by checking token expiration, tolerating the
+// default clock skew
 func (payload *Payload) Valid() error {
+	return payload.ValidAt(time.Now(), DefaultClockSkew)
+}
+
+// ValidAt validates the payload at the given time, tolerating clocks that are
+// off by up to clockSkew
+func (payload *Payload) ValidAt(now time.Time, clockSkew time.Duration) error {
 	//Reject token if expired
-	if time.Now().After(payload.ExpiredAt) {
-		return ErrExpiredToken
+	if now.After(payload.ExpiredAt.Add(clockSkew)) {
+		return &ExpiredTokenError{ExpiredAt: payload.ExpiredAt, Payload: payload}
+	}
+
+	//Reject token issued in the future
+	if payload.IssueAt.After(now.Add(clockSkew)) {
+		return ErrInvalidToken
+	}
+
+	//Reject post-dated token before it becomes active
+	if payload.NotBefore.After(now.Add(clockSkew)) {
+		return ErrTokenNotActiveYet
 	}
 
 	return nil
@@ -0,0 +1,235 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExpiredTokenReportsExpiry verifies both makers report when an expired token expired
+func TestExpiredTokenReportsExpiry(t *testing.T) {
+	pasetoMaker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+	jwtMaker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	for name, maker := range map[string]Maker{"Paseto": pasetoMaker, "JWT": jwtMaker} {
+		t.Run(name, func(t *testing.T) {
+			token, created, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, -time.Minute)
+			require.NoError(t, err)
+
+			payload, err := maker.VerifyToken(token)
+			require.Nil(t, payload)
+			require.ErrorIs(t, err, ErrExpiredToken)
+
+			var expiredErr *ExpiredTokenError
+			require.ErrorAs(t, err, &expiredErr)
+			require.WithinDuration(t, created.ExpiredAt, expiredErr.ExpiredAt, time.Second)
+			require.Equal(t, created.ID, expiredErr.Payload.ID)
+			require.Equal(t, created.Username, expiredErr.Payload.Username)
+		})
+	}
+}
+
+// TestClockSkew verifies both makers accept tokens only within the configured skew
+func TestClockSkew(t *testing.T) {
+	const skew = 30 * time.Second
+	key := util.RandomString(32)
+
+	testCases := []struct {
+		name    string
+		offset  time.Duration
+		checkFn func(t *testing.T, payload *Payload, err error)
+	}{
+		{
+			name:   "ExpiredWithinSkew",
+			offset: time.Minute + skew - time.Second,
+			checkFn: func(t *testing.T, payload *Payload, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, payload)
+			},
+		},
+		{
+			name:   "ExpiredBeyondSkew",
+			offset: time.Minute + skew + time.Second,
+			checkFn: func(t *testing.T, payload *Payload, err error) {
+				require.ErrorIs(t, err, ErrExpiredToken)
+				require.Nil(t, payload)
+			},
+		},
+		{
+			name:   "IssuedAheadWithinSkew",
+			offset: -skew + time.Second,
+			checkFn: func(t *testing.T, payload *Payload, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, payload)
+			},
+		},
+		{
+			name:   "IssuedAheadBeyondSkew",
+			offset: -skew - time.Second,
+			checkFn: func(t *testing.T, payload *Payload, err error) {
+				require.ErrorIs(t, err, ErrInvalidToken)
+				require.Nil(t, payload)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			//Verify on a clock shifted from the issuing clock
+			options := Options{
+				ClockSkew: skew,
+				Now:       func() time.Time { return time.Now().Add(tc.offset) },
+			}
+			pasetoMaker, err := NewPasetoMakerWithOptions(key, options)
+			require.NoError(t, err)
+			jwtMaker, err := NewJWTMakerWithOptions(key, options)
+			require.NoError(t, err)
+
+			for _, maker := range []Maker{pasetoMaker, jwtMaker} {
+				token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+				require.NoError(t, err)
+
+				payload, err := maker.VerifyToken(token)
+				tc.checkFn(t, payload, err)
+			}
+		})
+	}
+}
+
+// TestNegativeClockSkew verifies a negative skew is rejected
+func TestNegativeClockSkew(t *testing.T) {
+	_, err := NewPasetoMakerWithOptions(util.RandomString(32), Options{ClockSkew: -time.Second})
+	require.Error(t, err)
+	_, err = NewJWTMakerWithOptions(util.RandomString(32), Options{ClockSkew: -time.Second})
+	require.Error(t, err)
+}
+
+// TestDefaultClockSkew verifies makers and Payload.Valid tolerate the default skew when none is configured
+func TestDefaultClockSkew(t *testing.T) {
+	key := util.RandomString(32)
+
+	testCases := []struct {
+		name    string
+		expired time.Duration
+		valid   bool
+	}{
+		{name: "ExpiredWithinLeeway", expired: DefaultClockSkew - 5*time.Second, valid: true},
+		{name: "ExpiredBeyondLeeway", expired: DefaultClockSkew + 5*time.Second, valid: false},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			pasetoMaker, err := NewPasetoMaker(key)
+			require.NoError(t, err)
+			jwtMaker, err := NewJWTMaker(key)
+			require.NoError(t, err)
+
+			for _, maker := range []Maker{pasetoMaker, jwtMaker} {
+				token, created, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, -tc.expired)
+				require.NoError(t, err)
+
+				payload, err := maker.VerifyToken(token)
+				if tc.valid {
+					require.NoError(t, err)
+					require.NotNil(t, payload)
+					require.NoError(t, created.Valid())
+				} else {
+					require.ErrorIs(t, err, ErrExpiredToken)
+					require.Nil(t, payload)
+					require.ErrorIs(t, created.Valid(), ErrExpiredToken)
+				}
+			}
+		})
+	}
+
+	//Tokens issued further ahead than the leeway are invalid
+	payload, err := NewPayload(util.RandomOwner(), util.DepositorRole, time.Hour)
+	require.NoError(t, err)
+	payload.IssueAt = time.Now().Add(DefaultClockSkew + 5*time.Second)
+	require.ErrorIs(t, payload.Valid(), ErrInvalidToken)
+}
+
+// TestNotBefore verifies post-dated tokens are rejected by both makers until they become active
+func TestNotBefore(t *testing.T) {
+	key := util.RandomString(32)
+	notBefore := time.Now().Add(time.Hour)
+
+	payload, err := NewPayloadWithOptions(util.RandomOwner(), util.DepositorRole, time.Minute, PayloadOptions{NotBefore: notBefore})
+	require.NoError(t, err)
+	require.True(t, notBefore.Equal(payload.NotBefore))
+	require.WithinDuration(t, notBefore.Add(time.Minute), payload.ExpiredAt, time.Second)
+	require.ErrorIs(t, payload.Valid(), ErrTokenNotActiveYet)
+
+	testCases := []struct {
+		name    string
+		offset  time.Duration
+		checkFn func(t *testing.T, payload *Payload, err error)
+	}{
+		{
+			name:   "NotActiveYet",
+			offset: 0,
+			checkFn: func(t *testing.T, payload *Payload, err error) {
+				require.ErrorIs(t, err, ErrTokenNotActiveYet)
+				require.NotErrorIs(t, err, ErrExpiredToken)
+				require.Nil(t, payload)
+			},
+		},
+		{
+			name:   "Active",
+			offset: time.Hour + 30*time.Second,
+			checkFn: func(t *testing.T, payload *Payload, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, payload)
+			},
+		},
+		{
+			name:   "ExpiredAfterActive",
+			offset: time.Hour + 2*time.Minute,
+			checkFn: func(t *testing.T, payload *Payload, err error) {
+				require.ErrorIs(t, err, ErrExpiredToken)
+				require.Nil(t, payload)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			options := Options{
+				ClockSkew: time.Second,
+				Now:       func() time.Time { return time.Now().Add(tc.offset) },
+			}
+			pasetoMaker, err := NewPasetoMakerWithOptions(key, options)
+			require.NoError(t, err)
+			jwtMaker, err := NewJWTMakerWithOptions(key, options)
+			require.NoError(t, err)
+
+			//Makers have no post-dating API, so sign the payload directly
+			pasetoToken, err := pasetoMaker.(*PasetoMaker).paseto.Encrypt([]byte(key), payload, nil)
+			require.NoError(t, err)
+			jwtToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, payload).SignedString([]byte(key))
+			require.NoError(t, err)
+
+			verified, err := pasetoMaker.VerifyToken(pasetoToken)
+			tc.checkFn(t, verified, err)
+			verified, err = jwtMaker.VerifyToken(jwtToken)
+			tc.checkFn(t, verified, err)
+		})
+	}
+
+	//A payload without NotBefore is active at once
+	payload, err = NewPayload(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	require.True(t, payload.NotBefore.IsZero())
+	require.NoError(t, payload.Valid())
+}
@@ -0,0 +1,112 @@
+package token
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for signing tokens
+const minRSAKeyBits = 2048
+
+// RSAJWTMaker creates and verifies JWT tokens signed with RS256, so services
+// that only verify tokens need nothing more than the public key
+type RSAJWTMaker struct {
+	privateKey *rsa.PrivateKey
+	publicKey  *rsa.PublicKey
+	options    Options
+}
+
+// RSAJWTMaker must keep satisfying Maker
+var _ Maker = (*RSAJWTMaker)(nil)
+
+// NewRSAJWTMaker initializes an RSAJWTMaker from PEM encoded keys. An empty
+// private key gives a maker that can only verify.
+func NewRSAJWTMaker(privatePEM, publicPEM []byte) (Maker, error) {
+	return NewRSAJWTMakerWithOptions(privatePEM, publicPEM, Options{})
+}
+
+// NewRSAJWTMakerWithOptions initializes an RSAJWTMaker with custom verification options
+func NewRSAJWTMakerWithOptions(privatePEM, publicPEM []byte, options Options) (Maker, error) {
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if publicKey.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("invalid public key: must be at least %d bits, got %d", minRSAKeyBits, publicKey.N.BitLen())
+	}
+
+	var privateKey *rsa.PrivateKey
+	if len(privatePEM) > 0 {
+		privateKey, err = jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %w", err)
+		}
+
+		//Tokens signed with a mismatched pair would never verify
+		if !publicKey.Equal(privateKey.Public()) {
+			return nil, errors.New("public key does not match the private key")
+		}
+	}
+
+	options, err = options.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RSAJWTMaker{privateKey: privateKey, publicKey: publicKey, options: options}, nil
+}
+
+// CreateToken generates an RS256 signed JWT for a user
+func (maker *RSAJWTMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	if maker.privateKey == nil {
+		return "", nil, ErrCannotSign
+	}
+
+	//Create token payload with expiration
+	payload, err := maker.options.newPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	//Sign token using the private key
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, payload)
+	token, err := jwtToken.SignedString(maker.privateKey)
+	return token, payload, err
+}
+
+// VerifyToken validates the JWT signature with the public key and returns its payload
+func (maker *RSAJWTMaker) VerifyToken(token string) (*Payload, error) {
+
+	//Only RS256 is accepted, an HMAC token "signed" with the public key as
+	//its secret must not verify
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, ErrInvalidToken
+		}
+		return maker.publicKey, nil
+	}
+
+	//Parse the token, claims are validated below with the clock skew applied
+	parser := &jwt.Parser{SkipClaimsValidation: true}
+	jwtToken, err := parser.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	//Extract and assert payload type
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	//Validate payload claims
+	if err := maker.options.validate(payload); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,130 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/require"
+)
+
+// newRSAKeyPEM generates an RSA key pair encoded as PEM
+func newRSAKeyPEM(t *testing.T, bits int) (privatePEM []byte, publicPEM []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	require.NoError(t, err)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	privatePEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	publicPEM = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+	return privatePEM, publicPEM
+}
+
+// TestRSAJWTMaker verifies successful RS256 token creation and validation
+func TestRSAJWTMaker(t *testing.T) {
+	privatePEM, publicPEM := newRSAKeyPEM(t, minRSAKeyBits)
+	maker, err := NewRSAJWTMaker(privatePEM, publicPEM)
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	role := util.BankerRole
+	duration := time.Minute
+
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	//Create token
+	token, payload, err := maker.CreateToken(username, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	//A verifier holding only the public key accepts it
+	verifier, err := NewRSAJWTMaker(nil, publicPEM)
+	require.NoError(t, err)
+
+	payload, err = verifier.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.WithinDuration(t, issuedAt, payload.IssueAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+
+	//But cannot sign
+	_, _, err = verifier.CreateToken(username, role, duration)
+	require.ErrorIs(t, err, ErrCannotSign)
+
+	//Expired tokens keep their own error
+	token, _, err = maker.CreateToken(username, role, -time.Minute)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrExpiredToken)
+}
+
+// TestRSAJWTWrongPublicKey verifies tokens signed by another key pair are rejected
+func TestRSAJWTWrongPublicKey(t *testing.T) {
+	privatePEM, publicPEM := newRSAKeyPEM(t, minRSAKeyBits)
+	_, otherPublicPEM := newRSAKeyPEM(t, minRSAKeyBits)
+
+	maker, err := NewRSAJWTMaker(privatePEM, publicPEM)
+	require.NoError(t, err)
+	verifier, err := NewRSAJWTMaker(nil, otherPublicPEM)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := verifier.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+	require.Nil(t, payload)
+}
+
+// TestRSAJWTRejectsHMAC guards against algorithm confusion, where an HS256
+// token is signed using the public key as the HMAC secret
+func TestRSAJWTRejectsHMAC(t *testing.T) {
+	_, publicPEM := newRSAKeyPEM(t, minRSAKeyBits)
+	maker, err := NewRSAJWTMaker(nil, publicPEM)
+	require.NoError(t, err)
+
+	payload, err := NewPayload(util.RandomOwner(), util.BankerRole, time.Minute)
+	require.NoError(t, err)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, payload).SignedString(publicPEM)
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+
+	//Unsigned tokens are rejected too
+	token, err = jwt.NewWithClaims(jwt.SigningMethodNone, &Payload{}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+// TestNewRSAJWTMakerInvalidKeys rejects malformed, short and mismatched keys
+func TestNewRSAJWTMakerInvalidKeys(t *testing.T) {
+	privatePEM, publicPEM := newRSAKeyPEM(t, minRSAKeyBits)
+	_, otherPublicPEM := newRSAKeyPEM(t, minRSAKeyBits)
+	_, shortPublicPEM := newRSAKeyPEM(t, 1024)
+
+	_, err := NewRSAJWTMaker(privatePEM, []byte("not a key"))
+	require.ErrorContains(t, err, "invalid public key")
+
+	_, err = NewRSAJWTMaker([]byte("not a key"), publicPEM)
+	require.ErrorContains(t, err, "invalid private key")
+
+	_, err = NewRSAJWTMaker(nil, shortPublicPEM)
+	require.ErrorContains(t, err, "at least 2048 bits")
+
+	_, err = NewRSAJWTMaker(privatePEM, otherPublicPEM)
+	require.ErrorContains(t, err, "does not match")
+}
@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/codercollo/simple_bank/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// serviceName identifies the spans of this service in the tracing backend
+const serviceName = "simple_bank"
+
+// Shutdown flushes pending spans and stops the exporter
+type Shutdown func(ctx context.Context) error
+
+// Setup installs a global tracer provider exporting spans over OTLP/HTTP to
+// config.OTLPEndpoint, e.g. http://localhost:4318. Without an endpoint tracing
+// stays a no-op.
+func Setup(ctx context.Context, config util.Config) (Shutdown, error) {
+	if config.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	//The exporter only logs a malformed endpoint, so reject it at startup
+	endpoint, err := url.Parse(config.OTLPEndpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q, expected a URL like http://localhost:4318", config.OTLPEndpoint)
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(config.OTLPEndpoint))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create trace exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	//Continue traces started by callers and pass them on to ours
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/codercollo/simple_bank/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// TestSetupWithoutEndpoint verifies tracing stays a no-op when no endpoint is set
+func TestSetupWithoutEndpoint(t *testing.T) {
+	previous := otel.GetTracerProvider()
+
+	shutdown, err := Setup(context.Background(), util.Config{})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+	require.Equal(t, previous, otel.GetTracerProvider())
+}
+
+// TestSetupWithEndpoint verifies an SDK tracer provider is installed for the endpoint
+func TestSetupWithEndpoint(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	shutdown, err := Setup(context.Background(), util.Config{OTLPEndpoint: "http://localhost:4318"})
+	require.NoError(t, err)
+	require.IsType(t, &sdktrace.TracerProvider{}, otel.GetTracerProvider())
+
+	//Nothing was recorded, so shutting down doesn't need the collector
+	require.NoError(t, shutdown(context.Background()))
+}
+
+// TestSetupInvalidEndpoint verifies a malformed endpoint is rejected
+func TestSetupInvalidEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"://bad", "localhost:4318"} {
+		_, err := Setup(context.Background(), util.Config{OTLPEndpoint: endpoint})
+		require.ErrorContains(t, err, "invalid OTLP endpoint")
+	}
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"reflect"
 	"time"
 
 	"github.com/spf13/viper"
@@ -10,10 +11,63 @@ import (
 type Config struct {
 	DBDriver             string        `mapstructure:"DB_DRIVER"`
 	DBSource             string        `mapstructure:"DB_SOURCE"`
+	DBMaxOpenConns       int           `mapstructure:"DB_MAX_OPEN_CONNS"`
+	DBMaxIdleConns       int           `mapstructure:"DB_MAX_IDLE_CONNS"`
+	DBConnMaxLifetime    time.Duration `mapstructure:"DB_CONN_MAX_LIFETIME"`
+	DBMaxTxAttempts      int           `mapstructure:"DB_MAX_TX_ATTEMPTS"`
+	DBConnectAttempts    int           `mapstructure:"DB_CONNECT_ATTEMPTS"`
+	DBConnectBackoff     time.Duration `mapstructure:"DB_CONNECT_BACKOFF"`
+	DBConnectTimeout     time.Duration `mapstructure:"DB_CONNECT_TIMEOUT"`
 	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
+	ServerReadTimeout    time.Duration `mapstructure:"SERVER_READ_TIMEOUT"`
+	ServerWriteTimeout   time.Duration `mapstructure:"SERVER_WRITE_TIMEOUT"`
+	ServerIdleTimeout    time.Duration `mapstructure:"SERVER_IDLE_TIMEOUT"`
 	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenMaker           string        `mapstructure:"TOKEN_MAKER"`
+	TokenPrivateKey      string        `mapstructure:"TOKEN_PRIVATE_KEY"`
+	TokenPublicKey       string        `mapstructure:"TOKEN_PUBLIC_KEY"`
 	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
 	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	TokenClockSkew       time.Duration `mapstructure:"TOKEN_CLOCK_SKEW"`
+	TokenIssuer          string        `mapstructure:"TOKEN_ISSUER"`
+	TokenAudience        string        `mapstructure:"TOKEN_AUDIENCE"`
+	ContextTimeout       time.Duration `mapstructure:"CONTEXT_TIMEOUT"`
+	MaxRequestBodyBytes  int64         `mapstructure:"MAX_REQUEST_BODY_BYTES"`
+	OTLPEndpoint         string        `mapstructure:"OTLP_ENDPOINT"`
+	FXRoundingMode       string        `mapstructure:"FX_ROUNDING_MODE"`
+	FXRoundingModes      string        `mapstructure:"FX_ROUNDING_MODES"`
+	BatchGetMaxAccounts  int           `mapstructure:"BATCH_GET_MAX_ACCOUNTS"`
+	MaxTransferAmount    int64         `mapstructure:"MAX_TRANSFER_AMOUNT"`
+	DailyTransferLimit   int64         `mapstructure:"DAILY_TRANSFER_LIMIT"`
+	TransferIsolation    string        `mapstructure:"TRANSFER_ISOLATION"`
+	AuthCookieName       string        `mapstructure:"AUTH_COOKIE_NAME"`
+	StatementJobInterval time.Duration `mapstructure:"STATEMENT_JOB_INTERVAL"`
+	TransferRequestTTL   time.Duration `mapstructure:"TRANSFER_REQUEST_TTL"`
+	VerifyEmailTTL       time.Duration `mapstructure:"VERIFY_EMAIL_TTL"`
+	PasswordResetTTL     time.Duration `mapstructure:"PASSWORD_RESET_TTL"`
+	AllowedOrigins       []string      `mapstructure:"ALLOWED_ORIGINS"`
+	SupportedCurrencies  []string      `mapstructure:"SUPPORTED_CURRENCIES"`
+	DisabledCurrencies   []string      `mapstructure:"DISABLED_CURRENCIES"`
+	AdminIPAllowlist     []string      `mapstructure:"ADMIN_IP_ALLOWLIST"`
+	TrustedProxies       []string      `mapstructure:"TRUSTED_PROXIES"`
+	AuthRateLimit        float64       `mapstructure:"AUTH_RATE_LIMIT"`
+	AuthRateBurst        int           `mapstructure:"AUTH_RATE_BURST"`
+
+	AllowMultipleAccountsPerCurrency bool `mapstructure:"ALLOW_MULTIPLE_ACCOUNTS_PER_CURRENCY"`
+	RequireVerifiedEmailForTransfers bool `mapstructure:"REQUIRE_VERIFIED_EMAIL_FOR_TRANSFERS"`
+	AutoCreateDefaultAccount         bool `mapstructure:"AUTO_CREATE_DEFAULT_ACCOUNT"`
+	OptimisticTransfers              bool `mapstructure:"OPTIMISTIC_TRANSFERS"`
+	EnableMetrics                    bool `mapstructure:"ENABLE_METRICS"`
+
+	PasswordPepper          string `mapstructure:"PASSWORD_PEPPER"`
+	PasswordPepperVersion   int32  `mapstructure:"PASSWORD_PEPPER_VERSION"`
+	PreviousPasswordPeppers string `mapstructure:"PREVIOUS_PASSWORD_PEPPERS"`
+	PasswordHashCost        int    `mapstructure:"PASSWORD_HASH_COST"`
+
+	DataEncryptionKey          string `mapstructure:"DATA_ENCRYPTION_KEY"`
+	DataEncryptionKeyVersion   int32  `mapstructure:"DATA_ENCRYPTION_KEY_VERSION"`
+	PreviousDataEncryptionKeys string `mapstructure:"PREVIOUS_DATA_ENCRYPTION_KEYS"`
+	BlindIndexKey              string `mapstructure:"BLIND_INDEX_KEY"`
 }
 
 // LoadConfig reads configuration from file and environment var
@@ -24,6 +78,10 @@ func LoadConfig(path string) (config Config, err error) {
 
 	//Read from environment variables
 	viper.AutomaticEnv()
+	err = bindEnv()
+	if err != nil {
+		return
+	}
 
 	//Load config file
 	err = viper.ReadInConfig()
@@ -35,3 +93,20 @@ func LoadConfig(path string) (config Config, err error) {
 	err = viper.Unmarshal(&config)
 	return
 }
+
+// bindEnv registers every Config key with viper. AutomaticEnv only overrides
+// keys viper already knows about, so without this a value set only in the
+// environment and missing from app.env would be silently ignored.
+func bindEnv() error {
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		key := configType.Field(i).Tag.Get("mapstructure")
+		if key == "" {
+			continue
+		}
+		if err := viper.BindEnv(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeAppEnv writes a minimal app.env into a temporary directory
+func writeAppEnv(t *testing.T) string {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "app.env"), []byte("DB_DRIVER=postgres\n"), 0o600)
+	require.NoError(t, err)
+	return dir
+}
+
+// TestLoadConfigEnvOnly tests that keys missing from app.env are still read
+// from the environment
+func TestLoadConfigEnvOnly(t *testing.T) {
+	t.Setenv("ADMIN_IP_ALLOWLIST", "10.0.0.0/8,192.168.1.7")
+	dataEncryptionKey := RandomString(32)
+	t.Setenv("DATA_ENCRYPTION_KEY", dataEncryptionKey)
+	t.Setenv("PASSWORD_PEPPER", "env-pepper")
+	t.Setenv("PASSWORD_PEPPER_VERSION", "2")
+	t.Setenv("DAILY_TRANSFER_LIMIT", "50000")
+
+	config, err := LoadConfig(writeAppEnv(t))
+	require.NoError(t, err)
+	require.Equal(t, "postgres", config.DBDriver)
+	require.Equal(t, []string{"10.0.0.0/8", "192.168.1.7"}, config.AdminIPAllowlist)
+	require.Equal(t, dataEncryptionKey, config.DataEncryptionKey)
+	require.Equal(t, "env-pepper", config.PasswordPepper)
+	require.EqualValues(t, 2, config.PasswordPepperVersion)
+	require.Equal(t, int64(50000), config.DailyTransferLimit)
+}
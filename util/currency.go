@@ -1,17 +1,126 @@
 package util
 
-//Supported currency codes
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+//Built-in ISO 4217 currency codes
 const (
 	USD = "USD"
 	EUR = "EUR"
-	KSH = "Ksh"
+	KES = "KES"
+)
+
+// DefaultCurrencies are supported when no currencies are configured
+var DefaultCurrencies = []string{USD, EUR, KES}
+
+// legacyKES is the non-standard Kenyan shilling code accepted until clients migrate
+const legacyKES = "Ksh"
+
+// currencyCode matches the shape of an ISO 4217 alphabetic code
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// NormalizeCurrency upper-cases a currency code and maps legacy aliases to
+// their ISO 4217 code
+func NormalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == strings.ToUpper(legacyKES) {
+		return KES
+	}
+	return currency
+}
+
+// supportedCurrencies holds the currencies accounts may hold and
+// disabledCurrencies the supported ones blocked for new accounts and transfers
+var (
+	currencyMu          sync.RWMutex
+	supportedCurrencies = currencySet(DefaultCurrencies)
+	disabledCurrencies  = map[string]bool{}
 )
 
-//IsSupportedCurrency checks if currency is allowed
+// currencySet builds a lookup set of already normalized currencies
+func currencySet(currencies []string) map[string]bool {
+	set := make(map[string]bool, len(currencies))
+	for _, currency := range currencies {
+		set[currency] = true
+	}
+	return set
+}
+
+// SetSupportedCurrencies replaces the set of supported currencies, restoring
+// DefaultCurrencies when none are given
+func SetSupportedCurrencies(currencies []string) error {
+	supported := make(map[string]bool, len(currencies))
+	for _, currency := range currencies {
+		currency = NormalizeCurrency(currency)
+		if currency == "" {
+			continue
+		}
+		if !currencyCode.MatchString(currency) {
+			return fmt.Errorf("invalid currency code %q", currency)
+		}
+		supported[currency] = true
+	}
+	if len(supported) == 0 {
+		supported = currencySet(DefaultCurrencies)
+	}
+
+	currencyMu.Lock()
+	defer currencyMu.Unlock()
+	supportedCurrencies = supported
+	return nil
+}
+
+// SupportedCurrencies lists the supported currency codes in sorted order
+func SupportedCurrencies() []string {
+	currencyMu.RLock()
+	defer currencyMu.RUnlock()
+
+	currencies := make([]string, 0, len(supportedCurrencies))
+	for currency := range supportedCurrencies {
+		currencies = append(currencies, currency)
+	}
+	slices.Sort(currencies)
+	return currencies
+}
+
+//IsSupportedCurrency checks if currency is allowed, in any case or by a legacy alias
 func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, EUR, KSH:
-		return true
+	currencyMu.RLock()
+	defer currencyMu.RUnlock()
+	return supportedCurrencies[NormalizeCurrency(currency)]
+}
+
+// SetDisabledCurrencies replaces the set of disabled currencies.
+// Disabled currencies stay supported so existing accounts remain readable.
+func SetDisabledCurrencies(currencies []string) error {
+	disabled := make(map[string]bool, len(currencies))
+	for _, currency := range currencies {
+		currency = NormalizeCurrency(currency)
+		if currency == "" {
+			continue
+		}
+		if !IsSupportedCurrency(currency) {
+			return fmt.Errorf("cannot disable unsupported currency %s", currency)
+		}
+		disabled[currency] = true
 	}
-	return false
+
+	currencyMu.Lock()
+	defer currencyMu.Unlock()
+	disabledCurrencies = disabled
+	return nil
+}
+
+// IsEnabledCurrency checks if currency is supported and open for new activity
+func IsEnabledCurrency(currency string) bool {
+	currency = NormalizeCurrency(currency)
+
+	currencyMu.RLock()
+	defer currencyMu.RUnlock()
+	return supportedCurrencies[currency] && !disabledCurrencies[currency]
 }
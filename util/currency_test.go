@@ -0,0 +1,97 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestDisabledCurrencies verifies disabled currencies stay supported but not enabled
+func TestDisabledCurrencies(t *testing.T) {
+	defer SetDisabledCurrencies(nil)
+
+	require.True(t, IsEnabledCurrency(EUR))
+
+	require.NoError(t, SetDisabledCurrencies([]string{EUR}))
+	require.True(t, IsSupportedCurrency(EUR))
+	require.False(t, IsEnabledCurrency(EUR))
+	require.True(t, IsEnabledCurrency(USD))
+
+	//Replacing the set re-enables currencies no longer listed
+	require.NoError(t, SetDisabledCurrencies(nil))
+	require.True(t, IsEnabledCurrency(EUR))
+
+	require.Error(t, SetDisabledCurrencies([]string{"XYZ"}))
+	require.False(t, IsEnabledCurrency("XYZ"))
+}
+
+// TestIsSupportedCurrency verifies codes validate in any case and by the legacy alias
+func TestIsSupportedCurrency(t *testing.T) {
+	for _, currency := range []string{"kes", "KES", "Ksh", "usd", EUR} {
+		require.True(t, IsSupportedCurrency(currency), currency)
+	}
+	for _, currency := range []string{"", "XYZ", "KSHS", "K ES"} {
+		require.False(t, IsSupportedCurrency(currency), currency)
+	}
+}
+
+// TestNormalizeCurrency verifies codes are upper-cased and the legacy alias mapped to KES
+func TestNormalizeCurrency(t *testing.T) {
+	require.Equal(t, KES, NormalizeCurrency("kes"))
+	require.Equal(t, KES, NormalizeCurrency("Ksh"))
+	require.Equal(t, KES, NormalizeCurrency(" KSH "))
+	require.Equal(t, USD, NormalizeCurrency("usd"))
+	require.Equal(t, "XYZ", NormalizeCurrency("xyz"))
+}
+
+// TestDisabledCurrencyAlias verifies disabling KES also covers its legacy alias
+func TestDisabledCurrencyAlias(t *testing.T) {
+	defer SetDisabledCurrencies(nil)
+
+	require.NoError(t, SetDisabledCurrencies([]string{"Ksh"}))
+	require.False(t, IsEnabledCurrency(KES))
+	require.False(t, IsEnabledCurrency("kes"))
+	require.True(t, IsSupportedCurrency(KES))
+}
+
+// TestSetSupportedCurrencies verifies configured currencies replace the defaults
+func TestSetSupportedCurrencies(t *testing.T) {
+	defer SetSupportedCurrencies(nil)
+
+	//Defaults apply when nothing is configured
+	require.NoError(t, SetSupportedCurrencies(nil))
+	for _, currency := range DefaultCurrencies {
+		require.True(t, IsSupportedCurrency(currency), currency)
+	}
+	require.False(t, IsSupportedCurrency("GBP"))
+
+	//Configured codes are normalized and replace the defaults
+	require.NoError(t, SetSupportedCurrencies([]string{" gbp", "USD", "Ksh"}))
+	require.True(t, IsSupportedCurrency("GBP"))
+	require.True(t, IsEnabledCurrency("gbp"))
+	require.True(t, IsSupportedCurrency(KES))
+	require.False(t, IsSupportedCurrency(EUR))
+	require.False(t, IsEnabledCurrency(EUR))
+	require.False(t, IsSupportedCurrency("XYZ"))
+
+	//Only configured currencies may be disabled
+	require.Error(t, SetDisabledCurrencies([]string{EUR}))
+
+	require.Error(t, SetSupportedCurrencies([]string{"dollars"}))
+	require.True(t, IsSupportedCurrency("GBP"))
+
+	//An empty list restores the defaults
+	require.NoError(t, SetSupportedCurrencies([]string{" "}))
+	require.True(t, IsSupportedCurrency(EUR))
+	require.False(t, IsSupportedCurrency("GBP"))
+}
+
+// TestSupportedCurrenciesList verifies the supported set is listed sorted
+func TestSupportedCurrenciesList(t *testing.T) {
+	defer SetSupportedCurrencies(nil)
+
+	require.Equal(t, []string{EUR, KES, USD}, SupportedCurrencies())
+
+	require.NoError(t, SetSupportedCurrencies([]string{"usd", "GBP"}))
+	require.Equal(t, []string{"GBP", USD}, SupportedCurrencies())
+}
@@ -0,0 +1,138 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// dataEncryptionKeySize is the AES-256 key size expected from the config
+const dataEncryptionKeySize = 32
+
+// FieldEncryptor encrypts sensitive columns with AES-GCM before they are
+// stored. Each value is stored with the key version used; version 0 is a
+// plaintext value written before encryption was enabled. A nil encryptor
+// leaves values in plaintext.
+type FieldEncryptor struct {
+	version  int32
+	keys     map[int32]cipher.AEAD
+	indexKey []byte
+}
+
+// NewFieldEncryptor builds an encryptor from the configured current and previous
+// keys. It returns nil when encryption is not configured at all, and an error
+// when it is partly configured but the current key is missing, so emails are
+// never silently stored in plaintext.
+func NewFieldEncryptor(config Config) (*FieldEncryptor, error) {
+	if config.DataEncryptionKey == "" {
+		if config.BlindIndexKey != "" || config.PreviousDataEncryptionKeys != "" || config.DataEncryptionKeyVersion != 0 {
+			return nil, errors.New("data encryption key is required when data encryption is configured")
+		}
+		return nil, nil
+	}
+	if config.BlindIndexKey == "" {
+		return nil, errors.New("blind index key is required when data encryption is enabled")
+	}
+
+	//Previous keys, e.g. "1=old-key,2=older-key"
+	secrets, err := parseVersionedSecrets(config.PreviousDataEncryptionKeys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid previous data encryption key %w", err)
+	}
+
+	//The current key defaults to version 1
+	version := config.DataEncryptionKeyVersion
+	if version == 0 {
+		version = 1
+	}
+	if version < 0 {
+		return nil, fmt.Errorf("invalid data encryption key version %d", version)
+	}
+	secrets[version] = config.DataEncryptionKey
+
+	encryptor := &FieldEncryptor{
+		version:  version,
+		keys:     make(map[int32]cipher.AEAD, len(secrets)),
+		indexKey: []byte(config.BlindIndexKey),
+	}
+	for v, secret := range secrets {
+		if len(secret) != dataEncryptionKeySize {
+			return nil, fmt.Errorf("data encryption key %d must be exactly %d characters", v, dataEncryptionKeySize)
+		}
+		block, err := aes.NewCipher([]byte(secret))
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		encryptor.keys[v] = aead
+	}
+	return encryptor, nil
+}
+
+// Encrypt returns the encoded ciphertext of a value and the key version used
+func (encryptor *FieldEncryptor) Encrypt(plaintext string) (string, int32, error) {
+	if encryptor == nil {
+		return plaintext, 0, nil
+	}
+
+	aead := encryptor.keys[encryptor.version]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", 0, err
+	}
+
+	//Store the nonce in front of the sealed value
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawStdEncoding.EncodeToString(sealed), encryptor.version, nil
+}
+
+// Decrypt returns the plaintext of a value encrypted with the given key version
+func (encryptor *FieldEncryptor) Decrypt(ciphertext string, version int32) (string, error) {
+	if version == 0 {
+		return ciphertext, nil
+	}
+	if encryptor == nil {
+		return "", fmt.Errorf("cannot decrypt value with key version %d: data encryption is disabled", version)
+	}
+
+	aead, ok := encryptor.keys[version]
+	if !ok {
+		return "", fmt.Errorf("unknown data encryption key version %d", version)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex returns a keyed hash of a value for equality lookups on encrypted
+// columns. It is empty when encryption is disabled. The index key is separate
+// from the data keys so rotating them keeps existing indexes valid.
+func (encryptor *FieldEncryptor) BlindIndex(value string) string {
+	if encryptor == nil {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, encryptor.indexKey)
+	mac.Write([]byte(value))
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
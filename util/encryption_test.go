@@ -0,0 +1,131 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestFieldEncryptorRoundTrip verifies values encrypt to ciphertext and decrypt back
+func TestFieldEncryptorRoundTrip(t *testing.T) {
+	email := RandomEmail()
+
+	encryptor, err := NewFieldEncryptor(Config{
+		DataEncryptionKey: RandomString(32),
+		BlindIndexKey:     RandomString(32),
+	})
+	require.NoError(t, err)
+
+	ciphertext, version, err := encryptor.Encrypt(email)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), version)
+	require.NotContains(t, ciphertext, email)
+
+	//Encrypting twice gives different ciphertexts
+	other, _, err := encryptor.Encrypt(email)
+	require.NoError(t, err)
+	require.NotEqual(t, ciphertext, other)
+
+	plaintext, err := encryptor.Decrypt(ciphertext, version)
+	require.NoError(t, err)
+	require.Equal(t, email, plaintext)
+
+	//Tampered ciphertext and unknown versions are rejected
+	_, err = encryptor.Decrypt("A"+ciphertext[1:], version)
+	if ciphertext[0] != 'A' {
+		require.Error(t, err)
+	}
+	_, err = encryptor.Decrypt(ciphertext, 7)
+	require.Error(t, err)
+
+	//Version 0 values are plaintext
+	plaintext, err = encryptor.Decrypt(email, 0)
+	require.NoError(t, err)
+	require.Equal(t, email, plaintext)
+}
+
+// TestFieldEncryptorRotation verifies values encrypted with a previous key still decrypt
+func TestFieldEncryptorRotation(t *testing.T) {
+	email := RandomEmail()
+	oldKey := RandomString(32)
+	indexKey := RandomString(32)
+
+	old, err := NewFieldEncryptor(Config{DataEncryptionKey: oldKey, BlindIndexKey: indexKey})
+	require.NoError(t, err)
+	ciphertext, version, err := old.Encrypt(email)
+	require.NoError(t, err)
+
+	rotated, err := NewFieldEncryptor(Config{
+		DataEncryptionKey:          RandomString(32),
+		DataEncryptionKeyVersion:   2,
+		PreviousDataEncryptionKeys: "1=" + oldKey,
+		BlindIndexKey:              indexKey,
+	})
+	require.NoError(t, err)
+
+	plaintext, err := rotated.Decrypt(ciphertext, version)
+	require.NoError(t, err)
+	require.Equal(t, email, plaintext)
+
+	_, version, err = rotated.Encrypt(email)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), version)
+
+	//The blind index survives key rotation
+	require.Equal(t, old.BlindIndex(email), rotated.BlindIndex(email))
+}
+
+// TestFieldEncryptorBlindIndex verifies the blind index is deterministic and keyed
+func TestFieldEncryptorBlindIndex(t *testing.T) {
+	email := RandomEmail()
+
+	encryptor, err := NewFieldEncryptor(Config{DataEncryptionKey: RandomString(32), BlindIndexKey: RandomString(32)})
+	require.NoError(t, err)
+	require.Equal(t, encryptor.BlindIndex(email), encryptor.BlindIndex(email))
+	require.NotEqual(t, encryptor.BlindIndex(email), encryptor.BlindIndex(RandomEmail()))
+	require.NotContains(t, encryptor.BlindIndex(email), email)
+
+	other, err := NewFieldEncryptor(Config{DataEncryptionKey: RandomString(32), BlindIndexKey: RandomString(32)})
+	require.NoError(t, err)
+	require.NotEqual(t, encryptor.BlindIndex(email), other.BlindIndex(email))
+}
+
+// TestFieldEncryptorConfig verifies disabled and invalid configurations
+func TestFieldEncryptorConfig(t *testing.T) {
+	//No key disables encryption
+	encryptor, err := NewFieldEncryptor(Config{})
+	require.NoError(t, err)
+	require.Nil(t, encryptor)
+
+	ciphertext, version, err := encryptor.Encrypt("a@b.c")
+	require.NoError(t, err)
+	require.Equal(t, "a@b.c", ciphertext)
+	require.Zero(t, version)
+	require.Empty(t, encryptor.BlindIndex("a@b.c"))
+
+	//Encrypted values cannot be read without a key
+	_, err = encryptor.Decrypt(ciphertext, 1)
+	require.Error(t, err)
+
+	//Wrong key size
+	_, err = NewFieldEncryptor(Config{DataEncryptionKey: RandomString(10), BlindIndexKey: RandomString(32)})
+	require.Error(t, err)
+
+	//Missing blind index key
+	_, err = NewFieldEncryptor(Config{DataEncryptionKey: RandomString(32)})
+	require.Error(t, err)
+
+	//Missing data encryption key while the rest is configured
+	_, err = NewFieldEncryptor(Config{BlindIndexKey: RandomString(32)})
+	require.Error(t, err)
+	_, err = NewFieldEncryptor(Config{PreviousDataEncryptionKeys: "1=" + RandomString(32)})
+	require.Error(t, err)
+
+	//Malformed previous keys
+	_, err = NewFieldEncryptor(Config{
+		DataEncryptionKey:          RandomString(32),
+		BlindIndexKey:              RandomString(32),
+		PreviousDataEncryptionKeys: "old-key",
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,82 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Amounts are always integers in the minor unit of their currency, e.g. cents
+// for USD, so 150 USD cents is "1.50 USD" and 150 JPY is "150 JPY".
+
+// defaultMinorUnits is the ISO 4217 exponent of most currencies
+const defaultMinorUnits = 2
+
+// minorUnits lists the ISO 4217 currencies whose exponent is not 2
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyMinorUnits returns the number of decimal places of a currency's
+// minor unit, 2 for codes not listed as exceptions
+func CurrencyMinorUnits(code string) int {
+	if units, ok := minorUnits[NormalizeCurrency(code)]; ok {
+		return units
+	}
+	return defaultMinorUnits
+}
+
+// FormatMoney renders an amount of minor units in major units followed by its
+// currency code, e.g. FormatMoney(-150, "usd") is "-1.50 USD"
+func FormatMoney(amount int64, code string) string {
+	code = NormalizeCurrency(code)
+	units := CurrencyMinorUnits(code)
+
+	//Format the magnitude so the minimum int64 keeps its digits
+	digits := strconv.FormatUint(absAmount(amount), 10)
+	if units > 0 {
+		if len(digits) <= units {
+			digits = strings.Repeat("0", units-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-units] + "." + digits[len(digits)-units:]
+	}
+
+	if amount < 0 {
+		digits = "-" + digits
+	}
+	return digits + " " + code
+}
+
+// ParseMoney parses a major-unit amount such as "1.50" into minor units of the
+// currency, rejecting more decimals than the currency has
+func ParseMoney(value string, code string) (int64, error) {
+	units := CurrencyMinorUnits(code)
+
+	whole, fraction, hasFraction := strings.Cut(strings.TrimSpace(value), ".")
+	if strings.Trim(whole, "+-")+fraction == "" {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	if hasFraction && (fraction == "" || len(fraction) > units) {
+		return 0, fmt.Errorf("invalid amount %q: %s has %d decimal places", value, NormalizeCurrency(code), units)
+	}
+
+	//Parse the digits as one integer of minor units
+	digits := whole + fraction + strings.Repeat("0", units-len(fraction))
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || strings.HasPrefix(fraction, "-") || strings.HasPrefix(fraction, "+") {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return amount, nil
+}
+
+// absAmount returns the magnitude of an amount without overflowing
+func absAmount(amount int64) uint64 {
+	if amount < 0 {
+		return uint64(-(amount + 1)) + 1
+	}
+	return uint64(amount)
+}
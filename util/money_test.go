@@ -0,0 +1,82 @@
+package util
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCurrencyMinorUnits verifies ISO 4217 exponents and the default of 2
+func TestCurrencyMinorUnits(t *testing.T) {
+	require.Equal(t, 2, CurrencyMinorUnits(USD))
+	require.Equal(t, 2, CurrencyMinorUnits("usd"))
+	require.Equal(t, 2, CurrencyMinorUnits("Ksh"))
+	require.Equal(t, 0, CurrencyMinorUnits("JPY"))
+	require.Equal(t, 3, CurrencyMinorUnits("KWD"))
+	require.Equal(t, 2, CurrencyMinorUnits("XYZ"))
+}
+
+// TestFormatMoney verifies minor units are rendered in major units
+func TestFormatMoney(t *testing.T) {
+	testCases := []struct {
+		amount int64
+		code   string
+		want   string
+	}{
+		{150, USD, "1.50 USD"},
+		{5, "usd", "0.05 USD"},
+		{0, EUR, "0.00 EUR"},
+		{-150, KES, "-1.50 KES"},
+		{-5, USD, "-0.05 USD"},
+		{150, "JPY", "150 JPY"},
+		{-150, "JPY", "-150 JPY"},
+		{1234, "KWD", "1.234 KWD"},
+		{math.MinInt64, USD, "-92233720368547758.08 USD"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.want, FormatMoney(tc.amount, tc.code))
+	}
+}
+
+// TestParseMoney verifies major-unit amounts parse to minor units within the currency precision
+func TestParseMoney(t *testing.T) {
+	amount, err := ParseMoney("1.5", USD)
+	require.NoError(t, err)
+	require.Equal(t, int64(150), amount)
+
+	amount, err = ParseMoney("12", USD)
+	require.NoError(t, err)
+	require.Equal(t, int64(1200), amount)
+
+	amount, err = ParseMoney("150", "JPY")
+	require.NoError(t, err)
+	require.Equal(t, int64(150), amount)
+
+	for _, value := range []string{"", ".", "-", "1.", "1.234", "1.-5", "abc", "1,50"} {
+		_, err := ParseMoney(value, USD)
+		require.Error(t, err, value)
+	}
+	_, err = ParseMoney("1.5", "JPY")
+	require.Error(t, err)
+}
+
+// TestFormatMoneyRoundTrip verifies formatted amounts parse back to the same minor units
+func TestFormatMoneyRoundTrip(t *testing.T) {
+	//Seed the random amounts so a failure can be replayed with the logged seed
+	seed := time.Now().UnixNano()
+	SeedRandom(seed)
+	t.Logf("random seed %d", seed)
+
+	for _, code := range []string{USD, "JPY", "KWD"} {
+		for _, amount := range []int64{0, 1, -1, 99, 100, -1001, RandomMoney(), math.MaxInt64, math.MinInt64} {
+			value, _, _ := strings.Cut(FormatMoney(amount, code), " ")
+			parsed, err := ParseMoney(value, code)
+			require.NoError(t, err)
+			require.Equal(t, amount, parsed, "%d %s", amount, code)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseNetworks verifies CIDR and bare address parsing
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks([]string{"10.0.0.0/8", " 192.168.1.7 ", "", "2001:db8::/32"})
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+
+	testCases := []struct {
+		addr     string
+		expected bool
+	}{
+		{"10.1.2.3", true},
+		{"::ffff:10.1.2.3", true},
+		{"192.168.1.7", true},
+		{"192.168.1.8", false},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, NetworksContain(networks, netip.MustParseAddr(tc.addr)), tc.addr)
+	}
+
+	_, err = ParseNetworks([]string{"10.0.0.0/33"})
+	require.Error(t, err)
+	_, err = ParseNetworks([]string{"not-an-ip"})
+	require.Error(t, err)
+}
@@ -2,13 +2,19 @@ package util
 
 import (
 	"fmt"
+	"log"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 // HashPassword returns the bcrypt hash of the password
 func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return HashPasswordWithCost(password, bcrypt.DefaultCost)
+}
+
+// HashPasswordWithCost returns the bcrypt hash of the password at the given cost
+func HashPasswordWithCost(password string, cost int) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), cost)
 	if err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -22,3 +28,18 @@ func CheckPassword(password string, hashedPassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 
 }
+
+// BcryptCost returns the configured bcrypt cost, falling back to the default
+// when unset or outside the range bcrypt accepts
+func (config Config) BcryptCost() int {
+	cost := config.PasswordHashCost
+	if cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		log.Printf("password hash cost %d outside [%d, %d], using default %d",
+			cost, bcrypt.MinCost, bcrypt.MaxCost, bcrypt.DefaultCost)
+		return bcrypt.DefaultCost
+	}
+	return cost
+}
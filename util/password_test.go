@@ -1,6 +1,7 @@
 package util
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -31,3 +32,42 @@ func TestPassword(t *testing.T) {
 	require.NotEmpty(t, hashedPassword2)
 	require.NotEqual(t, hashedPassword1, hashedPassword2)
 }
+
+// TestHashPasswordWithCost verifies a higher cost yields a different hash that still checks
+func TestHashPasswordWithCost(t *testing.T) {
+	password := RandomString(6)
+
+	defaultHash, err := HashPassword(password)
+	require.NoError(t, err)
+
+	costlyHash, err := HashPasswordWithCost(password, bcrypt.DefaultCost+1)
+	require.NoError(t, err)
+	require.NotEqual(t, defaultHash, costlyHash)
+
+	cost, err := bcrypt.Cost([]byte(costlyHash))
+	require.NoError(t, err)
+	require.Equal(t, bcrypt.DefaultCost+1, cost)
+	require.NoError(t, CheckPassword(password, costlyHash))
+	require.Error(t, CheckPassword(RandomString(6), costlyHash))
+}
+
+// TestBcryptCost verifies unset and out-of-range costs fall back to the default
+func TestBcryptCost(t *testing.T) {
+	require.Equal(t, bcrypt.DefaultCost, Config{}.BcryptCost())
+	require.Equal(t, 12, Config{PasswordHashCost: 12}.BcryptCost())
+	require.Equal(t, bcrypt.DefaultCost, Config{PasswordHashCost: bcrypt.MinCost - 1}.BcryptCost())
+	require.Equal(t, bcrypt.DefaultCost, Config{PasswordHashCost: bcrypt.MaxCost + 1}.BcryptCost())
+}
+
+func BenchmarkHashPasswordWithCost(b *testing.B) {
+	password := RandomString(12)
+	for _, cost := range []int{bcrypt.MinCost, bcrypt.DefaultCost, bcrypt.DefaultCost + 2} {
+		b.Run(fmt.Sprintf("cost=%d", cost), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := HashPasswordWithCost(password, cost); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestPasswordHasherPepper verifies peppered hashing and verification
+func TestPasswordHasherPepper(t *testing.T) {
+	password := RandomString(8)
+
+	hasher, err := NewPasswordHasher(Config{PasswordPepper: RandomString(32)})
+	require.NoError(t, err)
+
+	hashedPassword, version, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), version)
+
+	//Correct password verifies, a wrong one does not
+	require.NoError(t, hasher.Check(password, hashedPassword, version))
+	require.ErrorIs(t, hasher.Check(RandomString(8), hashedPassword, version), bcrypt.ErrMismatchedHashAndPassword)
+
+	//The hash alone does not verify without the pepper
+	require.Error(t, CheckPassword(password, hashedPassword))
+
+	//A different pepper under the same version fails
+	other, err := NewPasswordHasher(Config{PasswordPepper: RandomString(32)})
+	require.NoError(t, err)
+	require.ErrorIs(t, other.Check(password, hashedPassword, version), bcrypt.ErrMismatchedHashAndPassword)
+}
+
+// TestPasswordHasherRotation verifies old hashes verify and are flagged for rehash
+func TestPasswordHasherRotation(t *testing.T) {
+	password := RandomString(8)
+	oldPepper := RandomString(32)
+
+	//Unpeppered and version 1 hashes made before the rotation
+	plainHash, err := HashPassword(password)
+	require.NoError(t, err)
+
+	oldHasher, err := NewPasswordHasher(Config{PasswordPepper: oldPepper})
+	require.NoError(t, err)
+	oldHash, oldVersion, err := oldHasher.Hash(password)
+	require.NoError(t, err)
+
+	hasher, err := NewPasswordHasher(Config{
+		PasswordPepper:          RandomString(32),
+		PasswordPepperVersion:   2,
+		PreviousPasswordPeppers: "1=" + oldPepper,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, hasher.Check(password, plainHash, 0))
+	require.True(t, hasher.NeedsRehash(0))
+
+	require.NoError(t, hasher.Check(password, oldHash, oldVersion))
+	require.True(t, hasher.NeedsRehash(oldVersion))
+
+	newHash, newVersion, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), newVersion)
+	require.False(t, hasher.NeedsRehash(newVersion))
+	require.NoError(t, hasher.Check(password, newHash, newVersion))
+
+	//Unknown versions never verify
+	require.Error(t, hasher.Check(password, oldHash, 3))
+}
+
+// TestNewPasswordHasherInvalidConfig rejects malformed previous peppers
+func TestNewPasswordHasherInvalidConfig(t *testing.T) {
+	_, err := NewPasswordHasher(Config{PreviousPasswordPeppers: "x=secret"})
+	require.Error(t, err)
+
+	_, err = NewPasswordHasher(Config{PreviousPasswordPeppers: "1="})
+	require.Error(t, err)
+
+	//Without a pepper, hashes stay unpeppered
+	hasher, err := NewPasswordHasher(Config{})
+	require.NoError(t, err)
+	_, version, err := hasher.Hash(RandomString(8))
+	require.NoError(t, err)
+	require.Equal(t, int32(0), version)
+}
+
+// TestPasswordHasherCost verifies the configured bcrypt cost is used for new hashes
+func TestPasswordHasherCost(t *testing.T) {
+	password := RandomString(8)
+
+	hasher, err := NewPasswordHasher(Config{PasswordHashCost: bcrypt.MinCost})
+	require.NoError(t, err)
+
+	hashedPassword, version, err := hasher.Hash(password)
+	require.NoError(t, err)
+
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	require.NoError(t, err)
+	require.Equal(t, bcrypt.MinCost, cost)
+	require.NoError(t, hasher.Check(password, hashedPassword, version))
+}
@@ -2,28 +2,45 @@ package util
 
 import (
 	"fmt"
-	"math/rand"
+	"math/rand/v2"
 	"strings"
-	"time"
+	"sync"
 )
 
 const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 
-// rng is a local random generator (safe for reproducible control)
-var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+// rng backs every generator. It starts from a random seed drawn from the
+// runtime's cryptographically seeded source; tests may reseed it with
+// SeedRandom to reproduce a run. The mutex keeps it safe for parallel tests.
+var (
+	rngMu sync.Mutex
+	rng   = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+)
+
+// SeedRandom makes the generators deterministic: the same seed always yields
+// the same sequence of values
+func SeedRandom(seed int64) {
+	rngMu.Lock()
+	defer rngMu.Unlock()
+	rng = rand.New(rand.NewPCG(uint64(seed), uint64(seed)))
+}
 
 // RandomInt returns a random integer between min and max (inclusive)
 func RandomInt(min, max int64) int64 {
-	return min + rng.Int63n(max-min+1)
+	rngMu.Lock()
+	defer rngMu.Unlock()
+	return min + rng.Int64N(max-min+1)
 }
 
-// RandomString generates a random string of length n
+// RandomString generates a random string of n ASCII letters
 func RandomString(n int) string {
 	var sb strings.Builder
 	k := len(alphabet)
 
+	rngMu.Lock()
+	defer rngMu.Unlock()
 	for i := 0; i < n; i++ {
-		sb.WriteByte(alphabet[rng.Intn(k)])
+		sb.WriteByte(alphabet[rng.IntN(k)])
 	}
 	return sb.String()
 }
@@ -33,16 +50,15 @@ func RandomOwner() string {
 	return RandomString(6)
 }
 
-// RandomMoney generates a random amount of money
+// RandomMoney generates a random amount of money between 0 and 1000 minor units
 func RandomMoney() int64 {
 	return RandomInt(0, 1000)
 }
 
-// RandomCurrency generates a random currency code
+// RandomCurrency picks one of the currently supported currency codes
 func RandomCurrency() string {
-	currencies := []string{EUR, USD, KSH}
-	n := len(currencies)
-	return currencies[rand.Intn(n)]
+	currencies := SupportedCurrencies()
+	return currencies[RandomInt(0, int64(len(currencies)-1))]
 }
 
 // RandomEmail generates a random email
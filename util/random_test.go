@@ -0,0 +1,84 @@
+package util
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestRandomInt verifies values stay within the inclusive bounds and reach both ends
+func TestRandomInt(t *testing.T) {
+	seen := map[int64]bool{}
+	for i := 0; i < 1000; i++ {
+		n := RandomInt(-2, 2)
+		require.GreaterOrEqual(t, n, int64(-2))
+		require.LessOrEqual(t, n, int64(2))
+		seen[n] = true
+	}
+	require.Len(t, seen, 5)
+
+	require.Equal(t, int64(7), RandomInt(7, 7))
+}
+
+// TestRandomString verifies the length and alphabet of random strings
+func TestRandomString(t *testing.T) {
+	for _, n := range []int{0, 1, 32} {
+		s := RandomString(n)
+		require.Len(t, s, n)
+		for _, c := range s {
+			require.True(t, strings.ContainsRune(alphabet, c), "unexpected rune %q", c)
+		}
+	}
+
+	require.Len(t, RandomOwner(), 6)
+	require.NotEqual(t, RandomString(32), RandomString(32))
+}
+
+// TestRandomMoney verifies amounts are non-negative minor units within range
+func TestRandomMoney(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		amount := RandomMoney()
+		require.GreaterOrEqual(t, amount, int64(0))
+		require.LessOrEqual(t, amount, int64(1000))
+	}
+}
+
+// TestRandomCurrency verifies only supported currencies are returned, following the configured set
+func TestRandomCurrency(t *testing.T) {
+	defer SetSupportedCurrencies(nil)
+
+	for i := 0; i < 100; i++ {
+		require.True(t, IsSupportedCurrency(RandomCurrency()))
+	}
+
+	require.NoError(t, SetSupportedCurrencies([]string{"GBP"}))
+	for i := 0; i < 10; i++ {
+		require.Equal(t, "GBP", RandomCurrency())
+	}
+}
+
+// TestRandomEmail verifies random emails are valid addresses
+func TestRandomEmail(t *testing.T) {
+	email := RandomEmail()
+	address, err := mail.ParseAddress(email)
+	require.NoError(t, err)
+	require.Equal(t, email, address.Address)
+}
+
+// TestSeedRandom verifies the same seed reproduces the same sequence
+func TestSeedRandom(t *testing.T) {
+	sequence := func(seed int64) []any {
+		SeedRandom(seed)
+		var values []any
+		for i := 0; i < 5; i++ {
+			values = append(values, RandomOwner(), RandomMoney(), RandomCurrency(), RandomEmail())
+		}
+		return values
+	}
+
+	first := sequence(42)
+	require.Equal(t, first, sequence(42))
+	require.NotEqual(t, first, sequence(43))
+}
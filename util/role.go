@@ -1,6 +1,8 @@
 package util
 
 //DepositorRole defines the depositor user role
+//BankerRole defines the banker user role, allowed to view any account
 const (
 	DepositorRole = "depositor"
+	BankerRole    = "banker"
 )
@@ -0,0 +1,88 @@
+package util
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RoundingMode controls how fractional minor units are resolved
+type RoundingMode string
+
+// Supported rounding modes
+const (
+	RoundHalfUp   RoundingMode = "half_up"
+	RoundHalfEven RoundingMode = "half_even"
+	RoundDown     RoundingMode = "down"
+)
+
+// DefaultRoundingMode is used when no rounding mode is configured
+const DefaultRoundingMode = RoundHalfEven
+
+// ParseRoundingMode validates a rounding mode name
+func ParseRoundingMode(mode string) (RoundingMode, error) {
+	switch RoundingMode(mode) {
+	case RoundHalfUp, RoundHalfEven, RoundDown:
+		return RoundingMode(mode), nil
+	}
+	return "", fmt.Errorf("unsupported rounding mode %q", mode)
+}
+
+// ConvertAmount multiplies an amount in minor units by an exchange rate and
+// rounds the result to whole minor units using the given mode
+func ConvertAmount(amount int64, rate *big.Rat, mode RoundingMode) (int64, error) {
+	if rate.Sign() <= 0 {
+		return 0, fmt.Errorf("exchange rate must be positive")
+	}
+
+	//Exact product as a fraction
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)
+	num := product.Num()
+	den := product.Denom()
+
+	//Truncate toward zero and keep the remainder
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() != 0 {
+		//Compare twice the remainder against the denominator
+		twiceRem := new(big.Int).Abs(rem)
+		twiceRem.Lsh(twiceRem, 1)
+		cmp := twiceRem.Cmp(den)
+
+		roundAway := false
+		switch mode {
+		case RoundDown:
+		case RoundHalfUp:
+			roundAway = cmp >= 0
+		case RoundHalfEven:
+			roundAway = cmp > 0 || (cmp == 0 && quo.Bit(0) == 1)
+		default:
+			return 0, fmt.Errorf("unsupported rounding mode %q", mode)
+		}
+
+		if roundAway {
+			quo.Add(quo, big.NewInt(int64(num.Sign())))
+		}
+	}
+
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("converted amount overflows")
+	}
+	return quo.Int64(), nil
+}
+
+// FXRoundingModeFor returns the rounding mode configured for conversions into
+// the given currency, falling back to the default mode
+func (config Config) FXRoundingModeFor(currency string) (RoundingMode, error) {
+	//Per-currency overrides, e.g. "KES=down,USD=half_even"
+	for _, pair := range strings.Split(config.FXRoundingModes, ",") {
+		code, mode, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if found && strings.EqualFold(code, currency) {
+			return ParseRoundingMode(mode)
+		}
+	}
+
+	if config.FXRoundingMode == "" {
+		return DefaultRoundingMode, nil
+	}
+	return ParseRoundingMode(config.FXRoundingMode)
+}
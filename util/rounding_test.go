@@ -0,0 +1,79 @@
+package util
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestConvertAmount verifies each rounding mode on exact half minor units
+func TestConvertAmount(t *testing.T) {
+	testCases := []struct {
+		name     string
+		amount   int64
+		rate     string
+		mode     RoundingMode
+		expected int64
+	}{
+		{"HalfUpRoundsHalfAway", 1, "0.5", RoundHalfUp, 1},
+		{"HalfUpOddHalf", 3, "0.5", RoundHalfUp, 2},
+		{"HalfEvenRoundsToEven", 1, "0.5", RoundHalfEven, 0},
+		{"HalfEvenOddHalf", 3, "0.5", RoundHalfEven, 2},
+		{"DownTruncatesHalf", 3, "0.5", RoundDown, 1},
+		{"HalfUpBelowHalf", 1049, "0.01", RoundHalfUp, 10},
+		{"HalfEvenAboveHalf", 1051, "0.01", RoundHalfEven, 11},
+		{"DownAboveHalf", 1099, "0.01", RoundDown, 10},
+		{"ExactRate", 1000, "1.25", RoundDown, 1250},
+		{"NegativeHalfUp", -3, "0.5", RoundHalfUp, -2},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			rate, ok := new(big.Rat).SetString(tc.rate)
+			require.True(t, ok)
+
+			converted, err := ConvertAmount(tc.amount, rate, tc.mode)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, converted)
+		})
+	}
+}
+
+// TestConvertAmountInvalid verifies bad rates and modes are rejected
+func TestConvertAmountInvalid(t *testing.T) {
+	_, err := ConvertAmount(100, big.NewRat(0, 1), RoundHalfUp)
+	require.Error(t, err)
+
+	_, err = ConvertAmount(1, big.NewRat(1, 2), RoundingMode("ceil"))
+	require.Error(t, err)
+}
+
+// TestFXRoundingModeFor verifies per-currency overrides and the default
+func TestFXRoundingModeFor(t *testing.T) {
+	config := Config{
+		FXRoundingMode:  "half_up",
+		FXRoundingModes: "KES=down, eur=half_even",
+	}
+
+	mode, err := config.FXRoundingModeFor(USD)
+	require.NoError(t, err)
+	require.Equal(t, RoundHalfUp, mode)
+
+	mode, err = config.FXRoundingModeFor(KES)
+	require.NoError(t, err)
+	require.Equal(t, RoundDown, mode)
+
+	mode, err = config.FXRoundingModeFor(EUR)
+	require.NoError(t, err)
+	require.Equal(t, RoundHalfEven, mode)
+
+	mode, err = Config{}.FXRoundingModeFor(USD)
+	require.NoError(t, err)
+	require.Equal(t, DefaultRoundingMode, mode)
+
+	_, err = Config{FXRoundingMode: "ceil"}.FXRoundingModeFor(USD)
+	require.Error(t, err)
+}